@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/feed"
+
+	"github.com/spf13/cobra"
+)
+
+// ----------------------------------------------------------------------
+// 'cache' コマンド (キャッシュの統計表示・削除)
+// ----------------------------------------------------------------------
+
+// キャッシュの種別 (--kind) です。
+const (
+	cacheKindLLM      = "llm"      // Map要約のキャッシュ (--map-cache-file)
+	cacheKindFeed     = "feed"     // フィードの ETag / Last-Modified のキャッシュ (--feed-cache-file)
+	cacheKindScrape   = "scrape"   // スクレイピング結果 (永続化するキャッシュなし)
+	cacheKindVoicevox = "voicevox" // 音声合成結果 (永続化するキャッシュなし)
+)
+
+// CacheFlags は 'cache' コマンド固有のフラグを保持する構造体です。
+type CacheFlags struct {
+	Kinds         []string // 操作対象のキャッシュの種別 (空の場合はファイルを指定したすべての種別)
+	MapCacheFile  string   // Map要約のキャッシュファイル ('run --map-cache-file' と同じファイル)
+	FeedCacheFile string   // フィードのキャッシュファイル ('run --feed-cache-file' と同じファイル)
+	OlderThan     string   // 'cache prune' で削除するエントリの経過時間 (7d, 12h 形式)
+	Yes           bool     // 削除の確認をスキップするか
+}
+
+var cacheFlags CacheFlags
+
+// cacheFile は 'cache' コマンドで操作するファイルキャッシュです (cleaner.FileMapCache / feed.FileCache)。
+type cacheFile interface {
+	Stats() (entries int, oldest, newest time.Time)
+	Prune(before time.Time) int
+	Save() error
+}
+
+// cacheTarget は操作対象の 1 種別のキャッシュファイルです。
+type cacheTarget struct {
+	kind string
+	path string
+}
+
+// load はキャッシュファイルを読み込みます。ファイルが存在しない場合は空のキャッシュを返します。
+func (t cacheTarget) load() (cacheFile, error) {
+	if t.kind == cacheKindLLM {
+		return cleaner.LoadFileMapCache(t.path)
+	}
+	return feed.LoadFileCache(t.path)
+}
+
+// cacheTargets は --kind とキャッシュファイルの指定から操作対象のキャッシュを返します。
+// --kind の指定がない場合は、ファイルを指定したすべての種別を対象とします。
+func cacheTargets(f CacheFlags) ([]cacheTarget, error) {
+	paths := map[string]string{cacheKindLLM: f.MapCacheFile, cacheKindFeed: f.FeedCacheFile}
+	kinds := f.Kinds
+	if len(kinds) == 0 {
+		for _, kind := range []string{cacheKindLLM, cacheKindFeed} {
+			if paths[kind] != "" {
+				kinds = append(kinds, kind)
+			}
+		}
+		if len(kinds) == 0 {
+			return nil, fmt.Errorf("--map-cache-file または --feed-cache-file でキャッシュファイルを指定してください")
+		}
+	}
+
+	var targets []cacheTarget
+	for _, kind := range kinds {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		switch kind {
+		case cacheKindLLM, cacheKindFeed:
+		case cacheKindScrape, cacheKindVoicevox:
+			return nil, fmt.Errorf("--kind %s: このツールは %s のキャッシュを永続化していません (操作できるのは %s / %s のみです)", kind, kind, cacheKindLLM, cacheKindFeed)
+		default:
+			return nil, fmt.Errorf("--kind には %s / %s のいずれかを指定してください: %q", cacheKindLLM, cacheKindFeed, kind)
+		}
+		if slices.ContainsFunc(targets, func(t cacheTarget) bool { return t.kind == kind }) {
+			continue
+		}
+		if paths[kind] == "" {
+			flag := "--map-cache-file"
+			if kind == cacheKindFeed {
+				flag = "--feed-cache-file"
+			}
+			return nil, fmt.Errorf("--kind %s には %s の指定が必要です", kind, flag)
+		}
+		targets = append(targets, cacheTarget{kind: kind, path: paths[kind]})
+	}
+	return targets, nil
+}
+
+// parseOlderThan は --older-than の値を期間に変換します。"7d" 形式の日数と "12h" などの期間を受け付けます。
+func parseOlderThan(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("--older-than には正の期間を指定してください: %q (例: 7d, 12h)", value)
+}
+
+// confirmCacheDeletion は削除の内容を表示し、標準入力から削除を続けるかどうかを確認します。
+// --yes の指定がある場合は確認しません。標準入力が端末でない場合は --yes の指定を促すエラーを返します。
+func confirmCacheDeletion(summary string) (bool, error) {
+	if cacheFlags.Yes {
+		return true, nil
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("標準入力が端末ではないため削除を確認できません。削除する場合は --yes を指定してください")
+	}
+	return askYesNo(summary + "削除しますか?")
+}
+
+// cacheStatsCmdFunc は 'cache stats' サブコマンドが呼び出されたときに実行される関数です。
+func cacheStatsCmdFunc(cmd *cobra.Command, args []string) error {
+	targets, err := cacheTargets(cacheFlags)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	for _, target := range targets {
+		c, err := target.load()
+		if err != nil {
+			return err
+		}
+		var size int64
+		if info, err := os.Stat(target.path); err == nil {
+			size = info.Size()
+		}
+		entries, oldest, newest := c.Stats()
+		fmt.Fprintf(out, "%-5s %s\n", target.kind, target.path)
+		fmt.Fprintf(out, "      エントリ数: %d 件 / ファイルサイズ: %d バイト\n", entries, size)
+		if entries > 0 {
+			fmt.Fprintf(out, "      保存時刻: %s 〜 %s\n", oldest.Format(time.DateTime), newest.Format(time.DateTime))
+		}
+	}
+	return nil
+}
+
+// cacheClearCmdFunc は 'cache clear' サブコマンドが呼び出されたときに実行される関数です。
+// 確認の後、対象のキャッシュファイルを削除します。
+func cacheClearCmdFunc(cmd *cobra.Command, args []string) error {
+	targets, err := cacheTargets(cacheFlags)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	var existing []cacheTarget
+	for _, target := range targets {
+		if _, err := os.Stat(target.path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		c, err := target.load()
+		if err != nil {
+			return err
+		}
+		entries, _, _ := c.Stats()
+		fmt.Fprintf(&sb, "  %-5s %s (%d 件)\n", target.kind, target.path, entries)
+		existing = append(existing, target)
+	}
+	out := cmd.OutOrStdout()
+	if len(existing) == 0 {
+		fmt.Fprintln(out, "削除するキャッシュファイルはありません。")
+		return nil
+	}
+	ok, err := confirmCacheDeletion("次のキャッシュをすべて削除します:\n" + sb.String())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(out, "削除を中止しました。")
+		return nil
+	}
+	for _, target := range existing {
+		if err := os.Remove(target.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("キャッシュファイルの削除に失敗しました (%s): %w", target.path, err)
+		}
+		fmt.Fprintf(out, "%s のキャッシュを削除しました: %s\n", target.kind, target.path)
+	}
+	return nil
+}
+
+// cachePruneCmdFunc は 'cache prune' サブコマンドが呼び出されたときに実行される関数です。
+// 保存から --older-than 以上経過したエントリを、確認の後にキャッシュファイルから削除します。
+func cachePruneCmdFunc(cmd *cobra.Command, args []string) error {
+	olderThan, err := parseOlderThan(cacheFlags.OlderThan)
+	if err != nil {
+		return err
+	}
+	targets, err := cacheTargets(cacheFlags)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-olderThan)
+
+	// Prune はメモリ上のみを更新するため、Save するまではファイルは変更されない
+	type pruned struct {
+		target  cacheTarget
+		cache   cacheFile
+		removed int
+	}
+	var sb strings.Builder
+	var changed []pruned
+	for _, target := range targets {
+		c, err := target.load()
+		if err != nil {
+			return err
+		}
+		if removed := c.Prune(cutoff); removed > 0 {
+			fmt.Fprintf(&sb, "  %-5s %s (%d 件)\n", target.kind, target.path, removed)
+			changed = append(changed, pruned{target: target, cache: c, removed: removed})
+		}
+	}
+	out := cmd.OutOrStdout()
+	if len(changed) == 0 {
+		fmt.Fprintf(out, "%s より前に保存されたエントリはありません。\n", cutoff.Format(time.DateTime))
+		return nil
+	}
+	ok, err := confirmCacheDeletion(fmt.Sprintf("%s より前に保存された次のエントリを削除します:\n%s", cutoff.Format(time.DateTime), sb.String()))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(out, "削除を中止しました。")
+		return nil
+	}
+	for _, p := range changed {
+		if err := p.cache.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s のキャッシュから %d 件を削除しました: %s\n", p.target.kind, p.removed, p.target.path)
+	}
+	return nil
+}
+
+// addCacheFlags は 'cache' コマンドとそのサブコマンドに共通のフラグを設定します。
+func addCacheFlags(cacheCmd *cobra.Command) {
+	flags := cacheCmd.PersistentFlags()
+	flags.StringSliceVar(&cacheFlags.Kinds,
+		"kind", nil, "操作するキャッシュの種別 (llm / feed)。カンマ区切りで複数指定可。未指定時はファイルを指定したすべての種別。")
+	flags.StringVar(&cacheFlags.MapCacheFile,
+		"map-cache-file", "", "Map要約のキャッシュファイル (llm。'run --map-cache-file' と同じファイル)。")
+	flags.StringVar(&cacheFlags.FeedCacheFile,
+		"feed-cache-file", "", "フィードの ETag / Last-Modified のキャッシュファイル (feed。'run --feed-cache-file' と同じファイル)。")
+	flags.BoolVarP(&cacheFlags.Yes,
+		"yes", "y", false, "削除の確認をスキップします (自動実行向け)。")
+	cachePruneCmd.Flags().StringVar(&cacheFlags.OlderThan,
+		"older-than", "", "保存からこの期間以上経過したエントリを削除します (例: 7d, 12h)。")
+	_ = cachePruneCmd.MarkFlagRequired("older-than")
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "キャッシュの統計表示・削除を行います。",
+	Long: "'run' の --map-cache-file (llm) と --feed-cache-file (feed) で保存したキャッシュの統計を表示し、削除します。\n" +
+		"スクレイピング結果と音声合成結果はキャッシュしていないため、操作の対象外です。",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:          "stats",
+	Short:        "キャッシュのエントリ数・ファイルサイズ・保存時刻の範囲を表示します。",
+	RunE:         cacheStatsCmdFunc,
+	SilenceUsage: true,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:          "clear",
+	Short:        "キャッシュファイルを削除します (削除前に確認します)。",
+	RunE:         cacheClearCmdFunc,
+	SilenceUsage: true,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:          "prune",
+	Short:        "保存から --older-than 以上経過したエントリを削除します (削除前に確認します)。",
+	RunE:         cachePruneCmdFunc,
+	SilenceUsage: true,
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ----------------------------------------------------------------------
+// 'config' コマンド
+// ----------------------------------------------------------------------
+
+// configValidateCmdFunc は 'config validate' サブコマンドが呼び出されたときに実行される関数です。
+// 'run' と同じフラグを受け取り、パイプラインを実行せずに設定の妥当性のみを検証します。
+func configValidateCmdFunc(cmd *cobra.Command, args []string) error {
+	if err := validateInputMode(cmd, Flags); err != nil {
+		return fmt.Errorf("設定に問題があります:\n%w", err)
+	}
+	if err := validateRunFlags(Flags); err != nil {
+		return fmt.Errorf("設定に問題があります:\n%w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "設定は有効です。")
+	return nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "設定に関する操作を行います。",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "'run' と同じフラグを検証し、不正な設定を報告します。",
+	Long:  "'run' コマンドと同じフラグを受け取り、LLMやVOICEVOXに接続せずに設定値の妥当性を検証します。",
+	RunE:  configValidateCmdFunc,
+	// 検証エラー時に使用方法の出力で結果が埋もれないようにする
+	SilenceUsage: true,
+}
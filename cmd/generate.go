@@ -2,10 +2,17 @@ package cmd
 
 import (
 	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/feed"
 	"act-feed-clean-go/internal/pipeline"
+	"act-feed-clean-go/internal/voice"
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
 	"github.com/shouni/go-voicevox/pkg/voicevox"
@@ -23,6 +30,10 @@ type appDependencies struct {
 	Cleaner                *cleaner.Cleaner
 	VoicevoxEngineExecutor voicevox.EngineExecutor
 	PipelineConfig         pipeline.PipelineConfig
+	// FeedCache は Conditional GET の検証子を保存するキャッシュです (--feed-cache-file 指定時のみ)。
+	FeedCache *feed.FileCache
+	// MapCache は Map要約のキャッシュです (--map-cache-file 指定時のみ)。
+	MapCache *cleaner.FileMapCache
 }
 
 // 依存関係構築 (メイン責務)
@@ -37,25 +48,75 @@ func newAppDependencies(ctx context.Context, f RunFlags) (*appDependencies, erro
 		return nil, fmt.Errorf("scraperRunnerの初期化に失敗しました: %w", err)
 	}
 
-	// 2. geminiの初期化
-	client, err := gemini.NewClientFromEnv(ctx)
+	// 1'. User-Agent・追加ヘッダーを付与し、Conditional GET でフィードを取得するパーサーへの差し替え
+	// (Conditional GET は --feed-cache-file 指定時のみ)
+	feedHeaders, err := feed.ParseHeaders(f.FeedHeaders)
+	if err != nil {
+		return nil, err
+	}
+	var feedCache *feed.FileCache
+	var conditionalCache feed.ConditionalCache
+	if f.FeedCacheFile != "" {
+		feedCache, err = feed.LoadFileCache(f.FeedCacheFile)
+		if err != nil {
+			return nil, err
+		}
+		conditionalCache = feedCache
+	}
+	scraperRunner.FeedParser = feed.NewParser(&http.Client{Timeout: f.HttpTimeout}, conditionalCache, feed.FetchConfig{
+		Headers:        feedHeaders,
+		MaxAttempts:    f.FeedRetries,
+		RetryBaseDelay: f.FeedRetryDelay,
+	})
+
+	// 2. geminiの初期化 (複数の APIキーがある場合はラウンドロビンで使い分ける)
+	client, err := newLLMClient(ctx, f)
 	if err != nil {
 		slog.Error("LLMクライアントの初期化に失敗しました。APIキーが設定されているか確認してください", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("LLMクライアントの初期化に失敗しました: %w", err)
 	}
 
 	// 3. cleanerの初期化
+	cleanerConfig, err := buildCleanerConfig(f)
+	if err != nil {
+		return nil, err
+	}
+	var mapCache *cleaner.FileMapCache
+	if f.MapCacheFile != "" {
+		if mapCache, err = cleaner.LoadFileMapCache(f.MapCacheFile); err != nil {
+			return nil, err
+		}
+		cleanerConfig.MapCache = mapCache
+	}
 	cleanerInstance, err := cleaner.NewCleaner(
 		client,
-		f.CleanerConfig,
+		cleanerConfig,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("クリーナーの初期化に失敗しました: %w", err)
 	}
 
-	// 4. VOICEVOX Engineの初期化
-	voicevoxExecutor, err := voicevox.NewEngineExecutor(ctx, f.HttpTimeout, f.OutputWAVPath != "")
-	if err != nil {
+	// 3'. ストリーミング用クライアントの初期化 (--stream 指定時のみ)
+	if f.Stream {
+		streamClient, err := cleaner.NewGeminiStreamClientFromEnv(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ストリーミング用クライアントの初期化に失敗しました: %w", err)
+		}
+		cleanerInstance.SetStreamClient(streamClient)
+	}
+
+	// 4. VOICEVOX Engineの初期化 (合成進捗は標準エラー出力に表示)
+	// 音声出力時はスクレイピング・LLM処理と並行してバックグラウンドで初期化し、合成の開始時に完了を待つ (warmup.goで定義)
+	// ドライランでは音声を合成しないため、バックグラウンドの初期化は行わない
+	var voicevoxExecutor voicevox.EngineExecutor
+	if f.OutputWAVPath != "" && !f.CleanerConfig.DryRun {
+		voicevoxExecutor = voice.StartEngineExecutor(
+			ctx,
+			f.HttpTimeout,
+			f.VoicevoxConcurrency,
+			voice.NewConsoleProgressFunc(os.Stderr, voice.DefaultProgressLogInterval),
+		)
+	} else if voicevoxExecutor, err = voice.NewEngineExecutor(ctx, f.HttpTimeout, false, f.VoicevoxConcurrency, nil); err != nil {
 		return nil, err
 	}
 
@@ -63,5 +124,430 @@ func newAppDependencies(ctx context.Context, f RunFlags) (*appDependencies, erro
 		ScraperRunner:          scraperRunner,
 		Cleaner:                cleanerInstance,
 		VoicevoxEngineExecutor: voicevoxExecutor,
+		FeedCache:              feedCache,
+		MapCache:               mapCache,
 	}, nil
 }
+
+// newLLMClient は LLMクライアントを生成します。環境変数 GEMINI_API_KEYS または --api-keys-file で
+// 複数の APIキーが指定された場合は、キーをローテーションする cleaner.KeyPool を返します。
+// それ以外の場合は GEMINI_API_KEY / GOOGLE_API_KEY の単一キーのクライアントを返します。
+// フェーズ別の生成パラメータが指定された場合、各クライアントは生成パラメータに対応した cleaner.GeminiClient です。
+func newLLMClient(ctx context.Context, f RunFlags) (gemini.GenerativeModel, error) {
+	// 生成パラメータの指定がある場合は、それに対応したクライアント (cleaner.GeminiClient) を使用する
+	genConfig := f.CleanerConfig
+	applyGenFlags(&genConfig, f)
+	configurable := genConfig.HasGenConfig()
+
+	keys, err := cleaner.LoadAPIKeys(f.APIKeysFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		if f.APIKeysFile != "" {
+			return nil, fmt.Errorf("APIキーファイルに有効なキーがありません (%s)", f.APIKeysFile)
+		}
+		if configurable {
+			return cleaner.NewGeminiClientFromEnv(ctx)
+		}
+		return gemini.NewClientFromEnv(ctx)
+	}
+	slog.Info("複数のAPIキーをローテーションして使用します", slog.Int("keys", len(keys)), slog.Duration("cooldown", f.KeyCooldown))
+	return cleaner.NewKeyPoolFromKeys(ctx, keys, f.KeyCooldown, configurable)
+}
+
+// applyGenFlags はフェーズ別の生成パラメータのフラグを config に設定します。
+func applyGenFlags(config *cleaner.CleanerConfig, f RunFlags) {
+	config.MapGenConfig = f.MapGen.config()
+	config.ReduceGenConfig = f.ReduceGen.config()
+	config.SummaryGenConfig = f.SummaryGen.config()
+	config.ScriptGenConfig = f.ScriptGen.config()
+}
+
+// buildCleanerConfig はフラグ情報から CleanerConfig を組み立てます。
+// トピック粒度・Reduce戦略の解析とNGリストファイルの読み込みもここで行います。
+func buildCleanerConfig(f RunFlags) (cleaner.CleanerConfig, error) {
+	cleanerConfig := f.CleanerConfig
+
+	granularity, err := cleaner.ParseTopicGranularity(f.TopicGranularity)
+	if err != nil {
+		return cleanerConfig, err
+	}
+	cleanerConfig.TopicGranularity = granularity
+
+	reduceStrategy, err := cleaner.ParseReduceStrategy(f.ReduceStrategy)
+	if err != nil {
+		return cleanerConfig, err
+	}
+	cleanerConfig.ReduceStrategy = reduceStrategy
+
+	layeredSummaryMode, err := cleaner.ParseLayeredSummaryMode(f.LayeredSummaryMode)
+	if err != nil {
+		return cleanerConfig, err
+	}
+	cleanerConfig.LayeredSummaryMode = layeredSummaryMode
+
+	summaryFormat, err := cleaner.ParseSummaryFormat(f.SummaryFormat)
+	if err != nil {
+		return cleanerConfig, err
+	}
+	cleanerConfig.SummaryFormat = summaryFormat
+
+	expertise, err := cleaner.ParseExpertiseLevel(f.Expertise)
+	if err != nil {
+		return cleanerConfig, err
+	}
+	cleanerConfig.ExpertiseLevel = expertise
+	applyGenFlags(&cleanerConfig, f)
+
+	if f.ReduceTemplateFile != "" {
+		process, err := cleaner.LoadTemplateProcessor(f.ReduceTemplateFile)
+		if err != nil {
+			return cleanerConfig, err
+		}
+		// フラグ側のマップを変更しないよう、コピーに追加する
+		processors := make(map[string][]cleaner.PostProcessor, len(cleanerConfig.PostProcessors)+1)
+		for phase, list := range cleanerConfig.PostProcessors {
+			processors[phase] = list
+		}
+		processors[cleaner.PhaseReduce] = append(slices.Clone(processors[cleaner.PhaseReduce]), process)
+		cleanerConfig.PostProcessors = processors
+	}
+
+	if f.NGWordsFile != "" {
+		ngWords, err := cleaner.LoadNGWords(f.NGWordsFile)
+		if err != nil {
+			return cleanerConfig, fmt.Errorf("NGリストの読み込みに失敗しました: %w", err)
+		}
+		cleanerConfig.NGWords = ngWords
+		slog.Debug("NGリストを読み込みました", slog.String("path", f.NGWordsFile), slog.Int("entries", len(ngWords)))
+	}
+
+	if f.PricingFile != "" {
+		pricing, err := cleaner.LoadPricingTable(f.PricingFile)
+		if err != nil {
+			return cleanerConfig, err
+		}
+		cleanerConfig.Pricing = pricing
+		slog.Debug("単価表を読み込みました", slog.String("path", f.PricingFile), slog.Int("models", len(pricing)))
+	}
+	return cleanerConfig, nil
+}
+
+// loadDisclaimerSettings は免責テンプレートファイルを読み込み、テンプレートと付与位置を検証して返します。
+func loadDisclaimerSettings(f RunFlags) (string, pipeline.DisclaimerPosition, error) {
+	position, err := pipeline.ParseDisclaimerPosition(f.DisclaimerPosition)
+	if err != nil {
+		return "", "", err
+	}
+	var templateText string
+	if f.DisclaimerTemplate != "" {
+		raw, err := os.ReadFile(f.DisclaimerTemplate)
+		if err != nil {
+			return "", "", fmt.Errorf("免責テンプレートファイルの読み込みに失敗しました: %w", err)
+		}
+		templateText = string(raw)
+	}
+	if _, err := pipeline.ParseDisclaimerTemplate(templateText); err != nil {
+		return "", "", err
+	}
+	return templateText, position, nil
+}
+
+// loadTTSNormalizer は --tts-normalize または --tts-dictionary 指定時に、読み上げ用の変換を行う TTSNormalizer を返します (無効の場合は nil)。
+func loadTTSNormalizer(f RunFlags) (*cleaner.TTSNormalizer, error) {
+	if f.TTSDictionary != "" {
+		dict, err := cleaner.LoadTTSDictionary(f.TTSDictionary)
+		if err != nil {
+			return nil, err
+		}
+		return cleaner.NewTTSNormalizer(dict), nil
+	}
+	if !f.TTSNormalize {
+		return nil, nil
+	}
+	return cleaner.NewTTSNormalizer(cleaner.DefaultTTSDictionary()), nil
+}
+
+// applyOutputFormats は --output-formats 指定時に、WAV・字幕の出力先を --output-dir 配下のパスに置き換えます。
+// wav を含まない場合は音声合成を行いません。
+func applyOutputFormats(f *RunFlags, formats []pipeline.OutputFormat) {
+	if len(formats) == 0 {
+		return
+	}
+	f.OutputWAVPath, f.SRTPath, f.VTTPath = "", "", ""
+	if pipeline.HasOutputFormat(formats, pipeline.OutputWAV) {
+		f.OutputWAVPath = pipeline.OutputFormatPath(f.OutputDir, pipeline.OutputWAV)
+	}
+	if pipeline.HasOutputFormat(formats, pipeline.OutputSRT) {
+		f.SRTPath = pipeline.OutputFormatPath(f.OutputDir, pipeline.OutputSRT)
+	}
+	if pipeline.HasOutputFormat(formats, pipeline.OutputVTT) {
+		f.VTTPath = pipeline.OutputFormatPath(f.OutputDir, pipeline.OutputVTT)
+	}
+}
+
+// mailConfig は --smtp-host 指定時に、ダイジェストメールの配信設定を返します (無効の場合は nil)。
+// SMTP の認証情報は環境変数から読み込みます。
+func mailConfig(f RunFlags) *pipeline.MailConfig {
+	if f.SMTPHost == "" {
+		return nil
+	}
+	return &pipeline.MailConfig{
+		Host:        f.SMTPHost,
+		Port:        f.SMTPPort,
+		From:        f.MailFrom,
+		To:          f.MailTo,
+		Username:    os.Getenv(pipeline.SMTPUsernameEnv),
+		Password:    os.Getenv(pipeline.SMTPPasswordEnv),
+		AttachAudio: f.MailAttachAudio,
+		AudioURL:    f.MailAudioURL,
+	}
+}
+
+// validateRunFlags はパイプライン実行前にフラグから組み立てた設定を検証します。
+func validateRunFlags(f RunFlags) error {
+	if err := feed.ValidateUndated(f.SinceUndated); err != nil {
+		return err
+	}
+	if _, err := pipeline.ParseSince(f.Since, time.Now()); err != nil {
+		return err
+	}
+	if _, _, err := loadDisclaimerSettings(f); err != nil {
+		return err
+	}
+	if err := feed.ValidatePrefer(f.FeedBodyPrefer); err != nil {
+		return err
+	}
+	if _, err := pipeline.ParseContentFormat(f.ContentFormat); err != nil {
+		return err
+	}
+	if f.Decorate {
+		if f.Stream {
+			return fmt.Errorf("--decorate は --stream と同時に指定できません (ストリーミング出力は装飾できません)")
+		}
+		if _, err := pipeline.LoadDecorationRules(f.DecorateTheme, f.DecorationRules); err != nil {
+			return err
+		}
+	}
+	if !f.ExtractCode && (f.ExtractInlineCode || f.CodeSnippetsFile != "") {
+		return fmt.Errorf("--extract-code-inline / --code-snippets-file は --extract-code と同時に指定してください")
+	}
+	scriptSource, err := pipeline.ParseScriptSource(f.ScriptSource)
+	if err != nil {
+		return err
+	}
+	if scriptSource != pipeline.ScriptSourceFinal && !f.LayeredSummary {
+		return fmt.Errorf("--script-source=%s は --layered-summary と同時に指定してください", scriptSource)
+	}
+	if f.LayeredSummary && f.Digest {
+		return fmt.Errorf("--layered-summary は --digest と同時に指定できません")
+	}
+	if f.CategoryProfiles != "" {
+		if _, err := cleaner.LoadCategoryProfiles(f.CategoryProfiles); err != nil {
+			return err
+		}
+	}
+	if _, err := pipeline.ParseDomainPriorities(f.DomainPriorities); err != nil {
+		return err
+	}
+	if _, err := pipeline.ParseDomains(f.BlockDomains); err != nil {
+		return fmt.Errorf("--block-domains: %w", err)
+	}
+	if _, err := pipeline.ParseDomains(f.UnblockDomains); err != nil {
+		return fmt.Errorf("--unblock-domains: %w", err)
+	}
+	if f.AutoBlockThreshold <= 0 || f.AutoBlockThreshold > 1 {
+		return fmt.Errorf("--auto-block-threshold には0より大きく1以下の値を指定してください: %v", f.AutoBlockThreshold)
+	}
+	if f.AutoBlockMinSamples < 1 {
+		return fmt.Errorf("--auto-block-min-samples には1以上を指定してください: %d", f.AutoBlockMinSamples)
+	}
+	if f.Digest && (len(f.BlockDomains) > 0 || f.TrackQuality || f.AutoBlock || len(f.UnblockDomains) > 0) {
+		return fmt.Errorf("--block-domains / --track-quality / --auto-block / --unblock-domains は --digest と同時に指定できません")
+	}
+	if len(f.SpeakerMap) > 0 {
+		if _, err := cleaner.ParseSpeakerMapping(f.SpeakerMap); err != nil {
+			return err
+		}
+		if f.Stream {
+			return fmt.Errorf("--speaker-map は --stream と同時に指定できません (ストリーミング出力は置き換えできません)")
+		}
+	}
+	if _, err := voice.ParseSpeakerIDs(f.Speakers); err != nil {
+		return err
+	}
+	if strings.ContainsAny(f.DefaultSpeaker, "[]：:") {
+		return fmt.Errorf("--default-speaker にはキャラクター名のみを指定してください: %q", f.DefaultSpeaker)
+	}
+	if err := speechProsody(f).Validate(); err != nil {
+		return fmt.Errorf("--speech-speed / --speech-pitch / --speech-intonation: %w", err)
+	}
+	if f.ConfirmOverCost < 0 {
+		return fmt.Errorf("--confirm-over-cost に負の値は指定できません: %v", f.ConfirmOverCost)
+	}
+	if f.CleanerConfig.DryRun && (f.Digest || f.EstimateOnly) {
+		return fmt.Errorf("--dry-run は --digest / --estimate-only と同時に指定できません")
+	}
+	if (f.EstimateOnly || f.ConfirmOverCost > 0) && f.Digest {
+		return fmt.Errorf("--estimate-only / --confirm-over-cost は --digest と同時に指定できません")
+	}
+	if f.ConfirmOverCost > 0 && f.URLsStdin && !f.Yes {
+		return fmt.Errorf("--urls-stdin 指定時は標準入力で確認できないため、--confirm-over-cost には --yes を併用してください")
+	}
+	if stdinFeeds := countStdinFeeds(f); stdinFeeds > 0 {
+		if stdinFeeds > 1 {
+			return fmt.Errorf("標準入力 (-) から読み込めるフィードは1つだけです")
+		}
+		if f.ConfirmOverCost > 0 && !f.Yes {
+			return fmt.Errorf("フィードを標準入力 (-) から読み込む場合は標準入力で確認できないため、--confirm-over-cost には --yes を併用してください")
+		}
+	}
+	sortBy, err := pipeline.ParseSortOrder(f.SortBy)
+	if err != nil {
+		return err
+	}
+	if _, err := pipeline.ParseImportanceMethods(f.ImportanceMethods); err != nil {
+		return err
+	}
+	if sortBy != pipeline.SortByImportance && len(f.ImportanceKeywords) > 0 {
+		return fmt.Errorf("--importance-keywords は --sort-by importance と同時に指定してください")
+	}
+	if f.PreviewContent && f.PreviewContentChars < 1 {
+		return fmt.Errorf("--preview-content-chars には1以上を指定してください: %d", f.PreviewContentChars)
+	}
+	if f.ReduceTemplateFile != "" {
+		if _, err := cleaner.LoadTemplateProcessor(f.ReduceTemplateFile); err != nil {
+			return err
+		}
+	}
+	if f.PerHostParallel < 0 {
+		return fmt.Errorf("--per-host-parallel には0以上を指定してください: %d", f.PerHostParallel)
+	}
+	if f.PerHostDelay < 0 {
+		return fmt.Errorf("--per-host-delay には0以上の期間を指定してください: %s", f.PerHostDelay)
+	}
+	if f.KeyCooldown <= 0 {
+		return fmt.Errorf("--key-cooldown には正の期間を指定してください: %s", f.KeyCooldown)
+	}
+	if f.References && f.Digest {
+		return fmt.Errorf("--references は --digest と同時に指定できません")
+	}
+	if len(f.OutputFormats) > 0 {
+		if _, err := pipeline.ParseOutputFormats(f.OutputFormats); err != nil {
+			return err
+		}
+		if f.Stream || f.Digest {
+			return fmt.Errorf("--output-formats は --stream / --digest と同時に指定できません")
+		}
+		if f.SRTPath != "" || f.VTTPath != "" {
+			return fmt.Errorf("--output-formats 指定時は --srt-path / --vtt-path の代わりに srt / vtt フォーマットを指定してください")
+		}
+	}
+	if f.SMTPHost != "" && (f.MailFrom == "" || len(f.MailTo) == 0) {
+		return fmt.Errorf("--smtp-host を指定する場合は --mail-from と --mail-to も指定してください")
+	}
+	if f.SMTPHost == "" && (len(f.MailTo) > 0 || f.MailAttachAudio || f.MailAudioURL != "") {
+		return fmt.Errorf("--mail-to / --mail-attach-audio / --mail-audio-url は --smtp-host と併せて指定してください")
+	}
+	if f.SMTPHost != "" {
+		if err := pipeline.ValidateMailAddress(f.MailFrom); err != nil {
+			return fmt.Errorf("--mail-from: %w", err)
+		}
+		for _, to := range f.MailTo {
+			if err := pipeline.ValidateMailAddress(to); err != nil {
+				return fmt.Errorf("--mail-to: %w", err)
+			}
+		}
+	}
+	if f.SMTPPort <= 0 || f.SMTPPort > 65535 {
+		return fmt.Errorf("--smtp-port には1以上65535以下の値を指定してください: %d", f.SMTPPort)
+	}
+	if f.MinSourceCoverage < 0 || f.MinSourceCoverage > 1 {
+		return fmt.Errorf("--min-source-coverage には0以上1以下の値を指定してください: %v", f.MinSourceCoverage)
+	}
+	if f.TargetDuration < 0 {
+		return fmt.Errorf("--target-duration に負の値は指定できません: %s", f.TargetDuration)
+	}
+	if f.DurationTolerance <= 0 || f.DurationTolerance > 1 {
+		return fmt.Errorf("--duration-tolerance には0より大きく1以下の値を指定してください: %v", f.DurationTolerance)
+	}
+	if f.TargetDuration > 0 && f.Digest {
+		return fmt.Errorf("--target-duration は --digest と同時に指定できません (ダイジェストはスクリプトを生成しません)")
+	}
+	if len(f.FeedURLs) > 0 && f.Digest {
+		return fmt.Errorf("--feed-urls は --digest と同時に指定できません (ダイジェストには --digest-feed-url を使用してください)")
+	}
+	if _, err := feed.ParseHeaders(f.FeedHeaders); err != nil {
+		return err
+	}
+	if f.FeedConcurrency < 1 {
+		return fmt.Errorf("--feed-concurrency には1以上を指定してください: %d", f.FeedConcurrency)
+	}
+	if f.FeedRetries < 1 {
+		return fmt.Errorf("--feed-retries には1以上を指定してください: %d", f.FeedRetries)
+	}
+	if f.FeedRetryDelay <= 0 {
+		return fmt.Errorf("--feed-retry-delay には正の期間を指定してください: %s", f.FeedRetryDelay)
+	}
+	if (f.RelatedLinks || f.ExtractedLinksFile != "") && f.Digest {
+		return fmt.Errorf("--related-links / --extracted-links-file は --digest と同時に指定できません")
+	}
+	if len(f.ABPrompts) > 0 {
+		if len(f.ABPrompts) < 2 {
+			return fmt.Errorf("--ab-prompts には比較するプロンプトセットのディレクトリを2つ以上指定してください")
+		}
+		if f.Digest {
+			return fmt.Errorf("--ab-prompts は --digest と同時に指定できません")
+		}
+		for _, dir := range f.ABPrompts {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return fmt.Errorf("--ab-prompts のディレクトリが見つかりません: %s", dir)
+			}
+			if _, err := cleaner.NewPromptManagerFromDir(dir); err != nil {
+				return err
+			}
+		}
+	}
+	if f.NoScrape && f.FallbackToFeedContent {
+		return fmt.Errorf("--no-scrape は --fallback-to-feed-content と同時に指定できません (--no-scrape は常にフィードの本文を使用します)")
+	}
+	if f.NoScrapeMinChars < 0 {
+		return fmt.Errorf("--no-scrape-min-chars に負の値は指定できません: %d", f.NoScrapeMinChars)
+	}
+	if f.MaxArticles < 0 {
+		return fmt.Errorf("--max-articles に負の値は指定できません: %d", f.MaxArticles)
+	}
+	if f.VoicevoxConcurrency < 1 {
+		return fmt.Errorf("--voicevox-concurrency には1以上を指定してください: %d", f.VoicevoxConcurrency)
+	}
+	if f.Digest && (f.SaveRunDir != "" || f.DiffAgainst != "") {
+		return fmt.Errorf("--save-run / --diff-against は --digest と同時に指定できません")
+	}
+	if f.Digest && f.DownloadImagesDir != "" {
+		return fmt.Errorf("--download-images-dir は --digest と同時に指定できません")
+	}
+	if f.DiffAgainst != "" {
+		if info, err := os.Stat(f.DiffAgainst); err != nil || !info.IsDir() {
+			return fmt.Errorf("--diff-against に指定したディレクトリが見つかりません: %s", f.DiffAgainst)
+		}
+	}
+	cleanerConfig, err := buildCleanerConfig(f)
+	if err != nil {
+		return err
+	}
+	return cleaner.ValidateCleanerConfig(cleanerConfig)
+}
+
+// countStdinFeeds は標準入力 (-) から読み込むよう指定されたフィードの数を返します。
+func countStdinFeeds(f RunFlags) int {
+	feedURLs := append([]string{f.FeedURL}, f.FeedURLs...)
+	feedURLs = append(feedURLs, f.DigestFeedURLs...)
+	n := 0
+	for _, u := range feedURLs {
+		if u == feed.StdinFeedURL {
+			n++
+		}
+	}
+	return n
+}
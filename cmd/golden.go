@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"act-feed-clean-go/internal/golden"
+
+	"github.com/spf13/cobra"
+)
+
+// ----------------------------------------------------------------------
+// 'golden' コマンド (Cleaner のフェーズ単位の回帰検出)
+// ----------------------------------------------------------------------
+
+// GoldenFlags は 'golden' コマンド固有のフラグを保持する構造体です。
+type GoldenFlags struct {
+	Dir    string // ゴールデンケースのディレクトリ
+	Update bool   // 期待出力を今回の出力で更新するか
+	Record bool   // 実 API を呼び出してカセットを記録し直すか (期待出力も更新する)
+}
+
+var goldenFlags GoldenFlags
+
+// goldenCmdFunc は 'golden' サブコマンドが呼び出されたときに実行される関数です。
+// 比較・更新は go test と同じハーネス (golden.Case.Replay) で行い、--record の場合のみ実 API でカセットを記録し直します。
+func goldenCmdFunc(cmd *cobra.Command, args []string) error {
+	initLogger()
+	ctx := cmd.Context()
+
+	cases, err := golden.LoadCases(goldenFlags.Dir)
+	if err != nil {
+		return err
+	}
+	if goldenFlags.Record {
+		return recordGolden(ctx, cases)
+	}
+
+	failed := 0
+	for _, c := range cases {
+		mismatches, unused, err := c.Replay(ctx, goldenFlags.Update)
+		if err != nil {
+			return fmt.Errorf("%w (--record で記録してください)", err)
+		}
+		if unused > 0 {
+			slog.Warn("カセットに使用されなかったレスポンスがあります。プロンプトを変更した場合は --record で記録し直してください",
+				slog.String("case", c.Name), slog.Int("unused", unused))
+		}
+		if goldenFlags.Update {
+			slog.Info("期待出力を更新しました", slog.String("case", c.Name))
+			continue
+		}
+		if len(mismatches) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "ok   %s\n", c.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s\n", c.Name)
+		for _, m := range mismatches {
+			fmt.Fprint(cmd.OutOrStdout(), m.Diff)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 件のケースで期待出力と一致しませんでした (意図した変更の場合は --update で更新してください)", failed)
+	}
+	return nil
+}
+
+// recordGolden は実 API を呼び出して各ケースのカセットを記録し直し、期待出力も更新します。
+func recordGolden(ctx context.Context, cases []golden.Case) error {
+	live, err := newLLMClient(ctx, Flags)
+	if err != nil {
+		return fmt.Errorf("カセットの記録には LLM の APIキーが必要です: %w", err)
+	}
+	for _, c := range cases {
+		cassette := golden.NewCassette()
+		outputs, err := c.Generate(ctx, golden.NewRecordingClient(live, cassette), golden.Config())
+		if err != nil {
+			return err
+		}
+		if err := cassette.Save(filepath.Join(c.Dir, golden.CassetteFile)); err != nil {
+			return err
+		}
+		if err := c.Update(outputs); err != nil {
+			return err
+		}
+		slog.Info("カセットと期待出力を記録しました", slog.String("case", c.Name))
+	}
+	return nil
+}
+
+// addGoldenFlags は 'golden' コマンドに固有のフラグを設定します。
+func addGoldenFlags(goldenCmd *cobra.Command) {
+	goldenCmd.Flags().StringVar(&goldenFlags.Dir,
+		"dir", golden.DefaultDir, "ゴールデンケースのディレクトリ (各サブディレクトリに input.md・cassette.json・期待出力を置く)。")
+	goldenCmd.Flags().BoolVar(&goldenFlags.Update,
+		"update", false, "期待出力 (map.md / reduce.md / summary.md / script.txt) を今回の出力で更新します。")
+	goldenCmd.Flags().BoolVar(&goldenFlags.Record,
+		"record", false, "実 API を呼び出して LLM のレスポンスをカセットに記録し直し、期待出力も更新します (APIキーが必要)。")
+}
+
+var goldenCmd = &cobra.Command{
+	Use:   "golden",
+	Short: "固定入力に対する Map/Reduce/Summary/Script の出力を期待出力と比較します。",
+	Long: "testdata/golden の各ケースの入力を、記録済みの LLM レスポンス (cassette.json) を再生して処理し、\n" +
+		"フェーズごとの期待出力との差分を報告します。実 API には接続しないため、プロンプトや後処理の変更による回帰を決定的に検出できます。",
+	RunE:         goldenCmdFunc,
+	SilenceUsage: true,
+}
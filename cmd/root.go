@@ -2,12 +2,21 @@ package cmd
 
 import (
 	"act-feed-clean-go/internal/pipeline"
+	"bufio"
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
 	"time"
 
 	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/correlation"
+	"act-feed-clean-go/internal/feed"
+	"act-feed-clean-go/internal/state"
+	"act-feed-clean-go/internal/voice"
 
 	"github.com/shouni/go-cli-base"
 	"github.com/spf13/cobra"
@@ -19,19 +28,148 @@ import (
 
 // RunFlags は 'run' コマンド固有のフラグを保持する構造体です。
 type RunFlags struct {
-	FeedURL       string
-	Parallel      int
-	HttpTimeout   time.Duration
-	OutputWAVPath string
-	CleanerConfig cleaner.CleanerConfig
+	FeedURL        string
+	Parallel       int
+	HttpTimeout    time.Duration
+	OutputWAVPath  string
+	NGWordsFile    string
+	TargetLUFS     float64
+	SRTPath        string // 音声のタイムコード付き字幕 (SRT) の出力先
+	VTTPath        string // 音声のタイムコード付き字幕 (WebVTT) の出力先
+	VTTSpeakerTags bool   // WebVTT に話者名を <v 話者> タグで埋め込むか
+	URLsStdin      bool   // フィードの代わりに標準入力のURLリストを処理するか
+	Stream         bool   // スクリプト生成をストリーミングで逐次表示するか
+	Since          string // 公開時刻がこれより前の記事を除外する (期間または日時)
+
+	Disclaimer         bool   // 出力に免責文を付与するか
+	DisclaimerTemplate string // 免責文テンプレートのファイルパス (空の場合はデフォルト)
+	DisclaimerPosition string // テキスト出力時の免責文の位置 (head / tail)
+
+	Decorate        bool   // テキスト出力に絵文字・装飾を付与するか
+	DecorateTheme   string // 装飾のテーマ (news / tech / casual、またはルールファイルで定義したテーマ)
+	DecorationRules string // 装飾ルールを上書きする JSON ファイルのパス
+
+	MemProfile  string // 実行終了時にヒーププロファイルを書き出すファイルパス
+	SaveRunDir  string // 中間要約・最終要約・スクリプトを保存するディレクトリ
+	DiffAgainst string // 今回の結果と比較する前回の実行結果のディレクトリ
+	DiffOnly    bool   // 前回から増えた記事のみを処理するか
+	StatePath   string // 処理済み記事を記録する状態ファイルのパス
+	RecordRuns  bool   // 実行結果 (ステータス・所要時間・要約) を状態ファイルの実行履歴に記録するか
+	// FallbackToFeedContent はスクレイピング失敗時にフィードの要約文で本文を代替するかどうかです。
+	FallbackToFeedContent bool
+	NoScrape              bool          // スクレイピングを行わずフィードの本文だけで処理するか
+	NoScrapeMinChars      int           // --no-scrape 時に記事として扱うフィード本文の最小文字数
+	SanitizeInput         bool          // 記事本文の不可視文字・制御文字の除去と空白・改行の正規化を行うか
+	RequireSingleLang     bool          // 本文の言語が最多の言語と異なる記事を除外するか
+	FeedBodyPrefer        string        // フィードの本文候補の優先順位 (content / description / longer)
+	FeedCacheFile         string        // Conditional GET 用の ETag / Last-Modified を保存するファイルのパス
+	MapCacheFile          string        // Map要約をセグメントの内容のハッシュで保存するキャッシュファイルのパス
+	FeedHeaders           []string      // フィード取得時に付与する HTTP ヘッダー (key=value 形式)
+	FeedRetries           int           // フィード取得の初回を含む最大試行回数 (ネットワークエラーと 5xx / 429 のみ再試行)
+	FeedRetryDelay        time.Duration // フィード取得の再試行の初回の待機時間 (以降は試行ごとに倍)
+	ContentFormat         string        // AI処理に渡す本文の形式 (markdown / plain)
+	DownloadImagesDir     string        // 記事のアイキャッチ画像の保存先ディレクトリ
+	PerHostParallel       int           // Webスクレイピングの 1 ホストあたりの同時リクエスト数 (0 で制限なし)
+	PerHostDelay          time.Duration // 同一ホストへのリクエストを開始する間隔 (0 で制限なし)
+	ImageDownloadParallel int           // 画像の同時ダウンロード数
+	ImageDownloadTimeout  time.Duration // 画像1件あたりのダウンロードのタイムアウト
+	VoicevoxConcurrency   int           // VOICEVOXで audio_query / synthesis を同時に実行する行数
+	ExtractCode           bool          // 記事本文のコードブロックを抽出して要約対象から除外するか
+	ExtractInlineCode     bool          // インラインコードも抽出するか
+	CodeSnippetsFile      string        // 抽出したコードを書き出すファイルのパス
+	CategoryProfiles      string        // カテゴリ別の処理設定 (モデル・プロンプトセット・トーン) の JSON ファイルのパス
+	LayeredSummary        bool          // 1行要約・段落要約・詳細要約の多層要約を生成するか
+	LayeredSummaryMode    string        // 多層要約の生成方法 (single / separate)
+	SummaryFormat         string        // 最終要約の出力形式 (prose / bullet / both)
+	Expertise             string        // 最終要約の想定読者の専門性 (beginner / intermediate / expert)
+	ScriptSource          string        // スクリプト生成の入力にする要約 (final / detailed / paragraph)
+	DomainPriorities      []string      // スクレイピング順のドメイン優先度 (example.com=10 形式)
+	PreferRecent          bool          // 同じ優先度の記事を公開時刻の新しい順に処理するか
+	MaxArticles           int           // フィードごとに処理する記事数の上限 (0 で無制限)
+	BlockDomains          []string      // 処理対象から除外するドメイン (サブドメインにも一致)
+	TrackQuality          bool          // 記事ごとの品質指標を計測し、ドメイン別の平均品質スコアを状態ファイルに蓄積するか
+	AutoBlock             bool          // 平均品質スコアが閾値を下回るドメインを自動ブロックするか
+	AutoBlockThreshold    float64       // 自動ブロックする平均品質スコアの閾値 (0〜1)
+	AutoBlockMinSamples   int           // 自動ブロックの判定に必要なドメインの記事数
+	UnblockDomains        []string      // 自動ブロックを解除するドメイン
+	SpeakerMap            []string      // 生成したスクリプトの話者の置き換え (ずんだもん=めたん 形式)
+	Speakers              []string      // キャラクターごとの VOICEVOX の話者 (スタイル) ID (ずんだもん=3 形式)
+	DefaultSpeaker        string        // 話者の指定がない行を読み上げるキャラクター
+	SpeechSpeed           float64       // 音声合成の話速 (speedScale。0 でエンジンの既定値)
+	SpeechPitch           float64       // 音声合成の音高 (pitchScale。0 でエンジンの既定値)
+	SpeechIntonation      float64       // 音声合成の抑揚 (intonationScale。0 でエンジンの既定値)
+	TTSNormalize          bool          // 音声合成の前にスクリプトの数値・単位・英略語を読みに変換するか
+	TTSDictionary         string        // 読み上げ用の変換ルール (単位・英略語の読み) を追加・上書きする JSON ファイルのパス
+	SMTPHost              string        // ダイジェストメールを送信する SMTP サーバーのホスト名 (空の場合は送信しない)
+	SMTPPort              int           // SMTP サーバーのポート
+	MailFrom              string        // ダイジェストメールの送信元アドレス
+	MailTo                []string      // ダイジェストメールの宛先アドレス
+	MailAttachAudio       bool          // 合成した音声ファイルをダイジェストメールに添付するか
+	MailAudioURL          string        // ダイジェストメールの本文に記載する音声ファイルのURL
+	OutputFormats         []string      // 1 回の実行で生成する出力フォーマット (text / markdown / json / wav / srt / vtt / rss)
+	OutputDir             string        // --output-formats の各フォーマットの出力先ディレクトリ
+	EstimateOnly          bool          // Mapフェーズのコストの見積もりのみを表示して終了するか
+	ConfirmOverCost       float64       // 見積もりコストがこの値 (USD) を超える場合に実行を確認する (0 で確認しない)
+	Yes                   bool          // コストの確認をスキップして続行するか
+	Force                 bool          // 同じ入力で成功した成果物があっても LLM 処理をやり直すか
+	SortBy                string        // スクレイピング後の記事の並び順 (feed / importance)
+	ImportanceMethods     []string      // 重要度の算出方法 (length / keywords / llm)
+	ImportanceKeywords    []string      // 重要度の算出でヒット数を数えるキーワード
+	PreviewContent        bool          // 抽出した各記事の本文の先頭を debug ログに出力するか
+	PreviewContentChars   int           // プレビューとして出力する本文の先頭の文字数
+	ReduceTemplateFile    string        // Reduce結果を最終要約に渡す前に整形する text/template ファイルのパス
+	APIKeysFile           string        // ローテーションする複数の APIキーのファイル (1行1キー)
+	KeyCooldown           time.Duration // レート制限に当たった APIキーをローテーションから外す時間
+	PricingFile           string        // モデルごとの 1000 トークンあたりの単価 (USD) の JSON ファイルのパス
+	FeedURLs              []string      // マージして 1 本のスクリプトとして処理する複数のフィードURL
+	FeedConcurrency       int           // --feed-urls のフィードを並列に取得する際の同時取得数
+	References            bool          // テキスト出力の末尾に参照記事 (タイトルとURL) の一覧を付与するか
+	MinSourceCoverage     float64       // --cite-sources 指定時に、最終要約のソースカバレッジがこれを下回ると警告する
+	SinceUndated          string        // --since 指定時に公開時刻のない記事を残すか (include / exclude)
+	TargetDuration        time.Duration // 音声の目標の再生時間 (スクリプトの目標文字数の算出とキャリブレーションに使用)
+	DurationTolerance     float64       // 再生時間が目標からこの割合を超えて外れた場合に読み上げ速度の係数を更新する
+	RelatedLinks          bool          // 記事本文から抽出した外部リンクを関連リンクとして出力の末尾に付与するか
+	ExtractedLinksFile    string        // 記事本文から抽出した外部リンクを書き出すファイルのパス
+	ABPrompts             []string      // A/B 比較するプロンプトセットのディレクトリ (2つ以上)
+	ABOutputDir           string        // A/B 比較の変種ごとの出力と比較サマリの保存先ディレクトリ
+	CleanerConfig         cleaner.CleanerConfig
+
+	Digest           bool     // 複数フィードをマージしたトピック別ダイジェストを生成するか
+	DigestFeedURLs   []string // ダイジェストモードで feed-url に加えて取得するフィードURL
+	TopicGranularity string   // トピック分類の粒度 (coarse / medium / fine)
+	ReduceStrategy   string   // Reduce戦略 (concat / hierarchical / refine)
+	Timeouts         pipeline.TimeoutBudget
+
+	// フェーズ別の生成パラメータ (--map-temperature など)
+	MapGen     genFlags
+	ReduceGen  genFlags
+	SummaryGen genFlags
+	ScriptGen  genFlags
 }
 
-var Flags RunFlags
+// genFlags は 1 フェーズの生成パラメータのフラグです。Temperature・TopP は負の値、MaxTokens は 0 で未指定です。
+type genFlags struct {
+	Temperature float64
+	TopP        float64
+	MaxTokens   int
+}
 
-const (
-	// contextTimeout は、パイプライン全体の実行に許容される最大時間です。
-	contextTimeout = 20 * time.Minute
-)
+// config は指定されたパラメータのみを設定した cleaner.GenConfig を返します。
+func (g genFlags) config() cleaner.GenConfig {
+	var config cleaner.GenConfig
+	if g.Temperature >= 0 {
+		temperature := float32(g.Temperature)
+		config.Temperature = &temperature
+	}
+	if g.TopP >= 0 {
+		topP := float32(g.TopP)
+		config.TopP = &topP
+	}
+	config.MaxOutputTokens = int32(g.MaxTokens)
+	return config
+}
+
+var Flags RunFlags
 
 // ----------------------------------------------------------------------
 // ヘルパー関数 (ロギング、正規化、初期化) (initLogger を保持)
@@ -53,7 +191,8 @@ func initLogger() {
 			return a
 		},
 	})
-	slog.SetDefault(slog.New(handler))
+	// 相関ID (cid) をコンテキストからログに付与するハンドラーでラップ
+	slog.SetDefault(slog.New(correlation.NewHandler(handler)))
 	slog.Info("ロガーを初期化しました", slog.String("level", logLevel.String()))
 }
 
@@ -64,22 +203,180 @@ func initLogger() {
 // runCmdFunc は 'run' サブコマンドが呼び出されたときに実行される関数です。
 func runCmdFunc(cmd *cobra.Command, args []string) error {
 	parentCtx := cmd.Context()
-	ctx, cancel := context.WithTimeout(parentCtx, contextTimeout)
+	ctx, cancel := context.WithTimeout(parentCtx, Flags.Timeouts.Overall)
 	defer cancel()
 
 	initLogger()
 
+	// URLリストモードでは、依存関係の構築前に標準入力を読み込む
+	var stdinURLs []string
+	if Flags.URLsStdin {
+		var err error
+		if stdinURLs, err = readStdinURLs(); err != nil {
+			return err
+		}
+	}
+
+	since, err := pipeline.ParseSince(Flags.Since, time.Now())
+	if err != nil {
+		return err
+	}
+	contentFormat, err := pipeline.ParseContentFormat(Flags.ContentFormat)
+	if err != nil {
+		return err
+	}
+	outputFormats, err := pipeline.ParseOutputFormats(Flags.OutputFormats)
+	if err != nil {
+		return err
+	}
+	applyOutputFormats(&Flags, outputFormats)
+	var decoration *pipeline.DecorationRules
+	if Flags.Decorate {
+		if decoration, err = pipeline.LoadDecorationRules(Flags.DecorateTheme, Flags.DecorationRules); err != nil {
+			return err
+		}
+	}
+	disclaimerTemplate, disclaimerPosition, err := loadDisclaimerSettings(Flags)
+	if err != nil {
+		return err
+	}
+	scriptSource, err := pipeline.ParseScriptSource(Flags.ScriptSource)
+	if err != nil {
+		return err
+	}
+	domainPriorities, err := pipeline.ParseDomainPriorities(Flags.DomainPriorities)
+	if err != nil {
+		return err
+	}
+	blockDomains, err := pipeline.ParseDomains(Flags.BlockDomains)
+	if err != nil {
+		return err
+	}
+	unblockDomains, err := pipeline.ParseDomains(Flags.UnblockDomains)
+	if err != nil {
+		return err
+	}
+	domainQuality := pipeline.DomainQualityConfig{
+		Track:      Flags.TrackQuality,
+		AutoBlock:  Flags.AutoBlock,
+		Threshold:  Flags.AutoBlockThreshold,
+		MinSamples: Flags.AutoBlockMinSamples,
+		Unblock:    unblockDomains,
+	}
+	speakerMapping, err := cleaner.ParseSpeakerMapping(Flags.SpeakerMap)
+	if err != nil {
+		return err
+	}
+	speakerIDs, err := voice.ParseSpeakerIDs(Flags.Speakers)
+	if err != nil {
+		return err
+	}
+	speakers := voice.SpeakerConfig{IDs: speakerIDs, Default: voice.SpeakerName(Flags.DefaultSpeaker)}
+	ttsNormalizer, err := loadTTSNormalizer(Flags)
+	if err != nil {
+		return err
+	}
+	sortBy, err := pipeline.ParseSortOrder(Flags.SortBy)
+	if err != nil {
+		return err
+	}
+	importanceMethods, err := pipeline.ParseImportanceMethods(Flags.ImportanceMethods)
+	if err != nil {
+		return err
+	}
+	var categoryProfiles map[string]cleaner.CategoryProfile
+	if Flags.CategoryProfiles != "" {
+		if categoryProfiles, err = cleaner.LoadCategoryProfiles(Flags.CategoryProfiles); err != nil {
+			return err
+		}
+	}
+
+	// 目標の再生時間は、学習済みの読み上げ速度でスクリプトの目標文字数に換算してプロンプトで指示する
+	if Flags.CleanerConfig.ScriptTargetChars, err = scriptTargetChars(Flags); err != nil {
+		return err
+	}
+
 	// 1. 依存関係の構築（generate.go にあるヘルパー関数に委譲）
 	deps, err := newAppDependencies(ctx, Flags)
 	if err != nil {
 		return err
 	}
+	// 終了時 (中断時を含む) に進行中のLLM処理をキャンセルしてリソースを解放する
+	defer deps.Cleaner.Close()
+	defer logKeyUsage(deps.Cleaner)
+	if Flags.MemProfile != "" {
+		defer writeMemProfile(Flags.MemProfile)
+	}
 
 	pipelineConfig := pipeline.PipelineConfig{
 		Parallel:      Flags.Parallel,
 		OutputWAVPath: Flags.OutputWAVPath,
-		ClientTimeout: Flags.HttpTimeout,
-		Verbose:       clibase.Flags.Verbose,
+		Timeouts:      Flags.Timeouts,
+
+		TargetLUFS:            Flags.TargetLUFS,
+		SRTPath:               Flags.SRTPath,
+		VTTPath:               Flags.VTTPath,
+		VTTSpeakerTags:        Flags.VTTSpeakerTags,
+		FallbackToFeedContent: Flags.FallbackToFeedContent,
+		NoScrape:              Flags.NoScrape,
+		NoScrapeMinChars:      Flags.NoScrapeMinChars,
+		SanitizeInput:         Flags.SanitizeInput,
+		RequireSingleLanguage: Flags.RequireSingleLang,
+		FeedConcurrency:       Flags.FeedConcurrency,
+		FeedBodyPrefer:        Flags.FeedBodyPrefer,
+		ContentFormat:         contentFormat,
+		DownloadImagesDir:     Flags.DownloadImagesDir,
+		ImageDownloadParallel: Flags.ImageDownloadParallel,
+		PerHostParallel:       Flags.PerHostParallel,
+		PerHostDelay:          Flags.PerHostDelay,
+		ImageDownloadTimeout:  Flags.ImageDownloadTimeout,
+		ExtractCode:           Flags.ExtractCode,
+		ExtractInlineCode:     Flags.ExtractInlineCode,
+		CodeSnippetsPath:      Flags.CodeSnippetsFile,
+		CategoryProfiles:      categoryProfiles,
+		LayeredSummary:        Flags.LayeredSummary,
+		ScriptSource:          scriptSource,
+		Priority:              pipeline.URLPriority{Domains: domainPriorities, PreferRecent: Flags.PreferRecent},
+		MaxArticles:           Flags.MaxArticles,
+		BlockDomains:          blockDomains,
+		DomainQuality:         domainQuality,
+		SpeakerMapping:        speakerMapping,
+		Speakers:              speakers,
+		Prosody:               speechProsody(Flags),
+		TTSNormalizer:         ttsNormalizer,
+		Mail:                  mailConfig(Flags),
+		OutputFormats:         outputFormats,
+		OutputDir:             Flags.OutputDir,
+		EstimateOnly:          Flags.EstimateOnly,
+		Force:                 Flags.Force,
+		ConfirmOverCostUSD:    Flags.ConfirmOverCost,
+		SortBy:                sortBy,
+		PreviewContentChars:   previewContentChars(Flags),
+		References:            Flags.References,
+		MinSourceCoverage:     Flags.MinSourceCoverage,
+		TargetDuration:        Flags.TargetDuration,
+		DurationTolerance:     Flags.DurationTolerance,
+		Importance:            pipeline.ImportanceConfig{Methods: importanceMethods, Keywords: Flags.ImportanceKeywords},
+		DiffOnly:              Flags.DiffOnly,
+		Stream:                Flags.Stream,
+		Since:                 since,
+		SinceUndated:          Flags.SinceUndated,
+		RelatedLinks:          Flags.RelatedLinks,
+		ExtractedLinksFile:    Flags.ExtractedLinksFile,
+		ABPromptDirs:          Flags.ABPrompts,
+		ABOutputDir:           Flags.ABOutputDir,
+
+		Disclaimer:         Flags.Disclaimer,
+		DisclaimerTemplate: disclaimerTemplate,
+		DisclaimerPosition: disclaimerPosition,
+		Decoration:         decoration,
+		StatePath:          Flags.StatePath,
+		SaveRunDir:         Flags.SaveRunDir,
+		DiffAgainst:        Flags.DiffAgainst,
+		Verbose:            clibase.Flags.Verbose,
+	}
+	if !Flags.Yes {
+		pipelineConfig.ConfirmCost = confirmCost(Flags.ConfirmOverCost)
 	}
 
 	// 2. Pipelineインスタンスを生成（依存関係を注入）
@@ -91,7 +388,168 @@ func runCmdFunc(cmd *cobra.Command, args []string) error {
 	)
 
 	// 3. Pipelineの実行
-	return pipelineInstance.Run(ctx, Flags.FeedURL)
+	startedAt := time.Now()
+	mode, result, err := executePipeline(ctx, pipelineInstance, stdinURLs)
+	// 検証子は処理が成功した場合のみ保存し、失敗した実行のフィードが次回スキップされないようにする
+	if err == nil && deps.FeedCache != nil {
+		if saveErr := deps.FeedCache.Save(); saveErr != nil {
+			slog.Warn("フィードキャッシュの保存に失敗しました", slog.String("error", saveErr.Error()))
+		}
+	}
+	// Map要約は失敗した実行で生成できた分も再利用できるため、結果によらず保存する
+	if deps.MapCache != nil {
+		if saveErr := deps.MapCache.Save(); saveErr != nil {
+			slog.Warn("Map要約キャッシュの保存に失敗しました", slog.String("error", saveErr.Error()))
+		}
+	}
+	if Flags.RecordRuns {
+		recordRun(Flags.StatePath, startedAt, mode, result, err)
+	}
+	return err
+}
+
+// executePipeline はフラグに応じたモードでパイプラインを実行し、実行モード名と結果を返します。
+func executePipeline(ctx context.Context, p *pipeline.Pipeline, stdinURLs []string) (string, *pipeline.RunResult, error) {
+	if Flags.URLsStdin {
+		result, err := p.RunURLs(ctx, stdinURLs)
+		return "urls", result, err
+	}
+	if Flags.Digest {
+		feedURLs := append([]string{Flags.FeedURL}, Flags.DigestFeedURLs...)
+		result, err := p.RunMulti(ctx, feedURLs)
+		return "digest", result, err
+	}
+	if len(Flags.FeedURLs) > 0 {
+		result, err := p.RunFeeds(ctx, Flags.FeedURLs)
+		return "feeds", result, err
+	}
+	result, err := p.Run(ctx, Flags.FeedURL)
+	return "feed", result, err
+}
+
+// validateInputMode は入力モード (フィード / 標準入力のURLリスト) のフラグの組み合わせを検証します。
+func validateInputMode(cmd *cobra.Command, f RunFlags) error {
+	if !f.URLsStdin {
+		return nil
+	}
+	var conflicts []string
+	for _, name := range []string{"feed-url", "feed-urls", "feed-concurrency", "digest", "digest-feed-url", "diff-only", "fallback-to-feed-content", "no-scrape", "no-scrape-min-chars", "feed-body-prefer", "feed-cache-file", "feed-header", "feed-retries", "feed-retry-delay", "download-images-dir", "since", "since-undated"} {
+		if cmd.Flags().Changed(name) {
+			conflicts = append(conflicts, "--"+name)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("--urls-stdin はフィードを使用するオプションと同時に指定できません: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// writeMemProfile はヒーププロファイルを path に書き出します (go tool pprof で分析できます)。
+// プロファイルの書き出しに失敗しても処理結果には影響させず、警告のみ出力します。
+func writeMemProfile(path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		slog.Warn("ヒーププロファイルの作成に失敗しました", slog.String("path", path), slog.String("error", err.Error()))
+		return
+	}
+	defer f.Close()
+
+	runtime.GC() // 直近の割り当て状況を反映させる
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		slog.Warn("ヒーププロファイルの書き出しに失敗しました", slog.String("path", path), slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("ヒーププロファイルを書き出しました", slog.String("path", path))
+}
+
+// previewContentChars は --preview-content 指定時のみ、プレビューとして出力する本文の文字数を返します (無効の場合は 0)。
+func previewContentChars(f RunFlags) int {
+	if !f.PreviewContent {
+		return 0
+	}
+	if !clibase.Flags.Verbose {
+		slog.Warn("--preview-content の出力は debug ログのため、表示するには --verbose を指定してください")
+	}
+	return f.PreviewContentChars
+}
+
+// scriptTargetChars は --target-duration 指定時に、状態ファイルに学習済みの読み上げ速度 (1分あたりの文字数) から
+// スクリプト全体の目標文字数を返します (未指定の場合は 0)。
+func scriptTargetChars(f RunFlags) (int, error) {
+	if f.TargetDuration <= 0 {
+		return 0, nil
+	}
+	store, err := state.Load(f.StatePath)
+	if err != nil {
+		return 0, err
+	}
+	charsPerMinute := pipeline.CharsPerMinute(store.SpeechRates())
+	chars := pipeline.TargetScriptChars(f.TargetDuration, charsPerMinute)
+	slog.Info("目標の再生時間からスクリプトの目標文字数を算出しました",
+		slog.Duration("target_duration", f.TargetDuration),
+		slog.Float64("chars_per_minute", charsPerMinute),
+		slog.Int("target_chars", chars),
+	)
+	return chars, nil
+}
+
+// confirmCost は見積もりコストを表示し、標準入力から実行を続けるかどうかを確認します。
+// 標準入力が端末でない場合は確認できないため、--yes の指定を促すエラーを返します。
+func confirmCost(threshold float64) pipeline.CostConfirmFunc {
+	return func(estimate cleaner.CostEstimate) (bool, error) {
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false, fmt.Errorf("見積もりコスト ($%.4f) が閾値 ($%.4f) を超えていますが、標準入力が端末ではないため確認できません。続行する場合は --yes を指定してください", estimate.CostUSD, threshold)
+		}
+		return askYesNo(fmt.Sprintf("%s\n見積もりコストが閾値 ($%.4f) を超えています。続行しますか?", estimate, threshold))
+	}
+}
+
+// speechProsody は --speech-speed / --speech-pitch / --speech-intonation から音声合成の話速・音高・抑揚を返します。
+func speechProsody(f RunFlags) voice.Prosody {
+	return voice.Prosody{SpeedScale: f.SpeechSpeed, PitchScale: f.SpeechPitch, IntonationScale: f.SpeechIntonation}
+}
+
+// askYesNo は prompt を標準エラー出力に表示し、標準入力から y / yes の入力があった場合に true を返します。
+func askYesNo(prompt string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("確認の入力の読み込みに失敗しました: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// logKeyUsage は複数の APIキーをローテーションした場合に、キーごとの使用状況をログに出力します。
+func logKeyUsage(c *cleaner.Cleaner) {
+	for _, u := range c.KeyUsage() {
+		slog.Info("APIキーの使用状況",
+			slog.String("key", u.Label),
+			slog.Int("calls", u.Calls),
+			slog.Int("rate_limited", u.RateLimited),
+			slog.Int("errors", u.Errors),
+			slog.Bool("cooling_down", u.CoolingDown),
+		)
+	}
+}
+
+// readStdinURLs は標準入力から URL リストを読み込みます。
+// 標準入力が端末の場合はパイプ等での入力を促すエラーを返します。
+func readStdinURLs() ([]string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return nil, fmt.Errorf("--urls-stdin 指定時は、URLリストを標準入力にパイプまたはリダイレクトで渡してください (例: cat urls.txt | actfeedclean run --urls-stdin)")
+	}
+	urls, err := pipeline.ReadURLList(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("標準入力から有効なURLが1件も読み込めませんでした")
+	}
+	return urls, nil
 }
 
 // ----------------------------------------------------------------------
@@ -105,10 +563,130 @@ func addRunFlags(runCmd *cobra.Command) {
 		"feed-url", "f", "https://news.yahoo.co.jp/rss/categories/it.xml", "処理対象のRSSフィードURL")
 	runCmd.Flags().IntVarP(&Flags.Parallel,
 		"parallel", "p", 10, "Webスクレイピングの最大同時並列リクエスト数")
+	runCmd.Flags().IntVar(&Flags.PerHostParallel,
+		"per-host-parallel", 0, "Webスクレイピングで 1 ホストあたりに許可する同時リクエスト数。--parallel とは独立に適用します (0 で制限なし)。")
+	runCmd.Flags().DurationVar(&Flags.PerHostDelay,
+		"per-host-delay", 0, "Webスクレイピングで同一ホストへのリクエストを開始する間隔 (例: 2s)。異なるホストへのリクエストは待機しません (0 で制限なし)。")
 	runCmd.Flags().DurationVarP(&Flags.HttpTimeout,
 		"http-timeout", "t", 30*time.Second, "HTTPタイムアウト時間")
+	runCmd.Flags().BoolVar(&Flags.FallbackToFeedContent,
+		"fallback-to-feed-content", false, "スクレイピングに失敗した記事の本文を、フィードの item.Content / item.Description で代替します。")
+	runCmd.Flags().BoolVar(&Flags.NoScrape,
+		"no-scrape", false, "記事ページをスクレイピングせず、フィードの item.Content / item.Description だけを本文として処理します (--feed-body-prefer に従う)。")
+	runCmd.Flags().IntVar(&Flags.NoScrapeMinChars,
+		"no-scrape-min-chars", pipeline.DefaultNoScrapeMinChars, "--no-scrape 時に記事として扱うフィード本文の最小文字数。これより短い記事はスキップします (0 の場合は本文が空の記事のみスキップ)。")
+	runCmd.Flags().BoolVar(&Flags.SanitizeInput,
+		"sanitize-input", false, "AI処理の前に記事本文からゼロ幅スペース・BOM・制御文字を除去し、改行コード (CRLF→LF) と連続する空白・空行を正規化します。")
+	runCmd.Flags().BoolVar(&Flags.RequireSingleLang,
+		"require-single-language", false, "記事本文の言語 (日本語・英語) を判定し、最も多い言語以外の記事を除外して処理を続行します。指定しない場合も、言語が混在していれば警告します。")
+	runCmd.Flags().StringVar(&Flags.FeedBodyPrefer,
+		"feed-body-prefer", feed.DefaultPrefer, "フィードの本文候補として item.Content と item.Description のどちらを優先するか (content, description, longer)。")
+	runCmd.Flags().StringVar(&Flags.FeedCacheFile,
+		"feed-cache-file", "", "フィードの ETag / Last-Modified を保存するファイル。指定すると Conditional GET で取得し、更新がないフィードの処理をスキップします。")
+	runCmd.Flags().StringArrayVar(&Flags.FeedHeaders,
+		"feed-header", nil, "フィード取得時に付与する HTTP ヘッダー (例: X-API-Token=xxxx)。繰り返し指定可。User-Agent を指定すると既定の User-Agent を上書きします。")
+	runCmd.Flags().IntVar(&Flags.FeedRetries,
+		"feed-retries", feed.DefaultFetchAttempts, "フィード取得の最大試行回数 (初回を含む)。ネットワークエラーと 5xx / 429 の場合のみ指数バックオフで再試行します。")
+	runCmd.Flags().DurationVar(&Flags.FeedRetryDelay,
+		"feed-retry-delay", feed.DefaultRetryBaseDelay, "フィード取得の再試行の初回の待機時間。以降は試行ごとに倍になります。")
+	runCmd.Flags().StringVar(&Flags.ContentFormat,
+		"content-format", string(pipeline.ContentMarkdown), "AI処理に渡す本文の形式 (markdown: スクレイパーのMarkdownのまま, plain: リンク・装飾を除去)。AIスキップ時の出力形式も連動します。")
+	runCmd.Flags().StringVar(&Flags.DownloadImagesDir,
+		"download-images-dir", "", "処理した記事のアイキャッチ画像 (フィードの画像・enclosure・media:thumbnail 等) をこのディレクトリにダウンロードします。")
+	runCmd.Flags().IntVar(&Flags.ImageDownloadParallel,
+		"image-download-parallel", pipeline.DefaultImageDownloadParallel, "画像の同時ダウンロード数。")
+	runCmd.Flags().DurationVar(&Flags.ImageDownloadTimeout,
+		"image-download-timeout", pipeline.DefaultImageDownloadTimeout, "画像1件あたりのダウンロードのタイムアウト。")
+	runCmd.Flags().StringSliceVar(&Flags.DomainPriorities,
+		"domain-priority", nil, "スクレイピング順のドメイン優先度 (例: example.com=10)。値が大きいドメインの記事から処理します。カンマ区切りで複数指定可。")
+	runCmd.Flags().BoolVar(&Flags.PreferRecent,
+		"prefer-recent", false, "同じ優先度の記事を公開時刻の新しい順に処理します。")
+	runCmd.Flags().IntVar(&Flags.MaxArticles,
+		"max-articles", 0, "フィードごとに処理する記事数の上限。優先度順に並べ替えた上位の記事を処理します (0で無制限)。")
+	runCmd.Flags().StringSliceVar(&Flags.BlockDomains,
+		"block-domains", nil, "処理対象から除外するドメイン (例: ads.example.com)。サブドメインにも一致します。カンマ区切りで複数指定可。")
+	runCmd.Flags().BoolVar(&Flags.TrackQuality,
+		"track-quality", false, "記事ごとの品質指標 (本文長・重複率・文字化け率) を計測し、ドメイン別の平均品質スコアを状態ファイルに蓄積します。")
+	runCmd.Flags().BoolVar(&Flags.AutoBlock,
+		"auto-block", false, "平均品質スコアが --auto-block-threshold を下回ったドメインを自動ブロックし、次回以降の処理対象から除外します (--track-quality を含む)。")
+	runCmd.Flags().Float64Var(&Flags.AutoBlockThreshold,
+		"auto-block-threshold", pipeline.DefaultAutoBlockThreshold, "--auto-block で自動ブロックするドメインの平均品質スコアの閾値 (0〜1)。")
+	runCmd.Flags().IntVar(&Flags.AutoBlockMinSamples,
+		"auto-block-min-samples", pipeline.DefaultAutoBlockMinSamples, "--auto-block の判定に必要なドメインの記事数。")
+	runCmd.Flags().StringSliceVar(&Flags.UnblockDomains,
+		"unblock-domains", nil, "自動ブロックを解除するドメイン。品質スコアの蓄積もリセットします。カンマ区切りで複数指定可。")
+	runCmd.Flags().BoolVar(&Flags.PreviewContent,
+		"preview-content", false, "抽出した各記事の本文の先頭と文字数を debug ログに出力します (--verbose と併用)。本文を含むため既定では無効です。")
+	runCmd.Flags().IntVar(&Flags.PreviewContentChars,
+		"preview-content-chars", pipeline.DefaultPreviewContentChars, "--preview-content で出力する本文の先頭の文字数。")
+	runCmd.Flags().StringVar(&Flags.SortBy,
+		"sort-by", string(pipeline.SortByFeed), "スクレイピング後の記事の並び順 (feed: フィードの取得順, importance: 重要度の降順)。")
+	runCmd.Flags().StringSliceVar(&Flags.ImportanceMethods,
+		"importance-method", []string{string(pipeline.ImportanceByLength), string(pipeline.ImportanceByKeywords)}, "--sort-by importance の重要度の算出方法 (length: 本文長, keywords: キーワードのヒット数, llm: LLMによる採点)。カンマ区切りで複数指定可 (スコアの平均を使用)。")
+	runCmd.Flags().StringSliceVar(&Flags.ImportanceKeywords,
+		"importance-keywords", nil, "重要度の算出 (keywords) でヒット数を数えるキーワード。カンマ区切りで複数指定可。")
+	runCmd.Flags().BoolVar(&Flags.ExtractCode,
+		"extract-code", false, "記事本文のフェンスドコードブロックを抽出し、本文では「（コード省略）」に置換して要約・読み上げの対象から除外します。")
+	runCmd.Flags().BoolVar(&Flags.ExtractInlineCode,
+		"extract-code-inline", false, "--extract-code でインラインコード (`...`) も抽出します。")
+	runCmd.Flags().StringVar(&Flags.CodeSnippetsFile,
+		"code-snippets-file", "", "--extract-code で抽出したコードを記事ごとに Markdown で書き出すファイル。")
 	runCmd.Flags().StringVarP(&Flags.OutputWAVPath,
 		"output-wav-path", "v", "asset/audio_output.wav", "音声合成されたWAVファイルの出力パス。")
+	runCmd.Flags().IntVar(&Flags.VoicevoxConcurrency,
+		"voicevox-concurrency", voice.DefaultConcurrency, "VOICEVOXエンジンで audio_query / synthesis を同時に実行する行数。エンジンの負荷に応じて調整します。")
+	runCmd.Flags().StringVar(&Flags.Since,
+		"since", "", "公開時刻がこれより前の記事を除外します。期間 (24h, 3d) または日時 (2025-01-01, RFC3339) で指定。")
+	runCmd.Flags().StringVar(&Flags.SinceUndated,
+		"since-undated", feed.DefaultUndated, "--since 指定時に公開時刻のない記事を残すか除外するか (include, exclude)。")
+	runCmd.Flags().BoolVar(&Flags.Disclaimer,
+		"disclaimer", false, "AI生成である旨と生成日時・出典を示す免責文を出力に付与します (音声の場合はスクリプトの冒頭行)。")
+	runCmd.Flags().StringVar(&Flags.DisclaimerTemplate,
+		"disclaimer-template", "", "免責文の text/template テンプレートファイル ({{.GeneratedAt}}, {{.Source}}, {{.SourceCount}} を埋め込み可能)。")
+	runCmd.Flags().StringVar(&Flags.DisclaimerPosition,
+		"disclaimer-position", string(pipeline.DisclaimerHead), "テキスト出力時の免責文の位置 (head, tail)。")
+	runCmd.Flags().BoolVar(&Flags.Decorate,
+		"decorate", false, "SNS・チャット向けに、テキスト出力の見出し・箇条書き・区切り線・話者行へテーマに応じた絵文字・装飾を付与します。")
+	runCmd.Flags().StringVar(&Flags.DecorateTheme,
+		"decorate-theme", pipeline.DefaultDecorationTheme, "装飾のテーマ (news, tech, casual、または --decoration-rules で定義したテーマ)。")
+	runCmd.Flags().StringVar(&Flags.DecorationRules,
+		"decoration-rules", "", "装飾ルールを上書きするJSONファイル。テーマ名をキーに heading_emojis, bullet, divider, speaker_emojis, header, footer を指定します。")
+	runCmd.Flags().StringVar(&Flags.MemProfile,
+		"memprofile", "", "実行終了時にヒーププロファイルを書き出すファイルパス (go tool pprof で分析)。")
+	runCmd.Flags().StringVar(&Flags.SaveRunDir,
+		"save-run", "", "中間要約 (reduce.md)・最終要約 (summary.md)・スクリプト (script.txt) を保存するディレクトリ。")
+	runCmd.Flags().BoolVar(&Flags.RelatedLinks,
+		"related-links", false, "記事本文の Markdown リンク・URL から外部リンクを抽出し、「関連リンク」セクションとしてテキスト出力の末尾に付与します (記事と同じドメインへのリンクは除外)。")
+	runCmd.Flags().StringVar(&Flags.ExtractedLinksFile,
+		"extracted-links-file", "", "記事本文から抽出した外部リンクを1行1URLで書き出すファイル。次回の --urls-stdin の入力候補として使用できます。")
+	runCmd.Flags().StringSliceVar(&Flags.ABPrompts,
+		"ab-prompts", nil, "A/B 比較するプロンプトセットのディレクトリ (例: dirA,dirB)。通常の出力に加えて、各プロンプトセットで最終要約とスクリプトを生成して比較します (変種の数だけコストが増えます)。")
+	runCmd.Flags().StringVar(&Flags.ABOutputDir,
+		"ab-output-dir", pipeline.DefaultABOutputDir, "--ab-prompts の変種ごとの最終要約・スクリプトと比較サマリ (comparison.md) の保存先ディレクトリ。")
+	runCmd.Flags().StringVar(&Flags.DiffAgainst,
+		"diff-against", "", "--save-run で保存した前回の実行結果のディレクトリ。今回の結果との行単位の差分を標準エラーに出力します。")
+	runCmd.Flags().BoolVar(&Flags.Stream,
+		"stream", false, "スクリプト生成をストリーミングで行い、完成した行から標準出力へ逐次表示します。")
+	runCmd.Flags().BoolVar(&Flags.URLsStdin,
+		"urls-stdin", false, "フィードの代わりに標準入力から1行1URLのリストを読み込んで処理します (空行と#始まりの行は無視)。")
+	runCmd.Flags().BoolVar(&Flags.DiffOnly,
+		"diff-only", false, "前回までに処理した記事を除外し、新着記事のみを処理します。新着が0件の場合は何もしません。")
+	runCmd.Flags().StringVar(&Flags.StatePath,
+		"state-file", state.DefaultPath, "処理済み記事 (GUID) とエピソード履歴を保存する状態ファイルのパス。")
+	runCmd.Flags().BoolVar(&Flags.RecordRuns,
+		"record-runs", false, "実行結果 (ステータス・所要時間・出力の冒頭) を状態ファイルの実行履歴に記録します ('serve' で参照できます)。")
+	runCmd.Flags().Float64Var(&Flags.TargetLUFS,
+		"target-lufs", 0, "出力WAVをEBU R128に基づき指定ラウドネス (例: -16) に正規化します。0で正規化しません。")
+	runCmd.Flags().StringVar(&Flags.SRTPath,
+		"srt-path", "", "音声合成したスクリプトのタイムコード付き字幕 (SRT) の出力先。")
+	runCmd.Flags().StringVar(&Flags.VTTPath,
+		"vtt-path", "", "音声合成したスクリプトのタイムコード付き字幕 (WebVTT) の出力先。--srt-path と同時に指定できます。")
+	runCmd.Flags().BoolVar(&Flags.VTTSpeakerTags,
+		"vtt-speaker-tags", false, "WebVTT の各キューに話者名を <v 話者> タグで埋め込みます。")
+	runCmd.Flags().StringSliceVar(&Flags.OutputFormats,
+		"output-formats", nil, "1 回の AI処理の結果から生成する出力フォーマット (text, markdown, json, wav, srt, vtt, rss)。カンマ区切りで複数指定可。各フォーマットは --output-dir 配下に拡張子違いで出力します。")
+	runCmd.Flags().StringVar(&Flags.OutputDir,
+		"output-dir", pipeline.DefaultOutputDir, "--output-formats の各フォーマットの出力先ディレクトリ。")
 	runCmd.Flags().StringVar(&Flags.CleanerConfig.MapModel,
 		"map-model", cleaner.DefaultMapModelName, "Mapフェーズ (クリーンアップ) に使用するAIモデル名 (例: gemini-2.5-flash)。")
 	runCmd.Flags().StringVar(&Flags.CleanerConfig.ReduceModel,
@@ -117,22 +695,241 @@ func addRunFlags(runCmd *cobra.Command) {
 		"summary-model", cleaner.DefaultSummaryModelName, "最終要約フェーズに使用するAIモデル名 (例: gemini-2.5-flash)。")
 	runCmd.Flags().StringVar(&Flags.CleanerConfig.ScriptModel,
 		"script-model", cleaner.DefaultScriptModelName, "スクリプト生成フェーズに使用するAIモデル名 (例: gemini-2.5-pro)。")
+	runCmd.Flags().Float64Var(&Flags.MapGen.Temperature,
+		"map-temperature", -1, "Mapフェーズ (重要度採点・トピック分類を含む)の temperature (0〜2。低いほど決定的)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().Float64Var(&Flags.MapGen.TopP,
+		"map-top-p", -1, "Mapフェーズ (重要度採点・トピック分類を含む)の topP (0〜1)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().IntVar(&Flags.MapGen.MaxTokens,
+		"map-max-tokens", 0, "Mapフェーズ (重要度採点・トピック分類を含む)の出力トークン数の上限 (0 で制限なし)。")
+	runCmd.Flags().Float64Var(&Flags.ReduceGen.Temperature,
+		"reduce-temperature", -1, "Reduceフェーズの temperature (0〜2。低いほど決定的)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().Float64Var(&Flags.ReduceGen.TopP,
+		"reduce-top-p", -1, "Reduceフェーズの topP (0〜1)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().IntVar(&Flags.ReduceGen.MaxTokens,
+		"reduce-max-tokens", 0, "Reduceフェーズの出力トークン数の上限 (0 で制限なし)。")
+	runCmd.Flags().Float64Var(&Flags.SummaryGen.Temperature,
+		"summary-temperature", -1, "最終要約フェーズの temperature (0〜2。低いほど決定的)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().Float64Var(&Flags.SummaryGen.TopP,
+		"summary-top-p", -1, "最終要約フェーズの topP (0〜1)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().IntVar(&Flags.SummaryGen.MaxTokens,
+		"summary-max-tokens", 0, "最終要約フェーズの出力トークン数の上限 (0 で制限なし)。")
+	runCmd.Flags().Float64Var(&Flags.ScriptGen.Temperature,
+		"script-temperature", -1, "スクリプト生成フェーズの temperature (0〜2。低いほど決定的)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().Float64Var(&Flags.ScriptGen.TopP,
+		"script-top-p", -1, "スクリプト生成フェーズの topP (0〜1)。負の値でクライアントのデフォルト。")
+	runCmd.Flags().IntVar(&Flags.ScriptGen.MaxTokens,
+		"script-max-tokens", 0, "スクリプト生成フェーズの出力トークン数の上限 (0 で制限なし)。")
+	runCmd.Flags().StringSliceVar(&Flags.CleanerConfig.MapModels,
+		"map-models", nil, "--map-model が再試行しても一時的なエラー (429 / 5xx) で失敗した場合に、順に試すモデル名 (カンマ区切り。例: gemini-2.5-flash-lite)。")
+	runCmd.Flags().StringSliceVar(&Flags.CleanerConfig.ReduceModels,
+		"reduce-models", nil, "--reduce-model が一時的なエラーで失敗した場合に、順に試すモデル名 (カンマ区切り)。")
+	runCmd.Flags().StringSliceVar(&Flags.CleanerConfig.SummaryModels,
+		"summary-models", nil, "--summary-model が一時的なエラーで失敗した場合に、順に試すモデル名 (カンマ区切り)。")
+	runCmd.Flags().StringSliceVar(&Flags.CleanerConfig.ScriptModels,
+		"script-models", nil, "--script-model が一時的なエラーで失敗した場合に、順に試すモデル名 (カンマ区切り)。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.EnforceMapFormat,
+		"enforce-map-format", false, "Map要約を「見出し＋箇条書き」形式に強制し、違反したセグメントを再生成します。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MapFormatRules.MinBullets,
+		"map-format-min-bullets", cleaner.DefaultMapFormatRules().MinBullets, "Map要約に必要な箇条書きの最小行数 (0で検証しない)。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.MapFormatRules.RequireHeading,
+		"map-format-require-heading", cleaner.DefaultMapFormatRules().RequireHeading, "Map要約にトピック見出し (##) を必須とするか。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MapFormatMaxRetries,
+		"map-format-max-retries", cleaner.DefaultMapFormatMaxRetries, "Map要約のフォーマット違反時の最大再生成回数。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.ScriptTurns,
+		"script-turns", 0, "スクリプトの目標ターン数 (話者交代までの発言のまとまりの数)。大きく外れた場合は1回再生成します。0で制約なし。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.ScriptMaxTurnChars,
+		"script-turn-max-chars", 0, "スクリプトの1ターンあたりの最大文字数をプロンプトで指示します。0で制約なし。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.ScriptOpening,
+		"script-opening", cleaner.DefaultScriptOpening, "スクリプト冒頭の挨拶のテンプレート。{{.Date}} (日付)・{{.Weekday}} (曜日)・{{.Title}}・{{.Highlights}} (話題の件数) を埋め込めます。空で無効化します。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.ScriptClosing,
+		"script-closing", cleaner.DefaultScriptClosing, "スクリプト末尾の挨拶のテンプレート (埋め込める値は --script-opening と同じ)。空で無効化します。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.ScriptOpeningSpeaker,
+		"script-opening-speaker", "", "冒頭の挨拶を担当する話者 (例: ずんだもん)。省略時は最初の話者が担当します。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.ScriptClosingSpeaker,
+		"script-closing-speaker", "", "末尾の挨拶を担当する話者 (例: めたん)。省略時は最後の話者が担当します。")
+	runCmd.Flags().DurationVar(&Flags.TargetDuration,
+		"target-duration", 0, "音声の目標の再生時間 (例: 5m)。学習済みの読み上げ速度でスクリプトの目標文字数に換算してプロンプトで指示し、合成後の長さが外れた場合は係数を状態ファイルに学習します。0で指定なし。")
+	runCmd.Flags().Float64Var(&Flags.DurationTolerance,
+		"duration-tolerance", pipeline.DefaultDurationTolerance, "合成後の再生時間が --target-duration からこの割合 (0〜1) を超えて外れた場合に、読み上げ速度の係数を更新します。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MinSegmentContentChars,
+		"min-segment-content-chars", cleaner.DefaultMinSegmentContentChars, "有意な文字 (かな・漢字・英数字) がこの数未満のセグメントはMap要約のLLM呼び出しをスキップします。0でスキップしません。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MaxSegmentTokens,
+		"max-segment-tokens", cleaner.DefaultMaxSegmentTokens, "Map要約の1セグメントあたりの推定トークン数の上限。文字数ではなくトークン数 (英語は約4文字、日本語は約1文字で1トークンと推定) でテキストを分割します。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.SegmentOverlapChars,
+		"segment-overlap-chars", 0, "2つ目以降のセグメントの先頭に、直前のセグメントの末尾のこの文字数を重ねて含めます。境界をまたぐ文の文脈をMap要約に残します (重複はReduceで統合されます)。0で重ねません。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.SegmentPerArticle,
+		"segment-per-article", false, "記事ごとにセグメントを分け、Map要約を記事単位で行います。複数の記事を1セグメントにまとめず、上限を超える長い記事のみ記事の中で分割します。")
+	runCmd.Flags().StringVar(&Flags.MapCacheFile,
+		"map-cache-file", "", "Map要約をセグメントの内容とモデル名のハッシュで保存するキャッシュファイル。指定すると、同じセグメントはLLMを呼び出さずに前回の要約を再利用します。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.FailFast,
+		"fail-fast", false, "Map要約で同種のエラー (認証エラー等) が閾値に達したら、残りのセグメントを待たずに中断します。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.FailFastThreshold,
+		"fail-fast-threshold", cleaner.DefaultFailFastThreshold, "--fail-fast で中断する同種エラーの件数。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MaxFailedSegments,
+		"max-failed-segments", 0, "Map要約に失敗したセグメントがこの件数以下なら、失敗したセグメントを除外して続行します (0 で許容しない)。")
+	runCmd.Flags().Float64Var(&Flags.CleanerConfig.MaxFailedSegmentsRatio,
+		"max-failed-segments-ratio", 0, "Map要約に失敗したセグメントの割合がこの値 (0〜1) 以下なら、失敗したセグメントを除外して続行します (0 で許容しない)。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MaxConcurrency,
+		"map-concurrency", cleaner.DefaultMaxConcurrency, "Map要約で同時にLLMを呼び出すセグメント数の上限。LLM呼び出し間隔のレートリミットとは独立に、同時実行数を制限します。")
+	runCmd.Flags().StringVar(&Flags.ReduceStrategy,
+		"reduce-strategy", string(cleaner.DefaultReduceStrategy), "Map要約を統合するReduce戦略 (concat: 単純連結, hierarchical: 階層, refine: 逐次洗練)。")
+	runCmd.Flags().StringVar(&Flags.ReduceTemplateFile,
+		"reduce-postprocess-template", "", "Reduce結果を最終要約に渡す前に整形する text/template ファイル (見出しの正規化・不要セクションの除去など)。未指定の場合はそのまま渡します。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.StructuredReduce,
+		"structured-reduce", false, "Reduce結果を「概要／主要ポイント／結論」に構造化し、その順にスクリプトの会話を展開します。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.CiteSources,
+		"cite-sources", false, "Map・Reduce・最終要約の各記述にソース番号 [n] を付けさせ、最終要約に反映された記事の割合 (ソースカバレッジ) と反映されなかった記事を集計します。スクリプトにはソース番号を含めません。")
+	runCmd.Flags().Float64Var(&Flags.MinSourceCoverage,
+		"min-source-coverage", pipeline.DefaultMinSourceCoverage, "--cite-sources 指定時に、ソースカバレッジ (0〜1) がこれを下回ると反映されなかった記事を警告します。")
+	runCmd.Flags().BoolVar(&Flags.LayeredSummary,
+		"layered-summary", false, "最終要約に加えて、1行要約・段落要約・詳細要約の多層要約を生成します。")
+	runCmd.Flags().StringVar(&Flags.SummaryFormat,
+		"summary-format", string(cleaner.DefaultSummaryFormat), "最終要約の出力形式 (prose: 散文, bullet: 箇条書き, both: 散文をスクリプト生成に、箇条書きをメール・RSSなどのテキスト出力に使用)。")
+	runCmd.Flags().StringVar(&Flags.Expertise,
+		"expertise", string(cleaner.DefaultExpertiseLevel), "最終要約の想定読者の専門性 (beginner: 専門用語に本文内で注釈を付ける, intermediate: 平易な言葉に意訳, expert: 前提知識の説明を省く)。")
+	runCmd.Flags().StringVar(&Flags.LayeredSummaryMode,
+		"layered-summary-mode", string(cleaner.DefaultLayeredSummaryMode), "多層要約の生成方法 (single: 1回の呼び出しで全レベルを生成, separate: レベルごとに個別に生成)。")
+	runCmd.Flags().StringVar(&Flags.ScriptSource,
+		"script-source", string(pipeline.ScriptSourceFinal), "スクリプト生成の入力にする要約 (final: 最終要約, detailed: 詳細要約, paragraph: 段落要約)。final 以外は --layered-summary が必要です。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.ParaphraseStrict,
+		"paraphrase-strict", false, "最終要約と原文の重複率が閾値以上の場合に、言い換えを強める指示を追加して1回だけ再生成します。")
+	runCmd.Flags().Float64Var(&Flags.CleanerConfig.OverlapThreshold,
+		"overlap-threshold", cleaner.DefaultOverlapThreshold, "最終要約の原文との重複率がこの値以上の場合に転載と判定します (0〜1)。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.OverlapNGram,
+		"overlap-ngram", cleaner.DefaultOverlapNGram, "重複率の判定に使用する n-gram の文字数。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.OverlapMinMatchChars,
+		"overlap-min-match-chars", cleaner.DefaultOverlapMinMatchChars, "重複として数える連続一致の最小文字数。これより短い一致は引用として許容します。")
+	runCmd.Flags().StringSliceVar(&Flags.SpeakerMap,
+		"speaker-map", nil, "生成したスクリプトの話者を置き換えます (例: ずんだもん=めたん,めたん=ずんだもん で入れ替え)。カンマ区切りで複数指定可。")
+	runCmd.Flags().StringSliceVar(&Flags.Speakers,
+		"speaker", nil, "キャラクターごとの VOICEVOX の話者 (スタイル) ID を指定します (例: --speaker ずんだもん=3 --speaker めたん=2)。「キャラ名：セリフ」形式の行もそのキャラクターで合成します。")
+	runCmd.Flags().StringVar(&Flags.DefaultSpeaker,
+		"default-speaker", "", "話者タグも「キャラ名：」もない行を読み上げるキャラクター (デフォルト: "+voice.DefaultSpeaker+")。")
+	runCmd.Flags().Float64Var(&Flags.SpeechSpeed,
+		"speech-speed", 0, "音声合成の話速 (0.5〜2.0。例: 1.2 で 2 割速く読み上げ)。0 の場合は VOICEVOX の既定値 (1.0)。")
+	runCmd.Flags().Float64Var(&Flags.SpeechPitch,
+		"speech-pitch", 0, "音声合成の音高 (-0.15〜0.15)。0 の場合は VOICEVOX の既定値。")
+	runCmd.Flags().Float64Var(&Flags.SpeechIntonation,
+		"speech-intonation", 0, "音声合成の抑揚 (0〜2.0)。0 の場合は VOICEVOX の既定値 (1.0)。")
+	runCmd.Flags().BoolVar(&Flags.TTSNormalize,
+		"tts-normalize", false, "音声合成の前にスクリプトの数値・単位・英略語を読み上げやすい表記に変換します (例: 100km/h → ひゃくキロメートル毎時)。")
+	runCmd.Flags().StringVar(&Flags.TTSDictionary,
+		"tts-dictionary", "", "読み上げ用の変換ルール (単位・英略語の読み) を組み込みのルールに追加・上書きする JSON ファイルのパス。指定すると --tts-normalize も有効になります。")
+	runCmd.Flags().StringVar(&Flags.SMTPHost,
+		"smtp-host", "", "処理結果のダイジェスト (最終要約・ハイライト・参照リンク) を HTML メールで送信する SMTP サーバーのホスト名。認証情報は環境変数 "+pipeline.SMTPUsernameEnv+" / "+pipeline.SMTPPasswordEnv+" で指定します。")
+	runCmd.Flags().IntVar(&Flags.SMTPPort,
+		"smtp-port", pipeline.DefaultSMTPPort, "SMTP サーバーのポート (サーバーが対応していれば STARTTLS を使用します)。")
+	runCmd.Flags().StringVar(&Flags.MailFrom,
+		"mail-from", "", "ダイジェストメールの送信元アドレス。")
+	runCmd.Flags().StringSliceVar(&Flags.MailTo,
+		"mail-to", nil, "ダイジェストメールの宛先アドレス。カンマ区切りで複数指定可。")
+	runCmd.Flags().BoolVar(&Flags.MailAttachAudio,
+		"mail-attach-audio", false, "合成した音声ファイル (--output-wav) をダイジェストメールに添付します。")
+	runCmd.Flags().StringVar(&Flags.MailAudioURL,
+		"mail-audio-url", "", "ダイジェストメールの本文に音声ファイルへのリンクとして記載するURL。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.BalanceSpeakers,
+		"balance-speakers", false, "スクリプトの話者バランスに偏りがある場合、バランス指示を追加して1回再生成します。")
+	runCmd.Flags().Float64Var(&Flags.CleanerConfig.SpeakerBalanceThreshold,
+		"speaker-balance-threshold", cleaner.DefaultSpeakerBalanceThreshold, "1話者の発話文字数の占有率がこの値を超えたら偏りと判定します (0〜1)。")
+	runCmd.Flags().BoolVar(&Flags.Digest,
+		"digest", false, "複数フィードの記事をトピック分類し、トピック別ダイジェストを出力します (LLM必須)。")
+	runCmd.Flags().StringSliceVar(&Flags.DigestFeedURLs,
+		"digest-feed-url", nil, "ダイジェストモードで --feed-url に加えて取得するフィードURL (複数指定可)。")
+	runCmd.Flags().StringVar(&Flags.TopicGranularity,
+		"topic-granularity", string(cleaner.DefaultTopicGranularity), "ダイジェストのトピック分類粒度 (coarse, medium, fine)。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.MaxTopics,
+		"max-topics", cleaner.DefaultMaxTopics, "ダイジェストで生成するトピック数の上限。")
+	runCmd.Flags().StringSliceVar(&Flags.CleanerConfig.ReasoningTags,
+		"reasoning-tags", cleaner.DefaultReasoningTags, "LLMのレスポンスから除去する推論部分のタグ名 (例: thinking は <thinking>...</thinking> を除去)。カンマ区切りで複数指定可。")
+	runCmd.Flags().StringVar(&Flags.CategoryProfiles,
+		"category-profiles", "", "記事のカテゴリ (フィードの category) をキーに、モデル・プロンプトセット・文体/トーンを切り替える設定のJSONファイル。")
+	runCmd.Flags().StringVar(&Flags.NGWordsFile,
+		"ng-words-file", "", "生成スクリプトから除去するNGワードの設定ファイル (1行1語、/pattern/ 形式は正規表現)。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.NGReplacement,
+		"ng-replacement", cleaner.DefaultNGReplacement, "NGワードの置換文字列。空文字列 (--ng-replacement=\"\") を指定するとNGワードを削除します。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.StrictNG,
+		"strict-ng", false, "NGワードを検出した場合に処理を失敗させます。")
+	runCmd.Flags().BoolVar(&Flags.CleanerConfig.DryRun,
+		"dry-run", false, "記事の取得後、セグメント数とフェーズ (map / reduce / summary / script) 別の呼び出し回数・推定トークン数・概算コストを表示して終了します (LLMは呼び出さず、出力も行いません)。")
+	runCmd.Flags().BoolVar(&Flags.EstimateOnly,
+		"estimate-only", false, "記事の取得後、Mapフェーズの呼び出し回数・推定トークン数・概算コストのみを表示して終了します (LLMは呼び出しません)。")
+	runCmd.Flags().Float64Var(&Flags.ConfirmOverCost,
+		"confirm-over-cost", 0, "Mapフェーズの見積もりコストがこの値 (USD) を超える場合、実行前に対話的に確認します (0で確認しない)。")
+	runCmd.Flags().BoolVarP(&Flags.Yes,
+		"yes", "y", false, "--confirm-over-cost の確認をスキップして続行します (自動実行向け)。")
+	runCmd.Flags().BoolVar(&Flags.Force,
+		"force", false, "同じ入力 (記事URL・本文・プロンプト・モデル等) で成功した成果物が状態ファイルにあっても、再利用せずに LLM 処理をやり直します。")
+	runCmd.Flags().StringVar(&Flags.APIKeysFile,
+		"api-keys-file", "", "ラウンドロビンで使い分ける複数のGemini APIキーのファイル (1行1キー、# はコメント)。環境変数 GEMINI_API_KEYS (カンマ区切り) と併用できます。")
+	runCmd.Flags().DurationVar(&Flags.KeyCooldown,
+		"key-cooldown", cleaner.DefaultKeyCooldown, "レート制限 (429) に当たったAPIキーをローテーションから外す時間。")
+	runCmd.Flags().IntVar(&Flags.CleanerConfig.LLMMaxAttempts,
+		"llm-retries", cleaner.DefaultLLMMaxAttempts, "LLM呼び出しの最大試行回数 (初回を含む)。レート制限 (429) とサーバーエラー (5xx) の場合のみ、指数バックオフで再試行します。")
+	runCmd.Flags().DurationVar(&Flags.CleanerConfig.LLMRetryBaseDelay,
+		"llm-retry-delay", cleaner.DefaultLLMRetryBaseDelay, "LLM呼び出しの再試行の初回の待機時間。以降は試行ごとに倍になります (ジッターを加算)。")
+	runCmd.Flags().StringSliceVar(&Flags.FeedURLs,
+		"feed-urls", nil, "記事URLをマージ・重複除去して 1 本のスクリプトとして処理する複数のフィードURL (カンマ区切り)。指定時は --feed-url を無視します。")
+	runCmd.Flags().IntVar(&Flags.FeedConcurrency,
+		"feed-concurrency", feed.DefaultFetchConcurrency, "--feed-urls のフィードを並列に取得する際の同時取得数。")
+	runCmd.Flags().BoolVar(&Flags.References,
+		"references", false, "テキスト出力の末尾に記事タイトルとURLの一覧を「参照記事」セクションとして付与します (AI処理時はソース番号付き)。音声出力時は付与しません。")
+	runCmd.Flags().Float64Var(&Flags.CleanerConfig.MaxCostUSD,
+		"max-cost-usd", 0, "LLM呼び出しの累積推定コストの上限 (USD)。上限に達した時点で残りのLLM処理を中止し、部分成果を出力します (0で無制限)。")
+	runCmd.Flags().StringVar(&Flags.PricingFile,
+		"pricing-file", "", "推定コストの計算に使用するモデルごとの 1000 トークンあたりの単価 (USD) の JSON ファイル。組み込みの単価表より優先します。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.OutputStyle.Politeness,
+		"output-politeness", "", "全フェーズの出力の文体 (polite: 敬体, plain: 常体)。空の場合は指示しません。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.OutputStyle.Formality,
+		"output-formality", "", "全フェーズの出力のトーン (formal, casual)。空の場合は指示しません。")
+	runCmd.Flags().StringVar(&Flags.CleanerConfig.OutputLang,
+		"output-lang", cleaner.DefaultOutputLang, "Reduce・要約・スクリプトの出力に期待する言語 (ja, en)。異なる言語で出力された場合は言語を明示して再生成します (空文字列で無効)。")
+	runCmd.Flags().DurationVar(&Flags.Timeouts.Overall,
+		"timeout", pipeline.DefaultOverallTimeout, "パイプライン全体のタイムアウト上限")
+	runCmd.Flags().DurationVar(&Flags.Timeouts.Feed,
+		"timeout-feed", pipeline.DefaultFeedTimeout, "フィード取得フェーズのタイムアウト (0で全体上限のみ)")
+	runCmd.Flags().DurationVar(&Flags.Timeouts.Scrape,
+		"timeout-scrape", pipeline.DefaultScrapeTimeout, "スクレイピングフェーズのタイムアウト (0で全体上限のみ)")
+	runCmd.Flags().DurationVar(&Flags.Timeouts.LLM,
+		"timeout-llm", pipeline.DefaultLLMTimeout, "LLM処理フェーズのタイムアウト (0で全体上限のみ)")
+	runCmd.Flags().DurationVar(&Flags.Timeouts.Synthesis,
+		"timeout-synthesis", pipeline.DefaultSynthesisTimeout, "音声合成フェーズのタイムアウト (0で全体上限のみ)")
+	runCmd.Flags().DurationVar(&Flags.CleanerConfig.TotalTimeout,
+		"timeout-map-reduce", 0, "Map-Reduce (中間統合要約) 1回あたりの処理時間の予算。残りが少なくなると Map を打ち切り、完了した要約で Reduce に進みます (0で無効)")
+	runCmd.Flags().Float64Var(&Flags.CleanerConfig.ReduceReserveRatio,
+		"reduce-reserve-ratio", cleaner.DefaultReduceReserveRatio, "--timeout-map-reduce のうち Reduce のために残す割合 (0〜1)")
 }
 
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "RSSフィードの取得、並列抽出、AI構造化処理を実行します。",
 	Long:  "RSSフィードからURLを抽出し、記事本文を並列で取得後、LLMでクリーンアップ・構造化します。",
-	RunE:  runCmdFunc,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// 起動前チェック: LLMクライアント等の初期化前に設定の不備を検出する
+		if err := validateInputMode(cmd, Flags); err != nil {
+			return err
+		}
+		return validateRunFlags(Flags)
+	},
+	RunE: runCmdFunc,
 }
 
 // Execute は、CLIアプリケーションのエントリポイントです。
 func Execute() {
 	addRunFlags(runCmd)
+	addRunFlags(configValidateCmd)
+	addServeFlags(serveCmd)
+	addGoldenFlags(goldenCmd)
+	addCacheFlags(cacheCmd)
+	configCmd.AddCommand(configValidateCmd)
+	cacheCmd.AddCommand(cacheStatsCmd, cacheClearCmd, cachePruneCmd)
 	clibase.Execute(
 		"act-feed-clean-go",
 		nil,
 		nil,
 		runCmd,
+		configCmd,
+		serveCmd,
+		goldenCmd,
+		cacheCmd,
 	)
 }
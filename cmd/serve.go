@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"act-feed-clean-go/internal/api"
+	"act-feed-clean-go/internal/pipeline"
+	"act-feed-clean-go/internal/state"
+
+	"github.com/spf13/cobra"
+)
+
+// ----------------------------------------------------------------------
+// 'serve' コマンド (実行履歴の JSON API)
+// ----------------------------------------------------------------------
+
+// apiTokenEnv は API の認証トークンを指定する環境変数名です。
+const apiTokenEnv = "ACT_FEED_API_TOKEN"
+
+// runSummaryMaxRunes は実行履歴に記録する出力の冒頭部分の最大文字数です。
+const runSummaryMaxRunes = 500
+
+// ServeFlags は 'serve' コマンド固有のフラグを保持する構造体です。
+type ServeFlags struct {
+	HTTPAddr  string // APIサーバーの待ち受けアドレス
+	StatePath string // 実行履歴を読み込む状態ファイルのパス
+	Token     string // API の認証トークン (未指定時は環境変数 ACT_FEED_API_TOKEN)
+}
+
+var serveFlags ServeFlags
+
+// serveCmdFunc は 'serve' サブコマンドが呼び出されたときに実行される関数です。
+// SIGINT / SIGTERM を受け取るまで API サーバーを起動し続けます。
+func serveCmdFunc(cmd *cobra.Command, args []string) error {
+	initLogger()
+
+	token := serveFlags.Token
+	if token == "" {
+		token = os.Getenv(apiTokenEnv)
+	}
+	if token == "" {
+		return fmt.Errorf("APIの認証トークンを --api-token または環境変数 %s で指定してください", apiTokenEnv)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return api.Serve(ctx, serveFlags.HTTPAddr, serveFlags.StatePath, token)
+}
+
+// recordRun は実行結果を状態ファイルの実行履歴に記録します。
+// 記録に失敗しても実行結果には影響させず、警告のみ出力します。
+func recordRun(statePath string, startedAt time.Time, mode string, result *pipeline.RunResult, runErr error) {
+	run := state.Run{
+		ID:         startedAt.Format("20060102-150405.000"),
+		StartedAt:  startedAt,
+		DurationMS: time.Since(startedAt).Milliseconds(),
+		Mode:       mode,
+		Status:     state.RunSucceeded,
+	}
+	if runErr != nil {
+		run.Status = state.RunFailed
+		run.Error = runErr.Error()
+	}
+	if result != nil {
+		run.FeedTitles = result.FeedTitles
+		run.Skipped = result.NotModified
+		run.Summary = runSummary(result)
+		run.CostUSD = result.CostUSD
+		run.ScrapeErrors = result.ScrapeErrors
+		if l := result.LayeredSummary; l != nil {
+			run.SummaryLayers = &state.SummaryLayers{OneLine: l.OneLine, Paragraph: l.Paragraph, Detailed: l.Detailed}
+		}
+	}
+
+	store, err := state.Load(statePath)
+	if err == nil {
+		store.RecordRun(run)
+		err = store.Save()
+	}
+	if err != nil {
+		slog.Warn("実行履歴の記録に失敗しました", slog.String("state_file", statePath), slog.String("error", err.Error()))
+		return
+	}
+	slog.Info("実行履歴を記録しました", slog.String("id", run.ID), slog.String("status", run.Status))
+}
+
+// runSummary は実行履歴に記録する要約 (出力の冒頭部分、ダイジェストの場合はトピック一覧) を返します。
+func runSummary(result *pipeline.RunResult) string {
+	if result.Output == "" && len(result.Topics) > 0 {
+		topics := make([]string, 0, len(result.Topics))
+		for _, t := range result.Topics {
+			topics = append(topics, t.Topic)
+		}
+		return "トピック: " + strings.Join(topics, ", ")
+	}
+	runes := []rune(strings.TrimSpace(result.Output))
+	if len(runes) > runSummaryMaxRunes {
+		return string(runes[:runSummaryMaxRunes]) + "…"
+	}
+	return string(runes)
+}
+
+// addServeFlags は 'serve' コマンドに固有のフラグを設定します。
+func addServeFlags(serveCmd *cobra.Command) {
+	serveCmd.Flags().StringVar(&serveFlags.HTTPAddr,
+		"http-addr", "127.0.0.1:8080", "APIサーバーの待ち受けアドレス。")
+	serveCmd.Flags().StringVar(&serveFlags.StatePath,
+		"state-file", state.DefaultPath, "実行履歴を読み込む状態ファイルのパス ('run --record-runs' の記録先)。")
+	serveCmd.Flags().StringVar(&serveFlags.Token,
+		"api-token", "", "APIの認証トークン (Authorization: Bearer で送信)。未指定時は環境変数 "+apiTokenEnv+" を使用します。")
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "最近の実行結果を JSON で返す API サーバーを起動します。",
+	Long: "'run --record-runs' で状態ファイルに記録した実行履歴を、/api/runs (一覧) と /api/runs/{id} (詳細) で JSON として返します。\n" +
+		"すべてのリクエストで Authorization: Bearer <token> ヘッダーによる認証が必要です。",
+	RunE:         serveCmdFunc,
+	SilenceUsage: true,
+}
@@ -3,14 +3,17 @@ module act-feed-clean-go
 go 1.25
 
 require (
+	github.com/mmcdole/gofeed v1.3.0
 	github.com/shouni/go-ai-client/v2 v2.0.2
 	github.com/shouni/go-cli-base v1.0.5
+	github.com/shouni/go-http-kit v1.1.0
 	github.com/shouni/go-utils v1.0.8
 	github.com/shouni/go-voicevox v1.1.5
 	github.com/shouni/go-web-exact/v2 v2.0.12
 	github.com/shouni/web-text-pipe-go v1.0.7
 	github.com/spf13/cobra v1.10.1
 	golang.org/x/time v0.14.0
+	google.golang.org/genai v1.33.0
 )
 
 require (
@@ -29,20 +32,17 @@ require (
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
-	github.com/mmcdole/gofeed v1.3.0 // indirect
 	github.com/mmcdole/goxpp v1.1.1-0.20240225020742-a0c311522b23 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/shouni/go-http-kit v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	golang.org/x/crypto v0.43.0 // indirect
 	golang.org/x/net v0.46.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
-	google.golang.org/genai v1.33.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
 	google.golang.org/grpc v1.76.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
@@ -0,0 +1,143 @@
+// Package api は、状態ファイルに記録された実行履歴を JSON で返す軽量な HTTP API を提供します。
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"act-feed-clean-go/internal/state"
+)
+
+// DefaultListLimit は /api/runs が返す実行履歴のデフォルト件数です。
+const DefaultListLimit = 20
+
+// shutdownTimeout はサーバー停止時に処理中のリクエストの完了を待つ時間です。
+const shutdownTimeout = 5 * time.Second
+
+// RunSummary は実行一覧に含める 1 件分の情報です (出力の冒頭部分は含めません)。
+type RunSummary struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Mode       string    `json:"mode"`
+	Status     string    `json:"status"`
+	FeedTitles []string  `json:"feed_titles,omitempty"`
+}
+
+// NewHandler は実行履歴 API のハンドラーを返します。
+// 状態ファイルはリクエストごとに読み込むため、別プロセスの 'run' で記録された実行も反映されます。
+// すべてのエンドポイントで "Authorization: Bearer <token>" による認証を要求します。
+func NewHandler(statePath, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/runs", func(w http.ResponseWriter, r *http.Request) {
+		limit := DefaultListLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("limit が不正です: %q", v))
+				return
+			}
+			limit = min(n, state.MaxRuns)
+		}
+		store, err := state.Load(statePath)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		runs := store.Runs(limit)
+		summaries := make([]RunSummary, 0, len(runs))
+		for _, run := range runs {
+			summaries = append(summaries, RunSummary{
+				ID:         run.ID,
+				StartedAt:  run.StartedAt,
+				DurationMS: run.DurationMS,
+				Mode:       run.Mode,
+				Status:     run.Status,
+				FeedTitles: run.FeedTitles,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"runs": summaries})
+	})
+	mux.HandleFunc("GET /api/runs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		store, err := state.Load(statePath)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		run, ok := store.RunByID(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "指定された実行が見つかりません")
+			return
+		}
+		writeJSON(w, http.StatusOK, run)
+	})
+	return requireToken(token, mux)
+}
+
+// Serve は addr で API サーバーを起動し、ctx が終了するまでリクエストを処理します。
+func Serve(ctx context.Context, addr, statePath, token string) error {
+	if token == "" {
+		return fmt.Errorf("APIトークンが設定されていません")
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(statePath, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("実行履歴APIサーバーを起動しました", slog.String("addr", addr), slog.String("state_file", statePath))
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("APIサーバーの起動に失敗しました: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("APIサーバーの停止に失敗しました: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("実行履歴APIサーバーを停止しました")
+	return nil
+}
+
+// requireToken は Bearer トークンが一致しないリクエストを 401 で拒否します。
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "認証に失敗しました")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("APIレスポンスの書き込みに失敗しました", slog.String("error", err.Error()))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,74 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ----------------------------------------------------------------
+// 処理時間の全体予算 (TotalTimeout)
+// ----------------------------------------------------------------
+
+// DefaultReduceReserveRatio は、全体予算のうち Reduce 以降のために残す割合のデフォルトです。
+// 予算の残りがこれを下回ると、Mapフェーズは新しいセグメントを開始せず、完了した要約で Reduce に進みます。
+const DefaultReduceReserveRatio = 0.3
+
+// ErrTotalTimeout は公開メソッドの処理が全体予算 (TotalTimeout) を超過したことを示します。
+var ErrTotalTimeout = errors.New("Cleanerの処理時間の全体予算 (TotalTimeout) を超過しました")
+
+// errMapBudgetExhausted は、Reduce のための予算を残すために Mapフェーズを打ち切ったことを示します。
+var errMapBudgetExhausted = errors.New("全体予算の残りが少ないため、Map要約を打ち切りました")
+
+// begin は公開メソッドの処理開始時に呼び出します (lifecycle.begin に全体予算を加えたもの)。
+// TotalTimeout が 0 より大きい場合は、返すコンテキストに予算の期限を設定します (呼び出し元の期限の方が早ければそちらが優先されます)。
+// 予算の超過で中断した場合、end はエラーを ErrTotalTimeout でラップします。
+func (c *Cleaner) begin(ctx context.Context) (context.Context, func(error) error, error) {
+	runCtx, end, err := c.lifecycle.begin(ctx)
+	if err != nil || c.config.TotalTimeout <= 0 {
+		return runCtx, end, err
+	}
+
+	budgetCtx, cancel := context.WithTimeoutCause(runCtx, c.config.TotalTimeout, ErrTotalTimeout)
+	return budgetCtx, func(err error) error {
+		if err != nil && errors.Is(context.Cause(budgetCtx), ErrTotalTimeout) {
+			err = fmt.Errorf("%w (%s): %v", ErrTotalTimeout, c.config.TotalTimeout, err)
+		}
+		cancel()
+		return end(err)
+	}, nil
+}
+
+// mapBudgetContext は Mapフェーズ用のコンテキストを返します。
+// TotalTimeout が有効で ctx に期限がある場合、期限から Reduce 以降の予備 (TotalTimeout × ReduceReserveRatio) を
+// 差し引いた時刻に errMapBudgetExhausted を原因として終了します。
+func (c *Cleaner) mapBudgetContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if c.config.TotalTimeout <= 0 || !ok {
+		return context.WithCancel(ctx)
+	}
+	reserve := time.Duration(float64(c.config.TotalTimeout) * c.config.ReduceReserveRatio)
+	return context.WithDeadlineCause(ctx, deadline.Add(-reserve), errMapBudgetExhausted)
+}
+
+// mapBudgetExhausted は mapCtx が Mapフェーズの予算切れ (errMapBudgetExhausted) で終了したかを返します。
+func mapBudgetExhausted(mapCtx context.Context) bool {
+	return mapCtx.Err() != nil && errors.Is(context.Cause(mapCtx), errMapBudgetExhausted)
+}
+
+// isMapBudgetSkip はセグメントの失敗 err が Mapフェーズの予算による打ち切りかを返します。
+// 打ち切りとするのは mapCtx の期限切れ (context.DeadlineExceeded。期限までに終わらないリミッターの待機を含む) のみで、
+// 認証・4xx・コスト上限などのエラーは、予算切れの後に届いた場合も通常の失敗として扱います。
+func (c *Cleaner) isMapBudgetSkip(ctx, mapCtx context.Context, err error) bool {
+	if ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mapBudgetExhausted(mapCtx) {
+		return true
+	}
+	// 期限の前にリミッターの待機が失敗した場合は、mapCtx の期限が予算によるもの (呼び出し元の期限より早い) かで判定する
+	deadline, ok := mapCtx.Deadline()
+	parent, hasParent := ctx.Deadline()
+	return c.config.TotalTimeout > 0 && ok && (!hasParent || deadline.Before(parent))
+}
@@ -0,0 +1,150 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"golang.org/x/time/rate"
+	"google.golang.org/genai"
+)
+
+// budgetTestModel は Mapフェーズの予算のテスト用の gemini.GenerativeModel です。
+// プロンプトに "SLOW" を含むセグメントは ctx が終了するまで応答せず、それ以外はプロンプトの "AUTH" を引き継いだ要約をすぐに返します。
+type budgetTestModel struct{}
+
+func (budgetTestModel) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	if strings.Contains(prompt, "SLOW") {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if strings.Contains(prompt, "AUTH") {
+		return &gemini.Response{Text: "- 要約 AUTH"}, nil
+	}
+	return &gemini.Response{Text: "- 要約"}, nil
+}
+
+// failAfter は "AUTH" を含む要約に対して、delay だけ待ってから err を返す Map の後処理です。
+func failAfter(delay time.Duration, err error) PostProcessor {
+	return func(text string) (string, error) {
+		if !strings.Contains(text, "AUTH") {
+			return text, nil
+		}
+		time.Sleep(delay)
+		return "", err
+	}
+}
+
+func TestProcessSegmentsInParallelBudgetSkipsOnlyDeadlineErrors(t *testing.T) {
+	// 全体予算 1 秒のうち 8 割を Reduce 以降に残すため、Map は 200ms で打ち切られる
+	const totalTimeout = time.Second
+	authErr := genai.APIError{Code: 401, Message: "API key not valid", Status: "UNAUTHENTICATED"}
+
+	newBudgetCleaner := func(t *testing.T, postProcessors []PostProcessor) *Cleaner {
+		t.Helper()
+		c, err := NewCleaner(budgetTestModel{}, CleanerConfig{
+			LLMRateLimit:       time.Millisecond,
+			TotalTimeout:       totalTimeout,
+			ReduceReserveRatio: 0.8,
+			PostProcessors:     map[string][]PostProcessor{PhaseMap: postProcessors},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	t.Run("deadline is skipped as budget exhaustion", func(t *testing.T) {
+		c := newBudgetCleaner(t, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), totalTimeout)
+		defer cancel()
+
+		segments := []string{"予算内に完了するセグメントの本文です。", "予算内に終わらないセグメントの本文です。SLOW"}
+		summaries, dropped, err := c.processSegmentsInParallel(ctx, segments)
+		if err != nil {
+			t.Fatalf("予算切れのセグメントを除外して続行することを期待: %v", err)
+		}
+		if len(summaries) != 1 || len(dropped) != 1 || dropped[0].Index != 2 || dropped[0].Error != errMapBudgetExhausted.Error() {
+			t.Errorf("summaries = %v, dropped = %+v, want 1 件の要約とセグメント 2 の予算切れ", summaries, dropped)
+		}
+	})
+
+	t.Run("auth error after budget is a failure", func(t *testing.T) {
+		c := newBudgetCleaner(t, []PostProcessor{failAfter(400*time.Millisecond, authErr)})
+		ctx, cancel := context.WithTimeout(context.Background(), totalTimeout)
+		defer cancel()
+
+		segments := []string{"予算内に完了するセグメントの本文です。", "予算切れの後に認証エラーになるセグメントの本文です。AUTH"}
+		_, dropped, err := c.processSegmentsInParallel(ctx, segments)
+		if err == nil {
+			t.Fatalf("予算切れの後の認証エラーが失敗として扱われていません (dropped = %+v)", dropped)
+		}
+		if errors.Is(err, errMapBudgetExhausted) || !strings.Contains(err.Error(), authErr.Error()) {
+			t.Errorf("err = %v, want 認証エラーによる失敗", err)
+		}
+	})
+
+	t.Run("auth error after budget triggers fail fast", func(t *testing.T) {
+		c := newBudgetCleaner(t, []PostProcessor{failAfter(400*time.Millisecond, authErr)})
+		c.config.FailFast, c.config.FailFastThreshold = true, 1
+		ctx, cancel := context.WithTimeout(context.Background(), totalTimeout)
+		defer cancel()
+
+		segments := []string{"予算切れの後に認証エラーになるセグメントの本文です。AUTH"}
+		_, _, err := c.processSegmentsInParallel(ctx, segments)
+		var apiErr genai.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != authErr.Code {
+			t.Errorf("err = %v, want FailFast による認証エラー (401)", err)
+		}
+	})
+}
+
+func TestIsMapBudgetSkip(t *testing.T) {
+	c, err := NewCleaner(budgetTestModel{}, CleanerConfig{TotalTimeout: time.Second, ReduceReserveRatio: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	// 予算内: リミッターの待機が期限までに終わらない場合のみ打ち切りとする
+	mapCtx, cancelMap := c.mapBudgetContext(ctx)
+	defer cancelMap()
+	limiter := rate.NewLimiter(rate.Every(2*time.Hour), 1)
+	limiter.Allow()
+	_, limiterErr := c.generateSegmentSummary(mapCtx, limiter, 1, "本文", "プロンプト")
+	if limiterErr == nil {
+		t.Fatal("期限までに終わらないリミッターの待機がエラーになることを期待")
+	}
+	if !c.isMapBudgetSkip(ctx, mapCtx, limiterErr) {
+		t.Errorf("期限までに終わらないリミッターの待機を予算による打ち切りとすることを期待")
+	}
+	if c.isMapBudgetSkip(ctx, mapCtx, genai.APIError{Code: 403}) {
+		t.Errorf("予算内の 403 を予算による打ち切りとしないことを期待")
+	}
+
+	// 予算切れの後: 期限切れのみを打ち切りとし、他のエラーは通常の失敗とする
+	expired, cancelExpired := context.WithDeadlineCause(ctx, time.Now().Add(-time.Second), errMapBudgetExhausted)
+	defer cancelExpired()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadline exceeded", expired.Err(), true},
+		{"limiter deadline", limiterErr, true},
+		{"auth error", genai.APIError{Code: 401}, false},
+		{"client error", genai.APIError{Code: 400}, false},
+		{"cost limit", &CostLimitError{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.isMapBudgetSkip(ctx, expired, tt.err); got != tt.want {
+				t.Errorf("isMapBudgetSkip(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// カテゴリ別の処理設定 (モデル・プロンプトセット・トーン)
+// ----------------------------------------------------------------
+
+// CategoryProfile は記事のカテゴリに応じて切り替える処理設定です。空のフィールドはデフォルトの設定を使用します。
+type CategoryProfile struct {
+	MapModel     string `json:"map_model,omitempty"`
+	ReduceModel  string `json:"reduce_model,omitempty"`
+	SummaryModel string `json:"summary_model,omitempty"`
+	ScriptModel  string `json:"script_model,omitempty"`
+	// PromptDir はプロンプトセットのディレクトリです。同名のファイルで組み込みテンプレートを上書きします (NewPromptManagerFromDir)。
+	PromptDir string `json:"prompt_dir,omitempty"`
+	// Politeness / Formality は出力の文体とトーンです (OutputStyle と同じ値)。
+	Politeness string `json:"politeness,omitempty"`
+	Formality  string `json:"formality,omitempty"`
+}
+
+// NormalizeCategory はカテゴリ名を照合用に正規化します (前後の空白を除去し、小文字化します)。
+func NormalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// LoadCategoryProfiles はカテゴリ名をキーとする JSON ファイル ({"Tech": {"script_model": ...}}) を読み込み、
+// 正規化したカテゴリ名をキーとするマッピングを返します。モデル名・文体・トーン・プロンプトセットもここで検証します。
+func LoadCategoryProfiles(path string) (map[string]CategoryProfile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("カテゴリ設定ファイルの読み込みに失敗しました: %w", err)
+	}
+	var decoded map[string]CategoryProfile
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("カテゴリ設定ファイルの解析に失敗しました (%s): %w", path, err)
+	}
+
+	profiles := make(map[string]CategoryProfile, len(decoded))
+	var errs []error
+	for category, profile := range decoded {
+		key := NormalizeCategory(category)
+		if key == "" {
+			errs = append(errs, fmt.Errorf("カテゴリ名が空です"))
+			continue
+		}
+		if err := profile.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("カテゴリ %q: %w", category, err))
+			continue
+		}
+		profiles[key] = profile
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("カテゴリ設定ファイルが不正です (%s): %w", path, err)
+	}
+	return profiles, nil
+}
+
+// validate はプロファイルのモデル名・文体・トーンと、プロンプトセットのテンプレートを検証します。
+func (p CategoryProfile) validate() error {
+	err := ValidateCleanerConfig(p.apply(CleanerConfig{}))
+	if err == nil && p.PromptDir != "" {
+		_, err = NewPromptManagerFromDir(p.PromptDir)
+	}
+	return err
+}
+
+// apply は config のうち、プロファイルで指定されたフィールドを上書きした設定を返します。
+func (p CategoryProfile) apply(config CleanerConfig) CleanerConfig {
+	override := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	override(&config.MapModel, p.MapModel)
+	override(&config.ReduceModel, p.ReduceModel)
+	override(&config.SummaryModel, p.SummaryModel)
+	override(&config.ScriptModel, p.ScriptModel)
+	override(&config.OutputStyle.Politeness, p.Politeness)
+	override(&config.OutputStyle.Formality, p.Formality)
+	return config
+}
+
+// WithProfile はプロファイルの設定を適用した Cleaner を返します。
+// LLMクライアント・累積コスト・ストリーミング用クライアント・シャットダウン管理は元の Cleaner と共有します。
+func (c *Cleaner) WithProfile(profile CategoryProfile) (*Cleaner, error) {
+	config := profile.apply(c.config)
+	if err := ValidateCleanerConfig(config); err != nil {
+		return nil, fmt.Errorf("カテゴリ設定が不正です: %w", err)
+	}
+	manager := c.prompt
+	if profile.PromptDir != "" {
+		var err error
+		if manager, err = NewPromptManagerFromDir(profile.PromptDir); err != nil {
+			return nil, err
+		}
+	}
+
+	derived := *c
+	derived.prompt = manager
+	derived.config = config
+	return &derived, nil
+}
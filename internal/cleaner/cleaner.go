@@ -2,6 +2,7 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -19,6 +20,7 @@ const ContentSeparator = "\n\n--- DOCUMENT END ---\n\n"
 const DefaultSeparator = "\n\n"
 
 // MaxSegmentChars は、MapフェーズでLLMに一度に渡す安全な最大文字数。
+// トークン数の予算 (CleanerConfig.MaxSegmentTokens) による分割の前に適用されるフォールバックの上限です。
 const MaxSegmentChars = 400000
 
 // ----------------------------------------------------------------
@@ -37,13 +39,23 @@ const (
 	DefaultScriptModelName = DefaultModelName
 	// DefaultLLMRateLimit は、LLMへのリクエスト間の最小間隔です。
 	DefaultLLMRateLimit = 1000 * time.Millisecond
+	// DefaultMinSegmentContentChars は、Map要約をスキップする有意な文字数のデフォルトの閾値 (CLIの既定値) です。
+	DefaultMinSegmentContentChars = 10
+	// DefaultFailFastThreshold は、FailFast で Mapフェーズを打ち切る同種エラーのデフォルト件数です。
+	DefaultFailFastThreshold = 3
+	// DefaultMaxConcurrency は Mapフェーズで同時に処理するセグメント数のデフォルトです。
+	DefaultMaxConcurrency = 4
 )
 
 // Cleaner はコンテンツのクリーンアップと要約を担当します。
 type Cleaner struct {
-	client *gemini.Client // LLMクライアントを注入
-	prompt *PromptManager // prompt_manager.go で定義
+	client gemini.GenerativeModel // LLMクライアントを注入 (複数 APIキーの場合は KeyPool。key_pool.goで定義)
+	prompt *PromptManager         // prompt_manager.go で定義
 	config CleanerConfig
+	cost   *costTracker // LLM呼び出しの累積推定コスト (cost.goで定義)
+	stream StreamClient // スクリプト生成のストリーミング用クライアント (stream.goで定義、未設定可)
+	// lifecycle は進行中の処理の追跡とシャットダウン時のキャンセル伝播を担います (shutdown.goで定義)
+	lifecycle *lifecycle
 	// LLMリクエストレートリミットの間隔
 	rateLimit time.Duration
 }
@@ -55,15 +67,127 @@ type CleanerConfig struct {
 	ScriptModel  string        // ScriptGenerationフェーズで使用するGeminiモデル名
 	LLMRateLimit time.Duration // LLMリクエストのレートリミット間隔
 	Verbose      bool          // 詳細ログを有効にするか
+
+	LLMMaxAttempts    int           // LLM呼び出しの初回を含む最大試行回数 (レート制限・サーバーエラーのみ再試行)。0の場合はデフォルト
+	LLMRetryBaseDelay time.Duration // LLM呼び出しの再試行の初回の待機時間 (以降は倍増)。0の場合はデフォルト
+
+	// 以下は各フェーズのモデルの優先順リストです。*Model のモデルが再試行しても一時的な失敗 (レート制限・サーバーエラー) で
+	// 失敗した場合に、リストの順にフォールバックします (model_fallback.goで定義)。*Model が空の場合は先頭のモデルを使用します
+	MapModels     []string
+	ReduceModels  []string
+	SummaryModels []string
+	ScriptModels  []string
+
+	// 以下は各フェーズの生成パラメータです (gen_config.goで定義)。LLMクライアントが ConfigurableModel の場合のみ適用し、
+	// 重要度採点・トピック分類には MapGenConfig を適用します
+	MapGenConfig     GenConfig
+	ReduceGenConfig  GenConfig
+	SummaryGenConfig GenConfig
+	ScriptGenConfig  GenConfig
+
+	EnforceMapFormat    bool           // Map要約を固定フォーマットに強制し、違反セグメントを再生成するか
+	MapFormatRules      MapFormatRules // Map要約フォーマットの検証ルール (ゼロ値は検証なし。CLIではフラグの既定値として DefaultMapFormatRules を設定)
+	MapFormatMaxRetries int            // フォーマット違反時の最大再生成回数
+
+	MinSegmentContentChars int // 有意な文字数がこれ未満のセグメントはMap要約のLLM呼び出しをスキップする。0の場合はスキップしない
+
+	MaxSegmentTokens int          // Mapフェーズの 1 セグメントあたりの推定トークン数の上限 (token_segment.goで定義)。0の場合はデフォルト
+	TokenCounter     TokenCounter // セグメント分割でトークン数を数える方法。nil の場合はヒューリスティックな推定 (EstimateTokens)
+
+	SegmentOverlapChars int // 2つ目以降のセグメントの先頭に含める、直前のセグメントの末尾の文字数 (境界をまたぐ文の文脈を保つ)。0の場合は重なりなし
+
+	// SegmentPerArticle は、文書区切り (ContentSeparator) ごとの記事を 1 セグメントとし、複数の記事を 1 セグメントにまとめないかです。
+	// 上限を超える長い記事のみ記事の中でさらに分割します (token_segment.goで定義)。
+	SegmentPerArticle bool
+
+	FailFast          bool // Map要約で同種のエラーが閾値に達したら残りのセグメントをキャンセルして即座に失敗させるか
+	FailFastThreshold int  // FailFast で打ち切る同種エラーの件数
+
+	// 以下は Map要約に失敗したセグメントを除外して Reduce を続行する許容範囲です (segment_failure.goで定義)。
+	// 失敗がいずれかの範囲に収まる場合は続行し、両方が 0 の場合は 1 件の失敗で Mapフェーズを失敗させます
+	MaxFailedSegments      int     // 許容する失敗セグメント数
+	MaxFailedSegmentsRatio float64 // 許容する失敗セグメントの割合 (要約を呼び出したセグメント数に対する 0〜1)
+
+	// TotalTimeout が 0 より大きい場合、公開メソッド (CleanAndStructureText など) の 1 回の処理時間をこの予算に制限します
+	// (budget.goで定義)。予算の残りが TotalTimeout × ReduceReserveRatio を下回ると、Mapフェーズは新しいセグメントを開始せず、
+	// 完了した要約で Reduce に進みます。0 の場合は無効で、ctx の期限のみに従います
+	TotalTimeout       time.Duration
+	ReduceReserveRatio float64 // 全体予算のうち Reduce 以降のために残す割合 (0〜1)。0の場合はデフォルト
+
+	MaxConcurrency int // Mapフェーズで同時に LLM を呼び出すセグメント数の上限 (レートリミッターとは独立)。0の場合はデフォルト
+
+	MapCache MapCache // Map要約のキャッシュ (map_cache.goで定義)。ヒットしたセグメントは LLM を呼び出さない。nil の場合は無効
+
+	ReduceStrategy   ReduceStrategyKind // Map要約を統合する Reduce戦略 (concat / hierarchical / refine。reduce_strategy.goで定義)
+	StructuredReduce bool               // Reduce結果を「概要／主要ポイント／結論」のセクション構造で出力させるか
+	CiteSources      bool               // Map・Reduce・最終要約の各記述にソース文書の番号 [n] を付けさせるか (ソースカバレッジの算出用)
+
+	LayeredSummaryMode LayeredSummaryMode // 多層要約の生成方法 (single / separate。layered_summary.goで定義)
+	SummaryFormat      SummaryFormat      // 最終要約の出力形式 (prose / bullet / both。summary_format.goで定義)
+	ExpertiseLevel     ExpertiseLevel     // 最終要約の想定読者の専門性 (beginner / intermediate / expert。expertise.goで定義)
+
+	TopicGranularity TopicGranularity // ダイジェストモードのトピック分類粒度
+	MaxTopics        int              // ダイジェストモードで生成するトピック数の上限
+
+	NGWords       []string // 生成スクリプトから除去するNGワード (`/pattern/` 形式は正規表現)
+	NGReplacement string   // NGワードの置換文字列 (空の場合は削除。CLIの既定値は DefaultNGReplacement)
+	StrictNG      bool     // NGワード検出時に処理を失敗させるか
+
+	BalanceSpeakers         bool    // 話者の偏りを検出した場合にバランス指示を追加して再生成するか
+	SpeakerBalanceThreshold float64 // 1話者の発話文字数の占有率がこの値を超えたら偏りと判定する (0〜1)
+
+	ScriptTurns        int // スクリプトの目標ターン数。0の場合は制約なし。大きく外れた場合は1回再生成する
+	ScriptMaxTurnChars int // スクリプトの1ターンあたりの最大文字数 (プロンプトで指示のみ)。0の場合は制約なし
+	ScriptTargetChars  int // スクリプト全体の目標文字数 (プロンプトで指示のみ。目標の再生時間から算出)。0の場合は制約なし
+
+	ScriptOpening        string // スクリプト冒頭の挨拶のテンプレート (script_greeting.goの GreetingData を埋め込み可能)。空の場合は挨拶なし
+	ScriptClosing        string // スクリプト末尾の挨拶のテンプレート。空の場合は挨拶なし
+	ScriptOpeningSpeaker string // 冒頭の挨拶を担当する話者名。空の場合は最初の話者
+	ScriptClosingSpeaker string // 末尾の挨拶を担当する話者名。空の場合は最後の話者
+
+	ParaphraseStrict     bool    // 要約と原文の重複率が閾値以上の場合に、言い換えを強める指示を追加して再生成するか
+	OverlapThreshold     float64 // 要約の原文との重複率がこの値以上の場合に転載と判定する (0〜1)
+	OverlapNGram         int     // 重複率の判定に使用する n-gram の文字数
+	OverlapMinMatchChars int     // 重複として数える連続一致の最小文字数。これより短い一致は引用として許容する
+
+	MaxCostUSD float64 // LLM呼び出しの累積推定コストの上限 (USD)。0の場合は無制限
+	// Pricing はモデル名 (前方一致) ごとの単価で、組み込みの単価表より優先します (LoadPricingTable で読み込みます)。
+	Pricing map[string]ModelPricing
+	// DryRun が true の場合、LLM を呼び出さずにセグメント数とフェーズ別の推定トークン数を見積もります (dry_run.goで定義)。
+	// CleanAndStructureText は見積もりを出力して ErrDryRun を返し、その他の LLM 呼び出しも ErrDryRun で失敗します。
+	DryRun bool
+
+	ReasoningTags []string // LLMのレスポンスから除去する推論部分のタグ名 (例: thinking → <thinking>...</thinking>)。nil の場合はデフォルト
+
+	OutputLang string // Reduce・Summary・Scriptの出力に期待する言語 (ja, en)。空の場合は言語ガードを無効化
+
+	OutputStyle OutputStyle // 全フェーズのプロンプトに共通で指示する言語・文体・トーン (output_style.goで定義)
+
+	// PostProcessors はフェーズ名 (PhaseMap など) ごとに、LLM出力へ登録順に適用する後処理です (post_process.goで定義)。
+	// 後処理がエラーを返した場合、そのフェーズは失敗として扱われます。
+	PostProcessors map[string][]PostProcessor
 }
 
 // NewCleaner は新しいCleanerインスタンスを作成し、依存関係とPromptBuilderを初期化します。
-func NewCleaner(client *gemini.Client, config CleanerConfig) (*Cleaner, error) {
+func NewCleaner(client gemini.GenerativeModel, config CleanerConfig) (*Cleaner, error) {
 	if client == nil {
 		return nil, fmt.Errorf("LLMクライアントはnilであってはなりません")
 	}
+	if err := ValidateCleanerConfig(config); err != nil {
+		return nil, fmt.Errorf("CleanerConfigが不正です: %w", err)
+	}
+	warnUnsupportedGenConfig(client, config)
 
 	// デフォルト値の設定
+	primaryModel := func(model *string, models []string) {
+		if *model == "" && len(models) > 0 {
+			*model = models[0]
+		}
+	}
+	primaryModel(&config.MapModel, config.MapModels)
+	primaryModel(&config.ReduceModel, config.ReduceModels)
+	primaryModel(&config.SummaryModel, config.SummaryModels)
+	primaryModel(&config.ScriptModel, config.ScriptModels)
 	if config.MapModel == "" {
 		config.MapModel = DefaultMapModelName
 	}
@@ -79,6 +203,71 @@ func NewCleaner(client *gemini.Client, config CleanerConfig) (*Cleaner, error) {
 	if config.LLMRateLimit <= 0 {
 		config.LLMRateLimit = DefaultLLMRateLimit
 	}
+	// MapFormatRules は呼び出し側の指定をそのまま使用する (ゼロ値で検証を緩められるよう、デフォルトで上書きしない)
+	if config.EnforceMapFormat && config.MapFormatMaxRetries <= 0 {
+		config.MapFormatMaxRetries = DefaultMapFormatMaxRetries
+	}
+
+	// 出力スタイルの言語は言語ガードと同じ設定を使用する
+	if config.OutputStyle.Language == "" {
+		config.OutputStyle.Language = config.OutputLang
+	}
+
+	if config.FailFastThreshold <= 0 {
+		config.FailFastThreshold = DefaultFailFastThreshold
+	}
+	if config.LLMMaxAttempts <= 0 {
+		config.LLMMaxAttempts = DefaultLLMMaxAttempts
+	}
+	if config.LLMRetryBaseDelay <= 0 {
+		config.LLMRetryBaseDelay = DefaultLLMRetryBaseDelay
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if config.ReduceReserveRatio <= 0 {
+		config.ReduceReserveRatio = DefaultReduceReserveRatio
+	}
+	if config.MaxSegmentTokens <= 0 {
+		config.MaxSegmentTokens = DefaultMaxSegmentTokens
+	}
+	if config.TokenCounter == nil {
+		config.TokenCounter = DefaultTokenCounter()
+	}
+
+	if config.ReduceStrategy == "" {
+		config.ReduceStrategy = DefaultReduceStrategy
+	}
+	if config.LayeredSummaryMode == "" {
+		config.LayeredSummaryMode = DefaultLayeredSummaryMode
+	}
+	if config.SummaryFormat == "" {
+		config.SummaryFormat = DefaultSummaryFormat
+	}
+	if config.ExpertiseLevel == "" {
+		config.ExpertiseLevel = DefaultExpertiseLevel
+	}
+	if config.TopicGranularity == "" {
+		config.TopicGranularity = DefaultTopicGranularity
+	}
+	if config.MaxTopics <= 0 {
+		config.MaxTopics = DefaultMaxTopics
+	}
+	if config.SpeakerBalanceThreshold <= 0 {
+		config.SpeakerBalanceThreshold = DefaultSpeakerBalanceThreshold
+	}
+	if config.OverlapThreshold <= 0 {
+		config.OverlapThreshold = DefaultOverlapThreshold
+	}
+	if config.OverlapNGram <= 0 {
+		config.OverlapNGram = DefaultOverlapNGram
+	}
+	if config.OverlapMinMatchChars <= 0 {
+		config.OverlapMinMatchChars = DefaultOverlapMinMatchChars
+	}
+	if config.ReasoningTags == nil {
+		config.ReasoningTags = DefaultReasoningTags
+	}
 
 	// PromptManagerを構築 (prompt_manager.goで定義)
 	manager, err := NewPromptManager()
@@ -90,104 +279,288 @@ func NewCleaner(client *gemini.Client, config CleanerConfig) (*Cleaner, error) {
 		client:    client, // 注入
 		prompt:    manager,
 		config:    config,
+		cost:      &costTracker{limitUSD: config.MaxCostUSD, pricing: config.Pricing},
+		lifecycle: newLifecycle(),
 		rateLimit: config.LLMRateLimit,
 	}, nil
 }
 
+// CiteSources は要約へのソース番号の付記が有効かどうかを返します。
+func (c *Cleaner) CiteSources() bool {
+	return c.config.CiteSources
+}
+
+// StructuredReduce は Reduce結果のセクション構造化が有効かどうかを返します。
+func (c *Cleaner) StructuredReduce() bool {
+	return c.config.StructuredReduce
+}
+
 // ----------------------------------------------------------------
 // メインロジック
 // ----------------------------------------------------------------
 
 // CleanAndStructureText は、コンテンツをMap-Reduceパターンで構造化します。
 // 最終的に中間統合要約を生成する役割を担います。
+// Map・Reduceフェーズで失敗した場合は、完了した Map要約を保持する *PartialError を返します (partial.goで定義)。
 func (c *Cleaner) CleanAndStructureText(ctx context.Context, combinedText string) (string, error) {
+	return c.cleanAndStructureText(ctx, combinedText, &MapReport{})
+}
+
+// cleanAndStructureText は CleanAndStructureText の本体です。Mapフェーズのメタデータを report に記録します。
+func (c *Cleaner) cleanAndStructureText(ctx context.Context, combinedText string, report *MapReport) (_ string, err error) {
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = end(err) }()
 
 	// 1. Mapフェーズのためのテキスト分割 (utils.goで定義)
-	segments := c.segmentText(combinedText, MaxSegmentChars)
-	slog.Info("テキストをセグメントに分割しました", slog.Int("segments", len(segments)))
+	segments := c.splitIntoSegments(combinedText)
+	slog.InfoContext(ctx, "テキストをセグメントに分割しました", slog.Int("segments", len(segments)), slog.Int("max_tokens", c.config.MaxSegmentTokens))
+	report.Segments = len(segments)
+
+	// ドライランの場合は見積もりのみを出力し、LLM を呼び出さずに戻る (dry_run.goで定義)
+	if c.config.DryRun {
+		estimate, err := c.EstimateDryRun("", combinedText)
+		if err != nil {
+			return "", err
+		}
+		LogDryRun(ctx, estimate)
+		return "", ErrDryRun
+	}
 
 	// 2. Mapフェーズの実行（各セグメントの並列処理）(utils.goで定義)
-	intermediateSummaries, err := c.processSegmentsInParallel(ctx, segments)
+	// 失敗が MaxFailedSegments / MaxFailedSegmentsRatio の範囲内であれば、失敗したセグメントを除外して続行する (segment_failure.goで定義)
+	intermediateSummaries, dropped, err := c.processSegmentsInParallel(ctx, segments)
+	report.Dropped = dropped
+	if err != nil {
+		// 完了したセグメントの要約は、失敗の種類によらず PartialError.MapSummaries で返す。
+		// コスト上限で打ち切られた場合のみ、出力する部分成果として CostLimitError.Partial にも設定する
+		// (Reduce の入力と同じ区切りで連結し、出力の形を揃える)
+		var costErr *CostLimitError
+		if errors.As(err, &costErr) && costErr.Partial == "" {
+			costErr.Partial = strings.Join(intermediateSummaries, intermediateSummarySeparator)
+		}
+		return "", &PartialError{
+			Phase:        PhaseMap,
+			MapSummaries: intermediateSummaries,
+			Err:          fmt.Errorf("コンテンツのセグメント処理（Mapフェーズ）中にエラーが発生しました: %w", err),
+		}
+	}
+
+	// 3. Reduceフェーズ：設定された戦略で中間要約を統合・構造化 (reduce_strategy.goで定義)
+	slog.InfoContext(ctx, "Reduceフェーズ（中間統合要約）を開始します。", slog.String("strategy", string(c.config.ReduceStrategy)))
+	finalText, err := newReduceStrategy(c.config.ReduceStrategy).Reduce(ctx, intermediateSummaries, c.reduceOnce)
+	if err != nil {
+		// Map と同様に、コスト上限で打ち切られた場合のみ Map要約を連結して CostLimitError.Partial に設定する
+		var costErr *CostLimitError
+		if errors.As(err, &costErr) && costErr.Partial == "" {
+			costErr.Partial = strings.Join(intermediateSummaries, intermediateSummarySeparator)
+		}
+		return "", &PartialError{
+			Phase:        PhaseReduce,
+			MapSummaries: intermediateSummaries,
+			Err:          fmt.Errorf("LLM Reduce処理（中間統合要約）に失敗しました: %w", err),
+		}
+	}
+	if finalText, err = c.postProcess(PhaseReduce, finalText); err != nil {
+		return "", &PartialError{Phase: PhaseReduce, MapSummaries: intermediateSummaries, Err: err}
+	}
+
+	// Reduceの結果（中間統合要約）を返します。
+	return finalText, nil
+}
+
+// GenerateFinalSummary は、中間統合要約を元に、簡潔な最終要約を生成します。
+// 出力形式は SummaryFormat に従います (both の場合は散文。summary_format.goで定義)。
+func (c *Cleaner) GenerateFinalSummary(ctx context.Context, title string, intermediateSummary string) (_ string, err error) {
+	ctx, end, err := c.begin(ctx)
 	if err != nil {
-		return "", fmt.Errorf("コンテンツのセグメント処理（Mapフェーズ）中にエラーが発生しました: %w", err)
+		return "", err
 	}
+	defer func() { err = end(err) }()
 
-	// 3. Reduceフェーズの準備：中間要約の結合
-	intermediateCombinedText := strings.Join(intermediateSummaries, "\n\n--- INTERMEDIATE SUMMARY END ---\n\n")
+	return c.generateFinalSummary(ctx, title, intermediateSummary, c.primarySummaryFormat())
+}
 
-	// 4. Reduceフェーズ：中間要約の統合と構造化のためのLLM呼び出し
-	slog.Info("中間要約の結合が完了しました。Reduceフェーズ（中間統合要約）を開始します。")
+// generateFinalSummary は出力形式 format の最終要約を生成します。
+func (c *Cleaner) generateFinalSummary(ctx context.Context, title string, intermediateSummary string, format SummaryFormat) (string, error) {
+	slog.InfoContext(ctx, "Final Summary Generation（最終要約）を開始します。", slog.String("format", string(format)))
 
-	// Reduce プロンプト（reduce_final_prompt.md）を使用して中間統合要約を作成
-	reduceData := prompts.ReduceTemplateData{CombinedText: intermediateCombinedText}
-	finalPrompt, err := c.prompt.ReduceBuilder.BuildReduce(reduceData)
+	prompt, err := c.buildFinalSummaryPrompt(title, intermediateSummary, format)
 	if err != nil {
-		return "", fmt.Errorf("Reduce プロンプトの生成に失敗しました: %w", err)
+		return "", err
 	}
 
-	// Reduceフェーズのモデル名に c.ReduceModel を使用
-	finalResponse, err := c.client.GenerateContent(ctx, finalPrompt, c.config.ReduceModel)
+	// SummaryModelName を使用
+	summaryText, err := c.generateInLanguage(ctx, "summary", prompt, c.config.SummaryModel, nil)
 	if err != nil {
-		return "", fmt.Errorf("LLM Reduce処理（中間統合要約）に失敗しました: %w", err)
+		return "", fmt.Errorf("LLM Final Summary処理（最終要約）に失敗しました: %w", err)
+	}
+	if summaryText, err = c.postProcess(PhaseSummary, summaryText); err != nil {
+		return "", err
 	}
+	slog.InfoContext(ctx, "Final Summary Generation（最終要約）が完了しました。", slog.Int("summary_length", len(summaryText)))
 
-	// Reduceの結果（中間統合要約）を返します。
-	return finalResponse.Text, nil
+	return summaryText, nil
 }
 
-// GenerateFinalSummary は、中間統合要約を元に、簡潔な最終要約を生成します。
-func (c *Cleaner) GenerateFinalSummary(ctx context.Context, title string, intermediateSummary string) (string, error) {
-	slog.Info("Final Summary Generation（最終要約）を開始します。")
-
+// buildFinalSummaryPrompt は出力形式 format の Final Summary フェーズのプロンプトを構築します。
+func (c *Cleaner) buildFinalSummaryPrompt(title string, intermediateSummary string, format SummaryFormat) (string, error) {
 	summaryData := prompts.FinalSummaryTemplateData{
 		Title:               title,
 		IntermediateSummary: intermediateSummary,
+		Format:              string(format),
+		ExpertiseLevel:      string(c.config.ExpertiseLevel),
+		CiteSources:         c.config.CiteSources,
+		OutputStyle:         c.config.OutputStyle.promptStyle(),
 	}
 	prompt, err := c.prompt.FinalSummaryBuilder.BuildFinalSummary(summaryData)
 	if err != nil {
 		return "", fmt.Errorf("Final Summary プロンプトの生成に失敗しました: %w", err)
 	}
+	return prompt, nil
+}
 
-	// SummaryModelName を使用
-	response, err := c.client.GenerateContent(ctx, prompt, c.config.SummaryModel)
+// GenerateScriptForVoicevox は、最終要約を元に、VOICEVOXエンジン向けのスクリプトを生成します。
+// structure が nil でない場合、そのセクション順に会話を展開するようプロンプトで指示します。
+func (c *Cleaner) GenerateScriptForVoicevox(ctx context.Context, title string, finalSummary string, structure *ReduceResult) (_ string, err error) {
+	ctx, end, err := c.begin(ctx)
 	if err != nil {
-		return "", fmt.Errorf("LLM Final Summary処理（最終要約）に失敗しました: %w", err)
+		return "", err
 	}
-	slog.Info("Final Summary Generation（最終要約）が完了しました。", slog.Int("summary_length", len(response.Text)))
+	defer func() { err = end(err) }()
+
+	slog.InfoContext(ctx, "Script Generation（スクリプト作成）を開始します。", slog.Bool("structured", structure != nil))
 
-	return response.Text, nil
+	prompt, err := c.buildScriptPrompt(title, finalSummary, structure)
+	if err != nil {
+		return "", err
+	}
+
+	scriptText, err := c.generateScript(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	// ターン数の検証 (script_turns.goで定義)
+	if scriptText, err = c.adjustScriptTurns(ctx, prompt, scriptText); err != nil {
+		return "", err
+	}
+
+	// 話者バランスの検証 (speaker_balance.goで定義)
+	balance := c.SpeakerBalance(scriptText)
+	logSpeakerBalance(ctx, balance, c.config.SpeakerBalanceThreshold)
+	if balance.Imbalanced && c.config.BalanceSpeakers {
+		slog.InfoContext(ctx, "話者バランスの指示を追加してスクリプトを再生成します")
+		retried, err := c.generateScript(ctx, prompt+balancePromptSuffix(balance))
+		if errors.Is(err, ErrCostLimitExceeded) {
+			slog.WarnContext(ctx, "コスト上限に達したため、話者バランスの再生成を行わずに最初のスクリプトを使用します")
+			return c.finishScript(ctx, scriptText)
+		}
+		if err != nil {
+			return "", err
+		}
+		retriedBalance := c.SpeakerBalance(retried)
+		logSpeakerBalance(ctx, retriedBalance, c.config.SpeakerBalanceThreshold)
+		if retriedBalance.DominantSpeaker != "" && retriedBalance.DominantShare <= balance.DominantShare {
+			scriptText = retried
+		} else {
+			slog.WarnContext(ctx, "再生成後の方が偏りが大きいため、最初のスクリプトを使用します")
+		}
+	}
+
+	return c.finishScript(ctx, scriptText)
 }
 
-// GenerateScriptForVoicevox は、最終要約を元に、VOICEVOXエンジン向けのスクリプトを生成します。
-func (c *Cleaner) GenerateScriptForVoicevox(ctx context.Context, title string, finalSummary string) (string, error) {
-	slog.Info("Script Generation（スクリプト作成）を開始します。")
+// finishScript は生成スクリプトに NGワードフィルタとユーザー定義の後処理を順に適用します。
+func (c *Cleaner) finishScript(ctx context.Context, scriptText string) (string, error) {
+	scriptText, err := c.filterNGWords(ctx, scriptText)
+	if err != nil {
+		return "", err
+	}
+	return c.postProcess(PhaseScript, scriptText)
+}
 
+// buildScriptPrompt は Script プロンプトを組み立てます。
+// structure が nil でない場合、そのセクション順に会話を展開するよう指示します。
+func (c *Cleaner) buildScriptPrompt(title string, finalSummary string, structure *ReduceResult) (string, error) {
+	// ソース番号は読み上げないため、スクリプトの入力からは除去する (source_coverage.goで定義)
+	if c.config.CiteSources {
+		finalSummary, structure = StripSourceCitations(finalSummary), structure.withoutCitations()
+	}
 	scriptData := prompts.ScriptTemplateData{
 		Title:            title,
 		FinalSummaryText: finalSummary,
+		TargetTurns:      c.config.ScriptTurns,
+		MaxCharsPerTurn:  c.config.ScriptMaxTurnChars,
+		TargetChars:      c.config.ScriptTargetChars,
+		OpeningSpeaker:   speakerTag(c.config.ScriptOpeningSpeaker),
+		ClosingSpeaker:   speakerTag(c.config.ScriptClosingSpeaker),
+		OutputStyle:      c.config.OutputStyle.promptStyle(),
+	}
+	if structure != nil {
+		scriptData.Overview = structure.Overview
+		scriptData.Points = structure.Points
+		scriptData.Conclusion = structure.Conclusion
+	}
+	greeting := newGreetingData(time.Now(), title, finalSummary, structure)
+	var err error
+	if scriptData.OpeningTemplate, err = RenderGreeting(c.config.ScriptOpening, greeting); err != nil {
+		return "", fmt.Errorf("オープニングの挨拶の生成に失敗しました: %w", err)
+	}
+	if scriptData.ClosingTemplate, err = RenderGreeting(c.config.ScriptClosing, greeting); err != nil {
+		return "", fmt.Errorf("クロージングの挨拶の生成に失敗しました: %w", err)
 	}
 	prompt, err := c.prompt.ScriptBuilder.BuildScript(scriptData)
 	if err != nil {
 		return "", fmt.Errorf("Script プロンプトの生成に失敗しました: %w", err)
 	}
+	return prompt, nil
+}
 
-	// ScriptModelName を使用
-	response, err := c.client.GenerateContent(ctx, prompt, c.config.ScriptModel)
+// generateScript は Script プロンプトで LLM を呼び出し、マーカー間のスクリプト本文を取り出します。
+func (c *Cleaner) generateScript(ctx context.Context, prompt string) (string, error) {
+	// ScriptModelName を使用。スクリプト本文 (マーカー間) の言語を判定対象とする
+	responseText, err := c.generateInLanguage(ctx, "script", prompt, c.config.ScriptModel, func(text string) string {
+		return ExtractTextBetweenTags(text, "SCRIPT_START", "SCRIPT_END")
+	})
 	if err != nil {
 		return "", fmt.Errorf("LLM Script Generation処理に失敗しました: %w", err)
 	}
 
 	// utils.goで定義されたヘルパー関数を使用
-	scriptText := ExtractTextBetweenTags(response.Text, "SCRIPT_START", "SCRIPT_END")
+	scriptText := ExtractTextBetweenTags(responseText, "SCRIPT_START", "SCRIPT_END")
 
 	if scriptText == "" {
-		slog.Warn("指定されたスクリプトマーカーが見つからないか、形式が不正です。LLMのレスポンス全体をスクリプトとして使用します。",
+		slog.WarnContext(ctx, "指定されたスクリプトマーカーが見つからないか、形式が不正です。LLMのレスポンス全体をスクリプトとして使用します。",
 			slog.String("startTag", "SCRIPT_START"),
 			slog.String("endTag", "SCRIPT_END"),
-			slog.String("llm_response_prefix", response.Text[:min(len(response.Text), 100)]),
+			slog.String("llm_response_prefix", responseText[:min(len(responseText), 100)]),
 		)
-		return response.Text, nil
+		scriptText = responseText
 	}
-
 	return scriptText, nil
 }
+
+// filterNGWords は生成スクリプトにNGワードフィルタを適用します (ng_filter.goで定義)。
+// StrictNG が有効な場合、検出があれば ErrNGWordDetected を返します。
+func (c *Cleaner) filterNGWords(ctx context.Context, scriptText string) (string, error) {
+	if len(c.config.NGWords) == 0 {
+		return scriptText, nil
+	}
+
+	filtered, detected := FilterScript(scriptText, c.config.NGWords, c.config.NGReplacement)
+	if len(detected) == 0 {
+		return scriptText, nil
+	}
+
+	slog.WarnContext(ctx, "生成スクリプトからNGワードを検出しました",
+		slog.Any("detected", detected),
+		slog.Bool("strict", c.config.StrictNG),
+	)
+	if c.config.StrictNG {
+		return "", fmt.Errorf("%w: %s", ErrNGWordDetected, strings.Join(detected, ", "))
+	}
+	return filtered, nil
+}
@@ -0,0 +1,112 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"act-feed-clean-go/internal/correlation"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+// captureHandler は出力されたログレコードを記録する slog.Handler です。
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler      { return h }
+
+// cids はメッセージが msg のレコードに付与された相関IDを返します (付与されていないレコードは空文字列)。
+func (h *captureHandler) cids(msg string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var cids []string
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		cid := ""
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == correlation.LogKey {
+				cid = a.Value.String()
+				return false
+			}
+			return true
+		})
+		cids = append(cids, cid)
+	}
+	return cids
+}
+
+// captureLogs は既定のロガーを、相関IDを付与して記録するロガーにテストの間だけ置き換えます。
+func captureLogs(t *testing.T) *captureHandler {
+	t.Helper()
+	capture := &captureHandler{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(correlation.NewHandler(capture)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return capture
+}
+
+// cidTestModel は常に同じ Map要約を返す gemini.GenerativeModel です。
+type cidTestModel struct{}
+
+func (cidTestModel) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	return &gemini.Response{Text: "- 要約"}, nil
+}
+
+func TestLogsCarryCorrelationID(t *testing.T) {
+	capture := captureLogs(t)
+	c, err := NewCleaner(cidTestModel{}, CleanerConfig{
+		LLMRateLimit: time.Millisecond,
+		MaxCostUSD:   1e-9, // 最初の呼び出しで上限に達する
+		NGWords:      []string{"最悪"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := c.processSegmentsInParallel(context.Background(), []string{"相関IDのテスト用のセグメントの本文です。"}); err != nil {
+		t.Fatal(err)
+	}
+	articleID := correlation.ArticleID("https://example.com/article")
+	if _, err := c.filterNGWords(correlation.WithID(context.Background(), articleID), "[ずんだもん][ノーマル] 最悪なのだ。"); err != nil {
+		t.Fatal(err)
+	}
+
+	segmentID := correlation.SegmentID(1)
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"Map要約を生成します", segmentID},
+		{"LLMの累積推定コストが上限に達しました。以降のLLM呼び出しは中止されます", segmentID},
+		{"生成スクリプトからNGワードを検出しました", articleID},
+	}
+	for _, tt := range tests {
+		cids := capture.cids(tt.msg)
+		if len(cids) == 0 {
+			t.Errorf("%q のログが出力されていません", tt.msg)
+			continue
+		}
+		for _, cid := range cids {
+			if cid != tt.want {
+				t.Errorf("%q の cid = %q, want %q", tt.msg, cid, tt.want)
+			}
+		}
+	}
+}
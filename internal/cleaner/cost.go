@@ -0,0 +1,200 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+// ----------------------------------------------------------------
+// LLM コストの見積もりと上限管理
+// ----------------------------------------------------------------
+
+// ModelPricing はモデルの 100万トークンあたりの単価 (USD) です。
+type ModelPricing struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// modelPricings は既知のモデルの単価表です (前方一致で検索します)。
+// 長い名前から順に照合するため、スライスで保持します。
+var modelPricings = []struct {
+	prefix  string
+	pricing ModelPricing
+}{
+	{"gemini-2.5-flash-lite", ModelPricing{InputPerMTok: 0.10, OutputPerMTok: 0.40}},
+	{"gemini-2.5-flash", ModelPricing{InputPerMTok: 0.30, OutputPerMTok: 2.50}},
+	{"gemini-2.5-pro", ModelPricing{InputPerMTok: 1.25, OutputPerMTok: 10.00}},
+	{"gemini-2.0-flash-lite", ModelPricing{InputPerMTok: 0.075, OutputPerMTok: 0.30}},
+	{"gemini-2.0-flash", ModelPricing{InputPerMTok: 0.10, OutputPerMTok: 0.40}},
+}
+
+// unknownModelPricing は単価表にないモデルに適用する単価です。
+// 上限を超過しないよう、既知のモデルで最も高い単価を使用します。
+var unknownModelPricing = ModelPricing{InputPerMTok: 1.25, OutputPerMTok: 10.00}
+
+// PricingFor はモデル名に対応する単価を返します。未知のモデルの場合は ok が false になります。
+func PricingFor(model string) (pricing ModelPricing, ok bool) {
+	return pricingFor(nil, model)
+}
+
+// pricingFor は単価表 overrides (CleanerConfig.Pricing) を優先してモデル名に対応する単価を返します。
+// overrides のキーもモデル名の前方一致で照合し、複数が一致する場合は最も長いキーを使用します。
+func pricingFor(overrides map[string]ModelPricing, model string) (pricing ModelPricing, ok bool) {
+	matched := ""
+	for prefix, p := range overrides {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			matched, pricing = prefix, p
+		}
+	}
+	if matched != "" {
+		return pricing, true
+	}
+	for _, entry := range modelPricings {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.pricing, true
+		}
+	}
+	return unknownModelPricing, false
+}
+
+// EstimateTokens はテキストのトークン数を概算します。
+// ASCII 文字は 4 文字で 1 トークン、それ以外 (日本語など) は 1 文字 1 トークンとして数えます。
+func EstimateTokens(text string) int {
+	ascii, others := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			others++
+		}
+	}
+	return (ascii+3)/4 + others
+}
+
+// EstimateCostUSD は 1 回の LLM 呼び出しの推定コスト (USD) を返します。
+func EstimateCostUSD(model, prompt, response string) float64 {
+	pricing, _ := PricingFor(model)
+	return pricing.cost(EstimateTokens(prompt), EstimateTokens(response))
+}
+
+// cost は入力・出力のトークン数に対するコスト (USD) を返します。
+func (p ModelPricing) cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.InputPerMTok + float64(outputTokens)*p.OutputPerMTok) / 1_000_000
+}
+
+// ErrCostLimitExceeded は累積推定コストが上限に達したため LLM 呼び出しを中止したことを示します。
+var ErrCostLimitExceeded = errors.New("LLMの累積推定コストが上限に達しました")
+
+// CostLimitError はコスト上限による打ち切りの詳細です。
+type CostLimitError struct {
+	Phase    string  // 打ち切られたフェーズ (map, reduce, summary, script, topic)
+	CostUSD  float64 // 打ち切り時点の累積推定コスト
+	LimitUSD float64 // 設定された上限
+	// Partial は打ち切り時点までに得られた部分成果 (最も処理の進んだテキスト) です。
+	Partial string
+}
+
+func (e *CostLimitError) Error() string {
+	return fmt.Sprintf("%v (phase=%s, cost=$%.4f, limit=$%.4f)", ErrCostLimitExceeded, e.Phase, e.CostUSD, e.LimitUSD)
+}
+
+func (e *CostLimitError) Unwrap() error {
+	return ErrCostLimitExceeded
+}
+
+// costTracker は LLM 呼び出しの累積推定コストと、フェーズ別のトークン数を並行安全に集計します。
+type costTracker struct {
+	mu       sync.Mutex
+	limitUSD float64                 // 0 の場合は無制限
+	pricing  map[string]ModelPricing // 組み込みの単価表より優先する単価表 (CleanerConfig.Pricing)
+	totalUSD float64
+	calls    int
+	phases   map[string]*PhaseUsage // フェーズ別のトークン数と推定コスト (token_usage.goで定義)
+	warned   map[string]bool        // 単価表にないモデルの警告済みフラグ
+}
+
+// reserve は LLM 呼び出し前に上限を確認し、既に上限に達している場合は CostLimitError を返します。
+func (t *costTracker) reserve(phase string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limitUSD > 0 && t.totalUSD >= t.limitUSD {
+		return &CostLimitError{Phase: phase, CostUSD: t.totalUSD, LimitUSD: t.limitUSD}
+	}
+	return nil
+}
+
+// add は 1 回の呼び出しのトークン数と推定コストを加算します。
+func (t *costTracker) add(ctx context.Context, phase, model, prompt, response string) {
+	promptTokens, responseTokens := EstimateTokens(prompt), EstimateTokens(response)
+	pricing, known := pricingFor(t.pricing, model)
+	cost := pricing.cost(promptTokens, responseTokens)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !known && !t.warned[model] {
+		if t.warned == nil {
+			t.warned = make(map[string]bool)
+		}
+		t.warned[model] = true
+		slog.WarnContext(ctx, "単価表にないモデルのため、最も高い単価でコストを見積もります", slog.String("model", model))
+	}
+	t.totalUSD += cost
+	t.calls++
+	if t.phases == nil {
+		t.phases = make(map[string]*PhaseUsage)
+	}
+	usage := t.phases[phase]
+	if usage == nil {
+		usage = &PhaseUsage{Phase: phase}
+		t.phases[phase] = usage
+	}
+	usage.Calls++
+	usage.PromptTokens += promptTokens
+	usage.ResponseTokens += responseTokens
+	usage.CostUSD += cost
+	if t.limitUSD > 0 && t.totalUSD >= t.limitUSD {
+		slog.WarnContext(ctx, "LLMの累積推定コストが上限に達しました。以降のLLM呼び出しは中止されます",
+			slog.String("phase", phase),
+			slog.Float64("cost_usd", t.totalUSD),
+			slog.Float64("limit_usd", t.limitUSD),
+		)
+	}
+}
+
+func (t *costTracker) snapshot() (float64, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totalUSD, t.calls
+}
+
+// generate はコスト上限を確認した上で LLM を呼び出し、推定コストを加算します。
+// Cleaner からの LLM 呼び出しはすべてこのメソッドを経由し、一時的な失敗は generateWithRetry で再試行されます (llm_retry.goで定義)。
+// 再試行しても失敗が続く場合は、フェーズの優先順リストの次のモデルで生成します (model_fallback.goで定義)。
+// レスポンスに含まれる推論部分 (<thinking> など) は除去して返します (reasoning.goで定義)。
+func (c *Cleaner) generate(ctx context.Context, phase, prompt, model string) (*gemini.Response, error) {
+	if c.config.DryRun {
+		return nil, fmt.Errorf("%w (phase=%s)", ErrDryRun, phase)
+	}
+	if err := c.cost.reserve(phase); err != nil {
+		return nil, err
+	}
+	response, usedModel, err := c.generateWithFallback(ctx, phase, prompt, model)
+	if err != nil {
+		return nil, err
+	}
+	c.cost.add(ctx, phase, usedModel, prompt, response.Text)
+	response.Text = c.stripReasoning(ctx, phase, response.Text)
+	return response, nil
+}
+
+// CostUSD は、これまでの LLM 呼び出しの累積推定コスト (USD) と呼び出し回数を返します。
+func (c *Cleaner) CostUSD() (float64, int) {
+	return c.cost.snapshot()
+}
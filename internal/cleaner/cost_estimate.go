@@ -0,0 +1,63 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// 実行前の Map フェーズのコスト見積もり
+// ----------------------------------------------------------------
+
+// estimatedMapOutputRatio は Map 要約の出力トークン数を、セグメントの入力トークン数に対する比率で見積もる係数です。
+const estimatedMapOutputRatio = 0.2
+
+// CostEstimate は LLM を呼び出す前に見積もった Map フェーズの呼び出し回数・トークン数・コストです。
+// Reduce 以降のフェーズは Map 要約の長さに依存するため含みません。
+type CostEstimate struct {
+	Model        string  // Map フェーズで使用するモデル名
+	Segments     int     // テキストを分割したセグメント数
+	Calls        int     // LLM の呼び出し回数 (有意な文字が少なくスキップするセグメントを除く)
+	InputTokens  int     // プロンプトの推定トークン数の合計
+	OutputTokens int     // Map 要約の推定トークン数の合計
+	CostUSD      float64 // 推定コスト (USD)
+	KnownPricing bool    // モデルが単価表に存在するか (false の場合は最も高い単価で見積もっています)
+}
+
+// String は見積もりを 1 行の表示用文字列にします。
+func (e CostEstimate) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Mapでおよそ %d 回呼び出し (%d セグメント)、約 %d トークン (入力 %d / 出力 %d)、概算 $%.4f (%s)",
+		e.Calls, e.Segments, e.InputTokens+e.OutputTokens, e.InputTokens, e.OutputTokens, e.CostUSD, e.Model)
+	if !e.KnownPricing {
+		sb.WriteString(" ※単価表にないモデルのため最も高い単価で見積もっています")
+	}
+	return sb.String()
+}
+
+// EstimateCost は CleanAndStructureText に text を渡した場合の Map フェーズのコストを、LLM を呼び出さずに見積もります。
+// セグメント分割とプロンプトの構築は実際の処理と同じ方法で行い、出力トークン数は入力に対する一定の比率で概算します。
+// フォーマット違反による再生成は含みません。
+func (c *Cleaner) EstimateCost(text string) (CostEstimate, error) {
+	if strings.TrimSpace(text) == "" {
+		return CostEstimate{}, fmt.Errorf("見積もり対象のテキストが空です")
+	}
+
+	pricing, known := pricingFor(c.config.Pricing, c.config.MapModel)
+	estimate := CostEstimate{Model: c.config.MapModel, KnownPricing: known}
+	for _, seg := range c.splitIntoSegments(text) {
+		estimate.Segments++
+		if minChars := c.config.MinSegmentContentChars; minChars > 0 && countContentChars(seg) < minChars {
+			continue
+		}
+		prompt, err := c.buildMapPrompt(seg)
+		if err != nil {
+			return CostEstimate{}, err
+		}
+		estimate.Calls++
+		estimate.InputTokens += EstimateTokens(prompt)
+		estimate.OutputTokens += int(float64(EstimateTokens(seg)) * estimatedMapOutputRatio)
+	}
+	estimate.CostUSD = pricing.cost(estimate.InputTokens, estimate.OutputTokens)
+	return estimate, nil
+}
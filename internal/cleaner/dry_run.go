@@ -0,0 +1,151 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// ドライラン (LLM を呼び出さずにセグメント数とトークン数を見積もる)
+// ----------------------------------------------------------------
+
+// ErrDryRun は DryRun が有効なため LLM を呼び出さなかったことを示します。
+var ErrDryRun = errors.New("ドライランのため LLM を呼び出しませんでした")
+
+// Reduce 以降のフェーズの出力トークン数を、入力 (前のフェーズの出力) に対する比率で見積もる係数です。
+const (
+	estimatedReduceOutputRatio  = 0.5 // 中間統合要約 / Map要約の合計
+	estimatedSummaryOutputRatio = 0.5 // 最終要約 / 中間統合要約
+	estimatedScriptOutputRatio  = 2.0 // スクリプト / 最終要約 (ScriptTargetChars の指定がない場合)
+)
+
+// dryRunPlaceholder は Reduce 以降のプロンプトの固定部分を見積もるために、前のフェーズの出力の代わりに埋め込む文字列です。
+// プロンプトの構築は入力が空の場合にエラーとなるため、空文字列は使用しません。
+const dryRunPlaceholder = "-"
+
+// PhaseEstimate は 1 フェーズの LLM 呼び出し回数・推定トークン数・推定コストです。
+type PhaseEstimate struct {
+	Phase        string  `json:"phase"`
+	Model        string  `json:"model"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// DryRunReport はドライランで見積もったフェーズ別の呼び出し回数・トークン数・コストです。
+// Map 以外のフェーズの出力は前のフェーズの出力に対する一定の比率で概算し、
+// 再生成 (フォーマット違反・出力言語の違いなど) や多段の Reduce 戦略による追加の呼び出しは含みません。
+type DryRunReport struct {
+	Segments     int             `json:"segments"` // テキストを分割したセグメント数
+	Phases       []PhaseEstimate `json:"phases"`   // map, reduce, summary, script の順
+	InputTokens  int             `json:"input_tokens"`
+	OutputTokens int             `json:"output_tokens"`
+	CostUSD      float64         `json:"cost_usd"`
+}
+
+// String は見積もりをフェーズごとの行の表示用文字列にします。
+func (r DryRunReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ドライラン: %d セグメント、合計 約 %d トークン (入力 %d / 出力 %d)、概算 $%.4f\n",
+		r.Segments, r.InputTokens+r.OutputTokens, r.InputTokens, r.OutputTokens, r.CostUSD)
+	for _, phase := range r.Phases {
+		fmt.Fprintf(&sb, "  %-8s %3d 回  入力 %7d / 出力 %7d トークン  $%.4f (%s)\n",
+			phase.Phase, phase.Calls, phase.InputTokens, phase.OutputTokens, phase.CostUSD, phase.Model)
+	}
+	return sb.String()
+}
+
+// DryRun はドライランが有効かどうか (CleanerConfig.DryRun) を返します。
+func (c *Cleaner) DryRun() bool {
+	return c.config.DryRun
+}
+
+// EstimateDryRun は title・text で CleanAndStructureText から台本生成までを実行した場合の
+// フェーズ別の呼び出し回数・トークン数・コストを、LLM を呼び出さずに見積もります。
+// Map フェーズは EstimateCost と同じ方法で、Reduce 以降は各フェーズのプロンプトの固定部分と前のフェーズの推定出力から見積もります。
+func (c *Cleaner) EstimateDryRun(title, text string) (DryRunReport, error) {
+	mapEstimate, err := c.EstimateCost(text)
+	if err != nil {
+		return DryRunReport{}, err
+	}
+	report := DryRunReport{Segments: mapEstimate.Segments}
+	report.add(PhaseEstimate{
+		Phase:        PhaseMap,
+		Model:        mapEstimate.Model,
+		Calls:        mapEstimate.Calls,
+		InputTokens:  mapEstimate.InputTokens,
+		OutputTokens: mapEstimate.OutputTokens,
+		CostUSD:      mapEstimate.CostUSD,
+	})
+
+	reducePrompt, err := c.buildReducePrompt(dryRunPlaceholder, true)
+	if err != nil {
+		return DryRunReport{}, err
+	}
+	reduceOutput := int(float64(mapEstimate.OutputTokens) * estimatedReduceOutputRatio)
+	report.add(c.phaseEstimate(PhaseReduce, c.config.ReduceModel, EstimateTokens(reducePrompt)+mapEstimate.OutputTokens, reduceOutput))
+
+	summaryPrompt, err := c.buildFinalSummaryPrompt(title, dryRunPlaceholder, c.primarySummaryFormat())
+	if err != nil {
+		return DryRunReport{}, err
+	}
+	summaryOutput := int(float64(reduceOutput) * estimatedSummaryOutputRatio)
+	report.add(c.phaseEstimate(PhaseSummary, c.config.SummaryModel, EstimateTokens(summaryPrompt)+reduceOutput, summaryOutput))
+
+	scriptPrompt, err := c.buildScriptPrompt(title, dryRunPlaceholder, nil)
+	if err != nil {
+		return DryRunReport{}, err
+	}
+	scriptOutput := int(float64(summaryOutput) * estimatedScriptOutputRatio)
+	if c.config.ScriptTargetChars > 0 {
+		// 台本は日本語のため、目標文字数をそのままトークン数とみなす
+		scriptOutput = c.config.ScriptTargetChars
+	}
+	report.add(c.phaseEstimate(PhaseScript, c.config.ScriptModel, EstimateTokens(scriptPrompt)+summaryOutput, scriptOutput))
+	return report, nil
+}
+
+// phaseEstimate は 1 回の呼び出しのフェーズの見積もりを返します。
+func (c *Cleaner) phaseEstimate(phase, model string, inputTokens, outputTokens int) PhaseEstimate {
+	pricing, _ := pricingFor(c.config.Pricing, model)
+	return PhaseEstimate{
+		Phase:        phase,
+		Model:        model,
+		Calls:        1,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+		CostUSD:      pricing.cost(inputTokens, outputTokens),
+	}
+}
+
+// add はフェーズの見積もりを追加し、合計に加算します。
+func (r *DryRunReport) add(phase PhaseEstimate) {
+	r.Phases = append(r.Phases, phase)
+	r.InputTokens += phase.InputTokens
+	r.OutputTokens += phase.OutputTokens
+	r.CostUSD += phase.CostUSD
+}
+
+// LogDryRun はドライランの見積もりを INFO レベルで出力します。
+func LogDryRun(ctx context.Context, report DryRunReport) {
+	slog.InfoContext(ctx, "ドライラン: LLM を呼び出さずに見積もりました",
+		slog.Int("segments", report.Segments),
+		slog.Int("input_tokens", report.InputTokens),
+		slog.Int("output_tokens", report.OutputTokens),
+		slog.Float64("cost_usd", report.CostUSD),
+	)
+	for _, phase := range report.Phases {
+		slog.InfoContext(ctx, "ドライラン: フェーズ別の見積もり",
+			slog.String("phase", phase.Phase),
+			slog.String("model", phase.Model),
+			slog.Int("calls", phase.Calls),
+			slog.Int("input_tokens", phase.InputTokens),
+			slog.Int("output_tokens", phase.OutputTokens),
+			slog.Float64("cost_usd", phase.CostUSD),
+		)
+	}
+}
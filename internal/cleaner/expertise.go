@@ -0,0 +1,37 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// 最終要約の想定読者の専門性 (専門用語の注釈レベル)
+// ----------------------------------------------------------------
+
+// ExpertiseLevel は最終要約の想定読者の専門性です。専門用語の注釈と前提知識の説明の量を切り替えます。
+type ExpertiseLevel string
+
+const (
+	// ExpertiseBeginner は一般向けです。専門用語を残したうえで、本文内に短い注釈を添えさせます。
+	ExpertiseBeginner ExpertiseLevel = "beginner"
+	// ExpertiseIntermediate は専門用語を平易な言葉に意訳させます (従来の指示)。
+	ExpertiseIntermediate ExpertiseLevel = "intermediate"
+	// ExpertiseExpert は専門家向けです。専門用語をそのまま使い、前提知識や背景の説明を省かせます。
+	ExpertiseExpert ExpertiseLevel = "expert"
+
+	// DefaultExpertiseLevel は最終要約のデフォルトの想定読者の専門性です。
+	DefaultExpertiseLevel = ExpertiseIntermediate
+)
+
+// ParseExpertiseLevel は文字列を ExpertiseLevel に変換します。空文字列の場合はデフォルトを返します。
+func ParseExpertiseLevel(s string) (ExpertiseLevel, error) {
+	switch l := ExpertiseLevel(strings.ToLower(strings.TrimSpace(s))); l {
+	case ExpertiseBeginner, ExpertiseIntermediate, ExpertiseExpert:
+		return l, nil
+	case "":
+		return DefaultExpertiseLevel, nil
+	default:
+		return "", fmt.Errorf("不明な想定読者の専門性です: %q (beginner, intermediate, expert のいずれかを指定してください)", s)
+	}
+}
@@ -0,0 +1,65 @@
+package cleaner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// ----------------------------------------------------------------
+// プロンプトと設定の識別子 (処理の冪等キー用)
+// ----------------------------------------------------------------
+
+// fingerprintLength は識別子として返す16進文字列の長さです。
+const fingerprintLength = 16
+
+// Version は全フェーズのプロンプトテンプレートから計算した版の識別子を返します。
+// テンプレート (プロンプトセットによる上書きを含む) が変わると値が変わります。
+func (m *PromptManager) Version() string {
+	h := sha256.New()
+	for _, b := range []interface{ Source() string }{
+		m.MapBuilder, m.ReduceBuilder, m.FinalSummaryBuilder, m.LayeredBuilder,
+		m.ScriptBuilder, m.TopicBuilder, m.ImportanceBuilder,
+	} {
+		h.Write([]byte(b.Source()))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:fingerprintLength]
+}
+
+// PromptVersion は Cleaner が使用するプロンプトテンプレートの版の識別子を返します。
+func (c *Cleaner) PromptVersion() string {
+	return c.prompt.Version()
+}
+
+// ConfigFingerprint は出力に影響する設定 (モデル名・フォールバックのモデル・文体・Reduce戦略など) から計算した識別子を返します。
+// レート制限・再試行・同時実行数・コスト上限などの出力に影響しない設定は含めません。
+// 後処理 (PostProcessors) とトークン数の数え方 (TokenCounter) は関数のため識別子に含まれない点に注意してください。
+func (c *Cleaner) ConfigFingerprint() string {
+	config := c.config
+	config.Verbose = false
+	config.LLMRateLimit = 0
+	config.LLMMaxAttempts = 0
+	config.LLMRetryBaseDelay = 0
+	config.MaxConcurrency = 0
+	config.FailFast = false
+	config.FailFastThreshold = 0
+	config.MaxFailedSegments = 0
+	config.MaxFailedSegmentsRatio = 0
+	config.TotalTimeout = 0
+	config.ReduceReserveRatio = 0
+	config.MaxCostUSD = 0
+	config.Pricing = nil
+	config.DryRun = false
+	config.PostProcessors = nil
+	config.MapCache = nil
+	config.TokenCounter = nil
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		// CleanerConfig はシリアライズ可能なフィールドのみのため通常は発生しない。識別子が一致しないよう空文字列を返す
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
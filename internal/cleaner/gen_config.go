@@ -0,0 +1,152 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"google.golang.org/genai"
+)
+
+// ----------------------------------------------------------------
+// フェーズ別の生成パラメータ (temperature / topP / maxOutputTokens)
+// ----------------------------------------------------------------
+
+// GenConfig は LLM 呼び出しの生成パラメータです。未設定 (nil / 0) の項目はクライアントのデフォルトに従います。
+type GenConfig struct {
+	Temperature     *float32 `json:"temperature,omitempty"`       // 0〜2。低いほど決定的
+	TopP            *float32 `json:"top_p,omitempty"`             // 0〜1
+	MaxOutputTokens int32    `json:"max_output_tokens,omitempty"` // 出力トークン数の上限。0 の場合は制限しない
+}
+
+// IsZero はすべての項目が未設定かを返します。
+func (g GenConfig) IsZero() bool {
+	return g.Temperature == nil && g.TopP == nil && g.MaxOutputTokens == 0
+}
+
+// ConfigurableModel は生成パラメータを指定して呼び出せる LLM クライアントです。
+// go-ai-client の GenerateContent は生成パラメータを受け取らないため、このパッケージで拡張として定義します。
+// LLMクライアントがこのインターフェースを実装しない場合、生成パラメータは無視されます。
+type ConfigurableModel interface {
+	gemini.GenerativeModel
+	GenerateContentWithConfig(ctx context.Context, prompt string, modelName string, config GenConfig) (*gemini.Response, error)
+}
+
+// genConfig は phase の LLM 呼び出しに使用する生成パラメータを返します。
+// 重要度採点・トピック分類は Mapフェーズと同じモデルを使うため、Mapフェーズの設定を適用します。
+func (c *Cleaner) genConfig(phase string) GenConfig {
+	switch phase {
+	case PhaseReduce:
+		return c.config.ReduceGenConfig
+	case PhaseSummary:
+		return c.config.SummaryGenConfig
+	case PhaseScript:
+		return c.config.ScriptGenConfig
+	default:
+		return c.config.MapGenConfig
+	}
+}
+
+// HasGenConfig はいずれかのフェーズに生成パラメータが設定されているかを返します。
+// 設定されている場合、LLMクライアントは ConfigurableModel (GeminiClient など) である必要があります。
+func (config CleanerConfig) HasGenConfig() bool {
+	for _, g := range []GenConfig{config.MapGenConfig, config.ReduceGenConfig, config.SummaryGenConfig, config.ScriptGenConfig} {
+		if !g.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// warnUnsupportedGenConfig は生成パラメータが設定されているのにクライアントが対応していない場合に警告します。
+func warnUnsupportedGenConfig(client gemini.GenerativeModel, config CleanerConfig) {
+	if _, ok := client.(ConfigurableModel); ok || !config.HasGenConfig() {
+		return
+	}
+	slog.Warn("LLMクライアントが生成パラメータの指定に対応していないため、フェーズ別の生成パラメータは無視されます",
+		slog.String("client", fmt.Sprintf("%T", client)),
+	)
+}
+
+// validateGenConfig は生成パラメータの範囲を検証します。
+func validateGenConfig(field string, g GenConfig, fieldErr func(field, format string, args ...any)) {
+	if g.Temperature != nil && (*g.Temperature < 0 || *g.Temperature > 2) {
+		fieldErr(field+".Temperature", "0以上2以下の値を指定してください (%v)", *g.Temperature)
+	}
+	if g.TopP != nil && (*g.TopP < 0 || *g.TopP > 1) {
+		fieldErr(field+".TopP", "0以上1以下の値を指定してください (%v)", *g.TopP)
+	}
+	if g.MaxOutputTokens < 0 {
+		fieldErr(field+".MaxOutputTokens", "負の値は指定できません (%d)", g.MaxOutputTokens)
+	}
+}
+
+// ----------------------------------------------------------------
+// 生成パラメータに対応した Gemini クライアント
+// ----------------------------------------------------------------
+
+// GeminiClient は genai SDK を直接利用する ConfigurableModel です。
+// 一時的な失敗の再試行は Cleaner (generateWithRetry) が行うため、このクライアントは再試行しません。
+type GeminiClient struct {
+	client *genai.Client
+}
+
+// NewGeminiClient は APIキー apiKey の GeminiClient を生成します。
+func NewGeminiClient(ctx context.Context, apiKey string) (*GeminiClient, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Gemini クライアントの生成には APIキーが必要です")
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: apiKey})
+	if err != nil {
+		return nil, fmt.Errorf("Gemini クライアントの生成に失敗しました: %w", err)
+	}
+	return &GeminiClient{client: client}, nil
+}
+
+// NewGeminiClientFromEnv は環境変数 (GEMINI_API_KEY / GOOGLE_API_KEY) の APIキーで GeminiClient を生成します。
+func NewGeminiClientFromEnv(ctx context.Context) (*GeminiClient, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("GOOGLE_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY または GOOGLE_API_KEY 環境変数が設定されていません")
+	}
+	return NewGeminiClient(ctx, apiKey)
+}
+
+// GenerateContent は go-ai-client と同じデフォルトの temperature で生成します。
+func (g *GeminiClient) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	return g.GenerateContentWithConfig(ctx, prompt, modelName, GenConfig{})
+}
+
+// GenerateContentWithConfig は生成パラメータ config を指定して生成します。
+// temperature が未設定の場合は go-ai-client と同じデフォルト (gemini.DefaultTemperature) を使用します。
+func (g *GeminiClient) GenerateContentWithConfig(ctx context.Context, prompt string, modelName string, config GenConfig) (*gemini.Response, error) {
+	if prompt == "" {
+		return nil, fmt.Errorf("プロンプトが空です")
+	}
+	temperature := gemini.DefaultTemperature
+	if config.Temperature != nil {
+		temperature = *config.Temperature
+	}
+	contents := []*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)}
+	resp, err := g.client.Models.GenerateContent(ctx, modelName, contents, &genai.GenerateContentConfig{
+		Temperature:     &temperature,
+		TopP:            config.TopP,
+		MaxOutputTokens: config.MaxOutputTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		return nil, fmt.Errorf("プロンプトがブロックされました: %s", resp.PromptFeedback.BlockReason)
+	}
+	text := resp.Text()
+	if text == "" {
+		return nil, fmt.Errorf("LLMのレスポンスにテキストが含まれていません (model=%s)", modelName)
+	}
+	return &gemini.Response{Text: text}, nil
+}
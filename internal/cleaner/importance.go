@@ -0,0 +1,78 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"act-feed-clean-go/prompts"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------
+// LLM による記事の重要度の採点
+// ----------------------------------------------------------------
+
+// maxImportanceScore は採点プロンプトで指示するスコアの最大値です。
+const maxImportanceScore = 10
+
+// ScoreImportance は、記事の重要度を LLM で 0〜10 の整数で採点し、0〜1 に正規化したスコアを URL ごとに返します。
+// 採点結果に含まれなかった記事はマップに含まれません。採点には軽量な Mapフェーズのモデルを使用します。
+func (c *Cleaner) ScoreImportance(ctx context.Context, results []types.URLResult, titlesMap map[string]string) (_ map[string]float64, err error) {
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = end(err) }()
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	prompt, err := c.prompt.ImportanceBuilder.BuildImportance(prompts.ImportanceTemplateData{
+		Articles: articleExcerpts(results, titlesMap),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Importance プロンプトの生成に失敗しました: %w", err)
+	}
+
+	slog.InfoContext(ctx, "記事の重要度の採点を開始します", slog.Int("articles", len(results)))
+	response, err := c.generate(ctx, "importance", prompt, c.config.MapModel)
+	if err != nil {
+		return nil, fmt.Errorf("LLM 重要度の採点処理に失敗しました: %w", err)
+	}
+
+	scores := parseImportanceScores(response.Text, results)
+	slog.InfoContext(ctx, "記事の重要度の採点が完了しました", slog.Int("scored", len(scores)))
+	return scores, nil
+}
+
+// parseImportanceScores は採点結果の `記事番号: スコア` 行を解析し、URL ごとの正規化したスコアを返します。
+// 範囲外のスコアは 0〜10 に丸めます。
+func parseImportanceScores(text string, results []types.URLResult) map[string]float64 {
+	body := ExtractTextBetweenTags(text, "SCORES_START", "SCORES_END")
+	if body == "" {
+		body = text
+	}
+
+	scores := make(map[string]float64)
+	for _, line := range strings.Split(body, "\n") {
+		m := topicLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		index, err := strconv.Atoi(m[1])
+		if err != nil || index < 1 || index > len(results) {
+			continue
+		}
+		score, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		scores[results[index-1].URL] = min(max(score, 0), maxImportanceScore) / maxImportanceScore
+	}
+	return scores
+}
@@ -0,0 +1,263 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"google.golang.org/genai"
+)
+
+// ----------------------------------------------------------------
+// 複数 APIキーのローテーション (レート制限の回避)
+// ----------------------------------------------------------------
+
+const (
+	// APIKeysEnv は複数の APIキーをカンマ区切りで指定する環境変数です。
+	APIKeysEnv = "GEMINI_API_KEYS"
+	// DefaultKeyCooldown はレート制限に当たった APIキーをローテーションから外す既定の時間です。
+	DefaultKeyCooldown = time.Minute
+)
+
+// ErrAllKeysExhausted は、すべての APIキーがレート制限によりローテーションから外れていることを示します。
+var ErrAllKeysExhausted = errors.New("すべてのAPIキーがレート制限に達しています")
+
+// AllKeysExhaustedError は全 APIキーの枯渇の詳細です。
+type AllKeysExhaustedError struct {
+	Keys       int           // APIキーの数
+	RetryAfter time.Duration // 最も早く復帰する APIキーが使えるようになるまでの時間
+	Err        error         // 最後に受け取ったレート制限のエラー (呼び出し前に枯渇していた場合は nil)
+}
+
+func (e *AllKeysExhaustedError) Error() string {
+	msg := fmt.Sprintf("%v (keys=%d, retry_after=%s)", ErrAllKeysExhausted, e.Keys, e.RetryAfter.Round(time.Second))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *AllKeysExhaustedError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrAllKeysExhausted}
+	}
+	return []error{ErrAllKeysExhausted, e.Err}
+}
+
+// KeyUsage は APIキーごとの使用状況です。
+type KeyUsage struct {
+	Label       string // ログ表示用のラベル (キーの末尾 4 文字のみを含む)
+	Calls       int    // 呼び出し回数 (失敗を含む)
+	RateLimited int    // レート制限に当たった回数
+	Errors      int    // レート制限以外のエラーの回数
+	CoolingDown bool   // 現在ローテーションから外れているか
+}
+
+// pooledKey は KeyPool が保持する 1 つの APIキーのクライアントと状態です。
+type pooledKey struct {
+	client gemini.GenerativeModel
+	usage  KeyUsage
+	until  time.Time // この時刻までローテーションから外す
+}
+
+// KeyPool は複数の APIキーのクライアントを保持し、呼び出しごとにラウンドロビンで使い分ける gemini.GenerativeModel です。
+// レート制限 (HTTP 429) に当たったキーは cooldown の間ローテーションから外し、次のキーで再試行します。
+type KeyPool struct {
+	mu       sync.Mutex
+	keys     []*pooledKey
+	next     int
+	cooldown time.Duration
+}
+
+// NewKeyPool は clients をラウンドロビンで使用する KeyPool を生成します。labels は clients と同じ順のログ表示用のラベルです。
+// cooldown が 0 以下の場合は DefaultKeyCooldown を使用します。
+func NewKeyPool(clients []gemini.GenerativeModel, labels []string, cooldown time.Duration) (*KeyPool, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("APIキーのクライアントが1つもありません")
+	}
+	if len(labels) != len(clients) {
+		return nil, fmt.Errorf("APIキーのラベルの数 (%d) がクライアントの数 (%d) と一致しません", len(labels), len(clients))
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultKeyCooldown
+	}
+	pool := &KeyPool{cooldown: cooldown}
+	for i, client := range clients {
+		pool.keys = append(pool.keys, &pooledKey{client: client, usage: KeyUsage{Label: labels[i]}})
+	}
+	return pool, nil
+}
+
+// NewKeyPoolFromKeys は APIキーごとに Gemini クライアントを生成し、KeyPool を返します。
+// configurable が true の場合は、生成パラメータに対応した GeminiClient (gen_config.goで定義) を生成します。
+func NewKeyPoolFromKeys(ctx context.Context, keys []string, cooldown time.Duration, configurable bool) (*KeyPool, error) {
+	clients := make([]gemini.GenerativeModel, 0, len(keys))
+	labels := make([]string, 0, len(keys))
+	for i, key := range keys {
+		var client gemini.GenerativeModel
+		var err error
+		if configurable {
+			client, err = NewGeminiClient(ctx, key)
+		} else {
+			client, err = gemini.NewClient(ctx, gemini.Config{APIKey: key})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("APIキー %s のクライアント生成に失敗しました: %w", keyLabel(i, key), err)
+		}
+		clients = append(clients, client)
+		labels = append(labels, keyLabel(i, key))
+	}
+	return NewKeyPool(clients, labels, cooldown)
+}
+
+// GenerateContent は次に使用できる APIキーで呼び出します。レート制限に当たった場合はキーを一時的に外し、
+// 残りのキーで再試行します。すべてのキーが外れている場合は *AllKeysExhaustedError を返します。
+func (p *KeyPool) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	return p.GenerateContentWithConfig(ctx, prompt, modelName, GenConfig{})
+}
+
+// GenerateContentWithConfig は GenerateContent と同様にキーをローテーションし、生成パラメータ config を指定して呼び出します
+// (ConfigurableModel の実装)。キーのクライアントが ConfigurableModel でない場合、config は無視されます。
+func (p *KeyPool) GenerateContentWithConfig(ctx context.Context, prompt string, modelName string, config GenConfig) (*gemini.Response, error) {
+	var lastErr error
+	for range p.keys {
+		key, retryAfter := p.acquire()
+		if key == nil {
+			return nil, &AllKeysExhaustedError{Keys: len(p.keys), RetryAfter: retryAfter, Err: lastErr}
+		}
+		var response *gemini.Response
+		var err error
+		if configurable, ok := key.client.(ConfigurableModel); ok && !config.IsZero() {
+			response, err = configurable.GenerateContentWithConfig(ctx, prompt, modelName, config)
+		} else {
+			response, err = key.client.GenerateContent(ctx, prompt, modelName)
+		}
+		if err == nil || !isRateLimitError(err) {
+			p.release(key, err, false)
+			return response, err
+		}
+		p.release(key, err, true)
+		slog.WarnContext(ctx, "APIキーがレート制限に達したため、一時的にローテーションから外します",
+			slog.String("key", key.usage.Label),
+			slog.Duration("cooldown", p.cooldown),
+		)
+		lastErr = err
+	}
+	_, retryAfter := p.acquire()
+	return nil, &AllKeysExhaustedError{Keys: len(p.keys), RetryAfter: retryAfter, Err: lastErr}
+}
+
+// acquire はラウンドロビンで次に使用できるキーを返します。
+// 使用できるキーがない場合は nil と、最も早く復帰するキーまでの待ち時間を返します。
+func (p *KeyPool) acquire() (*pooledKey, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	var retryAfter time.Duration
+	for i := range p.keys {
+		key := p.keys[(p.next+i)%len(p.keys)]
+		if wait := key.until.Sub(now); wait > 0 {
+			if retryAfter == 0 || wait < retryAfter {
+				retryAfter = wait
+			}
+			continue
+		}
+		p.next = (p.next + i + 1) % len(p.keys)
+		key.usage.Calls++
+		return key, 0
+	}
+	return nil, retryAfter
+}
+
+// release は呼び出し結果をキーの統計に記録し、レート制限の場合はキーを cooldown の間外します。
+func (p *KeyPool) release(key *pooledKey, err error, rateLimited bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case rateLimited:
+		key.usage.RateLimited++
+		key.until = time.Now().Add(p.cooldown)
+	case err != nil:
+		key.usage.Errors++
+	}
+}
+
+// Usage は APIキーごとの使用状況を返します。
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	usage := make([]KeyUsage, 0, len(p.keys))
+	for _, key := range p.keys {
+		u := key.usage
+		u.CoolingDown = key.until.After(now)
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+// KeyUsage は LLMクライアントが KeyPool の場合に APIキーごとの使用状況を返します。それ以外の場合は nil です。
+func (c *Cleaner) KeyUsage() []KeyUsage {
+	if pool, ok := c.client.(*KeyPool); ok {
+		return pool.Usage()
+	}
+	return nil
+}
+
+// isRateLimitError はエラーが API のレート制限 (HTTP 429 / RESOURCE_EXHAUSTED) によるものかを判定します。
+func isRateLimitError(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Status == "RESOURCE_EXHAUSTED"
+	}
+	return false
+}
+
+// LoadAPIKeys は環境変数 APIKeysEnv (カンマ区切り) と path のファイル (1行1キー、# で始まる行はコメント) から
+// APIキーを読み込み、重複を除いて返します。path が空の場合はファイルを読み込みません。
+func LoadAPIKeys(path string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if key = strings.TrimSpace(key); key != "" && !strings.HasPrefix(key, "#") && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range strings.Split(os.Getenv(APIKeysEnv), ",") {
+		add(key)
+	}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("APIキーファイルの読み込みに失敗しました: %w", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			add(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("APIキーファイルの読み込みに失敗しました (%s): %w", path, err)
+		}
+	}
+	return keys, nil
+}
+
+// keyLabel はログ表示用に、APIキーの番号と末尾 4 文字のみを含むラベルを返します。
+func keyLabel(index int, key string) string {
+	suffix := key
+	if len(suffix) > 4 {
+		suffix = suffix[len(suffix)-4:]
+	}
+	return fmt.Sprintf("key#%d(...%s)", index+1, suffix)
+}
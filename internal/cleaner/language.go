@@ -0,0 +1,176 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// ----------------------------------------------------------------
+// 出力言語ガード
+// ----------------------------------------------------------------
+
+const (
+	// OutputLangJapanese は日本語出力を期待する場合の言語コードです。
+	OutputLangJapanese = "ja"
+	// OutputLangEnglish は英語出力を期待する場合の言語コードです。
+	OutputLangEnglish = "en"
+	// DefaultOutputLang は出力言語のデフォルト値です。
+	DefaultOutputLang = OutputLangJapanese
+
+	// minJapaneseRatio は日本語と判定する文字 (かな・漢字) 比率の下限です。
+	// 技術用語などの英単語が混在しても誤判定しないよう、低めに設定しています。
+	minJapaneseRatio = 0.3
+	// maxJapaneseRatioForEnglish は英語と判定する場合に許容する日本語文字比率の上限です。
+	maxJapaneseRatioForEnglish = 0.05
+	// minConfidentUnits は言語判定の信頼度を割り引かない文字単位数の下限です。これより短い本文は信頼度を比例して下げます。
+	minConfidentUnits = 200
+)
+
+// languageInstructions は再生成時にプロンプト末尾へ追加する言語指示です。
+var languageInstructions = map[string]string{
+	OutputLangJapanese: "\n\n【重要】出力はすべて**日本語**で記述してください。英語など他の言語で出力してはいけません（固有名詞・製品名を除く）。",
+	OutputLangEnglish:  "\n\n[IMPORTANT] Write the entire output in **English**. Do not use any other language.",
+}
+
+// japaneseRatio は、文字単位 (記号・数字・空白を除く) に占めるかな・漢字の比率を返します。
+// かな・漢字は1文字、連続するラテン文字は1単語を1単位として数えるため、
+// 日本語の文中に英単語が多く混在しても比率が極端に下がりません。
+// 文字が含まれない場合は -1 を返します。
+func japaneseRatio(text string) float64 {
+	units, japanese := countLanguageUnits(text)
+	if units == 0 {
+		return -1
+	}
+	return float64(japanese) / float64(units)
+}
+
+// countLanguageUnits は japaneseRatio の文字単位の総数と、そのうちのかな・漢字の数を返します。
+func countLanguageUnits(text string) (units, japanese int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) || r == 'ー':
+			units++
+			japanese++
+			inWord = false
+		case unicode.IsLetter(r):
+			if !inWord {
+				units++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return units, japanese
+}
+
+// MatchesLanguage は text が期待する言語 lang で書かれているかを軽量に判定します。
+// 判定できない (文字を含まない、未対応の言語コード) 場合は true を返します。
+func MatchesLanguage(text, lang string) bool {
+	ratio := japaneseRatio(text)
+	if ratio < 0 {
+		return true
+	}
+	switch lang {
+	case OutputLangJapanese:
+		return ratio >= minJapaneseRatio
+	case OutputLangEnglish:
+		return ratio <= maxJapaneseRatioForEnglish
+	default:
+		return true
+	}
+}
+
+// DetectLanguage は text の言語を MatchesLanguage と同じ基準で判定し、言語コードを返します。
+// 日本語・英語のどちらとも判定できない場合は "mixed"、文字を含まない場合は空文字列を返します。
+func DetectLanguage(text string) string {
+	ratio := japaneseRatio(text)
+	switch {
+	case ratio < 0:
+		return ""
+	case ratio >= minJapaneseRatio:
+		return OutputLangJapanese
+	case ratio <= maxJapaneseRatioForEnglish:
+		return OutputLangEnglish
+	default:
+		return "mixed"
+	}
+}
+
+// DetectLanguageWithConfidence は DetectLanguage と同じ判定結果と、その信頼度 (0〜1) を返します。
+// 信頼度は判定した言語の文字単位の占有率 (mixed の場合は多い方の占有率) で、文字単位が minConfidentUnits 未満の
+// 短い本文では比例して下げます。文字を含まない場合は空文字列と 0 を返します。
+func DetectLanguageWithConfidence(text string) (string, float64) {
+	units, japanese := countLanguageUnits(text)
+	if units == 0 {
+		return "", 0
+	}
+	ratio := float64(japanese) / float64(units)
+	lang := DetectLanguage(text)
+	confidence := max(ratio, 1-ratio)
+	switch lang {
+	case OutputLangJapanese:
+		// かな・漢字の比率が minJapaneseRatio 以上で日本語と判定するため、比率を [minJapaneseRatio, 1] → [0.5, 1] に対応させる
+		confidence = 0.5 + 0.5*(ratio-minJapaneseRatio)/(1-minJapaneseRatio)
+	case OutputLangEnglish:
+		confidence = 1 - ratio
+	}
+	if units < minConfidentUnits {
+		confidence *= float64(units) / minConfidentUnits
+	}
+	return lang, round2(confidence)
+}
+
+// generateInLanguage は LLM でテキストを生成し、出力が設定の言語 (OutputLang) と異なる場合は
+// 言語を明示したプロンプトで1回だけ再生成します。再生成でも一致しない場合は警告して結果をそのまま返します。
+// check は判定対象のテキストを取り出す関数で、nil の場合はレスポンス全体を判定します。
+func (c *Cleaner) generateInLanguage(ctx context.Context, phase, prompt, model string, check func(string) string) (string, error) {
+	response, err := c.generate(ctx, phase, prompt, model)
+	if err != nil {
+		return "", err
+	}
+	if c.config.OutputLang == "" || c.languageMatches(response.Text, check) {
+		return response.Text, nil
+	}
+
+	slog.WarnContext(ctx, "LLMの出力が期待する言語と異なるため、言語を明示して再生成します",
+		slog.String("phase", phase),
+		slog.String("output_lang", c.config.OutputLang),
+		slog.Float64("japanese_ratio", round2(japaneseRatio(response.Text))),
+	)
+	retried, err := c.generate(ctx, phase, prompt+languageInstructions[c.config.OutputLang], model)
+	if errors.Is(err, ErrCostLimitExceeded) {
+		slog.WarnContext(ctx, "コスト上限に達したため、言語指定での再生成を行わずに最初の出力を使用します", slog.String("phase", phase))
+		return response.Text, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("言語指定での再生成に失敗しました: %w", err)
+	}
+	if !c.languageMatches(retried.Text, check) {
+		slog.WarnContext(ctx, "再生成後も出力言語が一致しませんでした。そのまま処理を続行します",
+			slog.String("phase", phase),
+			slog.String("output_lang", c.config.OutputLang),
+			slog.Float64("japanese_ratio", round2(japaneseRatio(retried.Text))),
+		)
+	}
+	return retried.Text, nil
+}
+
+// languageMatches は check で取り出したテキスト (nil の場合は全体) の言語を判定します。
+func (c *Cleaner) languageMatches(text string, check func(string) string) bool {
+	if check != nil {
+		if target := check(text); strings.TrimSpace(target) != "" {
+			text = target
+		}
+	}
+	return MatchesLanguage(text, c.config.OutputLang)
+}
+
+func round2(v float64) float64 {
+	return float64(int(v*100+0.5)) / 100
+}
@@ -0,0 +1,152 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"act-feed-clean-go/prompts"
+)
+
+// ----------------------------------------------------------------
+// 多層要約 (1行要約・段落要約・詳細要約)
+// ----------------------------------------------------------------
+
+// LayeredSummaryMode は多層要約の各レベルを LLM にどう生成させるかを表します。
+type LayeredSummaryMode string
+
+const (
+	// LayeredSummarySingle は 1 回の呼び出しで全レベルをマーカー区切りで構造化出力させます。
+	LayeredSummarySingle LayeredSummaryMode = "single"
+	// LayeredSummarySeparate はレベルごとに別々の呼び出しで生成します (呼び出し回数は増えるが、各レベルの品質が安定します)。
+	LayeredSummarySeparate LayeredSummaryMode = "separate"
+
+	// DefaultLayeredSummaryMode は多層要約のデフォルトの生成方法です。
+	DefaultLayeredSummaryMode = LayeredSummarySingle
+)
+
+// LayeredSummary は詳細度の異なる 3 レベルの要約です。
+type LayeredSummary struct {
+	OneLine   string `json:"one_line"`  // 1行要約
+	Paragraph string `json:"paragraph"` // 段落要約
+	Detailed  string `json:"detailed"`  // 詳細要約
+}
+
+// summaryLevel は多層要約の 1 レベルと、その出力を囲むマーカーです。
+type summaryLevel struct {
+	name     string
+	startTag string
+	endTag   string
+	enable   func(*prompts.LayeredSummaryTemplateData)
+	field    func(*LayeredSummary) *string
+}
+
+var summaryLevels = []summaryLevel{
+	{"one_line", "ONE_LINE_START", "ONE_LINE_END",
+		func(d *prompts.LayeredSummaryTemplateData) { d.OneLine = true },
+		func(s *LayeredSummary) *string { return &s.OneLine }},
+	{"paragraph", "PARAGRAPH_START", "PARAGRAPH_END",
+		func(d *prompts.LayeredSummaryTemplateData) { d.Paragraph = true },
+		func(s *LayeredSummary) *string { return &s.Paragraph }},
+	{"detailed", "DETAILED_START", "DETAILED_END",
+		func(d *prompts.LayeredSummaryTemplateData) { d.Detailed = true },
+		func(s *LayeredSummary) *string { return &s.Detailed }},
+}
+
+// ParseLayeredSummaryMode は文字列を LayeredSummaryMode に変換します。空文字列の場合はデフォルトを返します。
+func ParseLayeredSummaryMode(s string) (LayeredSummaryMode, error) {
+	switch m := LayeredSummaryMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case LayeredSummarySingle, LayeredSummarySeparate:
+		return m, nil
+	case "":
+		return DefaultLayeredSummaryMode, nil
+	default:
+		return "", fmt.Errorf("不明な多層要約の生成方法です: %q (single, separate のいずれかを指定してください)", s)
+	}
+}
+
+// GenerateLayeredSummary は、中間統合要約を元に 1行要約・段落要約・詳細要約の 3 レベルを生成します。
+// LayeredSummaryMode が single の場合は 1 回の呼び出しで全レベルを出力させ、
+// マーカーが欠けていたレベルのみを個別に再生成します。各レベルには Summary フェーズの後処理を適用します。
+func (c *Cleaner) GenerateLayeredSummary(ctx context.Context, intermediateSummary string) (_ LayeredSummary, err error) {
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return LayeredSummary{}, err
+	}
+	defer func() { err = end(err) }()
+
+	slog.InfoContext(ctx, "Layered Summary Generation（多層要約）を開始します。", slog.String("mode", string(c.config.LayeredSummaryMode)))
+
+	var summary LayeredSummary
+	pending := summaryLevels
+	if c.config.LayeredSummaryMode != LayeredSummarySeparate {
+		response, err := c.generateLevels(ctx, intermediateSummary, summaryLevels)
+		if err != nil {
+			return LayeredSummary{}, err
+		}
+		pending = nil
+		for _, level := range summaryLevels {
+			text := ExtractTextBetweenTags(response, level.startTag, level.endTag)
+			if text == "" {
+				pending = append(pending, level)
+				continue
+			}
+			*level.field(&summary) = text
+		}
+		if len(pending) > 0 {
+			slog.WarnContext(ctx, "多層要約の一部のレベルが出力されなかったため、個別に生成します", slog.Int("missing", len(pending)))
+		}
+	}
+
+	for _, level := range pending {
+		response, err := c.generateLevels(ctx, intermediateSummary, []summaryLevel{level})
+		if err != nil {
+			return LayeredSummary{}, err
+		}
+		text := ExtractTextBetweenTags(response, level.startTag, level.endTag)
+		if text == "" {
+			slog.WarnContext(ctx, "多層要約のマーカーが見つからないため、レスポンス全体を使用します", slog.String("level", level.name))
+			text = strings.TrimSpace(response)
+		}
+		*level.field(&summary) = text
+	}
+
+	for _, level := range summaryLevels {
+		field := level.field(&summary)
+		if *field, err = c.postProcess(PhaseSummary, *field); err != nil {
+			return LayeredSummary{}, err
+		}
+	}
+	slog.InfoContext(ctx, "Layered Summary Generation（多層要約）が完了しました。",
+		slog.Int("one_line_length", len(summary.OneLine)),
+		slog.Int("paragraph_length", len(summary.Paragraph)),
+		slog.Int("detailed_length", len(summary.Detailed)),
+	)
+	return summary, nil
+}
+
+// generateLevels は指定したレベルのみを出力させるプロンプトで LLM を呼び出し、レスポンス全体を返します。
+func (c *Cleaner) generateLevels(ctx context.Context, intermediateSummary string, levels []summaryLevel) (string, error) {
+	data := prompts.LayeredSummaryTemplateData{
+		IntermediateSummary: intermediateSummary,
+		OutputStyle:         c.config.OutputStyle.promptStyle(),
+	}
+	for _, level := range levels {
+		level.enable(&data)
+	}
+	prompt, err := c.prompt.LayeredBuilder.BuildLayeredSummary(data)
+	if err != nil {
+		return "", fmt.Errorf("Layered Summary プロンプトの生成に失敗しました: %w", err)
+	}
+
+	// 言語の判定対象は最も長い (最後の) レベルの本文とする
+	last := levels[len(levels)-1]
+	response, err := c.generateInLanguage(ctx, "summary", prompt, c.config.SummaryModel, func(text string) string {
+		return ExtractTextBetweenTags(text, last.startTag, last.endTag)
+	})
+	if err != nil {
+		return "", fmt.Errorf("LLM Layered Summary処理（多層要約）に失敗しました: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,117 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"google.golang.org/genai"
+)
+
+// ----------------------------------------------------------------
+// LLM 呼び出しの一時的な失敗の再試行 (指数バックオフ + ジッター)
+// ----------------------------------------------------------------
+
+const (
+	// DefaultLLMMaxAttempts は LLM 呼び出しの初回を含む最大試行回数のデフォルトです。
+	DefaultLLMMaxAttempts = 3
+	// DefaultLLMRetryBaseDelay は再試行の初回の待機時間のデフォルトです (以降は試行ごとに倍になります)。
+	DefaultLLMRetryBaseDelay = 2 * time.Second
+	// maxLLMRetryDelay は再試行の待機時間の上限です (KeyPool の復帰待ちを除く)。
+	maxLLMRetryDelay = time.Minute
+)
+
+// isRetryableLLMError はエラーが再試行で回復しうる一時的な失敗 (レート制限・サーバーエラー) かどうかを判定します。
+func isRetryableLLMError(err error) bool {
+	if errors.Is(err, ErrAllKeysExhausted) || isRateLimitError(err) {
+		return true
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// retryDelay は attempt 回目の失敗後の待機時間を返します。
+// base から試行ごとに倍にした値 (上限 maxLLMRetryDelay) に、同時に失敗した呼び出しが揃って再送しないよう
+// 0〜50% のジッターを加えます。全 APIキーの枯渇の場合は、最も早く復帰するキーまで待ちます。
+func retryDelay(err error, attempt int, base time.Duration) time.Duration {
+	delay := min(base<<(attempt-1), maxLLMRetryDelay)
+	delay += time.Duration(rand.Int64N(int64(delay)/2 + 1))
+	var exhausted *AllKeysExhaustedError
+	if errors.As(err, &exhausted) && exhausted.RetryAfter > delay {
+		delay = exhausted.RetryAfter
+	}
+	return delay
+}
+
+// generateContentContext は client.GenerateContent を呼び出し、ctx がキャンセルされた時点で応答を待たずに戻ります。
+// クライアントがキャンセルに即座に応じない場合も Mapフェーズなどの中断を遅らせないためのもので、
+// 呼び出し自体にも ctx を渡すため、応答を待たずに戻った後の呼び出しもクライアント側で中断されます。
+// config が設定されていてクライアントが ConfigurableModel の場合は、生成パラメータを指定して呼び出します。
+func generateContentContext(ctx context.Context, client gemini.GenerativeModel, prompt, model string, config GenConfig) (*gemini.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		response *gemini.Response
+		err      error
+	}
+	// 先に戻った場合も goroutine がブロックしないようバッファを持たせる
+	done := make(chan result, 1)
+	go func() {
+		var response *gemini.Response
+		var err error
+		if configurable, ok := client.(ConfigurableModel); ok && !config.IsZero() {
+			response, err = configurable.GenerateContentWithConfig(ctx, prompt, model, config)
+		} else {
+			response, err = client.GenerateContent(ctx, prompt, model)
+		}
+		done <- result{response, err}
+	}()
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("LLM呼び出しの応答待ち中に中断されました: %w", ctx.Err())
+	}
+}
+
+// generateWithRetry は LLM を呼び出し、レート制限とサーバーエラーの場合は指数バックオフで
+// LLMMaxAttempts 回まで試行します。ctx がキャンセルされた場合、または待機が ctx の期限を超える場合は
+// 直前のエラーを返します。
+func (c *Cleaner) generateWithRetry(ctx context.Context, phase, prompt, model string) (*gemini.Response, error) {
+	for attempt := 1; ; attempt++ {
+		response, err := generateContentContext(ctx, c.client, prompt, model, c.genConfig(phase))
+		if err == nil || attempt >= c.config.LLMMaxAttempts || !isRetryableLLMError(err) || ctx.Err() != nil {
+			return response, err
+		}
+
+		wait := retryDelay(err, attempt, c.config.LLMRetryBaseDelay)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, fmt.Errorf("再試行の待機が期限を超えるため中断しました: %w", err)
+		}
+		slog.WarnContext(ctx, "LLM呼び出しに一時的に失敗したため再試行します",
+			slog.String("phase", phase),
+			slog.String("model", model),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", c.config.LLMMaxAttempts),
+			slog.Duration("wait", wait),
+			slog.String("error", err.Error()),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("再試行の待機中に中断されました (%v): %w", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
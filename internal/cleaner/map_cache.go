@@ -0,0 +1,173 @@
+package cleaner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ----------------------------------------------------------------
+// Map要約のキャッシュ (セグメントの内容のハッシュをキーとする)
+// ----------------------------------------------------------------
+
+// MapCache は Map要約をキャッシュするインターフェースです。
+// 複数のセグメントから並行して呼び出されるため、実装は並行安全である必要があります。
+type MapCache interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+}
+
+// mapCacheStats は 1 回の Mapフェーズでのキャッシュのヒット・ミスの件数です。
+type mapCacheStats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// mapCacheKey は Map要約のキャッシュキーを返します。
+// セグメントを埋め込んだプロンプト (本文・テンプレート・文体の指示を含む) とモデル名のハッシュのため、
+// 同じセグメントでもテンプレートや設定を変えた場合はキャッシュを使用しません。
+func mapCacheKey(model, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// mapCacheEntry は 1 セグメント分の Map要約と保存時刻です。
+type mapCacheEntry struct {
+	Value    string    `json:"value"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// UnmarshalJSON は保存時刻を持たない旧形式 (値が Map要約の文字列のみ) のエントリも読み込みます。
+// 旧形式のエントリの保存時刻はゼロ値となり、LoadFileMapCache がファイルの更新時刻で補います。
+func (e *mapCacheEntry) UnmarshalJSON(raw []byte) error {
+	var legacy string
+	if err := json.Unmarshal(raw, &legacy); err == nil {
+		*e = mapCacheEntry{Value: legacy}
+		return nil
+	}
+	type plain mapCacheEntry
+	return json.Unmarshal(raw, (*plain)(e))
+}
+
+// MemoryMapCache はプロセス内でのみ保持するインメモリの MapCache です。
+type MemoryMapCache struct {
+	mu      sync.Mutex
+	entries map[string]mapCacheEntry
+}
+
+// NewMemoryMapCache は空の MemoryMapCache を生成します。
+func NewMemoryMapCache() *MemoryMapCache {
+	return &MemoryMapCache{entries: make(map[string]mapCacheEntry)}
+}
+
+// Get は key の Map要約を返します。
+func (c *MemoryMapCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.entries[key]
+	return v.Value, ok
+}
+
+// Set は key の Map要約を保存します。
+func (c *MemoryMapCache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = mapCacheEntry{Value: value, StoredAt: time.Now()}
+}
+
+// Stats はエントリ数と、最も古い・新しいエントリの保存時刻を返します (エントリがない場合はゼロ値)。
+func (c *MemoryMapCache) Stats() (entries int, oldest, newest time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.entries {
+		if oldest.IsZero() || e.StoredAt.Before(oldest) {
+			oldest = e.StoredAt
+		}
+		if e.StoredAt.After(newest) {
+			newest = e.StoredAt
+		}
+	}
+	return len(c.entries), oldest, newest
+}
+
+// Prune は保存時刻が before より前のエントリを削除し、削除した件数を返します。
+func (c *MemoryMapCache) Prune(before time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key, e := range c.entries {
+		if e.StoredAt.Before(before) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// FileMapCache は JSON ファイルに永続化する MapCache です。
+// Set はメモリ上のみを更新し、Save を呼び出した時点でファイルへ書き込みます。
+type FileMapCache struct {
+	*MemoryMapCache
+	path string
+}
+
+// LoadFileMapCache は path からキャッシュを読み込みます。ファイルが存在しない場合は空のキャッシュを返します。
+func LoadFileMapCache(path string) (*FileMapCache, error) {
+	c := &FileMapCache{MemoryMapCache: NewMemoryMapCache(), path: path}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Map要約キャッシュの読み込みに失敗しました: %w", err)
+	}
+	if err := json.Unmarshal(raw, &c.entries); err != nil {
+		return nil, fmt.Errorf("Map要約キャッシュの解析に失敗しました (%s): %w", path, err)
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]mapCacheEntry)
+	}
+	// 旧形式のエントリは保存時刻がないため、ファイルの更新時刻を保存時刻とみなす
+	if info, err := os.Stat(path); err == nil {
+		for key, e := range c.entries {
+			if e.StoredAt.IsZero() {
+				e.StoredAt = info.ModTime()
+				c.entries[key] = e
+			}
+		}
+	}
+	return c, nil
+}
+
+// Save はキャッシュをファイルに書き込みます。書き込み途中の破損を防ぐため、一時ファイル経由で置き換えます。
+func (c *FileMapCache) Save() error {
+	c.mu.Lock()
+	raw, err := json.MarshalIndent(c.entries, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("Map要約キャッシュのシリアライズに失敗しました: %w", err)
+	}
+
+	if dir := filepath.Dir(c.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("Map要約キャッシュのディレクトリ作成に失敗しました (%s): %w", dir, err)
+		}
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("Map要約キャッシュの書き込みに失敗しました: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("Map要約キャッシュの置き換えに失敗しました: %w", err)
+	}
+	slog.Debug("Map要約キャッシュを保存しました", slog.String("path", c.path))
+	return nil
+}
@@ -0,0 +1,67 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// Map要約フォーマット検証
+// ----------------------------------------------------------------
+
+const (
+	// DefaultMapFormatMinBullets は、Map要約に最低限含まれるべき箇条書きの行数です。
+	DefaultMapFormatMinBullets = 1
+	// DefaultMapFormatMaxRetries は、フォーマット違反時にセグメントを再生成する最大回数です。
+	DefaultMapFormatMaxRetries = 2
+)
+
+// MapFormatRules は Map要約に要求する構造の検証ルールです。
+// ゼロ値は「検証なし」を意味するため、必要なルールだけを設定して緩められます。
+// NewCleaner はゼロ値をデフォルトで置き換えないため、標準ルールを使う場合は DefaultMapFormatRules を設定してください。
+type MapFormatRules struct {
+	MinBullets     int  // 必要な箇条書き行 (`- ` / `* `) の最小数
+	RequireHeading bool // `##` 以下のトピック見出しを必須とするか
+}
+
+// DefaultMapFormatRules は標準ルールを返します (CLI の --map-format-* フラグの既定値)。
+func DefaultMapFormatRules() MapFormatRules {
+	return MapFormatRules{
+		MinBullets:     DefaultMapFormatMinBullets,
+		RequireHeading: true,
+	}
+}
+
+// ValidateMapFormat は、Map要約が rules で定めた構造を満たしているかを検証します。
+// <CLEANUP_START> マーカーが存在する場合は、その内側のみを検証対象とします。
+func ValidateMapFormat(summary string, rules MapFormatRules) error {
+	body := ExtractTextBetweenTags(summary, "CLEANUP_START", "CLEANUP_END")
+	if body == "" {
+		body = summary
+	}
+
+	bullets := 0
+	hasHeading := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			bullets++
+		case strings.HasPrefix(trimmed, "## "), strings.HasPrefix(trimmed, "### "), strings.HasPrefix(trimmed, "#### "):
+			hasHeading = true
+		}
+	}
+
+	var violations []string
+	if rules.RequireHeading && !hasHeading {
+		violations = append(violations, "トピック見出し (##) がありません")
+	}
+	if bullets < rules.MinBullets {
+		violations = append(violations, fmt.Sprintf("箇条書きが %d 行しかありません (最低 %d 行)", bullets, rules.MinBullets))
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("Map要約のフォーマット違反: %s", strings.Join(violations, "、"))
+	}
+	return nil
+}
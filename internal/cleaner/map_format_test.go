@@ -0,0 +1,50 @@
+package cleaner
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"golang.org/x/time/rate"
+)
+
+// fixedSummaryModel は常に同じ Map要約を返し、呼び出し回数を数える gemini.GenerativeModel です。
+type fixedSummaryModel struct {
+	summary string
+	calls   atomic.Int32
+}
+
+func (m *fixedSummaryModel) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	m.calls.Add(1)
+	return &gemini.Response{Text: m.summary}, nil
+}
+
+func TestNewCleanerKeepsRelaxedMapFormatRules(t *testing.T) {
+	const plain = "見出しも箇条書きもない要約"
+	model := &fixedSummaryModel{summary: plain}
+	c, err := NewCleaner(model, CleanerConfig{LLMRateLimit: time.Millisecond, EnforceMapFormat: true, MapFormatRules: MapFormatRules{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.config.MapFormatRules != (MapFormatRules{}) {
+		t.Errorf("MapFormatRules = %+v, ゼロ値 (検証なし) が保たれることを期待", c.config.MapFormatRules)
+	}
+	if err := ValidateMapFormat(plain, c.config.MapFormatRules); err != nil {
+		t.Errorf("ゼロ値のルールで検証エラー: %v", err)
+	}
+	if err := ValidateMapFormat(plain, DefaultMapFormatRules()); err == nil {
+		t.Error("標準ルールでは見出し・箇条書きのない要約をエラーにすることを期待")
+	}
+
+	// 緩めたルールでは、見出し・箇条書きのない要約も再生成せずにそのまま使用する
+	limiter := rate.NewLimiter(rate.Every(time.Millisecond), 1)
+	summary, err := c.generateSegmentSummary(context.Background(), limiter, 1, "本文", "プロンプト")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != plain || model.calls.Load() != 1 {
+		t.Errorf("summary = %q, calls = %d, want 再生成なしの %q", summary, model.calls.Load(), plain)
+	}
+}
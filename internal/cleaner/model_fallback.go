@@ -0,0 +1,74 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+// ----------------------------------------------------------------
+// フェーズごとのモデルのフォールバック
+// ----------------------------------------------------------------
+
+// phaseFallbackModels は phase で使用するモデルの優先順リスト (CleanerConfig.MapModels など) を返します。
+// 重要度採点とトピック分類は Mapフェーズのモデルを使用するため、MapModels を共有します。
+func (c *Cleaner) phaseFallbackModels(phase string) []string {
+	switch phase {
+	case PhaseMap, PhaseTopic, "importance":
+		return c.config.MapModels
+	case PhaseReduce:
+		return c.config.ReduceModels
+	case PhaseSummary:
+		return c.config.SummaryModels
+	case PhaseScript:
+		return c.config.ScriptModels
+	}
+	return nil
+}
+
+// modelChain は model を先頭に、phase の優先順リストのモデルを重複を除いて続けたリストを返します。
+func (c *Cleaner) modelChain(phase, model string) []string {
+	chain := []string{model}
+	for _, m := range c.phaseFallbackModels(phase) {
+		if m != "" && !slices.Contains(chain, m) {
+			chain = append(chain, m)
+		}
+	}
+	return chain
+}
+
+// generateWithFallback は modelChain のモデルを順に generateWithRetry で呼び出し、
+// 再試行しても一時的な失敗 (レート制限・サーバーエラー) が続いた場合は次のモデルで生成します。
+// 一時的でないエラーの場合は次のモデルを試さずに返します。生成に使用したモデル名も返します。
+func (c *Cleaner) generateWithFallback(ctx context.Context, phase, prompt, model string) (*gemini.Response, string, error) {
+	chain := c.modelChain(phase, model)
+	var lastErr error
+	for i, m := range chain {
+		response, err := c.generateWithRetry(ctx, phase, prompt, m)
+		if err == nil {
+			if i > 0 {
+				slog.InfoContext(ctx, "フォールバックモデルで生成しました",
+					slog.String("phase", phase),
+					slog.String("model", m),
+					slog.String("primary_model", model),
+				)
+			} else {
+				slog.DebugContext(ctx, "LLMで生成しました", slog.String("phase", phase), slog.String("model", m))
+			}
+			return response, m, nil
+		}
+		lastErr = err
+		if !isRetryableLLMError(err) || ctx.Err() != nil || i == len(chain)-1 {
+			break
+		}
+		slog.WarnContext(ctx, "モデルの呼び出しに失敗したため、次のモデルで再試行します",
+			slog.String("phase", phase),
+			slog.String("model", m),
+			slog.String("next_model", chain[i+1]),
+			slog.String("error", err.Error()),
+		)
+	}
+	return nil, "", lastErr
+}
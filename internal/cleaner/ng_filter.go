@@ -0,0 +1,114 @@
+package cleaner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// NGワード / 表現フィルタ
+// ----------------------------------------------------------------
+
+// DefaultNGReplacement は、NGワードを置換する際のデフォルト文字列です (CLI の --ng-replacement の既定値)。
+// NewCleaner は空の NGReplacement をこの値で置き換えないため、空文字列を指定するとNGワードを削除します。
+const DefaultNGReplacement = "〇〇"
+
+// ErrNGWordDetected は、厳格モードでNGワードが検出された場合に返されるエラーです。
+var ErrNGWordDetected = errors.New("生成スクリプトにNGワードが含まれています")
+
+// FilterScript は text に含まれるNGワードを replacement で置換し、検出された語のリストを返します。
+// replacement が空の場合はNGワードを削除します。
+// ngWords の各要素は単純語として扱われますが、`/pattern/` 形式の場合は正規表現として解釈されます。
+// 置換は行ごとに、行頭の話者タグ・スタイルタグ ([ずんだもん][ノーマル]) を除いたセリフの本文のみに適用するため、
+// タグに一致するNGワードがあっても話者の指定は壊れません (複数行にまたがるパターンには一致しません)。
+// 検出語リストは実際にマッチした文字列を出現順に重複なしで保持します。
+func FilterScript(text string, ngWords []string, replacement string) (string, []string) {
+	var patterns []*regexp.Regexp
+	for _, word := range ngWords {
+		if re := compileNGPattern(word); re != nil {
+			patterns = append(patterns, re)
+		}
+	}
+	if len(patterns) == 0 {
+		return text, nil
+	}
+
+	var detected []string
+	seen := make(map[string]bool)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix, body := "", line
+		if loc := speakerLinePattern.FindStringIndex(line); loc != nil {
+			prefix, body = line[:loc[1]], line[loc[1]:]
+		}
+		for _, re := range patterns {
+			for _, match := range re.FindAllString(body, -1) {
+				if match != "" && !seen[match] {
+					seen[match] = true
+					detected = append(detected, match)
+				}
+			}
+			body = re.ReplaceAllLiteralString(body, replacement)
+		}
+		lines[i] = prefix + body
+	}
+	return strings.Join(lines, "\n"), detected
+}
+
+// LoadNGWords はNGリストファイルを読み込みます。
+// 1行に1エントリを記述し、空行と `#` で始まる行は無視されます。
+// `/pattern/` 形式のエントリは読み込み時に正規表現として検証されます。
+func LoadNGWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("NGリストファイルを開けませんでした: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if pattern, ok := regexNGPattern(line); ok {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("NGリスト %d 行目の正規表現が不正です: %w", lineNo, err)
+			}
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("NGリストファイルの読み込みに失敗しました: %w", err)
+	}
+	return words, nil
+}
+
+// regexNGPattern は `/pattern/` 形式のエントリから正規表現部分を取り出します。
+func regexNGPattern(word string) (string, bool) {
+	if len(word) > 2 && strings.HasPrefix(word, "/") && strings.HasSuffix(word, "/") {
+		return word[1 : len(word)-1], true
+	}
+	return "", false
+}
+
+// compileNGPattern はNGエントリを正規表現にコンパイルします。
+// 不正な正規表現は単純語として扱い、空のエントリは nil を返します。
+func compileNGPattern(word string) *regexp.Regexp {
+	if word == "" {
+		return nil
+	}
+	if pattern, ok := regexNGPattern(word); ok {
+		if re, err := regexp.Compile(pattern); err == nil {
+			return re
+		}
+	}
+	return regexp.MustCompile(regexp.QuoteMeta(word))
+}
@@ -0,0 +1,107 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+func TestFilterScript(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		ngWords      []string
+		replacement  string
+		want         string
+		wantDetected []string
+	}{
+		{
+			name:         "replace",
+			text:         "[ずんだもん][ノーマル] 競合のA社は最悪なのだ。",
+			ngWords:      []string{"最悪"},
+			replacement:  "〇〇",
+			want:         "[ずんだもん][ノーマル] 競合のA社は〇〇なのだ。",
+			wantDetected: []string{"最悪"},
+		},
+		{
+			name:         "empty replacement deletes",
+			text:         "[めたん][ノーマル] これは最悪です。",
+			ngWords:      []string{"最悪"},
+			replacement:  "",
+			want:         "[めたん][ノーマル] これはです。",
+			wantDetected: []string{"最悪"},
+		},
+		{
+			name:         "speaker tag is not replaced",
+			text:         "[ずんだもん][ノーマル] ずんだもんはずんだ餅の妖精なのだ。\n[めたん][ノーマル] ずんだもん、説明ありがとう。",
+			ngWords:      []string{"ずんだもん"},
+			replacement:  "〇〇",
+			want:         "[ずんだもん][ノーマル] 〇〇はずんだ餅の妖精なのだ。\n[めたん][ノーマル] 〇〇、説明ありがとう。",
+			wantDetected: []string{"ずんだもん"},
+		},
+		{
+			name:         "style tag is not replaced",
+			text:         "[ずんだもん][ノーマル] ノーマルな話なのだ。",
+			ngWords:      []string{"/ノーマル/"},
+			replacement:  "",
+			want:         "[ずんだもん][ノーマル] な話なのだ。",
+			wantDetected: []string{"ノーマル"},
+		},
+		{
+			name:         "regex pattern",
+			text:         "電話は 090-1234-5678 まで。",
+			ngWords:      []string{`/\d{3}-\d{4}-\d{4}/`},
+			replacement:  "〇〇",
+			want:         "電話は 〇〇 まで。",
+			wantDetected: []string{"090-1234-5678"},
+		},
+		{
+			name:    "no match",
+			text:    "[めたん][ノーマル] 問題ありません。",
+			ngWords: []string{"最悪"},
+			want:    "[めたん][ノーマル] 問題ありません。",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, detected := FilterScript(tt.text, tt.ngWords, tt.replacement)
+			if got != tt.want {
+				t.Errorf("FilterScript() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(detected, tt.wantDetected) {
+				t.Errorf("detected = %v, want %v", detected, tt.wantDetected)
+			}
+		})
+	}
+}
+
+// fixedScriptModel は常に同じスクリプトを <SCRIPT_START> / <SCRIPT_END> で囲んで返す gemini.GenerativeModel です。
+type fixedScriptModel struct {
+	script string
+}
+
+func (m fixedScriptModel) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	return &gemini.Response{Text: "<SCRIPT_START>\n" + m.script + "\n<SCRIPT_END>"}, nil
+}
+
+func TestNewCleanerKeepsEmptyNGReplacement(t *testing.T) {
+	model := fixedScriptModel{script: "[ずんだもん][ノーマル] これは最悪なのだ。\n[めたん][ノーマル] 最悪ではないわ。"}
+	c, err := NewCleaner(model, CleanerConfig{LLMRateLimit: time.Millisecond, NGWords: []string{"最悪"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.config.NGReplacement != "" {
+		t.Errorf("NGReplacement = %q, 空 (削除) が保たれることを期待", c.config.NGReplacement)
+	}
+
+	script, err := c.GenerateScriptForVoicevox(context.Background(), "タイトル", "最終要約", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[ずんだもん][ノーマル] これはなのだ。\n[めたん][ノーマル] ではないわ。"; script != want {
+		t.Errorf("script = %q, want %q", script, want)
+	}
+}
@@ -0,0 +1,53 @@
+package cleaner
+
+import (
+	"act-feed-clean-go/prompts"
+)
+
+// ----------------------------------------------------------------
+// 出力スタイル (全フェーズ共通の言語・文体・トーン)
+// ----------------------------------------------------------------
+
+const (
+	// PolitenessPolite は敬体 (です・ます調) を指定する値です。
+	PolitenessPolite = "polite"
+	// PolitenessPlain は常体 (だ・である調) を指定する値です。
+	PolitenessPlain = "plain"
+
+	// FormalityFormal はフォーマルなトーンを指定する値です。
+	FormalityFormal = "formal"
+	// FormalityCasual はカジュアルなトーンを指定する値です。
+	FormalityCasual = "casual"
+)
+
+// OutputStyle は Map・Reduce・Summary・Script の全フェーズに共通で指示する出力スタイルです。
+// 空のフィールドは指示しません。Language が空の場合は CleanerConfig.OutputLang を使用します。
+type OutputStyle struct {
+	Language   string // 出力言語 (ja, en)
+	Politeness string // 文体 (polite: 敬体, plain: 常体)
+	Formality  string // トーン (formal, casual)
+}
+
+var (
+	languageLabels = map[string]string{
+		OutputLangJapanese: "日本語",
+		OutputLangEnglish:  "英語",
+	}
+	politenessLabels = map[string]string{
+		PolitenessPolite: "敬体（です・ます調）",
+		PolitenessPlain:  "常体（だ・である調）",
+	}
+	formalityLabels = map[string]string{
+		FormalityFormal: "フォーマル（報道・ビジネス文書のような硬めの表現）",
+		FormalityCasual: "カジュアル（親しみやすい話し言葉に近い表現）",
+	}
+)
+
+// promptStyle はテンプレートに埋め込む出力スタイルの指示文を返します。
+func (s OutputStyle) promptStyle() prompts.OutputStyle {
+	return prompts.OutputStyle{
+		Language:   languageLabels[s.Language],
+		Politeness: politenessLabels[s.Politeness],
+		Formality:  formalityLabels[s.Formality],
+	}
+}
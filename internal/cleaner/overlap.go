@@ -0,0 +1,137 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"log/slog"
+	"unicode"
+)
+
+// ----------------------------------------------------------------
+// 要約と原文の重複率 (転載の検出)
+// ----------------------------------------------------------------
+
+const (
+	// DefaultOverlapThreshold は、要約の原文との重複率がこの値以上の場合に転載と判定する閾値です。
+	DefaultOverlapThreshold = 0.3
+	// DefaultOverlapNGram は、重複の判定に使用する n-gram の文字数です。
+	DefaultOverlapNGram = 8
+	// DefaultOverlapMinMatchChars は、重複として数える連続一致の最小文字数です。これより短い一致は引用として許容します。
+	DefaultOverlapMinMatchChars = 20
+)
+
+// paraphraseInstruction は転載検出時の再生成でプロンプト末尾へ追加する指示です。
+const paraphraseInstruction = "\n\n【重要】前回の要約は原文との重複率が %.0f%% で、原文の文章をほぼそのまま転載している箇所がありました。" +
+	"原文の文をそのまま使わず、**内容を理解した上で自分の言葉に言い換えて**要約してください。" +
+	"固有名詞・数値・短い引用以外は、原文の言い回しを避けてください。"
+
+// OverlapRatio は summary のうち、source と n 文字以上連続して一致する部分の割合 (0〜1) を返します。
+// 比較は空白・記号を除き、英字を小文字にそろえた文字列で行います。
+// 連続一致の長さが minMatch 文字未満の部分は短い引用とみなして数えません。
+func OverlapRatio(summary, source string, n, minMatch int) float64 {
+	sum, src := normalizeForOverlap(summary), normalizeForOverlap(source)
+	if n <= 0 || len(sum) < n || len(src) < n {
+		return 0
+	}
+
+	seed := maphash.MakeSeed()
+	hashAt := func(rs []rune, i int) uint64 {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		h.WriteString(string(rs[i : i+n]))
+		return h.Sum64()
+	}
+	sourceGrams := make(map[uint64]struct{}, len(src)-n+1)
+	for i := 0; i+n <= len(src); i++ {
+		sourceGrams[hashAt(src, i)] = struct{}{}
+	}
+
+	// 原文に存在する n-gram が覆う文字に印を付ける
+	covered := make([]bool, len(sum))
+	for i := 0; i+n <= len(sum); i++ {
+		if _, ok := sourceGrams[hashAt(sum, i)]; ok {
+			for j := i; j < i+n; j++ {
+				covered[j] = true
+			}
+		}
+	}
+
+	// minMatch 文字以上の連続一致のみを重複として数える
+	overlapped, run := 0, 0
+	for i := 0; i <= len(covered); i++ {
+		if i < len(covered) && covered[i] {
+			run++
+			continue
+		}
+		if run >= minMatch {
+			overlapped += run
+		}
+		run = 0
+	}
+	return float64(overlapped) / float64(len(sum))
+}
+
+// normalizeForOverlap は重複判定用に文字と数字のみを残し、小文字にそろえます。
+func normalizeForOverlap(s string) []rune {
+	rs := make([]rune, 0, len(s))
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			rs = append(rs, unicode.ToLower(r))
+		}
+	}
+	return rs
+}
+
+// CheckSummaryOverlap は最終要約と原文の重複率を計測し、閾値以上であれば警告します。
+// ParaphraseStrict が有効な場合は言い換えの指示を追加して 1 回だけ再生成し、重複率が下がった場合のみ再生成結果を採用します。
+// 採用した要約とその重複率を返します。
+func (c *Cleaner) CheckSummaryOverlap(ctx context.Context, title, intermediateSummary, summary, source string) (_ string, _ float64, err error) {
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { err = end(err) }()
+
+	ratio := OverlapRatio(summary, source, c.config.OverlapNGram, c.config.OverlapMinMatchChars)
+	if ratio < c.config.OverlapThreshold {
+		slog.InfoContext(ctx, "要約と原文の重複率", slog.Float64("overlap", ratio), slog.Float64("threshold", c.config.OverlapThreshold))
+		return summary, ratio, nil
+	}
+	if !c.config.ParaphraseStrict {
+		slog.WarnContext(ctx, "要約が原文をほぼそのまま転載している可能性があります。--paraphrase-strict で言い換えを強めて再生成できます",
+			slog.Float64("overlap", ratio), slog.Float64("threshold", c.config.OverlapThreshold))
+		return summary, ratio, nil
+	}
+
+	slog.WarnContext(ctx, "要約と原文の重複率が閾値を超えたため、言い換えを強めて再生成します",
+		slog.Float64("overlap", ratio), slog.Float64("threshold", c.config.OverlapThreshold))
+	prompt, err := c.buildFinalSummaryPrompt(title, intermediateSummary, c.primarySummaryFormat())
+	if err != nil {
+		return "", 0, err
+	}
+	retried, err := c.generateInLanguage(ctx, "summary", prompt+fmt.Sprintf(paraphraseInstruction, ratio*100), c.config.SummaryModel, nil)
+	if errors.Is(err, ErrCostLimitExceeded) {
+		slog.WarnContext(ctx, "コスト上限に達したため、言い換えの再生成を行わずに最初の要約を使用します")
+		return summary, ratio, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("LLM Final Summary処理（言い換えの再生成）に失敗しました: %w", err)
+	}
+
+	if retried, err = c.postProcess(PhaseSummary, retried); err != nil {
+		return "", 0, err
+	}
+	retriedRatio := OverlapRatio(retried, source, c.config.OverlapNGram, c.config.OverlapMinMatchChars)
+	if retriedRatio >= ratio {
+		slog.WarnContext(ctx, "再生成後も重複率が下がらなかったため、最初の要約を使用します",
+			slog.Float64("overlap", ratio), slog.Float64("retried_overlap", retriedRatio))
+		return summary, ratio, nil
+	}
+	if retriedRatio >= c.config.OverlapThreshold {
+		slog.WarnContext(ctx, "再生成後も重複率が閾値を超えています", slog.Float64("overlap", retriedRatio), slog.Float64("threshold", c.config.OverlapThreshold))
+	}
+	slog.InfoContext(ctx, "言い換えを強めて再生成した要約を使用します", slog.Float64("overlap", retriedRatio), slog.Float64("previous_overlap", ratio))
+	return retried, retriedRatio, nil
+}
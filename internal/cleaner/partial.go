@@ -0,0 +1,21 @@
+package cleaner
+
+// ----------------------------------------------------------------
+// 失敗時の部分成果
+// ----------------------------------------------------------------
+
+// PartialError は CleanAndStructureText が途中で失敗した場合に、それまでに得られた Map要約を保持するエラーです。
+// errors.As で取り出し、Map要約の保存や Reduce からの再開に利用できます。
+type PartialError struct {
+	Phase        string   // 失敗したフェーズ (PhaseMap, PhaseReduce)
+	MapSummaries []string // 失敗時点までに完了した Map要約 (セグメントの順)
+	Err          error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,64 @@
+package cleaner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// フェーズごとのユーザー定義の後処理 (post-processor)
+// ----------------------------------------------------------------
+
+// PostProcessors のキーに指定するフェーズ名です。
+const (
+	PhaseMap     = "map"     // Map要約 (セグメントごと)
+	PhaseReduce  = "reduce"  // 中間統合要約
+	PhaseSummary = "summary" // 最終要約
+	PhaseScript  = "script"  // VOICEVOXスクリプト (NGワードフィルタの適用後)
+	PhaseTopic   = "topic"   // トピック分類のレスポンス (解析前)
+)
+
+// PostProcessor はフェーズの LLM 出力に適用する後処理です。
+// 変換後のテキストを返します。エラーを返した場合、そのフェーズは失敗として扱われます。
+type PostProcessor = func(string) (string, error)
+
+// postProcess は phase に登録された後処理を登録順に適用します。
+func (c *Cleaner) postProcess(phase, text string) (string, error) {
+	for i, process := range c.config.PostProcessors[phase] {
+		processed, err := process(text)
+		if err != nil {
+			return "", fmt.Errorf("%s フェーズの後処理 (%d 番目) に失敗しました: %w", phase, i+1, err)
+		}
+		text = processed
+	}
+	return text, nil
+}
+
+// TrimSpaceProcessor は前後の空白を除去する後処理です。
+func TrimSpaceProcessor(text string) (string, error) {
+	return strings.TrimSpace(text), nil
+}
+
+// RegexpReplaceProcessor は pattern に一致する部分を replacement ($1 などの参照可) に置換する後処理を返します。
+func RegexpReplaceProcessor(pattern, replacement string) (PostProcessor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("後処理の正規表現が不正です (%q): %w", pattern, err)
+	}
+	return func(text string) (string, error) {
+		return re.ReplaceAllString(text, replacement), nil
+	}, nil
+}
+
+// NGWordProcessor は NGワードを置換する後処理を返します (FilterScript と同じ規則)。
+// strict が true の場合、NGワードを検出すると ErrNGWordDetected を返します。
+func NGWordProcessor(words []string, replacement string, strict bool) PostProcessor {
+	return func(text string) (string, error) {
+		filtered, detected := FilterScript(text, words, replacement)
+		if strict && len(detected) > 0 {
+			return "", fmt.Errorf("%w: %s", ErrNGWordDetected, strings.Join(detected, ", "))
+		}
+		return filtered, nil
+	}
+}
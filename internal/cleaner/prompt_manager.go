@@ -2,7 +2,11 @@ package cleaner
 
 import (
 	"act-feed-clean-go/prompts"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 )
 
 // PromptManager は、Map-Reduceや最終要約などに使用される
@@ -11,7 +15,10 @@ type PromptManager struct {
 	MapBuilder          *prompts.PromptBuilder
 	ReduceBuilder       *prompts.PromptBuilder
 	FinalSummaryBuilder *prompts.PromptBuilder
+	LayeredBuilder      *prompts.PromptBuilder
 	ScriptBuilder       *prompts.PromptBuilder
+	TopicBuilder        *prompts.PromptBuilder
+	ImportanceBuilder   *prompts.PromptBuilder
 }
 
 // NewPromptManager は PromptManager を初期化し、必要なすべてのPromptBuilderを作成します。
@@ -28,15 +35,71 @@ func NewPromptManager() (*PromptManager, error) {
 	if err := finalSummaryBuilder.Err(); err != nil {
 		return nil, fmt.Errorf("Final Summary プロンプトビルダーの初期化に失敗しました: %w", err)
 	}
+	layeredBuilder := prompts.NewLayeredSummaryPromptBuilder()
+	if err := layeredBuilder.Err(); err != nil {
+		return nil, fmt.Errorf("Layered Summary プロンプトビルダーの初期化に失敗しました: %w", err)
+	}
 	scriptBuilder := prompts.NewScriptPromptBuilder()
 	if err := scriptBuilder.Err(); err != nil {
 		return nil, fmt.Errorf("Script プロンプトビルダーの初期化に失敗しました: %w", err)
 	}
 
+	topicBuilder := prompts.NewTopicPromptBuilder()
+	if err := topicBuilder.Err(); err != nil {
+		return nil, fmt.Errorf("Topic プロンプトビルダーの初期化に失敗しました: %w", err)
+	}
+	importanceBuilder := prompts.NewImportancePromptBuilder()
+	if err := importanceBuilder.Err(); err != nil {
+		return nil, fmt.Errorf("Importance プロンプトビルダーの初期化に失敗しました: %w", err)
+	}
+
 	return &PromptManager{
 		MapBuilder:          mapBuilder,
 		ReduceBuilder:       reduceBuilder,
 		FinalSummaryBuilder: finalSummaryBuilder,
+		LayeredBuilder:      layeredBuilder,
 		ScriptBuilder:       scriptBuilder,
+		TopicBuilder:        topicBuilder,
+		ImportanceBuilder:   importanceBuilder,
 	}, nil
 }
+
+// NewPromptManagerFromDir は組み込みテンプレートを基に、dir にある同名のテンプレートファイル
+// (prompts.MapPromptFile など) で Map・Reduce・Summary・Script のテンプレートを上書きした PromptManager を返します。
+// dir に存在しないファイルは組み込みテンプレートを使用します。
+// 上書きしたテンプレートは prompts.ValidateTemplate で検証し、未定義のフィールドの参照や必須のプレースホルダの欠落をエラーにします。
+func NewPromptManagerFromDir(dir string) (*PromptManager, error) {
+	manager, err := NewPromptManager()
+	if err != nil {
+		return nil, err
+	}
+	overrides := []struct {
+		file    string
+		builder **prompts.PromptBuilder
+	}{
+		{prompts.MapPromptFile, &manager.MapBuilder},
+		{prompts.ReducePromptFile, &manager.ReduceBuilder},
+		{prompts.SummaryPromptFile, &manager.FinalSummaryBuilder},
+		{prompts.ScriptPromptFile, &manager.ScriptBuilder},
+	}
+	for _, o := range overrides {
+		path := filepath.Join(dir, o.file)
+		raw, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("プロンプトテンプレートの読み込みに失敗しました (%s): %w", path, err)
+		}
+		builder := prompts.NewPromptBuilderFromText(o.file, string(raw))
+		if err := builder.Err(); err != nil {
+			return nil, fmt.Errorf("プロンプトテンプレートの解析に失敗しました (%s): %w", path, err)
+		}
+		// 未定義のフィールドの参照や本文のプレースホルダの欠落は、LLM を呼び出す前に検出する
+		if err := prompts.ValidateTemplate(o.file, builder); err != nil {
+			return nil, fmt.Errorf("プロンプトテンプレートの検証に失敗しました (%s): %w", path, err)
+		}
+		*o.builder = builder
+	}
+	return manager, nil
+}
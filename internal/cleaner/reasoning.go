@@ -0,0 +1,50 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// 推論部分 (<thinking> など) の除去
+// ----------------------------------------------------------------
+
+// DefaultReasoningTags は、LLM のレスポンスから除去する推論部分のタグ名のデフォルトです。
+var DefaultReasoningTags = []string{"thinking", "think", "reasoning", "scratchpad"}
+
+// reasoningTagPattern は推論マーカーとして受け付けるタグ名の形式です。
+var reasoningTagPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// RemoveTextBetweenTags は、<tag>...</tag> で囲まれた部分をタグごとすべて除去します。
+// ExtractTextBetweenTags と異なり、タグ名の大文字・小文字は区別せず、属性付きの開始タグ (<tag type="x">) にも一致します。
+// 閉じタグのない開始タグ・開始タグのない閉じタグは、タグのみを除去します。
+func RemoveTextBetweenTags(text, tag string) string {
+	name := regexp.QuoteMeta(tag)
+	block := regexp.MustCompile(`(?is)<` + name + `(\s[^>]*)?>.*?</` + name + `\s*>`)
+	stray := regexp.MustCompile(`(?i)</?` + name + `(\s[^>]*)?>`)
+	return stray.ReplaceAllString(block.ReplaceAllString(text, ""), "")
+}
+
+// stripReasoning は設定された推論マーカー (ReasoningTags) で囲まれた部分をレスポンスから除去します。
+// 除去後が空になる場合は推論マーカーの誤検出とみなし、元のレスポンスをそのまま返します。
+func (c *Cleaner) stripReasoning(ctx context.Context, phase, text string) string {
+	stripped := text
+	for _, tag := range c.config.ReasoningTags {
+		stripped = RemoveTextBetweenTags(stripped, tag)
+	}
+	if stripped == text {
+		return text
+	}
+	stripped = strings.TrimSpace(stripped)
+	if stripped == "" {
+		slog.WarnContext(ctx, "推論部分を除去するとレスポンスが空になるため、元のレスポンスを使用します", slog.String("phase", phase))
+		return text
+	}
+	slog.DebugContext(ctx, "LLMのレスポンスから推論部分を除去しました",
+		slog.String("phase", phase),
+		slog.Int("removed_chars", len(text)-len(stripped)),
+	)
+	return stripped
+}
@@ -0,0 +1,95 @@
+package cleaner
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// Reduce結果のセクション構造
+// ----------------------------------------------------------------
+
+// Reduceプロンプトで指定するセクション見出しです。
+const (
+	SectionOverview   = "概要"
+	SectionPoints     = "主要ポイント"
+	SectionConclusion = "結論"
+)
+
+// ErrReduceStructureNotFound は、Reduce結果からセクション構造を読み取れなかった場合のエラーです。
+var ErrReduceStructureNotFound = errors.New("Reduce結果にセクション構造が見つかりませんでした")
+
+// ReduceResult は、セクション構造化されたReduceフェーズの中間統合要約です。
+type ReduceResult struct {
+	Title      string   // `#` 見出しのタイトル
+	Overview   string   // 概要セクションの本文
+	Points     []string // 主要ポイントセクションの各項目
+	Conclusion string   // 結論セクションの本文
+}
+
+// listItemPattern は箇条書き (`- `, `* `, `1. `) の行頭記号に一致します。
+var listItemPattern = regexp.MustCompile(`^(?:[-*]|\d+[.)])\s+`)
+
+// ParseReduceResult は、Reduceフェーズの出力を「概要／主要ポイント／結論」の構造体に変換します。
+// <FINAL_START> マーカーが存在する場合は、その内側のみを解析対象とします。
+// いずれのセクションも見つからない場合は ErrReduceStructureNotFound を返します。
+func ParseReduceResult(text string) (*ReduceResult, error) {
+	body := ExtractTextBetweenTags(text, "FINAL_START", "FINAL_END")
+	if body == "" {
+		body = text
+	}
+
+	result := &ReduceResult{Title: ExtractTitleFromMarkdown(body)}
+	var overview, conclusion []string
+	current := ""
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "## ") {
+			current = classifySection(strings.TrimSpace(trimmed[3:]))
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "# ") || strings.HasPrefix(trimmed, "<") {
+			continue
+		}
+
+		switch current {
+		case SectionOverview:
+			overview = append(overview, trimmed)
+		case SectionConclusion:
+			conclusion = append(conclusion, trimmed)
+		case SectionPoints:
+			if loc := listItemPattern.FindStringIndex(trimmed); loc != nil {
+				result.Points = append(result.Points, trimmed[loc[1]:])
+			} else if n := len(result.Points); n > 0 {
+				// 箇条書きの継続行は直前の項目に連結
+				result.Points[n-1] += " " + trimmed
+			} else {
+				result.Points = append(result.Points, trimmed)
+			}
+		}
+	}
+
+	result.Overview = strings.Join(overview, "\n")
+	result.Conclusion = strings.Join(conclusion, "\n")
+
+	if result.Overview == "" && len(result.Points) == 0 && result.Conclusion == "" {
+		return nil, ErrReduceStructureNotFound
+	}
+	return result, nil
+}
+
+// classifySection は `##` 見出しのテキストから対応するセクション名を判定します。
+func classifySection(heading string) string {
+	switch {
+	case strings.Contains(heading, SectionOverview):
+		return SectionOverview
+	case strings.Contains(heading, "ポイント"):
+		return SectionPoints
+	case strings.Contains(heading, SectionConclusion), strings.Contains(heading, "まとめ"):
+		return SectionConclusion
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,169 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"act-feed-clean-go/prompts"
+)
+
+// ----------------------------------------------------------------
+// Reduce戦略
+// ----------------------------------------------------------------
+
+// ReduceStrategyKind は Map要約を中間統合要約へまとめる Reduce戦略の種類です。
+//
+//   - concat: すべての Map要約を連結して 1 回の LLM 呼び出しで統合します。呼び出し回数が最少で速い一方、
+//     Map要約の合計が大きいとプロンプトが長くなり、モデルのコンテキスト長やメモリを圧迫します。
+//   - hierarchical: Map要約を一定数ずつのグループに分けて統合し、その結果をさらに統合することを 1 つになるまで繰り返します。
+//     各階層のグループは並列に処理されるため、セグメント数が多い長大な入力に向きます。呼び出し回数は concat より増えます。
+//   - refine: 先頭の要約から始め、現在の統合要約に次の Map要約を 1 つずつ読み込んで逐次更新します。
+//     1 回のプロンプトは「現在の統合要約 + 1 件」に収まるためメモリ効率が良い反面、LLM 呼び出しが直列で遅く、
+//     先頭の要約の内容に引きずられやすい傾向があります。
+type ReduceStrategyKind string
+
+const (
+	ReduceStrategyConcat       ReduceStrategyKind = "concat"       // 単純連結 (1回のReduce)
+	ReduceStrategyHierarchical ReduceStrategyKind = "hierarchical" // 階層Reduce
+	ReduceStrategyRefine       ReduceStrategyKind = "refine"       // 逐次洗練
+)
+
+const (
+	// DefaultReduceStrategy は Reduce戦略のデフォルトです (従来どおりの単純連結)。
+	DefaultReduceStrategy = ReduceStrategyConcat
+	// hierarchicalFanIn は階層Reduceで 1 回の LLM 呼び出しにまとめる要約の数です。
+	hierarchicalFanIn = 4
+	// intermediateSummarySeparator は Reduce プロンプトに渡す要約間の区切りです。
+	intermediateSummarySeparator = "\n\n--- INTERMEDIATE SUMMARY END ---\n\n"
+)
+
+// ReduceFunc は要約 (区切り済みで連結したもの) を 1 回の LLM 呼び出しで統合します。
+// final が true の場合は最終段の Reduce として、StructuredReduce などの出力形式の指示を適用します。
+type ReduceFunc func(ctx context.Context, combinedText string, final bool) (string, error)
+
+// ReduceStrategy は Map要約から中間統合要約を作る手順です。
+// 実際の LLM 呼び出しは reduce に委ね、戦略は呼び出しの順序と入力の組み立てのみを担います。
+type ReduceStrategy interface {
+	Reduce(ctx context.Context, summaries []string, reduce ReduceFunc) (string, error)
+}
+
+// ParseReduceStrategy は文字列を ReduceStrategyKind に変換します。空文字列の場合はデフォルトを返します。
+func ParseReduceStrategy(s string) (ReduceStrategyKind, error) {
+	switch k := ReduceStrategyKind(strings.ToLower(strings.TrimSpace(s))); k {
+	case ReduceStrategyConcat, ReduceStrategyHierarchical, ReduceStrategyRefine:
+		return k, nil
+	case "":
+		return DefaultReduceStrategy, nil
+	default:
+		return "", fmt.Errorf("不明なReduce戦略です: %q (concat, hierarchical, refine のいずれかを指定してください)", s)
+	}
+}
+
+// newReduceStrategy は種類に対応する ReduceStrategy を返します。
+func newReduceStrategy(kind ReduceStrategyKind) ReduceStrategy {
+	switch kind {
+	case ReduceStrategyHierarchical:
+		return hierarchicalReduce{fanIn: hierarchicalFanIn}
+	case ReduceStrategyRefine:
+		return refineReduce{}
+	default:
+		return concatReduce{}
+	}
+}
+
+// concatReduce はすべての要約を連結して 1 回で統合します。
+type concatReduce struct{}
+
+func (concatReduce) Reduce(ctx context.Context, summaries []string, reduce ReduceFunc) (string, error) {
+	return reduce(ctx, strings.Join(summaries, intermediateSummarySeparator), true)
+}
+
+// hierarchicalReduce は fanIn 件ずつ統合する処理を、要約が fanIn 件以下になるまで階層的に繰り返します。
+type hierarchicalReduce struct {
+	fanIn int
+}
+
+func (h hierarchicalReduce) Reduce(ctx context.Context, summaries []string, reduce ReduceFunc) (string, error) {
+	// いずれかのグループが失敗した場合は、同じ階層の残りのグループをキャンセルする
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for level := 1; len(summaries) > h.fanIn; level++ {
+		groups := (len(summaries) + h.fanIn - 1) / h.fanIn
+		slog.InfoContext(ctx, "階層Reduceを実行します", slog.Int("level", level), slog.Int("inputs", len(summaries)), slog.Int("groups", groups))
+
+		next := make([]string, groups)
+		errCh := make(chan error, groups)
+		for g := range groups {
+			group := summaries[g*h.fanIn : min((g+1)*h.fanIn, len(summaries))]
+			go func() {
+				text, err := reduce(ctx, strings.Join(group, intermediateSummarySeparator), false)
+				if err != nil {
+					errCh <- fmt.Errorf("階層Reduce (レベル%d, グループ%d) に失敗しました: %w", level, g+1, err)
+					return
+				}
+				next[g] = text
+				errCh <- nil
+			}()
+		}
+		var firstErr error
+		for range groups {
+			if err := <-errCh; err != nil && firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		}
+		if firstErr != nil {
+			return "", firstErr
+		}
+		summaries = next
+	}
+	return reduce(ctx, strings.Join(summaries, intermediateSummarySeparator), true)
+}
+
+// refineReduce は現在の統合要約に次の要約を 1 件ずつ取り込み、逐次更新します。
+type refineReduce struct{}
+
+func (refineReduce) Reduce(ctx context.Context, summaries []string, reduce ReduceFunc) (string, error) {
+	if len(summaries) == 1 {
+		return reduce(ctx, summaries[0], true)
+	}
+	current := summaries[0]
+	for i, next := range summaries[1:] {
+		step := i + 2
+		slog.InfoContext(ctx, "逐次洗練Reduceを実行します", slog.Int("step", step-1), slog.Int("steps", len(summaries)-1))
+		refined, err := reduce(ctx, current+intermediateSummarySeparator+next, step == len(summaries))
+		if err != nil {
+			return "", fmt.Errorf("逐次洗練Reduce (%d/%d) に失敗しました: %w", step-1, len(summaries)-1, err)
+		}
+		current = refined
+	}
+	return current, nil
+}
+
+// reduceOnce は Reduce プロンプトを構築し、1 回の LLM 呼び出しで要約を統合します (ReduceFunc の実装)。
+func (c *Cleaner) reduceOnce(ctx context.Context, combinedText string, final bool) (string, error) {
+	prompt, err := c.buildReducePrompt(combinedText, final)
+	if err != nil {
+		return "", err
+	}
+	// 出力言語が異なる場合は言語を明示して再生成 (language.goで定義)
+	return c.generateInLanguage(ctx, "reduce", prompt, c.config.ReduceModel, nil)
+}
+
+// buildReducePrompt は Reduce プロンプトを構築します。final は最終段の Reduce かどうかです。
+func (c *Cleaner) buildReducePrompt(combinedText string, final bool) (string, error) {
+	reduceData := prompts.ReduceTemplateData{
+		CombinedText:       combinedText,
+		StructuredSections: final && c.config.StructuredReduce,
+		CiteSources:        c.config.CiteSources,
+		OutputStyle:        c.config.OutputStyle.promptStyle(),
+	}
+	prompt, err := c.prompt.ReduceBuilder.BuildReduce(reduceData)
+	if err != nil {
+		return "", fmt.Errorf("Reduce プロンプトの生成に失敗しました: %w", err)
+	}
+	return prompt, nil
+}
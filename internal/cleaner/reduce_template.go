@@ -0,0 +1,118 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// ----------------------------------------------------------------
+// Reduce結果のテンプレートによる前処理 (Summary へ渡す前の整形)
+// ----------------------------------------------------------------
+
+// ReduceTemplateData は Reduce結果の前処理テンプレートに渡すデータです。
+type ReduceTemplateData struct {
+	Text  string // Reduce結果 (中間統合要約) の Markdown
+	Title string // Reduce結果の先頭の "# " 見出し (ない場合は空)
+}
+
+// reduceTemplateFuncs は前処理テンプレートで使用できる関数です。
+// いずれも処理対象のテキストを最後の引数に取るため、{{.Text | removeSection "参考" | normalizeHeadings}} のようにパイプで連結できます。
+var reduceTemplateFuncs = template.FuncMap{
+	"normalizeHeadings": normalizeHeadings,
+	"removeSection":     removeSection,
+	"trim":              strings.TrimSpace,
+}
+
+// TemplateProcessor は text/template のテンプレート text で Reduce結果を整形する後処理を返します。
+// テンプレートには ReduceTemplateData が渡され、normalizeHeadings / removeSection / trim 関数を使用できます。
+func TemplateProcessor(name, text string) (PostProcessor, error) {
+	tmpl, err := template.New(name).Funcs(reduceTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("前処理テンプレートの解析に失敗しました (%s): %w", name, err)
+	}
+	return func(text string) (string, error) {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, ReduceTemplateData{Text: text, Title: ExtractTitleFromMarkdown(text)}); err != nil {
+			return "", fmt.Errorf("前処理テンプレートの実行に失敗しました (%s): %w", name, err)
+		}
+		return sb.String(), nil
+	}, nil
+}
+
+// LoadTemplateProcessor は path のテンプレートファイルを読み込み、TemplateProcessor を返します。
+func LoadTemplateProcessor(path string) (PostProcessor, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("前処理テンプレートの読み込みに失敗しました: %w", err)
+	}
+	return TemplateProcessor(path, string(raw))
+}
+
+// normalizeHeadings は ATX 形式の見出しを、最も浅い見出しが "#" になるようレベルを揃えて書き直します
+// (記号の後の空白と末尾の # も正規化します)。コードブロック内の行は変更しません。
+func normalizeHeadings(text string) string {
+	lines := strings.Split(text, "\n")
+	shallowest := 0
+	forEachHeading(lines, func(_ int, level int, _ string) {
+		if shallowest == 0 || level < shallowest {
+			shallowest = level
+		}
+	})
+	forEachHeading(lines, func(i int, level int, title string) {
+		lines[i] = strings.Repeat("#", level-shallowest+1) + " " + title
+	})
+	return strings.Join(lines, "\n")
+}
+
+// removeSection は見出しのテキストが title に一致するセクションを、次の同じ以上のレベルの見出しの直前まで除去します。
+// 見出しの比較は前後の空白を除いて行います。一致するセクションがない場合は text をそのまま返します。
+func removeSection(title, text string) string {
+	title = strings.TrimSpace(title)
+	lines := strings.Split(text, "\n")
+	remove := make([]bool, len(lines))
+	removing := 0 // 除去中のセクションの見出しレベル (0 の場合は除去していない)
+	start := -1
+	forEachHeading(lines, func(i int, level int, heading string) {
+		if removing > 0 && level <= removing {
+			for j := start; j < i; j++ {
+				remove[j] = true
+			}
+			removing = 0
+		}
+		if removing == 0 && heading == title {
+			removing, start = level, i
+		}
+	})
+	if removing > 0 {
+		for j := start; j < len(lines); j++ {
+			remove[j] = true
+		}
+	}
+
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !remove[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// forEachHeading はコードブロック外の ATX 形式の見出し行ごとに、行番号・レベル・見出しテキストで fn を呼び出します。
+func forEachHeading(lines []string, fn func(i int, level int, title string)) {
+	inCodeBlock := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			fn(i, len(m[1]), m[2])
+		}
+	}
+}
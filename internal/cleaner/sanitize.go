@@ -0,0 +1,166 @@
+package cleaner
+
+import (
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// ----------------------------------------------------------------
+// 入力テキストの事前クリーンアップ (--sanitize-input)
+// ----------------------------------------------------------------
+
+// SanitizeStats は SanitizeText で除去・置換した文字の種類ごとの件数です。
+type SanitizeStats struct {
+	BOM        int // バイトオーダーマーク (U+FEFF)
+	ZeroWidth  int // ゼロ幅スペース・単語結合子 (U+200B, U+2060)。絵文字の結合に使う ZWJ / ZWNJ は残す
+	SoftHyphen int // ソフトハイフン (U+00AD)
+	Control    int // 改行・タブ以外の制御文字 (C0 / C1)
+	CRLF       int // LF に変換した CRLF / CR
+	NBSP       int // 半角スペースに変換したノーブレークスペース (U+00A0)
+	Spaces     int // 1つに圧縮した連続空白 (行頭のインデントとコードブロック内は対象外)
+	BlankLines int // 1行に圧縮した連続空行
+	TrailingWS int // 空白を除去した行の数 (行末)
+}
+
+// Changed は何らかの文字を除去・置換したかどうかを返します。
+func (s SanitizeStats) Changed() bool {
+	return s != SanitizeStats{}
+}
+
+// LogAttrs は件数が 0 でない種類をログ属性として返します。
+func (s SanitizeStats) LogAttrs() []any {
+	kinds := []struct {
+		name  string
+		count int
+	}{
+		{"bom", s.BOM},
+		{"zero_width", s.ZeroWidth},
+		{"soft_hyphen", s.SoftHyphen},
+		{"control", s.Control},
+		{"crlf", s.CRLF},
+		{"nbsp", s.NBSP},
+		{"spaces", s.Spaces},
+		{"blank_lines", s.BlankLines},
+		{"trailing_ws", s.TrailingWS},
+	}
+	var attrs []any
+	for _, k := range kinds {
+		if k.count > 0 {
+			attrs = append(attrs, slog.Int(k.name, k.count))
+		}
+	}
+	return attrs
+}
+
+// SanitizeText は不可視文字 (BOM・ゼロ幅スペース・ソフトハイフン・制御文字) を除去し、
+// 改行コードを LF に正規化して、行中の連続空白・行末の空白・連続空行を圧縮します。
+// 全角スペース (U+3000) や全角/半角の文字はそのまま保持し、行頭のインデントと ``` で囲まれたコードブロックの中身は変更しません。
+func SanitizeText(text string) string {
+	sanitized, _ := SanitizeTextWithStats(text)
+	return sanitized
+}
+
+// SanitizeTextWithStats は SanitizeText と同じ処理を行い、除去・置換した文字の種類ごとの件数を返します。
+func SanitizeTextWithStats(text string) (string, SanitizeStats) {
+	var stats SanitizeStats
+	text = normalizeNewlines(text, &stats)
+	text = removeInvisible(text, &stats)
+
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	blank := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			blank = 0
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != line {
+			stats.TrailingWS++
+		}
+		if trimmed == "" {
+			blank++
+			if blank > 1 {
+				stats.BlankLines++
+				continue
+			}
+			out = append(out, "")
+			continue
+		}
+		blank = 0
+		out = append(out, collapseSpaces(trimmed, &stats))
+	}
+
+	return strings.Join(out, "\n"), stats
+}
+
+// normalizeNewlines は CRLF と単独の CR を LF に変換します。
+func normalizeNewlines(text string, stats *SanitizeStats) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	stats.CRLF = strings.Count(text, "\r")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// removeInvisible は不可視文字と制御文字を除去し、ノーブレークスペースを半角スペースに置き換えます。
+func removeInvisible(text string, stats *SanitizeStats) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\uFEFF':
+			stats.BOM++
+			return -1
+		case r == '\u200B' || r == '\u2060':
+			stats.ZeroWidth++
+			return -1
+		case r == '\u00AD':
+			stats.SoftHyphen++
+			return -1
+		case r == '\u00A0':
+			stats.NBSP++
+			return ' '
+		case unicode.IsControl(r):
+			stats.Control++
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// collapseSpaces は行頭のインデントを残したまま、行中の半角スペース・タブの連続を半角スペース 1 つにします。
+func collapseSpaces(line string, stats *SanitizeStats) string {
+	body := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(body)]
+
+	var sb strings.Builder
+	sb.WriteString(indent)
+	run, tab := 0, false
+	for _, r := range body {
+		if r == ' ' || r == '\t' {
+			run++
+			tab = tab || r == '\t'
+			continue
+		}
+		if run > 0 {
+			if run > 1 || tab {
+				stats.Spaces++
+			}
+			sb.WriteByte(' ')
+			run, tab = 0, false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ----------------------------------------------------------------
+// スクリプトのオープニング・クロージングの挨拶
+// ----------------------------------------------------------------
+
+const (
+	// DefaultScriptOpening はスクリプト冒頭の挨拶のデフォルトのテンプレートです。
+	DefaultScriptOpening = "こんにちは。{{.Date}}のニュースをお届けします。{{if .Highlights}}今日は{{.Highlights}}つの話題を紹介します。{{end}}"
+	// DefaultScriptClosing はスクリプト末尾の挨拶のデフォルトのテンプレートです。
+	DefaultScriptClosing = "以上、{{.Date}}のニュースでした。また次回お会いしましょう。"
+)
+
+// greetingDateLayout は挨拶に埋め込む日付の書式です。
+const greetingDateLayout = "2006年1月2日"
+
+// GreetingData は挨拶のテンプレートに埋め込む値です。
+type GreetingData struct {
+	Date       string // 生成日 (例: 2025年1月2日)
+	Weekday    string // 生成日の曜日 (例: 木曜日)
+	Title      string // エピソードのタイトル
+	Highlights int    // 取り上げる話題の件数 (主要ポイント、または最終要約の見出しの数)。不明な場合は 0
+}
+
+// weekdayNames は time.Weekday に対応する曜日の表記です。
+var weekdayNames = [...]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"}
+
+// parseGreeting は挨拶のテンプレートを解析します。
+func parseGreeting(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+// RenderGreeting は挨拶のテンプレート text に data を埋め込みます。text が空の場合は空文字列を返します。
+func RenderGreeting(text string, data GreetingData) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+	tmpl, err := parseGreeting("greeting", text)
+	if err != nil {
+		return "", fmt.Errorf("挨拶のテンプレートの解析に失敗しました: %w", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("挨拶のテンプレートの実行に失敗しました: %w", err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// validateGreeting はテンプレートをサンプルデータで試行し、構文エラーや未定義のフィールドの参照を検出します。
+func validateGreeting(text string) error {
+	_, err := RenderGreeting(text, GreetingData{Date: "date", Weekday: "weekday", Title: "title", Highlights: 1})
+	return err
+}
+
+// newGreetingData は生成時刻 now とスクリプトの入力から挨拶に埋め込む値を作成します。
+// 話題の件数は、セクション構造化された Reduce結果があれば主要ポイントの数、なければ最終要約の見出し (## ) の数です。
+func newGreetingData(now time.Time, title, finalSummary string, structure *ReduceResult) GreetingData {
+	data := GreetingData{
+		Date:    now.Format(greetingDateLayout),
+		Weekday: weekdayNames[now.Weekday()],
+		Title:   title,
+	}
+	if structure != nil && len(structure.Points) > 0 {
+		data.Highlights = len(structure.Points)
+		return data
+	}
+	for _, line := range strings.Split(finalSummary, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "## ") {
+			data.Highlights++
+		}
+	}
+	return data
+}
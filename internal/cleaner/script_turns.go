@@ -0,0 +1,107 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox/parser"
+)
+
+// ----------------------------------------------------------------
+// スクリプトのターン数の検証
+// ----------------------------------------------------------------
+
+const (
+	// scriptTurnsTolerance は目標ターン数からの許容ずれの割合です。これを超えると再生成します。
+	scriptTurnsTolerance = 0.3
+	// scriptTurnsMinSlack は許容ずれの下限 (ターン数) です。目標が小さい場合の過剰な再生成を防ぎます。
+	scriptTurnsMinSlack = 2
+)
+
+// turnsInstruction はターン数が目標から外れた場合の再生成でプロンプト末尾へ追加する指示です。
+const turnsInstruction = "\n\n【重要】前回の出力は会話が %d ターンで、目標の %d ターンから大きく外れていました。" +
+	"話者が交代するまでの発言のまとまりを1ターンとして、**%d ターン前後**になるよう%sしてください。"
+
+// ScriptTurn は話者が交代するまでの、同じ話者の連続した発言のまとまり (1ターン) です。
+type ScriptTurn struct {
+	Speaker string   // 話者タグ (例: "[ずんだもん]")
+	Lines   []string // ターン内のセリフ (タグを除いた本文)
+}
+
+// ParseScript はスクリプトを VOICEVOX のパーサーでセグメントに分解し、話者の交代ごとにターンへまとめます。
+// 話者タグを持たない行は無視します。
+func ParseScript(script string) []ScriptTurn {
+	segments, _ := parser.NewParser().Parse(script, "")
+
+	var turns []ScriptTurn
+	for _, seg := range segments {
+		if seg.BaseSpeakerTag == "" {
+			continue
+		}
+		if n := len(turns); n > 0 && turns[n-1].Speaker == seg.BaseSpeakerTag {
+			turns[n-1].Lines = append(turns[n-1].Lines, seg.Text)
+			continue
+		}
+		turns = append(turns, ScriptTurn{Speaker: seg.BaseSpeakerTag, Lines: []string{seg.Text}})
+	}
+	return turns
+}
+
+// turnsOutOfRange はターン数 actual が目標 target から許容範囲を超えて外れているかを判定します。
+func turnsOutOfRange(actual, target int) bool {
+	slack := max(int(float64(target)*scriptTurnsTolerance), scriptTurnsMinSlack)
+	return actual < target-slack || actual > target+slack
+}
+
+// turnsPromptSuffix はターン数のずれを含めた再生成指示を返します。
+func turnsPromptSuffix(actual, target int) string {
+	direction := "会話を増や"
+	if actual > target {
+		direction = "発言をまとめて会話を減ら"
+	}
+	return fmt.Sprintf(turnsInstruction, actual, target, target, direction)
+}
+
+// adjustScriptTurns は ScriptTurns が設定されている場合にスクリプトのターン数を測り、
+// 目標から大きく外れていればターン数の指示を追加して 1 回だけ再生成します。
+// 再生成後の方が目標に近い場合のみ再生成結果を採用します。
+func (c *Cleaner) adjustScriptTurns(ctx context.Context, prompt, scriptText string) (string, error) {
+	target := c.config.ScriptTurns
+	if target <= 0 {
+		return scriptText, nil
+	}
+
+	actual := len(ParseScript(scriptText))
+	if !turnsOutOfRange(actual, target) {
+		slog.InfoContext(ctx, "スクリプトのターン数", slog.Int("turns", actual), slog.Int("target", target))
+		return scriptText, nil
+	}
+
+	slog.WarnContext(ctx, "スクリプトのターン数が目標から外れているため、再生成します", slog.Int("turns", actual), slog.Int("target", target))
+	retried, err := c.generateScript(ctx, prompt+turnsPromptSuffix(actual, target))
+	if errors.Is(err, ErrCostLimitExceeded) {
+		slog.WarnContext(ctx, "コスト上限に達したため、ターン数の再生成を行わずに最初のスクリプトを使用します")
+		return scriptText, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	retriedTurns := len(ParseScript(retried))
+	if retriedTurns == 0 || absInt(retriedTurns-target) >= absInt(actual-target) {
+		slog.WarnContext(ctx, "再生成後もターン数が改善しなかったため、最初のスクリプトを使用します",
+			slog.Int("turns", actual), slog.Int("retried_turns", retriedTurns), slog.Int("target", target))
+		return scriptText, nil
+	}
+	slog.InfoContext(ctx, "再生成したスクリプトを使用します", slog.Int("turns", retriedTurns), slog.Int("target", target))
+	return retried, nil
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
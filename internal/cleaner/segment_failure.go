@@ -0,0 +1,50 @@
+package cleaner
+
+import (
+	"context"
+	"sort"
+)
+
+// ----------------------------------------------------------------
+// Mapフェーズの部分的な失敗の許容
+// ----------------------------------------------------------------
+
+// DroppedSegment は Map要約に失敗し、Reduce の入力から除外したセグメントです。
+type DroppedSegment struct {
+	Index int    `json:"index"` // セグメントの番号 (1 始まり)
+	Error string `json:"error"` // 失敗の内容
+}
+
+// MapReport は Mapフェーズの処理結果のメタデータです。
+type MapReport struct {
+	Segments int              `json:"segments"`          // 分割したセグメント数
+	Dropped  []DroppedSegment `json:"dropped,omitempty"` // 失敗を許容して、または全体予算の不足で除外したセグメント (セグメントの順)
+}
+
+// failuresTolerated は Map要約に失敗したセグメント数 failed が、MaxFailedSegments と MaxFailedSegmentsRatio の
+// いずれかの許容範囲に収まるかを返します。両方が 0 (デフォルト) の場合は 1 件の失敗も許容しません。
+// 要約を呼び出したセグメント launched がすべて失敗した場合は、Reduce の入力がなくなるため許容しません。
+func (c *Cleaner) failuresTolerated(failed, launched int) bool {
+	if failed == 0 || failed >= launched {
+		return false
+	}
+	if limit := c.config.MaxFailedSegments; limit > 0 && failed <= limit {
+		return true
+	}
+	ratio := c.config.MaxFailedSegmentsRatio
+	return ratio > 0 && float64(failed) <= ratio*float64(launched)
+}
+
+// sortedDropped は除外したセグメントをセグメントの順に並べて返します。
+func sortedDropped(dropped []DroppedSegment) []DroppedSegment {
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i].Index < dropped[j].Index })
+	return dropped
+}
+
+// CleanAndStructureTextWithReport は CleanAndStructureText と同じ処理を行い、Mapフェーズのメタデータ
+// (失敗を許容して除外したセグメントなど) もあわせて返します。
+func (c *Cleaner) CleanAndStructureTextWithReport(ctx context.Context, combinedText string) (string, MapReport, error) {
+	var report MapReport
+	text, err := c.cleanAndStructureText(ctx, combinedText, &report)
+	return text, report, err
+}
@@ -0,0 +1,90 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// ----------------------------------------------------------------
+// シャットダウン (処理キャンセルの伝播)
+// ----------------------------------------------------------------
+
+// ErrClosed は Shutdown / Close 後の Cleaner が呼び出されたことを示します。
+var ErrClosed = errors.New("Cleanerはシャットダウン済みです")
+
+// lifecycle は Cleaner の進行中の処理を追跡し、シャットダウン時にキャンセルを伝播させます。
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+	ctx      context.Context // シャットダウンでキャンセルされるコンテキスト
+	cancel   context.CancelFunc
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// begin は公開メソッドの処理開始時に呼び出し、シャットダウンでもキャンセルされるコンテキストを返します。
+// 返された end は処理終了時に必ず呼び出し、シャットダウンによる中断エラーを ErrClosed に変換します。
+func (l *lifecycle) begin(ctx context.Context) (context.Context, func(error) error, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, nil, ErrClosed
+	}
+	l.inflight.Add(1)
+	l.mu.Unlock()
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(l.ctx, func() { cancel(ErrClosed) })
+
+	end := func(err error) error {
+		if err != nil && errors.Is(context.Cause(runCtx), ErrClosed) {
+			err = fmt.Errorf("%w: %v", ErrClosed, err)
+		}
+		stop()
+		cancel(nil)
+		l.inflight.Done()
+		return err
+	}
+	return runCtx, end, nil
+}
+
+// Shutdown は新規の呼び出しを受け付けなくし、進行中の LLM 呼び出しやリミッター待ちをキャンセルして、
+// それらが終了するまで待機します。ctx が先に終了した場合は ctx.Err() を返します。
+// Shutdown 後の Cleaner の呼び出しはすべて ErrClosed を返します。複数回呼び出しても安全です。
+// なお、LLM クライアント (go-ai-client / genai) は明示的な切断を必要としないため、接続のクローズは行いません。
+func (c *Cleaner) Shutdown(ctx context.Context) error {
+	l := c.lifecycle
+	l.mu.Lock()
+	alreadyClosed := l.closed
+	l.closed = true
+	l.mu.Unlock()
+
+	if !alreadyClosed {
+		slog.InfoContext(ctx, "Cleanerをシャットダウンします。進行中のLLM処理をキャンセルします")
+		l.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("進行中の処理の終了待ちがタイムアウトしました: %w", ctx.Err())
+	}
+}
+
+// Close は Shutdown を待機時間の制限なしで実行します。
+func (c *Cleaner) Close() error {
+	return c.Shutdown(context.Background())
+}
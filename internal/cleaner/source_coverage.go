@@ -0,0 +1,72 @@
+package cleaner
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// ----------------------------------------------------------------
+// ソース番号の引用とソースカバレッジ (CiteSources 有効時)
+// ----------------------------------------------------------------
+
+// citationPattern は要約中のソース番号の引用 (`[1]`、`[1, 3]`、`[1、3]`) に一致します。
+// 連続する `[1][3]` は個別に一致します。直前の空白も除去の対象に含めます。
+var citationPattern = regexp.MustCompile(`[ \t]*\[(\d{1,4}(?:\s*[,、]\s*\d{1,4})*)\]`)
+
+// citationNumberPattern は引用の中の個々のソース番号に一致します。
+var citationNumberPattern = regexp.MustCompile(`\d+`)
+
+// SourceCoverage は要約に引用されたソース文書の集計です。
+// 引用の判定はソース番号 [n] の出現による近似で、実際に内容が反映されたかどうかは検証しません。
+type SourceCoverage struct {
+	Total   int     // ソース文書の数
+	Cited   []int   // 要約に引用されたソース番号 (昇順)
+	Uncited []int   // 要約に引用されなかったソース番号 (昇順)
+	Ratio   float64 // 引用されたソース文書の割合 (0〜1)。Total が 0 の場合は 0
+}
+
+// MeasureSourceCoverage は summary に出現するソース番号 [n] から、total 件のソース文書 (1〜total) の引用状況を集計します。
+// 範囲外の番号は無視します。
+func MeasureSourceCoverage(summary string, total int) SourceCoverage {
+	cited := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(summary, -1) {
+		for _, num := range citationNumberPattern.FindAllString(m[1], -1) {
+			if n, err := strconv.Atoi(num); err == nil && n >= 1 && n <= total {
+				cited[n] = true
+			}
+		}
+	}
+
+	coverage := SourceCoverage{Total: total}
+	for n := 1; n <= total; n++ {
+		if cited[n] {
+			coverage.Cited = append(coverage.Cited, n)
+		} else {
+			coverage.Uncited = append(coverage.Uncited, n)
+		}
+	}
+	if total > 0 {
+		coverage.Ratio = float64(len(coverage.Cited)) / float64(total)
+	}
+	return coverage
+}
+
+// StripSourceCitations はテキストからソース番号の引用 [n] を除去します。
+func StripSourceCitations(text string) string {
+	return citationPattern.ReplaceAllString(text, "")
+}
+
+// withoutCitations はセクションの本文からソース番号の引用を除去した ReduceResult を返します。r が nil の場合は nil を返します。
+func (r *ReduceResult) withoutCitations() *ReduceResult {
+	if r == nil {
+		return nil
+	}
+	stripped := *r
+	stripped.Overview = StripSourceCitations(r.Overview)
+	stripped.Conclusion = StripSourceCitations(r.Conclusion)
+	stripped.Points = make([]string, len(r.Points))
+	for i, point := range r.Points {
+		stripped.Points[i] = StripSourceCitations(point)
+	}
+	return &stripped
+}
@@ -0,0 +1,108 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox/parser"
+)
+
+// ----------------------------------------------------------------
+// 話者バランスの検証
+// ----------------------------------------------------------------
+
+// DefaultSpeakerBalanceThreshold は、1人の話者の発話文字数の占有率がこの値を超えた場合に偏りと判定する閾値です。
+const DefaultSpeakerBalanceThreshold = 0.8
+
+// SpeakerStat は 1 話者分のセリフ数と総文字数です。
+type SpeakerStat struct {
+	Speaker string // 話者タグ (例: "[ずんだもん]")
+	Lines   int    // セリフ (セグメント) 数
+	Chars   int    // 総文字数
+}
+
+// SpeakerBalance はスクリプト全体の話者別集計と偏りの判定結果です。
+type SpeakerBalance struct {
+	Stats           []SpeakerStat // 話者別の集計 (総文字数の多い順)
+	DominantSpeaker string        // 最も多く話している話者
+	DominantShare   float64       // 最も多く話している話者の総文字数の占有率 (0〜1)
+	Imbalanced      bool          // 占有率が閾値を超えているか
+}
+
+// balanceInstruction は偏り検出時の再生成でプロンプト末尾へ追加する指示です。
+const balanceInstruction = "\n\n【重要】前回の出力では %s の発話が全体の %.0f%% を占め、会話が一方的になっていました。" +
+	"**両方の話者がほぼ同じ分量で話す**ように構成し、相槌だけのセリフを続けず、それぞれが内容のある発言をしてください。"
+
+// AnalyzeSpeakerBalance は、スクリプトを VOICEVOX のパーサーでセグメントに分解し、
+// 話者ごとのセリフ数・総文字数を集計します。threshold は偏りと判定する占有率です。
+// 話者が1人しかいない場合も偏りとして扱います。
+func AnalyzeSpeakerBalance(script string, threshold float64) SpeakerBalance {
+	segments, _ := parser.NewParser().Parse(script, "")
+
+	byTag := make(map[string]*SpeakerStat)
+	total := 0
+	for _, seg := range segments {
+		if seg.BaseSpeakerTag == "" {
+			continue
+		}
+		stat, ok := byTag[seg.BaseSpeakerTag]
+		if !ok {
+			stat = &SpeakerStat{Speaker: seg.BaseSpeakerTag}
+			byTag[seg.BaseSpeakerTag] = stat
+		}
+		chars := utf8.RuneCountInString(seg.Text)
+		stat.Lines++
+		stat.Chars += chars
+		total += chars
+	}
+
+	var balance SpeakerBalance
+	for _, stat := range byTag {
+		balance.Stats = append(balance.Stats, *stat)
+	}
+	sort.Slice(balance.Stats, func(i, j int) bool {
+		if balance.Stats[i].Chars != balance.Stats[j].Chars {
+			return balance.Stats[i].Chars > balance.Stats[j].Chars
+		}
+		return balance.Stats[i].Speaker < balance.Stats[j].Speaker
+	})
+	if total == 0 {
+		return balance
+	}
+
+	balance.DominantSpeaker = balance.Stats[0].Speaker
+	balance.DominantShare = float64(balance.Stats[0].Chars) / float64(total)
+	balance.Imbalanced = balance.DominantShare > threshold
+	return balance
+}
+
+// SpeakerBalance は設定された閾値でスクリプトの話者バランスを集計します。
+func (c *Cleaner) SpeakerBalance(script string) SpeakerBalance {
+	return AnalyzeSpeakerBalance(script, c.config.SpeakerBalanceThreshold)
+}
+
+// logSpeakerBalance は話者別の集計をログに出力し、偏りがあれば警告します。
+func logSpeakerBalance(ctx context.Context, balance SpeakerBalance, threshold float64) {
+	attrs := []any{
+		slog.String("dominant_speaker", balance.DominantSpeaker),
+		slog.Float64("dominant_share", round2(balance.DominantShare)),
+	}
+	for _, stat := range balance.Stats {
+		attrs = append(attrs, slog.Group(stat.Speaker, slog.Int("lines", stat.Lines), slog.Int("chars", stat.Chars)))
+	}
+
+	if !balance.Imbalanced {
+		slog.InfoContext(ctx, "スクリプトの話者バランス", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.Float64("threshold", threshold))
+	slog.WarnContext(ctx, "スクリプトの話者バランスに偏りがあります", attrs...)
+}
+
+// balancePromptSuffix は偏りの内容を含めたバランス指示を返します。
+func balancePromptSuffix(balance SpeakerBalance) string {
+	return fmt.Sprintf(balanceInstruction, balance.DominantSpeaker, balance.DominantShare*100)
+}
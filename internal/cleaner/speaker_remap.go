@@ -0,0 +1,90 @@
+package cleaner
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// スクリプトの話者の入れ替え (キャスティングの変更)
+// ----------------------------------------------------------------
+
+// speakerLinePattern はスクリプトの行頭の話者タグ (VOICEVOXパーサーと同じ「[話者][スタイル]」形式) に一致します。
+var speakerLinePattern = regexp.MustCompile(`^(\s*)(\[.+?\])(\s*\[.+?\])`)
+
+// RemapSpeakers はスクリプトの話者タグを mapping (元の話者名 → 新しい話者名) に従って置き換えたスクリプトと、
+// 置き換えた行数を返します。話者名は "ずんだもん" と "[ずんだもん]" のどちらの形式でも指定できます。
+// 置き換えは 1 行ずつ同時に行うため、{"ずんだもん": "めたん", "めたん": "ずんだもん"} のような入れ替えも可能です。
+// スタイルタグ・本文・話者タグのない行はそのまま残し、スクリプトに存在しない元の話者名は無視します。
+func RemapSpeakers(script string, mapping map[string]string) (string, int, error) {
+	tags := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		fromTag, toTag := speakerTag(from), speakerTag(to)
+		if fromTag == "" || toTag == "" {
+			return "", 0, fmt.Errorf("話者のマッピングに空の話者名があります (%q → %q)", from, to)
+		}
+		tags[fromTag] = toTag
+	}
+
+	// VOICEVOXのパーサーで話者を認識できないスクリプトは置き換えの対象外とする
+	speakers := make(map[string]bool)
+	for _, turn := range ParseScript(script) {
+		speakers[turn.Speaker] = true
+	}
+	if len(speakers) == 0 {
+		return "", 0, fmt.Errorf("スクリプトに話者タグ付きの行がありません")
+	}
+	for from := range tags {
+		if !speakers[from] {
+			slog.Debug("スクリプトに存在しない話者のマッピングを無視します", slog.String("speaker", from))
+		}
+	}
+
+	lines := strings.Split(script, "\n")
+	applied := 0
+	for i, line := range lines {
+		m := speakerLinePattern.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		to, ok := tags[line[m[4]:m[5]]]
+		if !ok {
+			continue
+		}
+		lines[i] = line[:m[4]] + to + line[m[5]:]
+		applied++
+	}
+	slog.Info("スクリプトの話者を置き換えました", slog.Int("lines", applied))
+	return strings.Join(lines, "\n"), applied, nil
+}
+
+// ParseSpeakerMapping は "ずんだもん=めたん" 形式の指定を話者のマッピングに変換します。
+func ParseSpeakerMapping(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	mapping := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		from, to, ok := strings.Cut(entry, "=")
+		if !ok || speakerTag(from) == "" || speakerTag(to) == "" {
+			return nil, fmt.Errorf("話者のマッピングの形式が不正です: %q (例: ずんだもん=めたん)", entry)
+		}
+		if _, dup := mapping[speakerTag(from)]; dup {
+			return nil, fmt.Errorf("話者のマッピングで同じ話者が複数回指定されています: %q", from)
+		}
+		mapping[speakerTag(from)] = speakerTag(to)
+	}
+	return mapping, nil
+}
+
+// speakerTag は話者名を "[話者名]" 形式のタグに正規化します。空の場合は空文字列を返します。
+func speakerTag(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(name, "["), "]"))
+	if name == "" {
+		return ""
+	}
+	return "[" + name + "]"
+}
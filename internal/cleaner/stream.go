@@ -0,0 +1,221 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"google.golang.org/genai"
+)
+
+// ----------------------------------------------------------------
+// スクリプト生成のストリーミング
+// ----------------------------------------------------------------
+
+// StreamClient はテキストを逐次生成する LLM クライアントです。
+// go-ai-client はストリーミング生成に対応していないため、genai SDK を直接利用して実装します。
+type StreamClient interface {
+	GenerateContentStream(ctx context.Context, prompt string, model string) iter.Seq2[string, error]
+}
+
+// geminiStreamClient は genai SDK の GenerateContentStream をラップした StreamClient です。
+type geminiStreamClient struct {
+	client      *genai.Client
+	temperature float32
+}
+
+// NewGeminiStreamClientFromEnv は環境変数 (GEMINI_API_KEY / GOOGLE_API_KEY) の APIキーで StreamClient を生成します。
+func NewGeminiStreamClientFromEnv(ctx context.Context) (StreamClient, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("GOOGLE_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY または GOOGLE_API_KEY 環境変数が設定されていません")
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: apiKey})
+	if err != nil {
+		return nil, fmt.Errorf("ストリーミング用 Gemini クライアントの生成に失敗しました: %w", err)
+	}
+	return &geminiStreamClient{client: client, temperature: gemini.DefaultTemperature}, nil
+}
+
+// GenerateContentStream はレスポンスのテキスト断片を受信順に返します。
+func (g *geminiStreamClient) GenerateContentStream(ctx context.Context, prompt string, model string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		contents := []*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)}
+		config := &genai.GenerateContentConfig{Temperature: &g.temperature}
+		for resp, err := range g.client.Models.GenerateContentStream(ctx, model, contents, config) {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if !yield(resp.Text(), nil) {
+				return
+			}
+		}
+	}
+}
+
+// SetStreamClient はスクリプト生成のストリーミングに使用するクライアントを設定します。
+func (c *Cleaner) SetStreamClient(stream StreamClient) {
+	c.stream = stream
+}
+
+// ScriptStream はストリーミング中のスクリプト生成です。
+// Chunks() で表示用の断片を受け取り、Wait() で確定したスクリプトを取得します。
+type ScriptStream struct {
+	chunks chan string
+	done   chan struct{}
+	script string
+	err    error
+}
+
+// Chunks は表示用のスクリプト断片 (行単位) を返します。生成が終わるとクローズされます。
+// 断片は SCRIPT_START / SCRIPT_END マーカーの内側のみで、NGワードフィルタ適用済みです。
+func (s *ScriptStream) Chunks() <-chan string {
+	return s.chunks
+}
+
+// Wait は生成の完了を待ち、全体を蓄積したレスポンスからマーカー抽出と NGワードフィルタを行ったスクリプトを返します。
+// 断片の送信はブロックするため、Chunks() を読み切ってから呼び出してください。
+func (s *ScriptStream) Wait() (string, error) {
+	<-s.done
+	return s.script, s.err
+}
+
+// GenerateScriptStream は GenerateScriptForVoicevox のストリーミング版です。
+// レスポンス全体を蓄積しつつ、マーカー内のスクリプトを完成した行から順に Chunks() へ流します。
+// 表示済みの出力は取り消せないため、言語ガードと話者バランスによる再生成は行いません (偏りの警告のみ)。
+func (c *Cleaner) GenerateScriptStream(ctx context.Context, title string, finalSummary string, structure *ReduceResult) (*ScriptStream, error) {
+	if c.stream == nil {
+		return nil, fmt.Errorf("ストリーミング用クライアントが設定されていません")
+	}
+	prompt, err := c.buildScriptPrompt(title, finalSummary, structure)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cost.reserve("script"); err != nil {
+		return nil, err
+	}
+	// ストリーム終了までを進行中の処理として扱い、シャットダウン時はキャンセルする
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Script Generation（スクリプト作成）をストリーミングで開始します。", slog.Bool("structured", structure != nil))
+
+	s := &ScriptStream{chunks: make(chan string), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer func() { s.err = end(s.err) }()
+		defer close(s.chunks)
+
+		var response strings.Builder
+		filter := &scriptLineFilter{}
+		for text, err := range c.stream.GenerateContentStream(ctx, prompt, c.config.ScriptModel) {
+			if err != nil {
+				s.err = fmt.Errorf("LLM Script Generation処理 (ストリーミング) に失敗しました: %w", err)
+				return
+			}
+			response.WriteString(text)
+			for _, line := range filter.push(text) {
+				c.emitScriptLine(ctx, s.chunks, line)
+			}
+		}
+		for _, line := range filter.flush() {
+			c.emitScriptLine(ctx, s.chunks, line)
+		}
+
+		responseText := response.String()
+		c.cost.add(ctx, "script", c.config.ScriptModel, prompt, responseText)
+		responseText = c.stripReasoning(ctx, "script", responseText)
+		scriptText := ExtractTextBetweenTags(responseText, "SCRIPT_START", "SCRIPT_END")
+		if scriptText == "" {
+			slog.WarnContext(ctx, "指定されたスクリプトマーカーが見つからないか、形式が不正です。LLMのレスポンス全体をスクリプトとして使用します。")
+			scriptText = responseText
+		}
+		logSpeakerBalance(ctx, c.SpeakerBalance(scriptText), c.config.SpeakerBalanceThreshold)
+		s.script, s.err = c.finishScript(ctx, scriptText)
+	}()
+	return s, nil
+}
+
+// emitScriptLine は NGワードを置換した 1 行を送信します。
+func (c *Cleaner) emitScriptLine(ctx context.Context, out chan<- string, line string) {
+	if len(c.config.NGWords) > 0 {
+		line, _ = FilterScript(line, c.config.NGWords, c.config.NGReplacement)
+	}
+	select {
+	case out <- line + "\n":
+	case <-ctx.Done():
+	}
+}
+
+// scriptLineFilter はストリームを行単位に区切り、SCRIPT_START / SCRIPT_END マーカーの内側の行だけを返します。
+// マーカーが最後まで現れなかった場合は、flush で蓄積した全行を返します (非ストリーミング時のフォールバックと同じ扱い)。
+type scriptLineFilter struct {
+	pending  string   // 改行で終わっていない末尾
+	held     []string // 開始マーカー出現前の行 (フォールバック用)
+	started  bool
+	finished bool
+}
+
+func (f *scriptLineFilter) push(text string) []string {
+	f.pending += text
+	var out []string
+	for {
+		i := strings.IndexByte(f.pending, '\n')
+		if i < 0 {
+			return out
+		}
+		line := f.pending[:i]
+		f.pending = f.pending[i+1:]
+		out = append(out, f.line(line)...)
+	}
+}
+
+func (f *scriptLineFilter) flush() []string {
+	var out []string
+	if f.pending != "" {
+		out = f.line(f.pending)
+		f.pending = ""
+	}
+	if !f.started {
+		out = append(out, f.held...)
+		f.held = nil
+	}
+	return out
+}
+
+func (f *scriptLineFilter) line(line string) []string {
+	const startMarker = "<SCRIPT_START>"
+	if f.finished {
+		return nil
+	}
+	if !f.started {
+		i := strings.Index(line, startMarker)
+		if i < 0 {
+			f.held = append(f.held, line)
+			return nil
+		}
+		f.started = true
+		f.held = nil
+		line = line[i+len(startMarker):]
+	}
+	for _, endMarker := range []string{"</SCRIPT_END>", "<SCRIPT_END>"} {
+		if i := strings.Index(line, endMarker); i >= 0 {
+			f.finished = true
+			line = line[:i]
+			break
+		}
+	}
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	return []string{line}
+}
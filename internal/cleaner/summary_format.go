@@ -0,0 +1,65 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// 最終要約の出力形式 (箇条書き / 散文)
+// ----------------------------------------------------------------
+
+// SummaryFormat は最終要約の出力形式です。
+type SummaryFormat string
+
+const (
+	// SummaryFormatProse は読み物的な散文で最終要約を出力させます (従来の形式)。
+	SummaryFormatProse SummaryFormat = "prose"
+	// SummaryFormatBullet は要点の箇条書きで最終要約を出力させます。
+	SummaryFormatBullet SummaryFormat = "bullet"
+	// SummaryFormatBoth は散文と箇条書きの両方を生成します (呼び出しが 1 回増えます)。
+	// 散文は最終要約としてスクリプト生成に、箇条書きはメール・RSS などのテキスト向けの出力に使用します。
+	SummaryFormatBoth SummaryFormat = "both"
+
+	// DefaultSummaryFormat は最終要約のデフォルトの出力形式です。
+	DefaultSummaryFormat = SummaryFormatProse
+)
+
+// ParseSummaryFormat は文字列を SummaryFormat に変換します。空文字列の場合はデフォルトを返します。
+func ParseSummaryFormat(s string) (SummaryFormat, error) {
+	switch f := SummaryFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case SummaryFormatProse, SummaryFormatBullet, SummaryFormatBoth:
+		return f, nil
+	case "":
+		return DefaultSummaryFormat, nil
+	default:
+		return "", fmt.Errorf("不明な最終要約の出力形式です: %q (prose, bullet, both のいずれかを指定してください)", s)
+	}
+}
+
+// SummaryFormat は最終要約の出力形式 (CleanerConfig.SummaryFormat) を返します。
+func (c *Cleaner) SummaryFormat() SummaryFormat {
+	return c.config.SummaryFormat
+}
+
+// primarySummaryFormat は GenerateFinalSummary で生成する最終要約の形式です。
+// 両方を生成する場合、最終要約 (スクリプト生成の入力) は散文です。
+func (c *Cleaner) primarySummaryFormat() SummaryFormat {
+	if c.config.SummaryFormat == SummaryFormatBoth {
+		return SummaryFormatProse
+	}
+	return c.config.SummaryFormat
+}
+
+// GenerateBulletSummary は中間統合要約を元に、箇条書きの最終要約を生成します。
+// SummaryFormat が both の場合に、GenerateFinalSummary の散文とは別にテキスト向けの要約として使用します。
+func (c *Cleaner) GenerateBulletSummary(ctx context.Context, title string, intermediateSummary string) (_ string, err error) {
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = end(err) }()
+
+	return c.generateFinalSummary(ctx, title, intermediateSummary, SummaryFormatBullet)
+}
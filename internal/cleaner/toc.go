@@ -0,0 +1,123 @@
+package cleaner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ----------------------------------------------------------------
+// 目次 (Table of Contents) の生成
+// ----------------------------------------------------------------
+
+const (
+	// MinTOCEntries は目次を生成するのに必要な見出しの最小数です。これ未満の場合は目次を省略します。
+	MinTOCEntries = 3
+	// tocMinLevel / tocMaxLevel は目次に含める見出しレベルの範囲です (# の文書タイトルは含めない)。
+	tocMinLevel = 2
+	tocMaxLevel = 4
+)
+
+// TOCEntry は目次の 1 項目です。
+type TOCEntry struct {
+	Level  int    `json:"level"`  // 見出しレベル (## なら 2)
+	Title  string `json:"title"`  // 見出しテキスト
+	Anchor string `json:"anchor"` // 見出しへのリンク用アンカー (# を含まない)
+}
+
+// headingPattern は ATX 形式の Markdown 見出し行に一致します。
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// GenerateTableOfContents は Reduce結果などの Markdown から見出しを抽出し、アンカー付きの目次を構築します。
+// コードブロック内の行は無視し、アンカーは GitHub 形式 (小文字化・記号除去・空白をハイフン化) で重複時は連番を付けます。
+// 見出しが MinTOCEntries 未満の場合は nil を返します。
+func GenerateTableOfContents(reduceResult string) []TOCEntry {
+	return NewTOCGenerator().Generate(reduceResult)
+}
+
+// TOCGenerator は、1 つの文書を複数回に分けて出力する場合に、アンカーの重複採番を文書全体で引き継ぐための目次生成器です。
+type TOCGenerator struct {
+	used map[string]int
+}
+
+// NewTOCGenerator は新しい TOCGenerator を生成します。
+func NewTOCGenerator() *TOCGenerator {
+	return &TOCGenerator{used: make(map[string]int)}
+}
+
+// Generate は markdown の見出しから目次を構築します。これまでに Generate した見出しのアンカーと重複しないよう採番します。
+// 見出しが MinTOCEntries 未満の場合は nil を返します。
+func (g *TOCGenerator) Generate(markdown string) []TOCEntry {
+	var entries []TOCEntry
+	inCodeBlock := false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		m := headingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		level := len(m[1])
+		title := strings.TrimSpace(m[2])
+		anchor := headingAnchor(title)
+		// アンカーは目次に含めない見出しも含めて採番する (GitHub のレンダリングと一致させるため)
+		if n := g.used[anchor]; n > 0 {
+			g.used[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			g.used[anchor] = 1
+		}
+		if level < tocMinLevel || level > tocMaxLevel {
+			continue
+		}
+		entries = append(entries, TOCEntry{Level: level, Title: title, Anchor: anchor})
+	}
+
+	if len(entries) < MinTOCEntries {
+		return nil
+	}
+	return entries
+}
+
+// RenderTableOfContents は目次を Markdown のリンク付きリストとして出力します。
+// entries が空の場合は空文字列を返します。
+func RenderTableOfContents(entries []TOCEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	// 最も浅い見出しレベルを基準にインデントする
+	minLevel := entries[0].Level
+	for _, entry := range entries {
+		minLevel = min(minLevel, entry.Level)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**目次**\n\n")
+	for _, entry := range entries {
+		indent := strings.Repeat("  ", entry.Level-minLevel)
+		sb.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", indent, entry.Title, entry.Anchor))
+	}
+	return sb.String()
+}
+
+// headingAnchor は見出しテキストから GitHub 形式のアンカーを生成します。
+func headingAnchor(title string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,135 @@
+package cleaner
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// トークン数の予算によるセグメント分割
+// ----------------------------------------------------------------
+
+// DefaultMaxSegmentTokens は Mapフェーズの 1 セグメントあたりの推定トークン数の上限のデフォルトです。
+// プロンプトのテンプレートと Map要約の出力の分を残すため、モデルの入力上限より十分小さくしています。
+const DefaultMaxSegmentTokens = 200000
+
+// TokenCounter はテキストのトークン数を数えます。
+// セグメント分割では先頭からの部分文字列のトークン数が長さに対して単調に増えることを前提とします。
+type TokenCounter interface {
+	CountTokens(text string) int
+}
+
+// TokenCounterFunc は関数を TokenCounter として使用するためのアダプターです。
+type TokenCounterFunc func(text string) int
+
+// CountTokens は f(text) を返します。
+func (f TokenCounterFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// DefaultTokenCounter は EstimateTokens (ASCII は 4 文字で 1 トークン、日本語などは 1 文字 1 トークン) による
+// ヒューリスティックな TokenCounter を返します。
+func DefaultTokenCounter() TokenCounter {
+	return TokenCounterFunc(EstimateTokens)
+}
+
+// splitIntoSegments は Mapフェーズのためにテキストを分割します。
+// まず MaxSegmentChars の文字数で分割し (フォールバック)、推定トークン数が MaxSegmentTokens を超えるセグメントは
+// 予算に収まる最長の範囲の中で、segmentText と同じ優先順の区切り (文書区切り・段落・句読点) でさらに分割します。
+// SegmentPerArticle が有効な場合は、記事ごとに分けてから同じ方法で分割します (splitPerArticle)。
+func (c *Cleaner) splitIntoSegments(text string) []string {
+	if c.config.SegmentPerArticle {
+		return c.splitPerArticle(text)
+	}
+	return c.splitBySize(text)
+}
+
+// splitPerArticle は text を文書区切り (ContentSeparator) ごとの記事に分け、記事ごとに splitBySize で分割します。
+// 上限に収まる記事は 1 セグメントとなり、複数の記事の内容が 1 つの Map要約に混ざることはありません。
+// 長い記事を分割した各セグメントには、出典が分かるよう記事の見出し (SOURCE DOCUMENT・TITLE・URL の行) を
+// 先頭に付けます (見出しの分だけセグメントの上限をわずかに超えることがあります)。
+func (c *Cleaner) splitPerArticle(text string) []string {
+	var segments []string
+	articles, split := 0, 0
+	for article := range strings.SplitSeq(text, ContentSeparator) {
+		if strings.TrimSpace(article) == "" {
+			continue
+		}
+		articles++
+		// 見出しだけのセグメントができないよう、本文のみを分割して各セグメントに見出しを付ける
+		header := sourceHeader(article)
+		parts := c.splitBySize(article[len(header):])
+		if len(parts) > 1 {
+			split++
+		}
+		for _, part := range parts {
+			segments = append(segments, header+part)
+		}
+	}
+	if c.config.Verbose {
+		slog.Debug("記事ごとにセグメントを分割しました",
+			slog.Int("articles", articles),
+			slog.Int("split_articles", split),
+			slog.Int("segments", len(segments)),
+		)
+	}
+	return segments
+}
+
+// sourceHeader は CombineContents が記事の先頭に付ける見出し (空行まで) を返します。見出しがない場合は "" を返します。
+func sourceHeader(article string) string {
+	if !strings.HasPrefix(article, "--- SOURCE DOCUMENT ") {
+		return ""
+	}
+	header, _, ok := strings.Cut(article, "\n\n")
+	if !ok {
+		return ""
+	}
+	return header + "\n\n"
+}
+
+// splitBySize は text を MaxSegmentChars の文字数と MaxSegmentTokens の推定トークン数に収まるように分割します。
+func (c *Cleaner) splitBySize(text string) []string {
+	var segments []string
+	for _, seg := range c.segmentText(text, MaxSegmentChars) {
+		segments = append(segments, c.segmentByTokens(seg)...)
+	}
+	return segments
+}
+
+// segmentByTokens は text を推定トークン数が MaxSegmentTokens 以下のセグメントに分割します。
+func (c *Cleaner) segmentByTokens(text string) []string {
+	counter, budget := c.config.TokenCounter, c.config.MaxSegmentTokens
+
+	var segments []string
+	rest := text
+	for rest != "" {
+		if counter.CountTokens(rest) <= budget {
+			segments = append(segments, rest)
+			break
+		}
+		maxChars := maxCharsWithinTokens(counter, []rune(rest), budget)
+		head := []rune(c.segmentText(rest, maxChars)[0])
+		segments = append(segments, string(head))
+		// 重なり (SegmentOverlapChars) の分だけ戻った位置から次のセグメントを開始する
+		rest = rest[len(string(head[:len(head)-c.segmentOverlap(len(head))])):]
+	}
+
+	if len(segments) > 1 && c.config.Verbose {
+		slog.Debug("トークン数の予算に合わせてセグメントを分割しました",
+			slog.Int("segments", len(segments)),
+			slog.Int("max_tokens", budget),
+		)
+	}
+	return segments
+}
+
+// maxCharsWithinTokens は runes の先頭から推定トークン数が budget 以下に収まる最大の文字数を二分探索で返します。
+// 1 文字で予算を超える場合も分割が進むよう、最小値は 1 です。
+func maxCharsWithinTokens(counter TokenCounter, runes []rune, budget int) int {
+	n := sort.Search(len(runes), func(i int) bool {
+		return counter.CountTokens(string(runes[:i+1])) > budget
+	})
+	return max(n, 1)
+}
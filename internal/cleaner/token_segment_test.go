@@ -0,0 +1,126 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// unusedModel はセグメント分割のテストで Cleaner の生成にだけ使う gemini.GenerativeModel です。
+// セグメント分割は LLM を呼び出さないため、呼び出された場合はエラーを返します。
+type unusedModel struct{}
+
+func (unusedModel) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	return nil, fmt.Errorf("セグメント分割のテストで LLM が呼び出されました")
+}
+
+// newSegmentTestCleaner はセグメント分割のテスト用の Cleaner を生成します。
+func newSegmentTestCleaner(t *testing.T, config CleanerConfig) *Cleaner {
+	t.Helper()
+	c, err := NewCleaner(unusedModel{}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSegmentByTokensStaysWithinBudget(t *testing.T) {
+	mixed := strings.Repeat("Go 1.25 では iterator と range-over-func が安定し、VOICEVOX engine との連携も改善されました。", 40)
+	dense := strings.Repeat("日本語漢字仮名交文章区切無連続", 200)
+	english := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 120)
+	tests := []struct {
+		name    string
+		text    string
+		budget  int
+		overlap int
+	}{
+		{"mixed japanese and english", mixed, 100, 0},
+		{"mixed with overlap", mixed, 100, 30},
+		{"cjk dense without separators", dense, 64, 0},
+		{"cjk dense with overlap", dense, 64, 20},
+		{"english only", english, 50, 0},
+		{"budget of one token", "日本語とEnglish", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSegmentTestCleaner(t, CleanerConfig{MaxSegmentTokens: tt.budget, SegmentOverlapChars: tt.overlap})
+			segments := c.segmentByTokens(tt.text)
+			if len(segments) < 2 {
+				t.Fatalf("セグメント数 = %d, 分割されることを期待", len(segments))
+			}
+			for i, seg := range segments {
+				if seg == "" {
+					t.Errorf("segments[%d] が空です", i)
+				}
+				if tokens := EstimateTokens(seg); tokens > tt.budget {
+					t.Errorf("segments[%d] の推定トークン数 = %d, 上限 %d を超えています", i, tokens, tt.budget)
+				}
+			}
+			if tt.overlap == 0 {
+				if got := strings.Join(segments, ""); got != tt.text {
+					t.Errorf("重なりなしのセグメントを連結しても元のテキストに戻りません")
+				}
+			}
+		})
+	}
+}
+
+func TestSplitIntoSegmentsRespectsMaxSegmentTokens(t *testing.T) {
+	text := strings.Repeat("Gemini API の rate limit は 1 分あたり 60 requests です。Map フェーズでは segment ごとに呼び出します。\n\n", 80)
+	const budget = 120
+	c := newSegmentTestCleaner(t, CleanerConfig{MaxSegmentTokens: budget})
+	segments := c.splitIntoSegments(text)
+	if len(segments) < 2 {
+		t.Fatalf("セグメント数 = %d, 分割されることを期待", len(segments))
+	}
+	for i, seg := range segments {
+		if tokens := EstimateTokens(seg); tokens > budget {
+			t.Errorf("segments[%d] の推定トークン数 = %d, MaxSegmentTokens %d を超えています", i, tokens, budget)
+		}
+	}
+}
+
+func TestSplitPerArticleKeepsArticleBoundaries(t *testing.T) {
+	results := []types.URLResult{
+		{URL: "https://example.com/a", Content: "記事Aの本文です。ARTICLE-A の内容。"},
+		{URL: "https://example.com/b", Content: strings.Repeat("記事Bは長い本文です。ARTICLE-B の内容が続きます。\n\n", 30)},
+		{URL: "https://example.com/c", Content: "Article C body. ARTICLE-C mixed 日本語。"},
+	}
+	titles := map[string]string{"https://example.com/a": "記事A", "https://example.com/b": "記事B", "https://example.com/c": "記事C"}
+	text := CombineContents(results, titles)
+
+	const budget = 200
+	c := newSegmentTestCleaner(t, CleanerConfig{MaxSegmentTokens: budget, SegmentPerArticle: true})
+	segments := c.splitIntoSegments(text)
+
+	counts := map[string]int{}
+	for i, seg := range segments {
+		var found []string
+		for _, id := range []string{"ARTICLE-A", "ARTICLE-B", "ARTICLE-C"} {
+			if strings.Contains(seg, id) {
+				found = append(found, id)
+			}
+		}
+		if len(found) != 1 {
+			t.Fatalf("segments[%d] は 1 つの記事のみを含むことを期待しましたが %v を含みます:\n%s", i, found, seg)
+		}
+		counts[found[0]]++
+		if !strings.HasPrefix(seg, "--- SOURCE DOCUMENT ") {
+			t.Errorf("segments[%d] が記事の見出しで始まっていません:\n%s", i, seg)
+		}
+		header := sourceHeader(seg)
+		if tokens := EstimateTokens(seg[len(header):]); tokens > budget {
+			t.Errorf("segments[%d] の本文の推定トークン数 = %d, 上限 %d を超えています", i, tokens, budget)
+		}
+	}
+	if counts["ARTICLE-A"] != 1 || counts["ARTICLE-C"] != 1 {
+		t.Errorf("上限に収まる記事は 1 セグメントとなることを期待: %v", counts)
+	}
+	if counts["ARTICLE-B"] < 2 {
+		t.Errorf("長い記事 B は複数のセグメントに分割されることを期待: %v", counts)
+	}
+}
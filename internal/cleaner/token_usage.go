@@ -0,0 +1,130 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// トークン使用量の集計と単価表
+// ----------------------------------------------------------------
+
+// go-ai-client のレスポンス (gemini.Response) は使用量のメタデータを含まないため、
+// トークン数はプロンプトとレスポンスのテキストから EstimateTokens で概算します。
+
+// PhaseUsage は 1 フェーズの LLM 呼び出しのトークン数と推定コストです。
+type PhaseUsage struct {
+	Phase          string  `json:"phase"`           // map, reduce, summary, script, topic, importance
+	Calls          int     `json:"calls"`           // 成功した呼び出し回数
+	PromptTokens   int     `json:"prompt_tokens"`   // プロンプトの推定トークン数の合計
+	ResponseTokens int     `json:"response_tokens"` // レスポンスの推定トークン数の合計
+	CostUSD        float64 `json:"cost_usd"`        // 推定コスト (USD)
+}
+
+// TokenUsage は Cleaner の LLM 呼び出し全体のトークン数と推定コストです。
+type TokenUsage struct {
+	Phases         []PhaseUsage `json:"phases"` // フェーズの処理順
+	Calls          int          `json:"calls"`
+	PromptTokens   int          `json:"prompt_tokens"`
+	ResponseTokens int          `json:"response_tokens"`
+	CostUSD        float64      `json:"cost_usd"`
+}
+
+// usagePhaseOrder は TokenUsage.Phases の並び順です。ここにないフェーズは末尾に名前順で並べます。
+var usagePhaseOrder = []string{"importance", PhaseMap, PhaseReduce, PhaseSummary, PhaseScript, PhaseTopic}
+
+// usage は集計したトークン数と推定コストを返します。
+func (t *costTracker) usage() TokenUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := TokenUsage{Calls: t.calls, CostUSD: t.totalUSD}
+	for _, phase := range t.phases {
+		usage.Phases = append(usage.Phases, *phase)
+		usage.PromptTokens += phase.PromptTokens
+		usage.ResponseTokens += phase.ResponseTokens
+	}
+	slices.SortFunc(usage.Phases, func(a, b PhaseUsage) int {
+		ai, bi := slices.Index(usagePhaseOrder, a.Phase), slices.Index(usagePhaseOrder, b.Phase)
+		if ai < 0 {
+			ai = len(usagePhaseOrder)
+		}
+		if bi < 0 {
+			bi = len(usagePhaseOrder)
+		}
+		if ai != bi {
+			return ai - bi
+		}
+		return strings.Compare(a.Phase, b.Phase)
+	})
+	return usage
+}
+
+// TokenUsage は、これまでの LLM 呼び出しのフェーズ別のトークン数と推定コストを返します。
+func (c *Cleaner) TokenUsage() TokenUsage {
+	return c.cost.usage()
+}
+
+// LogTokenUsage はトークン数と推定コストを、合計とフェーズごとに INFO レベルで出力します。
+func LogTokenUsage(ctx context.Context, usage TokenUsage) {
+	slog.InfoContext(ctx, "LLMのトークン使用量 (推定)",
+		slog.Int("calls", usage.Calls),
+		slog.Int("prompt_tokens", usage.PromptTokens),
+		slog.Int("response_tokens", usage.ResponseTokens),
+		slog.Float64("cost_usd", usage.CostUSD),
+	)
+	for _, phase := range usage.Phases {
+		slog.InfoContext(ctx, "フェーズ別のトークン使用量 (推定)",
+			slog.String("phase", phase.Phase),
+			slog.Int("calls", phase.Calls),
+			slog.Int("prompt_tokens", phase.PromptTokens),
+			slog.Int("response_tokens", phase.ResponseTokens),
+			slog.Float64("cost_usd", phase.CostUSD),
+		)
+	}
+}
+
+// pricingEntry は単価表ファイルの 1 モデル分の 1000 トークンあたりの単価 (USD) です。
+type pricingEntry struct {
+	InputPer1K  *float64 `json:"input_per_1k"`
+	OutputPer1K *float64 `json:"output_per_1k"`
+}
+
+// LoadPricingTable はモデル名 (前方一致) をキーとする 1000 トークンあたりの単価の JSON ファイル
+// ({"gemini-2.5-flash": {"input_per_1k": 0.0003, "output_per_1k": 0.0025}}) を読み込み、
+// CleanerConfig.Pricing に設定する単価表を返します。
+func LoadPricingTable(path string) (map[string]ModelPricing, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("単価表ファイルの読み込みに失敗しました: %w", err)
+	}
+	var decoded map[string]pricingEntry
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("単価表ファイルの解析に失敗しました (%s): %w", path, err)
+	}
+
+	table := make(map[string]ModelPricing, len(decoded))
+	var errs []error
+	for model, entry := range decoded {
+		model = strings.TrimSpace(model)
+		switch {
+		case model == "":
+			errs = append(errs, fmt.Errorf("モデル名が空です"))
+		case entry.InputPer1K == nil || entry.OutputPer1K == nil:
+			errs = append(errs, fmt.Errorf("モデル %q: input_per_1k と output_per_1k の両方を指定してください", model))
+		case *entry.InputPer1K < 0 || *entry.OutputPer1K < 0:
+			errs = append(errs, fmt.Errorf("モデル %q: 単価に負の値は指定できません", model))
+		default:
+			table[model] = ModelPricing{InputPerMTok: *entry.InputPer1K * 1000, OutputPerMTok: *entry.OutputPer1K * 1000}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("単価表ファイルが不正です (%s): %w", path, err)
+	}
+	return table, nil
+}
@@ -0,0 +1,170 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"act-feed-clean-go/prompts"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------
+// トピック分類
+// ----------------------------------------------------------------
+
+// TopicGranularity はトピック分類の粒度です。
+type TopicGranularity string
+
+const (
+	TopicGranularityCoarse TopicGranularity = "coarse" // 大分類 (業界・分野単位)
+	TopicGranularityMedium TopicGranularity = "medium" // 中分類 (テーマ単位)
+	TopicGranularityFine   TopicGranularity = "fine"   // 小分類 (個別の出来事単位)
+)
+
+const (
+	// DefaultTopicGranularity はトピック分類のデフォルト粒度です。
+	DefaultTopicGranularity = TopicGranularityMedium
+	// DefaultMaxTopics はトピック分類で生成するトピック数の上限です。
+	DefaultMaxTopics = 8
+	// FallbackTopic は分類できなかった記事に割り当てるトピック名です。
+	FallbackTopic = "その他"
+	// topicExcerptChars は分類プロンプトに含める本文抜粋の最大文字数です。
+	topicExcerptChars = 300
+)
+
+// TopicGroup は同一トピックに分類された記事のまとまりです。
+type TopicGroup struct {
+	Topic string
+	URLs  []string
+}
+
+// topicLinePattern は分類結果の `記事番号: トピック名` 行に一致します。
+var topicLinePattern = regexp.MustCompile(`^\s*(\d+)\s*[:：]\s*(.+?)\s*$`)
+
+// ClassifyTopics は、記事をLLMでトピック分類し、トピックごとのグループを返します。
+// グループは最初に出現した記事の順に並び、分類されなかった記事は FallbackTopic に入ります。
+// 分類には軽量な Mapフェーズのモデルを使用します。
+func (c *Cleaner) ClassifyTopics(ctx context.Context, results []types.URLResult, titlesMap map[string]string) (_ []TopicGroup, err error) {
+	ctx, end, err := c.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = end(err) }()
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	articles := articleExcerpts(results, titlesMap)
+	prompt, err := c.prompt.TopicBuilder.BuildTopic(prompts.TopicTemplateData{
+		Granularity: c.config.TopicGranularity.instruction(),
+		MaxTopics:   c.config.MaxTopics,
+		Articles:    articles,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Topic プロンプトの生成に失敗しました: %w", err)
+	}
+
+	slog.InfoContext(ctx, "トピック分類を開始します",
+		slog.Int("articles", len(articles)),
+		slog.String("granularity", string(c.config.TopicGranularity)),
+	)
+	response, err := c.generate(ctx, "topic", prompt, c.config.MapModel)
+	if err != nil {
+		return nil, fmt.Errorf("LLM トピック分類処理に失敗しました: %w", err)
+	}
+	text, err := c.postProcess(PhaseTopic, response.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := groupByTopic(text, results)
+	slog.InfoContext(ctx, "トピック分類が完了しました", slog.Int("topics", len(groups)))
+	return groups, nil
+}
+
+// articleExcerpts は記事ごとに 1 始まりの番号・タイトル・本文の抜粋を付けた、プロンプト用の記事一覧を返します。
+// タイトルがない記事は URL をタイトルとして使用します。
+func articleExcerpts(results []types.URLResult, titlesMap map[string]string) []prompts.TopicArticle {
+	articles := make([]prompts.TopicArticle, 0, len(results))
+	for i, res := range results {
+		title := titlesMap[res.URL]
+		if title == "" {
+			title = res.URL
+		}
+		excerpt := []rune(strings.Join(strings.Fields(res.Content), " "))
+		articles = append(articles, prompts.TopicArticle{
+			Index:   i + 1,
+			Title:   title,
+			Excerpt: string(excerpt[:min(len(excerpt), topicExcerptChars)]),
+		})
+	}
+	return articles
+}
+
+// groupByTopic は分類結果のテキストを解析し、results をトピックごとにグループ化します。
+func groupByTopic(text string, results []types.URLResult) []TopicGroup {
+	body := ExtractTextBetweenTags(text, "TOPICS_START", "TOPICS_END")
+	if body == "" {
+		body = text
+	}
+
+	assigned := make(map[int]string)
+	for _, line := range strings.Split(body, "\n") {
+		m := topicLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		index, err := strconv.Atoi(m[1])
+		if err != nil || index < 1 || index > len(results) {
+			continue
+		}
+		assigned[index] = m[2]
+	}
+
+	var groups []TopicGroup
+	position := make(map[string]int)
+	for i, res := range results {
+		topic, ok := assigned[i+1]
+		if !ok {
+			topic = FallbackTopic
+		}
+		pos, exists := position[topic]
+		if !exists {
+			pos = len(groups)
+			position[topic] = pos
+			groups = append(groups, TopicGroup{Topic: topic})
+		}
+		groups[pos].URLs = append(groups[pos].URLs, res.URL)
+	}
+	return groups
+}
+
+// instruction は粒度に対応するプロンプト用の指示文を返します。
+func (g TopicGranularity) instruction() string {
+	switch g {
+	case TopicGranularityCoarse:
+		return "分野・業界単位の**大まかな分類**にしてください（例: 「AI」「セキュリティ」「半導体」）。"
+	case TopicGranularityFine:
+		return "個別の出来事・発表単位の**細かい分類**にしてください。同じ出来事を報じた記事だけを同一トピックにまとめます。"
+	default:
+		return "関連するテーマ単位の**標準的な分類**にしてください（例: 「生成AIの規制動向」「スマートフォン新製品」）。"
+	}
+}
+
+// ParseTopicGranularity は文字列を TopicGranularity に変換します。
+func ParseTopicGranularity(s string) (TopicGranularity, error) {
+	switch g := TopicGranularity(strings.ToLower(strings.TrimSpace(s))); g {
+	case TopicGranularityCoarse, TopicGranularityMedium, TopicGranularityFine:
+		return g, nil
+	case "":
+		return DefaultTopicGranularity, nil
+	default:
+		return "", fmt.Errorf("不明なトピック分類の粒度です: %q (coarse, medium, fine のいずれかを指定してください)", s)
+	}
+}
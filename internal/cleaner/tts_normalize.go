@@ -0,0 +1,357 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ----------------------------------------------------------------
+// 読み上げ用のテキスト正規化 (数値・単位・英略語)
+// ----------------------------------------------------------------
+
+// TTSDictionary は NormalizeForTTS の変換ルールです。
+type TTSDictionary struct {
+	// Units は数値の直後に続く単位の読みです (例: "km/h" → "キロメートル毎時")。数値に続かない場合は変換しません。
+	Units map[string]string `json:"units"`
+	// Abbreviations は英略語の読みです (例: "CEO" → "シーイーオー")。前後が英数字でない、単独の語のみを変換します。
+	Abbreviations map[string]string `json:"abbreviations"`
+	// Numbers が true の場合、数値をひらがなの読みに変換します (例: 100 → ひゃく)。
+	// 英字・記号と連続する数値 (型番・バージョン・日付・時刻など) は変換しません。
+	Numbers bool `json:"numbers"`
+}
+
+// DefaultTTSDictionary は組み込みの変換ルールを返します。
+func DefaultTTSDictionary() TTSDictionary {
+	return TTSDictionary{
+		Units: map[string]string{
+			"km/h": "キロメートル毎時",
+			"km":   "キロメートル",
+			"cm":   "センチメートル",
+			"mm":   "ミリメートル",
+			"kg":   "キログラム",
+			"mg":   "ミリグラム",
+			"ms":   "ミリ秒",
+			"kW":   "キロワット",
+			"kWh":  "キロワットアワー",
+			"MB":   "メガバイト",
+			"GB":   "ギガバイト",
+			"TB":   "テラバイト",
+			"MHz":  "メガヘルツ",
+			"GHz":  "ギガヘルツ",
+			"%":    "パーセント",
+			"％":    "パーセント",
+		},
+		Abbreviations: map[string]string{
+			"AI":  "エーアイ",
+			"API": "エーピーアイ",
+			"CEO": "シーイーオー",
+			"CFO": "シーエフオー",
+			"COO": "シーオーオー",
+			"CTO": "シーティーオー",
+			"CPU": "シーピーユー",
+			"DX":  "ディーエックス",
+			"EV":  "イーブイ",
+			"GDP": "ジーディーピー",
+			"GPU": "ジーピーユー",
+			"IoT": "アイオーティー",
+			"IT":  "アイティー",
+			"LLM": "エルエルエム",
+			"OS":  "オーエス",
+			"PC":  "ピーシー",
+			"SNS": "エスエヌエス",
+			"URL": "ユーアールエル",
+			"USB": "ユーエスビー",
+		},
+		Numbers: true,
+	}
+}
+
+// LoadTTSDictionary は JSON ファイルの変換ルールを組み込みのルールに重ねて読み込みます。
+// ファイルのエントリは組み込みのエントリを上書きし、読みが空文字列のエントリは組み込みのエントリを無効化します。
+// "numbers" を省略した場合は数値の変換を有効のままにします。
+func LoadTTSDictionary(path string) (TTSDictionary, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return TTSDictionary{}, fmt.Errorf("読み上げ辞書ファイルの読み込みに失敗しました: %w", err)
+	}
+	var decoded struct {
+		Units         map[string]string `json:"units"`
+		Abbreviations map[string]string `json:"abbreviations"`
+		Numbers       *bool             `json:"numbers"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return TTSDictionary{}, fmt.Errorf("読み上げ辞書ファイルの解析に失敗しました (%s): %w", path, err)
+	}
+
+	dict := DefaultTTSDictionary()
+	overlay := func(dst, src map[string]string) {
+		for word, reading := range src {
+			if reading == "" {
+				delete(dst, word)
+				continue
+			}
+			dst[word] = reading
+		}
+	}
+	overlay(dict.Units, decoded.Units)
+	overlay(dict.Abbreviations, decoded.Abbreviations)
+	if decoded.Numbers != nil {
+		dict.Numbers = *decoded.Numbers
+	}
+	return dict, nil
+}
+
+// TTSNormalizer は TTSDictionary の変換ルールをスクリプトに適用します。
+type TTSNormalizer struct {
+	dict          TTSDictionary
+	unitPattern   *regexp.Regexp // 数値 + 単位 (nil の場合は単位の変換なし)
+	abbrevPattern *regexp.Regexp // 英略語 (nil の場合は英略語の変換なし)
+}
+
+// ttsNumberPattern は変換対象の数値 (3桁区切りのカンマ・小数を含む) に一致します。
+var ttsNumberPattern = regexp.MustCompile(`\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?`)
+
+// NewTTSNormalizer は dict の変換ルールの TTSNormalizer を生成します。
+func NewTTSNormalizer(dict TTSDictionary) *TTSNormalizer {
+	n := &TTSNormalizer{dict: dict}
+	if len(dict.Units) > 0 {
+		n.unitPattern = regexp.MustCompile(`\d( ?)(` + alternation(dict.Units) + `)`)
+	}
+	if len(dict.Abbreviations) > 0 {
+		n.abbrevPattern = regexp.MustCompile(alternation(dict.Abbreviations))
+	}
+	return n
+}
+
+// alternation は words のキーを長い順 (同じ長さは辞書順) に並べた正規表現の選択肢を返します。
+// 長い語を先に試すことで、"km/h" が "km" として変換されるのを防ぎます。
+func alternation(words map[string]string) string {
+	keys := make([]string, 0, len(words))
+	for word := range words {
+		keys = append(keys, regexp.QuoteMeta(word))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return strings.Join(keys, "|")
+}
+
+// defaultTTSNormalizer は組み込みのルールの TTSNormalizer です (NormalizeForTTS 用)。
+var defaultTTSNormalizer = sync.OnceValue(func() *TTSNormalizer {
+	return NewTTSNormalizer(DefaultTTSDictionary())
+})
+
+// NormalizeForTTS は組み込みの変換ルールで、スクリプトの数値・単位・英略語を読み上げやすい表記に変換します。
+func NormalizeForTTS(text string) string {
+	return defaultTTSNormalizer().Normalize(text)
+}
+
+// Normalize はスクリプトの各行の本文 (行頭の話者タグ以降) に、単位・数値・英略語の順で変換を適用します。
+// 話者タグは変換しません。変換した行は変換前後をデバッグログに出力します。
+func (n *TTSNormalizer) Normalize(text string) string {
+	lines := strings.Split(text, "\n")
+	changed := 0
+	for i, line := range lines {
+		prefix, body := line, ""
+		if loc := speakerLinePattern.FindStringIndex(line); loc != nil {
+			prefix, body = line[:loc[1]], line[loc[1]:]
+		} else {
+			prefix, body = "", line
+		}
+
+		normalized := n.normalizeUnits(body)
+		if n.dict.Numbers {
+			normalized = normalizeNumbers(normalized)
+		}
+		normalized = n.normalizeAbbreviations(normalized)
+		if normalized == body {
+			continue
+		}
+		changed++
+		slog.Debug("読み上げ用にテキストを変換しました", slog.String("before", body), slog.String("after", normalized))
+		lines[i] = prefix + normalized
+	}
+	if changed > 0 {
+		slog.Debug("読み上げ用の変換を適用しました", slog.Int("lines", changed))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeUnits は数値の直後の単位を読みに変換します。単位の直後に英字が続く場合 (例: "5min" の "m") は変換しません。
+func (n *TTSNormalizer) normalizeUnits(text string) string {
+	if n.unitPattern == nil {
+		return text
+	}
+	return replaceMatches(text, n.unitPattern, func(m []int) (string, bool) {
+		if isASCIIAlnum(runeAfter(text, m[1])) && isASCIILetter(runeBefore(text, m[1])) {
+			return "", false
+		}
+		// 数値 (m[0] の1文字) は残し、数値と単位の間の空白を詰めて単位のみを置き換える
+		return text[m[0]:m[2]] + n.dict.Units[text[m[4]:m[5]]], true
+	})
+}
+
+// normalizeAbbreviations は前後が英数字でない英略語を読みに変換します (例: "OpenAI" の "AI" は変換しません)。
+func (n *TTSNormalizer) normalizeAbbreviations(text string) string {
+	if n.abbrevPattern == nil {
+		return text
+	}
+	return replaceMatches(text, n.abbrevPattern, func(m []int) (string, bool) {
+		if isASCIIAlnum(runeBefore(text, m[0])) || isASCIIAlnum(runeAfter(text, m[1])) {
+			return "", false
+		}
+		return n.dict.Abbreviations[text[m[0]:m[1]]], true
+	})
+}
+
+// normalizeNumbers は単独の数値をひらがなの読みに変換します。
+// 英字・記号 (`-` `.` `_` `/` `:`) と連続する数値は、型番・バージョン・日付・時刻などとみなして変換しません。
+func normalizeNumbers(text string) string {
+	return replaceMatches(text, ttsNumberPattern, func(m []int) (string, bool) {
+		before, after := runeBefore(text, m[0]), runeAfter(text, m[1])
+		if isASCIIAlnum(before) || isASCIIAlnum(after) || strings.ContainsRune("-._/:", before) || strings.ContainsRune("-._/:", after) {
+			return "", false
+		}
+		return readNumber(strings.ReplaceAll(text[m[0]:m[1]], ",", ""))
+	})
+}
+
+// replaceMatches は pattern の一致ごとに replace を呼び出し、true を返した一致のみを置き換えます。
+func replaceMatches(text string, pattern *regexp.Regexp, replace func(m []int) (string, bool)) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		replacement, ok := replace(m)
+		if !ok {
+			continue
+		}
+		sb.WriteString(text[last:m[0]])
+		sb.WriteString(replacement)
+		last = m[1]
+	}
+	if last == 0 {
+		return text
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// runeBefore は text の位置 i の直前の ASCII 文字を返します (先頭または非 ASCII の場合は 0)。
+func runeBefore(text string, i int) rune {
+	if i == 0 || text[i-1] >= 0x80 {
+		return 0
+	}
+	return rune(text[i-1])
+}
+
+// runeAfter は text の位置 i の ASCII 文字を返します (末尾または非 ASCII の場合は 0)。
+func runeAfter(text string, i int) rune {
+	if i >= len(text) || text[i] >= 0x80 {
+		return 0
+	}
+	return rune(text[i])
+}
+
+func isASCIILetter(r rune) bool {
+	return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func isASCIIAlnum(r rune) bool {
+	return isASCIILetter(r) || ('0' <= r && r <= '9')
+}
+
+// ----------------------------------------------------------------
+// 数値の読み
+// ----------------------------------------------------------------
+
+// maxReadableNumber は読みに変換する整数の上限です (兆以上は変換しません)。
+const maxReadableNumber = 1_000_000_000_000
+
+var (
+	digitReadings    = [10]string{"ぜろ", "いち", "に", "さん", "よん", "ご", "ろく", "なな", "はち", "きゅう"}
+	hundredReadings  = [10]string{"", "ひゃく", "にひゃく", "さんびゃく", "よんひゃく", "ごひゃく", "ろっぴゃく", "ななひゃく", "はっぴゃく", "きゅうひゃく"}
+	thousandReadings = [10]string{"", "せん", "にせん", "さんぜん", "よんせん", "ごせん", "ろくせん", "ななせん", "はっせん", "きゅうせん"}
+	largeUnits       = []string{"", "まん", "おく"}
+)
+
+// readNumber は整数または小数の数字列をひらがなの読みに変換します (例: 1200 → せんにひゃく、3.5 → さんてんご)。
+// 上限を超える場合は false を返します。
+func readNumber(s string) (string, bool) {
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || n >= maxReadableNumber {
+		return "", false
+	}
+
+	var sb strings.Builder
+	if n == 0 && hasFrac {
+		sb.WriteString("れい")
+	} else {
+		sb.WriteString(readInteger(n))
+	}
+	if hasFrac {
+		sb.WriteString("てん")
+		for _, d := range fracPart {
+			sb.WriteString(digitReadings[d-'0'])
+		}
+	}
+	return sb.String(), true
+}
+
+// readInteger は 0 以上 maxReadableNumber 未満の整数の読みを返します。
+func readInteger(n int64) string {
+	if n == 0 {
+		return digitReadings[0]
+	}
+	var sb strings.Builder
+	for k := len(largeUnits) - 1; k >= 0; k-- {
+		group := int(n / pow10000(k) % 10000)
+		if group == 0 {
+			continue
+		}
+		sb.WriteString(readGroup(group, k > 0))
+		sb.WriteString(largeUnits[k])
+	}
+	return sb.String()
+}
+
+// readGroup は 1〜9999 の読みを返します。large が true (万・億の位) の場合、1000 は「いっせん」と読みます。
+func readGroup(g int, large bool) string {
+	var sb strings.Builder
+	switch d := g / 1000; {
+	case d == 1 && large:
+		sb.WriteString("いっせん")
+	case d > 0:
+		sb.WriteString(thousandReadings[d])
+	}
+	sb.WriteString(hundredReadings[g/100%10])
+	switch d := g / 10 % 10; {
+	case d == 1:
+		sb.WriteString("じゅう")
+	case d > 1:
+		sb.WriteString(digitReadings[d] + "じゅう")
+	}
+	if d := g % 10; d > 0 {
+		sb.WriteString(digitReadings[d])
+	}
+	return sb.String()
+}
+
+// pow10000 は 10000 の k 乗を返します。
+func pow10000(k int) int64 {
+	p := int64(1)
+	for range k {
+		p *= 10000
+	}
+	return p
+}
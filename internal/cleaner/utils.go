@@ -1,22 +1,39 @@
 package cleaner
 
 import (
+	"act-feed-clean-go/internal/correlation"
 	"act-feed-clean-go/prompts"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
-	"sync"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/shouni/go-web-exact/v2/pkg/types"
 	"golang.org/x/time/rate"
+	"google.golang.org/genai"
 )
 
+// FeedFallbackMarker は、スクレイピングに失敗しフィードの要約文で本文を代替した記事の先頭に付与される印です。
+// 最終成果物でも本文の質が低い記事を区別できるよう、本文の一部として保持されます。
+const FeedFallbackMarker = "【注記: 本文の取得に失敗したため、フィードの要約文で代替しています】"
+
 // ----------------------------------------------------------------
 // パッケージレベルのユーティリティ関数
 // ----------------------------------------------------------------
 
+// MarkFeedFallback は、フィードの要約文を代替本文として使用するために印を付けた本文を返します。
+func MarkFeedFallback(feedContent string) string {
+	return FeedFallbackMarker + "\n\n" + feedContent
+}
+
+// IsFeedFallback は、本文がフィードの要約文による代替かどうかを判定します。
+func IsFeedFallback(content string) bool {
+	return strings.HasPrefix(content, FeedFallbackMarker)
+}
+
 // CombineContents は、成功した抽出結果の本文を効率的に結合します。
 func CombineContents(results []types.URLResult, titlesMap map[string]string) string {
 	var builder strings.Builder
@@ -84,27 +101,71 @@ func ExtractTextBetweenTags(text, startTag, endTag string) string {
 	return strings.TrimSpace(text[startIndex:endIndex])
 }
 
-// ExtractTitleFromMarkdown は、Markdownテキストの最初の # 見出しの内容を抽出します。
+// ExtractTitleFromMarkdown は、Markdownテキストの最初の # 見出し (ATX形式のレベル1見出し) の内容を抽出します。
+// コードフェンス (``` / ~~~) 内の行、"#タイトル" のように # の後に空白のない行、
+// 4文字以上インデントされた行 (インデントによるコードブロック)、Setext形式の見出し (=== の下線) は見出しとして扱いません。
+// 見出しの末尾の閉じ記号 (" #") と空白は取り除き、内容が空の見出しは読み飛ばします。見つからない場合は "" を返します。
 func ExtractTitleFromMarkdown(markdownText string) string {
-	lines := strings.Split(markdownText, "\n")
-	for _, line := range lines {
-		// 先頭が "# " で始まる行を探す
-		if strings.HasPrefix(line, "# ") {
-			// "# " を取り除き、前後のスペースをトリム
-			title := strings.TrimSpace(line[2:])
-			if title != "" {
-				return title
+	fence := "" // 開いているコードフェンスの記号 (フェンスの外では空)
+	for line := range strings.Lines(markdownText) {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > 3 {
+			continue
+		}
+		if marker, rest := codeFence(trimmed); marker != "" {
+			if fence == "" {
+				fence = marker
+			} else if marker[0] == fence[0] && len(marker) >= len(fence) && strings.TrimSpace(rest) == "" {
+				fence = ""
 			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if title, ok := atxTitle(trimmed); ok && title != "" {
+			return title
 		}
 	}
 	return ""
 }
 
+// codeFence は行がコードフェンス (3文字以上の ` または ~) で始まる場合に、フェンスの記号と残りの文字列を返します。
+func codeFence(line string) (marker, rest string) {
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return "", ""
+	}
+	n := len(line) - len(strings.TrimLeft(line, line[:1]))
+	if n < 3 {
+		return "", ""
+	}
+	return line[:n], line[n:]
+}
+
+// atxTitle は行が "# " で始まるレベル1見出しの場合に、末尾の閉じ記号と空白を取り除いた内容を返します。
+func atxTitle(line string) (string, bool) {
+	if line == "#" {
+		return "", true
+	}
+	if !strings.HasPrefix(line, "# ") && !strings.HasPrefix(line, "#\t") {
+		return "", false
+	}
+	title := strings.TrimSpace(line[2:])
+	// 閉じ記号は空白の後に続く # の並びのみ ("C#" のような末尾の # は内容の一部として残す)
+	if closed := strings.TrimRight(title, "#"); closed == "" || strings.HasSuffix(closed, " ") || strings.HasSuffix(closed, "\t") {
+		title = strings.TrimSpace(closed)
+	}
+	return title, true
+}
+
 // ----------------------------------------------------------------
 // Cleaner 内部ヘルパーメソッド
 // ----------------------------------------------------------------
 
 // segmentText は、結合されたテキストを、安全な最大文字数を超えないように分割します。
+// SegmentOverlapChars が設定されている場合、2つ目以降のセグメントは直前のセグメントの末尾を含めて開始します
+// (重なりを含めても maxChars を超えません)。
 func (c *Cleaner) segmentText(text string, maxChars int) []string {
 	var segments []string
 	current := []rune(text)
@@ -123,7 +184,8 @@ func (c *Cleaner) segmentText(text string, maxChars int) []string {
 
 		// 1. ContentSeparator (最高優先度) を探す
 		if lastSepIdx := strings.LastIndex(segmentCandidate, ContentSeparator); lastSepIdx != -1 {
-			potentialSplitIndex := lastSepIdx + len(ContentSeparator)
+			// strings.LastIndex はバイト位置のため、文字 (rune) 単位の位置に変換する
+			potentialSplitIndex := utf8.RuneCountInString(segmentCandidate[:lastSepIdx]) + utf8.RuneCountInString(ContentSeparator)
 			if potentialSplitIndex <= maxChars {
 				splitIndex = potentialSplitIndex
 				separatorFound = true
@@ -133,7 +195,8 @@ func (c *Cleaner) segmentText(text string, maxChars int) []string {
 		// 2. ContentSeparator が見つからない、または採用されなかった場合、一般的な改行(\n\n)を探す
 		if !separatorFound {
 			if lastSepIdx := strings.LastIndex(segmentCandidate, DefaultSeparator); lastSepIdx != -1 {
-				potentialSplitIndex := lastSepIdx + len(DefaultSeparator)
+				// strings.LastIndex はバイト位置のため、文字 (rune) 単位の位置に変換する
+				potentialSplitIndex := utf8.RuneCountInString(segmentCandidate[:lastSepIdx]) + utf8.RuneCountInString(DefaultSeparator)
 				if potentialSplitIndex <= maxChars {
 					splitIndex = potentialSplitIndex
 					separatorFound = true
@@ -141,7 +204,19 @@ func (c *Cleaner) segmentText(text string, maxChars int) []string {
 			}
 		}
 
-		// 3. 意味的な区切り文字（句読点、スペース）を探し、より自然な場所で分割
+		// 3. 日本語の文末（。！？ や閉じ括弧）を探し、文の途中で分割しないようにする
+		if !separatorFound {
+			start := max(0, len(segmentCandidateRunes)-japaneseSentenceLookback)
+			for i := len(segmentCandidateRunes) - 1; i >= start; i-- {
+				if isJapaneseSentenceEnd(segmentCandidateRunes[i]) {
+					splitIndex = i + 1
+					separatorFound = true
+					break
+				}
+			}
+		}
+
+		// 4. 意味的な区切り文字（句読点、スペース）を探し、より自然な場所で分割
 		if !separatorFound {
 			const lookback = 50
 			start := max(0, len(segmentCandidateRunes)-lookback)
@@ -171,96 +246,354 @@ func (c *Cleaner) segmentText(text string, maxChars int) []string {
 		}
 
 		segments = append(segments, string(current[:splitIndex]))
-		current = current[splitIndex:]
+		current = current[splitIndex-c.segmentOverlap(splitIndex):]
 	}
 
 	return segments
 }
 
+// japaneseSentenceLookback は segmentText で日本語の文末を探す、分割位置からさかのぼる最大の文字数です。
+// 日本語の 1 文は数十〜百数十文字になるため、汎用の区切り文字 (50文字) より広く探します。
+const japaneseSentenceLookback = 300
+
+// isJapaneseSentenceEnd は r が日本語の文末を示す文字 (句点・感嘆符・疑問符とその全角・半角の異体、閉じ括弧) かどうかを返します。
+func isJapaneseSentenceEnd(r rune) bool {
+	switch r {
+	case '。', '｡', '．', '！', '？', '」', '』', '）', '｣', '】':
+		return true
+	}
+	return false
+}
+
+// segmentOverlap は長さ segmentChars 文字のセグメントの次のセグメントに含める、重なりの文字数を返します。
+// 重なりが分割位置以上になると分割が進まず無限ループになるため、セグメントの半分を上限とします。
+func (c *Cleaner) segmentOverlap(segmentChars int) int {
+	return min(c.config.SegmentOverlapChars, segmentChars/2)
+}
+
 // processSegmentsInParallel は Mapフェーズを並列処理します。
 // LLMリクエストのレートリミット（DefaultLLMRateLimit = 1秒）を適用します。
-func (c *Cleaner) processSegmentsInParallel(ctx context.Context, segments []string) ([]string, error) {
-	var wg sync.WaitGroup
+// エラー時も、成功したセグメントの要約を部分成果として返します。
+// TotalTimeout が有効な場合、予算の残りが Reduce のための予備を下回った時点で Map を打ち切り、完了した要約と
+// 打ち切ったセグメントを返します (budget.goで定義)。
+// 失敗したセグメントが MaxFailedSegments / MaxFailedSegmentsRatio の許容範囲内の場合は、それらを除外した要約と
+// 除外したセグメントを返し、エラーにしません。
+// FailFast が有効な場合、同種のエラーが FailFastThreshold 件に達した時点で残りのセグメントをキャンセルし、即座にエラーを返します。
+// 有意な文字が MinSegmentContentChars 未満のセグメントは LLM を呼び出さずに空要約として扱います。
+// ctx がキャンセルされた場合は、未開始のセグメントを開始せずに中断し、完了したセグメントの要約を部分成果として
+// キャンセルのエラーとともに返します。
+func (c *Cleaner) processSegmentsInParallel(ctx context.Context, segments []string) ([]string, []DroppedSegment, error) {
+	// 早期打ち切り時に残りの goroutine (LLM呼び出し・リミッター待ち) を止めるためのコンテキスト
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 全体予算 (TotalTimeout) が有効な場合は、Reduce のための予備を残した時刻で Map を打ち切る (budget.goで定義)
+	mapCtx, cancelMap := c.mapBudgetContext(ctx)
+	defer cancelMap()
 
 	// LLMリクエストレートリミッターの準備
 	// DefaultLLMRateLimit (1秒) に基づき、バーストサイズ1の厳密なリミッターを作成
 	limiter := rate.NewLimiter(rate.Every(c.rateLimit), 1)
 
 	// segmentIndex, summary, error を格納するチャネル
+	// 早期打ち切りで受信側が先に戻っても goroutine がブロックしないよう、セグメント数分のバッファを持たせる
 	resultsChan := make(chan struct {
 		index   int
 		summary string
 		err     error
 	}, len(segments))
 
-	for i, segment := range segments {
-		wg.Add(1)
+	// 同時に処理するセグメント数を MaxConcurrency に制限するセマフォ
+	// (goroutine はセグメントごとに起動するが、LLM呼び出しに進めるのは上限までとする)
+	sem := make(chan struct{}, c.config.MaxConcurrency)
+
+	// Map要約キャッシュのヒット・ミスの件数 (MapCache 指定時のみ)
+	var cacheStats mapCacheStats
+	if c.config.MapCache != nil {
+		defer func() {
+			slog.InfoContext(ctx, "Map要約キャッシュの利用状況",
+				slog.Int64("hits", cacheStats.hits.Load()),
+				slog.Int64("misses", cacheStats.misses.Load()),
+			)
+		}()
+	}
 
+	launched, skipped := 0, 0
+	for i, segment := range segments {
+		if minChars := c.config.MinSegmentContentChars; minChars > 0 && countContentChars(segment) < minChars {
+			slog.DebugContext(ctx, "有意な文字が少ないため、Map要約をスキップします", slog.Int("segment", i+1), slog.Int("min_chars", minChars))
+			skipped++
+			continue
+		}
+		launched++
 		go func(index int, seg string) {
-			defer wg.Done()
-
-			// 💡 レートリミットの待機
-			// Wait(ctx) は、レートリミットに達した場合に待機し、ctx.Done() が発火した場合はエラーを返す。
-			if err := limiter.Wait(ctx); err != nil {
-				resultsChan <- struct {
-					index   int
-					summary string
-					err     error
-				}{index: index + 1, summary: "", err: fmt.Errorf("LLMリミット待機中にキャンセル: %w", err)}
-				return
-			}
-
-			mapData := prompts.MapTemplateData{SegmentText: seg}
-			prompt, err := c.prompt.MapBuilder.BuildMap(mapData)
-			if err != nil {
-				resultsChan <- struct {
-					index   int
-					summary string
-					err     error
-				}{index: index + 1, summary: "", err: fmt.Errorf("プロンプト生成失敗: %w", err)}
-				return
-			}
-
-			// Mapフェーズのモデル名に c.config.MapModel を使用
-			response, err := c.client.GenerateContent(ctx, prompt, c.config.MapModel)
-
-			if err != nil {
-				resultsChan <- struct {
-					index   int
-					summary string
-					err     error
-				}{index: index + 1, summary: "", err: fmt.Errorf("LLM処理失敗: %w", err)}
-				return
+			var summary string
+			var err error
+			select {
+			case sem <- struct{}{}:
+				// セマフォの取得とキャンセルが同時に成立した場合も、未開始のセグメントは開始しない
+				if err = mapCtx.Err(); err == nil {
+					segCtx := correlation.WithID(mapCtx, correlation.SegmentID(index+1))
+					summary, err = c.summarizeSegment(segCtx, limiter, &cacheStats, index+1, seg)
+					if err == nil {
+						summary, err = c.postProcess(PhaseMap, summary)
+					}
+				}
+				<-sem
+			case <-mapCtx.Done():
+				err = mapCtx.Err()
 			}
-
 			resultsChan <- struct {
 				index   int
 				summary string
 				err     error
-			}{index: index + 1, summary: response.Text, err: nil}
+			}{index: index + 1, summary: summary, err: err}
 		}(i, segment)
 	}
 
-	wg.Wait()
-	close(resultsChan)
-
-	// エラー蓄積ロジック
-	var summaries []string
+	// エラー蓄積ロジック (完了した順に受信し、要約はセグメントの順に格納する)
+	ordered := make([]string, len(segments))
+	done := make([]bool, len(segments))
 	var errorMessages []string
+	var failed []DroppedSegment
+	var budgetSkipped []DroppedSegment // 全体予算の残りが少ないため打ち切ったセグメント
+	var costErr *CostLimitError
+	errorCounts := make(map[string]int) // FailFast 用の種類別エラー件数
+	canceled := 0                       // キャンセルにより中断したセグメント数
+
+	if skipped > 0 {
+		slog.InfoContext(ctx, "空または無意味なセグメントのMap要約をスキップしました",
+			slog.Int("skipped", skipped),
+			slog.Int("segments", len(segments)),
+			slog.Int("min_chars", c.config.MinSegmentContentChars),
+		)
+	}
+	if launched == 0 {
+		return nil, nil, fmt.Errorf("有意な内容を含むセグメントがありません (全 %d セグメントをスキップしました)", len(segments))
+	}
 
-	for res := range resultsChan {
-		if res.err != nil {
-			errorMessages = append(errorMessages, fmt.Sprintf("セグメント %d: %v", res.index, res.err))
-		} else {
-			summaries = append(summaries, res.summary)
+	for range launched {
+		res := <-resultsChan
+		if res.err == nil {
+			ordered[res.index-1], done[res.index-1] = res.summary, true
+			continue
+		}
+		// 予算切れによる打ち切りはエラーとして数えず、除外して Reduce に進む。
+		// 期限切れ以外のエラーは予算切れの後に届いても打ち切りとせず、FailFast・コスト上限・失敗の許容判定の対象とする
+		if c.isMapBudgetSkip(ctx, mapCtx, res.err) {
+			budgetSkipped = append(budgetSkipped, DroppedSegment{Index: res.index, Error: errMapBudgetExhausted.Error()})
+			continue
+		}
+		// 呼び出し元のキャンセルによる中断は個別のエラーとして数えない (FailFast の対象外)
+		if ctx.Err() != nil && errors.Is(res.err, ctx.Err()) {
+			canceled++
+			continue
+		}
+		if costErr == nil {
+			errors.As(res.err, &costErr)
+		}
+		errorMessages = append(errorMessages, fmt.Sprintf("セグメント %d: %v", res.index, res.err))
+		failed = append(failed, DroppedSegment{Index: res.index, Error: res.err.Error()})
+
+		if !c.config.FailFast {
+			continue
+		}
+		kind := errorKind(res.err)
+		errorCounts[kind]++
+		if errorCounts[kind] >= c.config.FailFastThreshold {
+			cancel()
+			slog.ErrorContext(ctx, "同種のエラーが閾値に達したため、Mapフェーズを早期に打ち切ります",
+				slog.String("kind", kind),
+				slog.Int("count", errorCounts[kind]),
+				slog.Int("segments", len(segments)),
+			)
+			return orderedSummaries(ordered, done), nil, fmt.Errorf("Mapフェーズを早期に打ち切りました (同種のエラー %q が %d 件発生、全 %d セグメント中): %w",
+				kind, errorCounts[kind], len(segments), res.err)
 		}
 	}
 
+	summaries := orderedSummaries(ordered, done)
+	if err := ctx.Err(); err != nil {
+		slog.WarnContext(ctx, "Mapフェーズがキャンセルされました。完了したセグメントの要約を部分成果として返します",
+			slog.Int("completed", len(summaries)),
+			slog.Int("canceled", canceled),
+			slog.Int("segments", len(segments)),
+		)
+		return summaries, nil, fmt.Errorf("Mapフェーズがキャンセルされました (完了 %d / 全 %d セグメント): %w", len(summaries), len(segments), err)
+	}
+	if costErr != nil {
+		// コスト上限による打ち切りは他のエラーと区別できるよう、そのまま返す
+		return summaries, nil, costErr
+	}
+	if len(budgetSkipped) > 0 {
+		if len(summaries) == 0 {
+			return nil, nil, fmt.Errorf("全体予算 (%s) 内に完了したMap要約がありません (全 %d セグメント): %w",
+				c.config.TotalTimeout, len(segments), errMapBudgetExhausted)
+		}
+		slog.WarnContext(ctx, "全体予算の残りが少ないため、Map要約を打ち切り、完了した要約で Reduce に進みます",
+			slog.Int("completed", len(summaries)),
+			slog.Int("skipped", len(budgetSkipped)),
+			slog.Int("segments", len(segments)),
+			slog.Duration("total_timeout", c.config.TotalTimeout),
+		)
+	}
 	if len(errorMessages) > 0 {
-		return nil, fmt.Errorf("Mapフェーズで %d 件のエラーが発生しました:\n- %s",
+		if c.failuresTolerated(len(failed), launched) {
+			// 許容範囲内の失敗は、失敗したセグメントを除外して Reduce を続行する
+			for _, seg := range failed {
+				slog.WarnContext(correlation.WithID(ctx, correlation.SegmentID(seg.Index)), "Map要約に失敗したセグメントを除外して続行します", slog.Int("segment", seg.Index), slog.String("error", seg.Error))
+			}
+			slog.WarnContext(ctx, "一部のセグメントのMap要約に失敗しましたが、許容範囲内のため続行します",
+				slog.Int("dropped", len(failed)),
+				slog.Int("segments", launched),
+				slog.Int("max_failed", c.config.MaxFailedSegments),
+				slog.Float64("max_failed_ratio", c.config.MaxFailedSegmentsRatio),
+			)
+			return summaries, sortedDropped(append(failed, budgetSkipped...)), nil
+		}
+		return summaries, nil, fmt.Errorf("Mapフェーズで %d 件のエラーが発生しました:\n- %s",
 			len(errorMessages),
 			strings.Join(errorMessages, "\n- "))
 	}
 
-	return summaries, nil
+	return summaries, sortedDropped(budgetSkipped), nil
+}
+
+// orderedSummaries は成功したセグメントの要約のみを、セグメントの順に返します。
+// 完了順に並べると長い文書の流れが崩れ、Reduce の統合結果が不自然になるため、必ず元の順序を保ちます。
+func orderedSummaries(ordered []string, done []bool) []string {
+	var summaries []string
+	for i, summary := range ordered {
+		if done[i] {
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries
+}
+
+// countContentChars は有意な文字 (かな・漢字・英数字などの文字と数字) の数を数えます。空白や記号は数えません。
+func countContentChars(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// errorKind は FailFast の判定に使うエラーの種類を返します。
+// API エラーは HTTP ステータスコード単位 (例: 認証エラーの 401/403) で、それ以外はエラーの型で分類します。
+func errorKind(err error) string {
+	var apiErr genai.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("api:%d", apiErr.Code)
+	case errors.Is(err, ErrCostLimitExceeded):
+		return "cost_limit"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// summarizeSegment は 1 セグメント分の Map 要約を返します。
+// MapCache にプロンプトとモデル名が一致する要約がある場合は LLM を呼び出さずにそれを使用し、
+// ない場合は生成した要約を MapCache に保存します。
+func (c *Cleaner) summarizeSegment(ctx context.Context, limiter *rate.Limiter, cacheStats *mapCacheStats, index int, seg string) (string, error) {
+	prompt, err := c.buildMapPrompt(seg)
+	if err != nil {
+		return "", err
+	}
+	if c.config.MapCache == nil {
+		return c.generateSegmentSummary(ctx, limiter, index, seg, prompt)
+	}
+
+	key := mapCacheKey(c.config.MapModel, prompt)
+	if summary, ok := c.config.MapCache.Get(key); ok {
+		cacheStats.hits.Add(1)
+		slog.DebugContext(ctx, "キャッシュ済みのMap要約を使用します", slog.Int("segment", index))
+		return summary, nil
+	}
+	cacheStats.misses.Add(1)
+	summary, err := c.generateSegmentSummary(ctx, limiter, index, seg, prompt)
+	if err != nil {
+		return "", err
+	}
+	c.config.MapCache.Set(key, summary)
+	return summary, nil
+}
+
+// generateSegmentSummary は 1 セグメント分の Map 要約を LLM で生成します。
+// EnforceMapFormat が有効な場合、フォーマット違反の出力は MapFormatMaxRetries 回まで再生成されます。
+func (c *Cleaner) generateSegmentSummary(ctx context.Context, limiter *rate.Limiter, index int, seg, prompt string) (string, error) {
+	var summary string
+	for attempt := 0; ; attempt++ {
+		// 💡 レートリミットの待機
+		// Wait(ctx) は、レートリミットに達した場合に待機し、ctx.Done() が発火した場合はエラーを返す。
+		if err := limiter.Wait(ctx); err != nil {
+			// 待機が期限までに終わらない場合、リミッターは期限の前でもエラーを返すため、期限切れとして扱えるようにする
+			if ctx.Err() == nil {
+				err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+			}
+			return "", fmt.Errorf("LLMリミット待機中にキャンセル: %w", err)
+		}
+
+		// Mapフェーズのモデル名に c.config.MapModel を使用
+		slog.DebugContext(ctx, "Map要約を生成します",
+			slog.Int("segment", index),
+			slog.Int("segment_length", len([]rune(seg))),
+		)
+		response, err := c.generate(ctx, "map", prompt, c.config.MapModel)
+		if errors.Is(err, ErrCostLimitExceeded) {
+			return "", err
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Map要約の生成に失敗しました", slog.Int("segment", index), slog.String("error", err.Error()))
+			return "", fmt.Errorf("LLM処理失敗: %w", err)
+		}
+		summary = response.Text
+
+		if !c.config.EnforceMapFormat {
+			return summary, nil
+		}
+		formatErr := ValidateMapFormat(summary, c.config.MapFormatRules)
+		if formatErr == nil {
+			return summary, nil
+		}
+		if attempt >= c.config.MapFormatMaxRetries {
+			slog.WarnContext(ctx, "Map要約のフォーマット違反が解消されませんでした。最後の出力をそのまま使用します。",
+				slog.Int("segment", index),
+				slog.String("error", formatErr.Error()),
+			)
+			return summary, nil
+		}
+		slog.InfoContext(ctx, "Map要約がフォーマット違反のため再生成します",
+			slog.Int("segment", index),
+			slog.Int("attempt", attempt+1),
+			slog.String("reason", formatErr.Error()),
+		)
+	}
+}
+
+// buildMapPrompt は 1 セグメント分の Map フェーズのプロンプトを構築します。
+func (c *Cleaner) buildMapPrompt(seg string) (string, error) {
+	mapData := prompts.MapTemplateData{
+		SegmentText:   seg,
+		EnforceFormat: c.config.EnforceMapFormat,
+		CiteSources:   c.config.CiteSources,
+		OutputStyle:   c.config.OutputStyle.promptStyle(),
+	}
+	prompt, err := c.prompt.MapBuilder.BuildMap(mapData)
+	if err != nil {
+		return "", fmt.Errorf("プロンプト生成失敗: %w", err)
+	}
+	return prompt, nil
 }
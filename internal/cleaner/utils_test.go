@@ -0,0 +1,130 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+func TestExtractTitleFromMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"simple", "# タイトル\n本文", "タイトル"},
+		{"leading blank lines", "\n\n  \n# タイトル\n本文", "タイトル"},
+		{"crlf", "\r\n# タイトル\r\n本文", "タイトル"},
+		{"no space after hash", "#タイトルではない\n# タイトル", "タイトル"},
+		{"no space only", "#タグ\n本文", ""},
+		{"level 2 is not a title", "## 見出し2\n# タイトル", "タイトル"},
+		{"closing hashes", "# タイトル ##", "タイトル"},
+		{"trailing hash in content", "# C#", "C#"},
+		{"empty heading skipped", "#\n# タイトル", "タイトル"},
+		{"setext equals", "タイトル候補\n===\n本文", ""},
+		{"setext dashes", "タイトル候補\n---\n本文", ""},
+		{"setext then atx", "セテキスト\n===\n# タイトル", "タイトル"},
+		{"hash inside backtick fence", "```\n# コメント\n```\n# タイトル", "タイトル"},
+		{"hash inside tilde fence", "~~~sh\n# コメント\n~~~\n# タイトル", "タイトル"},
+		{"unclosed fence", "```\n# コメント", ""},
+		{"shorter closing fence does not close", "````\n```\n# コメント\n````\n# タイトル", "タイトル"},
+		{"indented code block", "    # コード\n# タイトル", "タイトル"},
+		{"up to three spaces of indent", "   # タイトル", "タイトル"},
+		{"no heading", "本文のみ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractTitleFromMarkdown(tt.in); got != tt.want {
+				t.Errorf("ExtractTitleFromMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// reSegmentID はテスト用のセグメント本文に埋め込んだ番号です。
+var reSegmentID = regexp.MustCompile(`SEG-(\d+)`)
+
+// gatedFirstModel は、最初のセグメント (SEG-0) の応答を他のすべてのセグメントが応答するまで保留する gemini.GenerativeModel です。
+// 完了順は必ず入力順と異なり、完了した順序を記録します。
+type gatedFirstModel struct {
+	total   int
+	release chan struct{}
+
+	mu        sync.Mutex
+	completed []int
+}
+
+func newGatedFirstModel(total int) *gatedFirstModel {
+	return &gatedFirstModel{total: total, release: make(chan struct{})}
+}
+
+func (m *gatedFirstModel) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	match := reSegmentID.FindStringSubmatch(prompt)
+	if match == nil {
+		return nil, fmt.Errorf("プロンプトにセグメント番号がありません")
+	}
+	index, _ := strconv.Atoi(match[1])
+	if index == 0 {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("他のセグメントの応答を待つ間にタイムアウトしました")
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completed = append(m.completed, index)
+	if index != 0 && len(m.completed) == m.total-1 {
+		close(m.release)
+	}
+	return &gemini.Response{Text: fmt.Sprintf("- 要約 SUMMARY-%d", index)}, nil
+}
+
+func TestProcessSegmentsInParallelKeepsInputOrder(t *testing.T) {
+	const total = 6
+	model := newGatedFirstModel(total)
+	c, err := NewCleaner(model, CleanerConfig{LLMRateLimit: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := make([]string, total)
+	for i := range segments {
+		segments[i] = fmt.Sprintf("これはテスト用のセグメント SEG-%d の本文です。", i)
+	}
+
+	summaries, dropped, err := c.processSegmentsInParallel(context.Background(), segments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 0 {
+		t.Fatalf("除外されたセグメントがあります: %v", dropped)
+	}
+	if len(summaries) != total {
+		t.Fatalf("要約の件数 = %d, want %d", len(summaries), total)
+	}
+	if last := model.completed[len(model.completed)-1]; last != 0 {
+		t.Fatalf("最初のセグメントが最後に完了していません (完了順: %v)", model.completed)
+	}
+	for i, summary := range summaries {
+		if want := fmt.Sprintf("SUMMARY-%d", i); !strings.Contains(summary, want) {
+			t.Errorf("summaries[%d] = %q, want %s を含む (完了順: %v)", i, summary, want, model.completed)
+		}
+	}
+}
+
+func TestOrderedSummaries(t *testing.T) {
+	got := orderedSummaries([]string{"a", "", "c", "d"}, []bool{true, false, true, true})
+	if want := []string{"a", "c", "d"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("orderedSummaries = %v, want %v", got, want)
+	}
+}
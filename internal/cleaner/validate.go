@@ -0,0 +1,214 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ----------------------------------------------------------------
+// 設定のバリデーション
+// ----------------------------------------------------------------
+
+// modelNamePattern は受け付けるGeminiモデル名の形式です (例: gemini-2.5-flash)。
+var modelNamePattern = regexp.MustCompile(`^gemini-[0-9a-z][0-9a-z.\-]*$`)
+
+// ValidateCleanerConfig は CleanerConfig の不正な値や矛盾する組み合わせを検証します。
+// 空文字列やゼロ値は NewCleaner でデフォルト値に置き換えられるため、エラーにはなりません。
+// 問題が複数ある場合は errors.Join でまとめて返し、各エラーには対象フィールド名を含めます。
+func ValidateCleanerConfig(cfg CleanerConfig) error {
+	var errs []error
+	fieldErr := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("CleanerConfig.%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	type modelField struct {
+		field string
+		name  string
+	}
+	models := []modelField{
+		{"MapModel", cfg.MapModel},
+		{"ReduceModel", cfg.ReduceModel},
+		{"SummaryModel", cfg.SummaryModel},
+		{"ScriptModel", cfg.ScriptModel},
+	}
+	chains := []struct {
+		field string
+		names []string
+	}{
+		{"MapModels", cfg.MapModels},
+		{"ReduceModels", cfg.ReduceModels},
+		{"SummaryModels", cfg.SummaryModels},
+		{"ScriptModels", cfg.ScriptModels},
+	}
+	for _, chain := range chains {
+		for i, name := range chain.names {
+			models = append(models, modelField{fmt.Sprintf("%s[%d]", chain.field, i), name})
+		}
+	}
+	for _, m := range models {
+		if m.name != "" && !modelNamePattern.MatchString(m.name) {
+			fieldErr(m.field, "未知のモデル名です (%q)。gemini-2.5-flash のような形式で指定してください", m.name)
+		}
+	}
+
+	if cfg.LLMRateLimit < 0 {
+		fieldErr("LLMRateLimit", "負の値は指定できません (%s)", cfg.LLMRateLimit)
+	}
+
+	if cfg.LLMMaxAttempts < 0 {
+		fieldErr("LLMMaxAttempts", "負の値は指定できません (%d)", cfg.LLMMaxAttempts)
+	}
+	if cfg.LLMRetryBaseDelay < 0 {
+		fieldErr("LLMRetryBaseDelay", "負の値は指定できません (%s)", cfg.LLMRetryBaseDelay)
+	}
+
+	if cfg.MapFormatRules.MinBullets < 0 {
+		fieldErr("MapFormatRules.MinBullets", "負の値は指定できません (%d)", cfg.MapFormatRules.MinBullets)
+	}
+	if cfg.MapFormatMaxRetries < 0 {
+		fieldErr("MapFormatMaxRetries", "負の値は指定できません (%d)", cfg.MapFormatMaxRetries)
+	}
+
+	if cfg.ScriptTurns < 0 {
+		fieldErr("ScriptTurns", "負の値は指定できません (%d)", cfg.ScriptTurns)
+	}
+	if cfg.ScriptMaxTurnChars < 0 {
+		fieldErr("ScriptMaxTurnChars", "負の値は指定できません (%d)", cfg.ScriptMaxTurnChars)
+	}
+	if cfg.ScriptTargetChars < 0 {
+		fieldErr("ScriptTargetChars", "負の値は指定できません (%d)", cfg.ScriptTargetChars)
+	}
+	if err := validateGreeting(cfg.ScriptOpening); err != nil {
+		fieldErr("ScriptOpening", "%v", err)
+	}
+	if err := validateGreeting(cfg.ScriptClosing); err != nil {
+		fieldErr("ScriptClosing", "%v", err)
+	}
+	if cfg.MinSegmentContentChars < 0 {
+		fieldErr("MinSegmentContentChars", "負の値は指定できません (%d)", cfg.MinSegmentContentChars)
+	}
+	if cfg.MaxSegmentTokens < 0 {
+		fieldErr("MaxSegmentTokens", "負の値は指定できません (%d)", cfg.MaxSegmentTokens)
+	}
+	if cfg.SegmentOverlapChars < 0 {
+		fieldErr("SegmentOverlapChars", "負の値は指定できません (%d)", cfg.SegmentOverlapChars)
+	}
+	if cfg.FailFastThreshold < 0 {
+		fieldErr("FailFastThreshold", "負の値は指定できません (%d)", cfg.FailFastThreshold)
+	}
+	validateGenConfig("MapGenConfig", cfg.MapGenConfig, fieldErr)
+	validateGenConfig("ReduceGenConfig", cfg.ReduceGenConfig, fieldErr)
+	validateGenConfig("SummaryGenConfig", cfg.SummaryGenConfig, fieldErr)
+	validateGenConfig("ScriptGenConfig", cfg.ScriptGenConfig, fieldErr)
+	if cfg.TotalTimeout < 0 {
+		fieldErr("TotalTimeout", "負の値は指定できません (%s)", cfg.TotalTimeout)
+	}
+	if cfg.ReduceReserveRatio < 0 || cfg.ReduceReserveRatio >= 1 {
+		fieldErr("ReduceReserveRatio", "0以上1未満の値を指定してください (%v)", cfg.ReduceReserveRatio)
+	}
+	if cfg.MaxFailedSegments < 0 {
+		fieldErr("MaxFailedSegments", "負の値は指定できません (%d)", cfg.MaxFailedSegments)
+	}
+	if cfg.MaxFailedSegmentsRatio < 0 || cfg.MaxFailedSegmentsRatio > 1 {
+		fieldErr("MaxFailedSegmentsRatio", "0以上1以下の値を指定してください (%v)", cfg.MaxFailedSegmentsRatio)
+	}
+	if cfg.MaxConcurrency < 0 {
+		fieldErr("MaxConcurrency", "負の値は指定できません (%d)", cfg.MaxConcurrency)
+	}
+
+	switch cfg.ReduceStrategy {
+	case "", ReduceStrategyConcat, ReduceStrategyHierarchical, ReduceStrategyRefine:
+	default:
+		fieldErr("ReduceStrategy", "不明なReduce戦略です (%q)。concat, hierarchical, refine のいずれかを指定してください", cfg.ReduceStrategy)
+	}
+
+	switch cfg.LayeredSummaryMode {
+	case "", LayeredSummarySingle, LayeredSummarySeparate:
+	default:
+		fieldErr("LayeredSummaryMode", "不明な多層要約の生成方法です (%q)。single, separate のいずれかを指定してください", cfg.LayeredSummaryMode)
+	}
+
+	switch cfg.SummaryFormat {
+	case "", SummaryFormatProse, SummaryFormatBullet, SummaryFormatBoth:
+	default:
+		fieldErr("SummaryFormat", "不明な最終要約の出力形式です (%q)。prose, bullet, both のいずれかを指定してください", cfg.SummaryFormat)
+	}
+
+	switch cfg.TopicGranularity {
+	case "", TopicGranularityCoarse, TopicGranularityMedium, TopicGranularityFine:
+	default:
+		fieldErr("TopicGranularity", "不明な粒度です (%q)。coarse, medium, fine のいずれかを指定してください", cfg.TopicGranularity)
+	}
+	if cfg.MaxTopics < 0 {
+		fieldErr("MaxTopics", "負の値は指定できません (%d)", cfg.MaxTopics)
+	}
+
+	if cfg.StrictNG && len(cfg.NGWords) == 0 {
+		fieldErr("StrictNG", "NGWords が空のため厳格モードは機能しません。NGリストを指定してください")
+	}
+	for i, word := range cfg.NGWords {
+		if pattern, ok := regexNGPattern(word); ok {
+			if _, err := regexp.Compile(pattern); err != nil {
+				fieldErr(fmt.Sprintf("NGWords[%d]", i), "正規表現が不正です: %v", err)
+			}
+		}
+	}
+
+	if cfg.MaxCostUSD < 0 {
+		fieldErr("MaxCostUSD", "負の値は指定できません (%v)", cfg.MaxCostUSD)
+	}
+
+	if cfg.SpeakerBalanceThreshold < 0 || cfg.SpeakerBalanceThreshold > 1 {
+		fieldErr("SpeakerBalanceThreshold", "0〜1 の範囲で指定してください (%v)", cfg.SpeakerBalanceThreshold)
+	}
+
+	if cfg.OverlapThreshold < 0 || cfg.OverlapThreshold > 1 {
+		fieldErr("OverlapThreshold", "0〜1 の範囲で指定してください (%v)", cfg.OverlapThreshold)
+	}
+	if cfg.OverlapNGram < 0 {
+		fieldErr("OverlapNGram", "負の値は指定できません (%d)", cfg.OverlapNGram)
+	}
+	if cfg.OverlapMinMatchChars < 0 {
+		fieldErr("OverlapMinMatchChars", "負の値は指定できません (%d)", cfg.OverlapMinMatchChars)
+	}
+
+	for phase, processors := range cfg.PostProcessors {
+		switch phase {
+		case PhaseMap, PhaseReduce, PhaseSummary, PhaseScript, PhaseTopic:
+		default:
+			fieldErr("PostProcessors", "未知のフェーズです (%q)。map, reduce, summary, script, topic のいずれかを指定してください", phase)
+		}
+		for i, process := range processors {
+			if process == nil {
+				fieldErr(fmt.Sprintf("PostProcessors[%q][%d]", phase, i), "nil の後処理は登録できません")
+			}
+		}
+	}
+	for i, tag := range cfg.ReasoningTags {
+		if !reasoningTagPattern.MatchString(tag) {
+			fieldErr(fmt.Sprintf("ReasoningTags[%d]", i), "タグ名の形式が不正です (%q)。英字で始まる英数字・'_'・'-' で指定してください", tag)
+		}
+	}
+
+	switch cfg.OutputLang {
+	case "", OutputLangJapanese, OutputLangEnglish:
+	default:
+		fieldErr("OutputLang", "未対応の出力言語です (%q)。ja, en のいずれかを指定してください", cfg.OutputLang)
+	}
+	if lang := cfg.OutputStyle.Language; lang != "" {
+		if _, ok := languageLabels[lang]; !ok {
+			fieldErr("OutputStyle.Language", "未対応の出力言語です (%q)。ja, en のいずれかを指定してください", lang)
+		} else if cfg.OutputLang != "" && lang != cfg.OutputLang {
+			fieldErr("OutputStyle.Language", "OutputLang (%q) と異なる言語は指定できません (%q)", cfg.OutputLang, lang)
+		}
+	}
+	if _, ok := politenessLabels[cfg.OutputStyle.Politeness]; !ok && cfg.OutputStyle.Politeness != "" {
+		fieldErr("OutputStyle.Politeness", "未対応の文体です (%q)。polite, plain のいずれかを指定してください", cfg.OutputStyle.Politeness)
+	}
+	if _, ok := formalityLabels[cfg.OutputStyle.Formality]; !ok && cfg.OutputStyle.Formality != "" {
+		fieldErr("OutputStyle.Formality", "未対応のトーンです (%q)。formal, casual のいずれかを指定してください", cfg.OutputStyle.Formality)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+// Package correlation は、並列処理中のログを記事・セグメント単位で追跡するための
+// 相関ID (cid) を context に載せて伝播させる仕組みを提供します。
+package correlation
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+)
+
+// LogKey は相関IDをログに出力する際の属性キーです。
+const LogKey = "cid"
+
+type contextKey struct{}
+
+// WithID は相関IDを載せた新しいコンテキストを返します。
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFromContext はコンテキストに載っている相関IDを返します。
+func IDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ArticleID は記事URLから短い相関ID (例: art-a1b2) を生成します。
+// 同じURLからは常に同じIDが生成されます。
+func ArticleID(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return "art-" + hex.EncodeToString(sum[:2])
+}
+
+// SegmentID はセグメント番号 (1始まり) から相関ID (例: seg-03) を生成します。
+func SegmentID(index int) string {
+	return fmt.Sprintf("seg-%02d", index)
+}
+
+// ----------------------------------------------------------------------
+// slog ハンドラー
+// ----------------------------------------------------------------------
+
+// Handler は、コンテキストに相関IDが存在する場合にレコードへ cid 属性を付与する slog.Handler です。
+// slog.InfoContext などの Context 付きロギング関数と組み合わせて使用します。
+type Handler struct {
+	slog.Handler
+}
+
+// NewHandler は next をラップした Handler を返します。
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{Handler: next}
+}
+
+// Handle はコンテキストの相関IDをレコードに追加してから、ラップ先のハンドラーに委譲します。
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := IDFromContext(ctx); ok {
+		r.AddAttrs(slog.String(LogKey, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs はラップ先のハンドラーに属性を追加した Handler を返します。
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup はラップ先のハンドラーにグループを追加した Handler を返します。
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{Handler: h.Handler.WithGroup(name)}
+}
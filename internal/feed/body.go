@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// フィードアイテムからの本文候補の抽出
+// ----------------------------------------------------------------------
+
+// 本文候補の優先順位 (ExtractItemBody の prefer に指定する値)
+const (
+	PreferContent     = "content"     // item.Content (全文) を優先
+	PreferDescription = "description" // item.Description (要約) を優先
+	PreferLonger      = "longer"      // プレーン化後の文字数が長い方を優先
+)
+
+// DefaultPrefer は本文候補のデフォルトの優先順位です。
+const DefaultPrefer = PreferContent
+
+// ValidatePrefer は prefer が既知の値かどうかを検証します。空文字列は DefaultPrefer として扱います。
+func ValidatePrefer(prefer string) error {
+	switch prefer {
+	case "", PreferContent, PreferDescription, PreferLonger:
+		return nil
+	default:
+		return fmt.Errorf("不明な本文の優先順位です: %q (%s, %s, %s のいずれかを指定してください)", prefer, PreferContent, PreferDescription, PreferLonger)
+	}
+}
+
+// ExtractItemBody は item.Content と item.Description から、prefer に従って本文候補を 1 つ選び、プレーンテキストで返します。
+// HTML を含む場合はタグを取り除いてプレーン化し、優先した候補が空の場合は次の候補にフォールバックします。
+// どちらも空の場合は空文字列を返します。
+func ExtractItemBody(item *gofeed.Item, prefer string) string {
+	if item == nil {
+		return ""
+	}
+	content := htmlToText(item.Content)
+	description := htmlToText(item.Description)
+
+	first, second := content, description
+	switch prefer {
+	case PreferDescription:
+		first, second = description, content
+	case PreferLonger:
+		if len([]rune(description)) > len([]rune(content)) {
+			first, second = description, content
+		}
+	}
+	if first != "" {
+		return first
+	}
+	return second
+}
+
+// htmlTagPattern はHTMLタグに一致します。
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// htmlToText はフィードの本文/要約に含まれるHTMLを取り除き、プレーンテキストに変換します。
+func htmlToText(s string) string {
+	text := html.UnescapeString(htmlTagPattern.ReplaceAllString(s, " "))
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,233 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// Conditional GET (ETag / Last-Modified) によるフィード取得
+// ----------------------------------------------------------------------
+
+// ErrNotModified はフィードが前回の取得から更新されていない (304 Not Modified) ことを示します。
+var ErrNotModified = errors.New("フィードは前回の取得から更新されていません")
+
+// ConditionalCache は Conditional GET に使用する ETag / Last-Modified の保存先です。
+// 実装はファイル・メモリ・外部KVなどに差し替えられます。並行して呼び出されても安全である必要があります。
+type ConditionalCache interface {
+	Get(url string) (etag, lastModified string, ok bool)
+	Set(url, etag, lastModified string)
+}
+
+// Parser は ConditionalCache を使ってフィードを Conditional GET で取得し、パースします。
+// runner.FeedParser インターフェースを満たします。
+type Parser struct {
+	client *http.Client
+	cache  ConditionalCache
+	config FetchConfig
+}
+
+// NewParser は新しい Parser を初期化します。cache が nil の場合は常に通常の GET で取得します。
+// config.UserAgent が空の場合は DefaultUserAgent を使用します。
+func NewParser(client *http.Client, cache ConditionalCache, config FetchConfig) *Parser {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+	return &Parser{client: client, cache: cache, config: config}
+}
+
+// FetchAndParse は指定されたURLからフィードを取得し、パースします。
+// キャッシュに検証子があれば If-None-Match / If-Modified-Since を付与し、304 の場合は ErrNotModified を返します。
+// 取得に成功した場合はレスポンスの ETag / Last-Modified をキャッシュに保存します。
+// feedURL が "-" または file:// の場合は HTTP リクエストを行わず、標準入力またはローカルファイルから読み込みます (local.goで定義)。
+func (p *Parser) FetchAndParse(ctx context.Context, feedURL string) (*gofeed.Feed, error) {
+	if IsLocalFeed(feedURL) {
+		return parseLocalFeed(feedURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("フィードのリクエスト作成失敗 (URL: %s): %w", feedURL, err)
+	}
+	p.config.apply(req)
+	if p.cache != nil {
+		if etag, lastModified, ok := p.cache.Get(feedURL); ok {
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	resp, err := p.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("フィードの取得失敗 (URL: %s): %w", feedURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, fmt.Errorf("%w (URL: %s)", ErrNotModified, feedURL)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("フィードの取得失敗 (URL: %s): HTTPステータス %d", feedURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("フィードの読み込み失敗 (URL: %s): %w", feedURL, err)
+	}
+
+	feed, err := gofeed.NewParser().ParseString(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("RSSフィードのパース失敗 (URL: %s): %w", feedURL, err)
+	}
+	if p.cache != nil {
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			p.cache.Set(feedURL, etag, lastModified)
+		}
+	}
+	return feed, nil
+}
+
+// ----------------------------------------------------------------------
+// ConditionalCache の実装
+// ----------------------------------------------------------------------
+
+// validators は 1 フィード分の検証子です。
+type validators struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at,omitzero"` // 旧形式のキャッシュではゼロ値 (LoadFileCache がファイルの更新時刻で補う)
+}
+
+// MemoryCache はプロセス内でのみ保持するインメモリの ConditionalCache です。
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]validators
+}
+
+// NewMemoryCache は空の MemoryCache を生成します。
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]validators)}
+}
+
+// Get は url の検証子を返します。
+func (c *MemoryCache) Get(url string) (string, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.entries[url]
+	return v.ETag, v.LastModified, ok
+}
+
+// Set は url の検証子を保存します。
+func (c *MemoryCache) Set(url, etag, lastModified string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[url] = validators{ETag: etag, LastModified: lastModified, StoredAt: time.Now()}
+}
+
+// Stats はエントリ数と、最も古い・新しいエントリの保存時刻を返します (エントリがない場合はゼロ値)。
+func (c *MemoryCache) Stats() (entries int, oldest, newest time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range c.entries {
+		if oldest.IsZero() || v.StoredAt.Before(oldest) {
+			oldest = v.StoredAt
+		}
+		if v.StoredAt.After(newest) {
+			newest = v.StoredAt
+		}
+	}
+	return len(c.entries), oldest, newest
+}
+
+// Prune は保存時刻が before より前のエントリを削除し、削除した件数を返します。
+// 削除したフィードは次回の取得で Conditional GET を使用せず、全体を取得し直します。
+func (c *MemoryCache) Prune(before time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for url, v := range c.entries {
+		if v.StoredAt.Before(before) {
+			delete(c.entries, url)
+			removed++
+		}
+	}
+	return removed
+}
+
+// FileCache は JSON ファイルに永続化する ConditionalCache です。
+// Set はメモリ上のみを更新し、Save を呼び出した時点でファイルへ書き込みます。
+// 処理が成功した場合のみ Save することで、失敗した実行の検証子で次回の取得がスキップされることを防げます。
+type FileCache struct {
+	*MemoryCache
+	path string
+}
+
+// LoadFileCache は path からキャッシュを読み込みます。ファイルが存在しない場合は空のキャッシュを返します。
+func LoadFileCache(path string) (*FileCache, error) {
+	c := &FileCache{MemoryCache: NewMemoryCache(), path: path}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("フィードキャッシュの読み込みに失敗しました: %w", err)
+	}
+	if err := json.Unmarshal(raw, &c.entries); err != nil {
+		return nil, fmt.Errorf("フィードキャッシュの解析に失敗しました (%s): %w", path, err)
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]validators)
+	}
+	// 旧形式のエントリは保存時刻がないため、ファイルの更新時刻を保存時刻とみなす
+	if info, err := os.Stat(path); err == nil {
+		for url, v := range c.entries {
+			if v.StoredAt.IsZero() {
+				v.StoredAt = info.ModTime()
+				c.entries[url] = v
+			}
+		}
+	}
+	return c, nil
+}
+
+// Save はキャッシュをファイルに書き込みます。書き込み途中の破損を防ぐため、一時ファイル経由で置き換えます。
+func (c *FileCache) Save() error {
+	c.mu.Lock()
+	raw, err := json.MarshalIndent(c.entries, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("フィードキャッシュのシリアライズに失敗しました: %w", err)
+	}
+
+	if dir := filepath.Dir(c.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("フィードキャッシュのディレクトリ作成に失敗しました (%s): %w", dir, err)
+		}
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("フィードキャッシュの書き込みに失敗しました: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("フィードキャッシュの置き換えに失敗しました: %w", err)
+	}
+	slog.Debug("フィードキャッシュを保存しました", slog.String("path", c.path))
+	return nil
+}
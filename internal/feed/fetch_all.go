@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// 複数フィードの並列取得と記事URLの集約
+// ----------------------------------------------------------------------
+
+// DefaultFetchConcurrency は複数フィードを並列に取得する際の同時取得数の既定値です。
+const DefaultFetchConcurrency = 4
+
+// Fetcher はフィードを取得・パースします (Parser と runner.FeedParser が満たします)。
+type Fetcher interface {
+	FetchAndParse(ctx context.Context, feedURL string) (*gofeed.Feed, error)
+}
+
+// FetcherFunc は関数を Fetcher として扱うためのアダプタです。
+type FetcherFunc func(ctx context.Context, feedURL string) (*gofeed.Feed, error)
+
+// FetchAndParse は f(ctx, feedURL) を呼び出します。
+func (f FetcherFunc) FetchAndParse(ctx context.Context, feedURL string) (*gofeed.Feed, error) {
+	return f(ctx, feedURL)
+}
+
+// FetchResult は 1 つのフィードの取得結果です。Err が nil でない場合、Feed は nil です。
+type FetchResult struct {
+	FeedURL string
+	Feed    *gofeed.Feed
+	Err     error
+}
+
+// FetchError はフィードごとの取得失敗です。
+type FetchError struct {
+	FeedURL string
+	Err     error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("フィード (%s) の取得に失敗しました: %v", e.FeedURL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// LinkItem は集約した記事のリンクとメタデータ、および取得元のフィードです。
+type LinkItem struct {
+	FeedItem
+	FeedURL   string // 記事を最初に含んでいたフィードのURL
+	FeedTitle string // 記事を最初に含んでいたフィードのタイトル
+}
+
+// FetchAll は feedURLs を最大 concurrency 件ずつ並列に取得・パースし、feedURLs と同じ順序で結果を返します。
+// concurrency が 0 以下の場合は DefaultFetchConcurrency を使用します。
+// フィードごとの失敗は FetchResult.Err に記録し、他のフィードの取得は継続します。
+func FetchAll(ctx context.Context, fetcher Fetcher, feedURLs []string, concurrency int) []FetchResult {
+	if concurrency <= 0 {
+		concurrency = DefaultFetchConcurrency
+	}
+	results := make([]FetchResult, len(feedURLs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, feedURL := range feedURLs {
+		results[i].FeedURL = feedURL
+		wg.Add(1)
+		go func(i int, feedURL string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			}
+			results[i].Feed, results[i].Err = fetcher.FetchAndParse(ctx, feedURL)
+		}(i, feedURL)
+	}
+	wg.Wait()
+	return results
+}
+
+// FetchAllAndExtract は feedURLs を並列に取得し、成功したフィードの記事をフィードの指定順・出現順に集約して返します。
+// 複数のフィードに同じ記事URLが含まれる場合は、最初のフィードのものだけを残します。
+// 取得に失敗したフィードは *FetchError として errs に記録します。
+func FetchAllAndExtract(ctx context.Context, fetcher Fetcher, feedURLs []string, concurrency int) (aggregated []LinkItem, errs []error) {
+	seen := make(map[string]bool)
+	for _, res := range FetchAll(ctx, fetcher, feedURLs, concurrency) {
+		if res.Err != nil {
+			errs = append(errs, &FetchError{FeedURL: res.FeedURL, Err: res.Err})
+			continue
+		}
+		for _, item := range ExtractItems(res.Feed) {
+			if seen[item.URL] {
+				continue
+			}
+			seen[item.URL] = true
+			aggregated = append(aggregated, LinkItem{FeedItem: item, FeedURL: res.FeedURL, FeedTitle: res.Feed.Title})
+		}
+	}
+	return aggregated, errs
+}
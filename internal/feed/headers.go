@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// フィード取得時の HTTP ヘッダー (User-Agent・認証トークンなど)
+// ----------------------------------------------------------------------
+
+// DefaultUserAgent はフィード取得時に既定で送信する User-Agent です。
+// User-Agent のないリクエストを 403 で拒否するフィードがあるため、常に付与します。
+const DefaultUserAgent = "act-feed-clean-go/1.0 (+https://github.com/shouni/act-feed-clean-go)"
+
+// FetchConfig はフィード取得リクエストに付与するヘッダーと、一時的な失敗の再試行の設定です。
+type FetchConfig struct {
+	// UserAgent はリクエストの User-Agent です (空の場合は DefaultUserAgent)。
+	UserAgent string
+	// Headers は追加で付与するヘッダーです。"User-Agent" を含む場合は UserAgent より優先します。
+	Headers map[string]string
+	// MaxAttempts は初回を含む最大試行回数です (0 以下の場合は DefaultFetchAttempts)。retry.go で使用します。
+	MaxAttempts int
+	// RetryBaseDelay は再試行の初回の待機時間で、以降は試行ごとに倍になります (0 以下の場合は DefaultRetryBaseDelay)。
+	RetryBaseDelay time.Duration
+}
+
+// apply は req に User-Agent と追加のヘッダーを設定します。
+// Conditional GET の検証子 (If-None-Match など) はこの後に設定されるため、Headers で上書きされません。
+func (c FetchConfig) apply(req *http.Request) {
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+}
+
+// ParseHeaders は "key=value" 形式のヘッダー指定をマップに変換します (例: X-API-Token=xxxx)。
+// 値にはカンマや "=" を含められます。同じヘッダー名 (大文字小文字を区別しない) の重複指定はエラーです。
+func ParseHeaders(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("ヘッダーの形式が不正です: %q (例: X-API-Token=xxxx)", entry)
+		}
+		if strings.ContainsAny(key, " \t:") {
+			return nil, fmt.Errorf("ヘッダー名が不正です: %q", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("ヘッダー (%s) の値に改行は含められません", key)
+		}
+		key = http.CanonicalHeaderKey(key)
+		if _, dup := headers[key]; dup {
+			return nil, fmt.Errorf("ヘッダーが複数回指定されています: %q", key)
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,88 @@
+package feed
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// フィードアイテムのメタデータの抽出
+// ----------------------------------------------------------------------
+
+// FeedItem はフィードアイテムのリンクと、gofeed が解析したメタデータです。
+type FeedItem struct {
+	URL       string
+	Title     string    // アイテムのタイトル (前後の空白を除去。ない場合は空)
+	Published time.Time // 公開時刻 (未設定の場合は更新時刻。どちらもない場合はゼロ値)
+	Author    string    // 著者名 (ない場合は空)
+}
+
+// HasPublished は公開時刻 (または更新時刻) が設定されているかどうかを返します。
+func (i FeedItem) HasPublished() bool {
+	return !i.Published.IsZero()
+}
+
+// ExtractItems はフィードのアイテムをリンク・タイトル・公開時刻・著者とともに出現順に返します。
+// リンクのないアイテムは含めません。feed が nil の場合は nil を返します。
+func ExtractItems(feed *gofeed.Feed) []FeedItem {
+	if feed == nil {
+		return nil
+	}
+	items := make([]FeedItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		if item == nil || item.Link == "" {
+			continue
+		}
+		published, _ := ItemPublished(item)
+		items = append(items, FeedItem{
+			URL:       item.Link,
+			Title:     strings.TrimSpace(item.Title),
+			Published: published,
+			Author:    itemAuthor(item),
+		})
+	}
+	return items
+}
+
+// ExtractLinks はフィードのアイテムのリンクを出現順に返します (ExtractItems の URL のみ)。
+func ExtractLinks(feed *gofeed.Feed) []string {
+	items := ExtractItems(feed)
+	if items == nil {
+		return nil
+	}
+	links := make([]string, 0, len(items))
+	for _, item := range items {
+		links = append(links, item.URL)
+	}
+	return links
+}
+
+// ExtractLinksLimit はフィードのアイテムのリンクを出現順に先頭から最大 n 件返します。n <= 0 の場合は上限なしです。
+// 公開時刻で絞り込む場合は、ExtractLinksSince の結果に LimitLinks を適用してください。
+func ExtractLinksLimit(feed *gofeed.Feed, n int) []string {
+	links, _ := LimitLinks(ExtractLinks(feed), n)
+	return links
+}
+
+// LimitLinks は links の先頭から最大 n 件を返し、あわせて除外した件数を返します。n <= 0 の場合は上限なしです。
+func LimitLinks(links []string, n int) ([]string, int) {
+	if n <= 0 || len(links) <= n {
+		return links, 0
+	}
+	return links[:n], len(links) - n
+}
+
+// itemAuthor はアイテムの著者名を返します。item.Author がない場合は item.Authors の最初の著者を使用します。
+func itemAuthor(item *gofeed.Item) string {
+	if item.Author != nil && strings.TrimSpace(item.Author.Name) != "" {
+		return strings.TrimSpace(item.Author.Name)
+	}
+	for _, author := range item.Authors {
+		if author != nil && strings.TrimSpace(author.Name) != "" {
+			return strings.TrimSpace(author.Name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// ローカルファイル・標準入力からのフィード読み込み
+// ----------------------------------------------------------------------
+
+// StdinFeedURL はフィードを標準入力から読み込むことを示すフィードURLです。
+const StdinFeedURL = "-"
+
+// fileScheme はローカルファイルのフィードを示す URL スキームです。
+const fileScheme = "file://"
+
+// ParseReader は r からフィード (RSS / Atom / JSON Feed) を読み込み、パースします。
+func ParseReader(r io.Reader) (*gofeed.Feed, error) {
+	feed, err := gofeed.NewParser().Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("RSSフィードのパース失敗: %w", err)
+	}
+	return feed, nil
+}
+
+// IsLocalFeed は feedURL が標準入力 ("-") またはローカルファイル (file://) を指すかどうかを返します。
+func IsLocalFeed(feedURL string) bool {
+	return feedURL == StdinFeedURL || strings.HasPrefix(strings.ToLower(feedURL), fileScheme)
+}
+
+// parseLocalFeed は標準入力または file:// URL のファイルからフィードを読み込みます。
+// ネットワークにアクセスしないため、Conditional GET のキャッシュは使用しません。
+func parseLocalFeed(feedURL string) (*gofeed.Feed, error) {
+	if feedURL == StdinFeedURL {
+		feed, err := ParseReader(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("標準入力からのフィードの読み込み失敗: %w", err)
+		}
+		return feed, nil
+	}
+
+	path, err := fileURLPath(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("フィードファイルの読み込み失敗 (URL: %s): %w", feedURL, err)
+	}
+	defer f.Close()
+	feed, err := ParseReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w (URL: %s)", err, feedURL)
+	}
+	return feed, nil
+}
+
+// fileURLPath は file:// URL をファイルパスに変換します。
+// file:///abs/feed.xml は絶対パス、file://./feed.xml や file://feed.xml はカレントディレクトリからの相対パスとして扱います。
+func fileURLPath(feedURL string) (string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return "", fmt.Errorf("フィードファイルの URL が不正です (%s): %w", feedURL, err)
+	}
+	path := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		path = u.Host + u.Path
+	}
+	if path == "" {
+		return "", fmt.Errorf("フィードファイルのパスが空です (%s)", feedURL)
+	}
+	return path, nil
+}
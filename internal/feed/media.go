@@ -0,0 +1,74 @@
+package feed
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// フィードアイテムからの画像 (アイキャッチ) URL の抽出
+// ----------------------------------------------------------------------
+
+// imgSrcPattern は本文 HTML 中の最初の <img src="..."> を抽出します。
+var imgSrcPattern = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["']`)
+
+// Media はフィードアイテムに含まれるメディアの URL です。
+type Media struct {
+	// Images は画像 URL の候補です。アイキャッチとしての確度が高い順 (重複なし) に並びます。
+	Images []string
+}
+
+// ImageURL は最も確度の高い画像 URL を返します。候補がない場合は空文字列を返します。
+func (m Media) ImageURL() string {
+	if len(m.Images) == 0 {
+		return ""
+	}
+	return m.Images[0]
+}
+
+// ExtractMedia はフィードアイテムから画像 URL の候補を抽出します。
+// item.Image、画像の enclosure、media:thumbnail / media:content、iTunes の画像、本文中の最初の <img> の順に候補とします。
+func ExtractMedia(item *gofeed.Item) Media {
+	var m Media
+	if item == nil {
+		return m
+	}
+	seen := make(map[string]bool)
+	add := func(url string) {
+		url = strings.TrimSpace(url)
+		if (strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")) && !seen[url] {
+			seen[url] = true
+			m.Images = append(m.Images, url)
+		}
+	}
+
+	if item.Image != nil {
+		add(item.Image.URL)
+	}
+	for _, enc := range item.Enclosures {
+		if enc != nil && strings.HasPrefix(enc.Type, "image/") {
+			add(enc.URL)
+		}
+	}
+	if media, ok := item.Extensions["media"]; ok {
+		for _, thumb := range media["thumbnail"] {
+			add(thumb.Attrs["url"])
+		}
+		for _, content := range media["content"] {
+			if content.Attrs["medium"] == "image" || strings.HasPrefix(content.Attrs["type"], "image/") {
+				add(content.Attrs["url"])
+			}
+		}
+	}
+	if item.ITunesExt != nil {
+		add(item.ITunesExt.Image)
+	}
+	for _, html := range []string{item.Content, item.Description} {
+		if match := imgSrcPattern.FindStringSubmatch(html); match != nil {
+			add(match[1])
+		}
+	}
+	return m
+}
@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// フィード取得の一時的な失敗の再試行 (指数バックオフ)
+// ----------------------------------------------------------------------
+
+const (
+	// DefaultFetchAttempts はフィード取得の初回を含む最大試行回数の既定値です。
+	DefaultFetchAttempts = 3
+	// DefaultRetryBaseDelay は再試行の初回の待機時間の既定値です (以降は試行ごとに倍になります)。
+	DefaultRetryBaseDelay = time.Second
+	// maxRetryDelay は再試行の待機時間の上限です。
+	maxRetryDelay = 30 * time.Second
+)
+
+// retryableStatus はステータスコードが一時的な失敗 (5xx / 429) かどうかを返します。
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// do は req を送信し、ネットワークエラーと 5xx / 429 の場合は指数バックオフで再試行します。
+// 4xx (429 を除く) と 304 はそのまま返します。ctx がキャンセルされた場合は待機を中断して ctx のエラーを返します。
+// 再試行しても一時的な失敗が続いた場合は、最後のレスポンス (5xx / 429) またはエラーを返します。
+func (p *Parser) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	attempts := p.config.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultFetchAttempts
+	}
+	delay := p.config.RetryBaseDelay
+	if delay <= 0 {
+		delay = DefaultRetryBaseDelay
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := p.client.Do(req)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= attempts || (err == nil && !retryableStatus(resp.StatusCode)) {
+			return resp, err
+		}
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = fmt.Sprintf("HTTPステータス %d", resp.StatusCode)
+			// 接続を再利用できるよう本文を読み捨ててから閉じる
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		slog.Warn("フィードの取得に一時的に失敗したため再試行します",
+			slog.String("url", req.URL.String()),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", attempts),
+			slog.Duration("wait", delay),
+			slog.String("reason", reason),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("再試行の待機中に中断されました (%s): %w", reason, ctx.Err())
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
@@ -0,0 +1,71 @@
+package feed
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ----------------------------------------------------------------------
+// 公開時刻によるアイテムの絞り込み
+// ----------------------------------------------------------------------
+
+// 公開時刻のないアイテムの扱い (ExtractLinksSince / KeepSince の undated に指定する値)
+const (
+	UndatedInclude = "include" // 公開時刻のないアイテムを残す
+	UndatedExclude = "exclude" // 公開時刻のないアイテムを除外する
+)
+
+// DefaultUndated は公開時刻のないアイテムのデフォルトの扱いです。
+const DefaultUndated = UndatedInclude
+
+// ValidateUndated は undated が既知の値かどうかを検証します。空文字列は DefaultUndated として扱います。
+func ValidateUndated(undated string) error {
+	switch undated {
+	case "", UndatedInclude, UndatedExclude:
+		return nil
+	default:
+		return fmt.Errorf("不明な公開時刻なしの扱いです: %q (%s, %s のいずれかを指定してください)", undated, UndatedInclude, UndatedExclude)
+	}
+}
+
+// ItemPublished はアイテムの公開時刻を返します。公開時刻がない場合は更新時刻を使用し、
+// どちらもない場合 (item が nil の場合を含む) は false を返します。
+func ItemPublished(item *gofeed.Item) (time.Time, bool) {
+	if item == nil {
+		return time.Time{}, false
+	}
+	if item.PublishedParsed != nil {
+		return *item.PublishedParsed, true
+	}
+	if item.UpdatedParsed != nil {
+		return *item.UpdatedParsed, true
+	}
+	return time.Time{}, false
+}
+
+// KeepSince は公開時刻が since 以降のアイテムを残すかどうかを返します。
+// dated が false (公開時刻なし) の場合は undated に従います。since がゼロ値の場合は常に true です。
+func KeepSince(published time.Time, dated bool, since time.Time, undated string) bool {
+	if since.IsZero() {
+		return true
+	}
+	if !dated {
+		return undated != UndatedExclude
+	}
+	return !published.Before(since)
+}
+
+// ExtractLinksSince はフィードから公開時刻が since 以降のアイテムのリンクを出現順に返します。
+// 公開時刻のないアイテムは undated に従って残すか除外し、リンクのないアイテムは含めません。
+// feed が nil の場合は nil を返します。
+func ExtractLinksSince(feed *gofeed.Feed, since time.Time, undated string) []string {
+	var links []string
+	for _, item := range ExtractItems(feed) {
+		if KeepSince(item.Published, item.HasPublished(), since, undated) {
+			links = append(links, item.URL)
+		}
+	}
+	return links
+}
@@ -0,0 +1,163 @@
+package golden
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+// ----------------------------------------------------------------------
+// LLM 呼び出しの記録・再生 (カセット)
+// ----------------------------------------------------------------------
+
+// ErrNotRecorded は再生時に、プロンプトに対応するレスポンスがカセットに記録されていないことを示します。
+// プロンプトテンプレートを変更した場合に発生するため、--record で実 API から再記録してください。
+var ErrNotRecorded = errors.New("プロンプトに対応するレスポンスがカセットに記録されていません")
+
+// Interaction は 1 回分の LLM 呼び出しのプロンプトとレスポンスです。
+type Interaction struct {
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// Cassette はモデル名とプロンプトをキーに LLM のレスポンスを保持します。
+// 並行して呼び出されても安全です (Mapフェーズはセグメントを並列に処理するため)。
+type Cassette struct {
+	mu           sync.Mutex
+	interactions map[string]Interaction
+	used         map[string]bool
+}
+
+// NewCassette は空のカセットを生成します。
+func NewCassette() *Cassette {
+	return &Cassette{interactions: make(map[string]Interaction), used: make(map[string]bool)}
+}
+
+// LoadCassette は path からカセットを読み込みます。
+func LoadCassette(path string) (*Cassette, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("カセットの読み込みに失敗しました: %w", err)
+	}
+	var list []Interaction
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("カセットの解析に失敗しました (%s): %w", path, err)
+	}
+	c := NewCassette()
+	for _, in := range list {
+		c.interactions[interactionKey(in.Model, in.Prompt)] = in
+	}
+	return c, nil
+}
+
+// Save はカセットを path に書き込みます。差分を確認しやすいよう、モデル名とプロンプトの順に並べます。
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	list := make([]Interaction, 0, len(c.interactions))
+	for _, in := range c.interactions {
+		list = append(list, in)
+	}
+	c.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Model != list[j].Model {
+			return list[i].Model < list[j].Model
+		}
+		return list[i].Prompt < list[j].Prompt
+	})
+
+	raw, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("カセットのシリアライズに失敗しました: %w", err)
+	}
+	if err := os.WriteFile(path, append(raw, '\n'), 0o644); err != nil {
+		return fmt.Errorf("カセットの書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Unused は記録されているものの、再生で一度も使われなかったレスポンスの数を返します。
+// 0 でない場合、プロンプトの変更により古い記録が残っている可能性があります。
+func (c *Cassette) Unused() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.interactions) - len(c.used)
+}
+
+// lookup は記録済みのレスポンスを返します。
+func (c *Cassette) lookup(model, prompt string) (string, bool) {
+	key := interactionKey(model, prompt)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	in, ok := c.interactions[key]
+	if ok {
+		c.used[key] = true
+	}
+	return in.Response, ok
+}
+
+// record はレスポンスを記録します。
+func (c *Cassette) record(model, prompt, response string) {
+	key := interactionKey(model, prompt)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions[key] = Interaction{Model: model, Prompt: prompt, Response: response}
+	c.used[key] = true
+}
+
+// interactionKey はモデル名とプロンプトから記録のキーを作成します。
+func interactionKey(model, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReplayClient はカセットに記録されたレスポンスを返す gemini.GenerativeModel です。実 API には接続しません。
+type ReplayClient struct {
+	cassette *Cassette
+}
+
+// NewReplayClient は cassette を再生する ReplayClient を生成します。
+func NewReplayClient(cassette *Cassette) *ReplayClient {
+	return &ReplayClient{cassette: cassette}
+}
+
+// GenerateContent は記録済みのレスポンスを返します。記録がない場合は ErrNotRecorded を返します。
+func (r *ReplayClient) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	response, ok := r.cassette.lookup(modelName, prompt)
+	if !ok {
+		return nil, fmt.Errorf("%w (model: %s)", ErrNotRecorded, modelName)
+	}
+	return &gemini.Response{Text: response}, nil
+}
+
+// RecordingClient は client を呼び出し、成功したレスポンスをカセットに記録する gemini.GenerativeModel です。
+type RecordingClient struct {
+	client   gemini.GenerativeModel
+	cassette *Cassette
+}
+
+// NewRecordingClient は client のレスポンスを cassette に記録する RecordingClient を生成します。
+func NewRecordingClient(client gemini.GenerativeModel, cassette *Cassette) *RecordingClient {
+	return &RecordingClient{client: client, cassette: cassette}
+}
+
+// GenerateContent は client を呼び出し、成功した場合はレスポンスを記録します。
+func (r *RecordingClient) GenerateContent(ctx context.Context, prompt string, modelName string) (*gemini.Response, error) {
+	response, err := r.client.GenerateContent(ctx, prompt, modelName)
+	if err != nil {
+		return nil, err
+	}
+	r.cassette.record(modelName, prompt, response.Text)
+	return response, nil
+}
@@ -0,0 +1,204 @@
+package golden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/pipeline"
+
+	"github.com/shouni/go-ai-client/v2/pkg/ai/gemini"
+)
+
+// ----------------------------------------------------------------------
+// Cleaner のフェーズ単位のゴールデンファイル比較
+// ----------------------------------------------------------------------
+
+// DefaultDir はゴールデンケースを置くデフォルトのディレクトリです。
+const DefaultDir = "testdata/golden"
+
+const (
+	// InputFile は Map・Reduce に渡す結合済みの本文 (cleaner.CombineContents の出力相当) のファイル名です。
+	InputFile = "input.md"
+	// TitleFile はタイトルのファイル名です (任意。Reduce結果からタイトルを抽出できない場合に使用)。
+	TitleFile = "title.txt"
+	// CassetteFile は LLM のレスポンスを記録したカセットのファイル名です。
+	CassetteFile = "cassette.json"
+)
+
+// mapSeparator は map.md で Map要約を区切る行です。
+const mapSeparator = "\n\n---\n\n"
+
+// phaseFiles はフェーズと期待出力のファイル名の対応です (比較する順序)。
+var phaseFiles = []struct {
+	Phase string
+	File  string
+}{
+	{cleaner.PhaseMap, "map.md"},
+	{cleaner.PhaseReduce, "reduce.md"},
+	{cleaner.PhaseSummary, "summary.md"},
+	{cleaner.PhaseScript, "script.txt"},
+}
+
+// Case は 1 つのゴールデンケース (InputFile を含むディレクトリ) です。
+type Case struct {
+	Name string
+	Dir  string
+}
+
+// Mismatch は期待出力と一致しなかったファイルと、その行単位の差分です。
+type Mismatch struct {
+	File string
+	Diff string
+}
+
+// LoadCases は root 直下のディレクトリのうち、InputFile を含むものをゴールデンケースとして名前順に返します。
+func LoadCases(root string) ([]Case, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("ゴールデンケースのディレクトリの読み込みに失敗しました: %w", err)
+	}
+	var cases []Case
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, InputFile)); err != nil {
+			continue
+		}
+		cases = append(cases, Case{Name: entry.Name(), Dir: dir})
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("ゴールデンケースが見つかりません (%s/*/%s)", root, InputFile)
+	}
+	return cases, nil
+}
+
+// Generate はケースの入力から Map・Reduce・Summary・Script の各フェーズを client で実行し、
+// 期待出力のファイル名をキーとする出力を返します。Map要約は並列に生成されるため、並べ替えてから連結します。
+func (c Case) Generate(ctx context.Context, client gemini.GenerativeModel, config cleaner.CleanerConfig) (map[string]string, error) {
+	input, err := os.ReadFile(filepath.Join(c.Dir, InputFile))
+	if err != nil {
+		return nil, fmt.Errorf("ケース %s の入力の読み込みに失敗しました: %w", c.Name, err)
+	}
+	fallbackTitle := c.Name
+	if raw, err := os.ReadFile(filepath.Join(c.Dir, TitleFile)); err == nil {
+		fallbackTitle = strings.TrimSpace(string(raw))
+	}
+
+	// Map要約は Cleaner の外から取得できないため、後処理として登録して収集する
+	var mu sync.Mutex
+	var mapSummaries []string
+	processors := make(map[string][]cleaner.PostProcessor, len(config.PostProcessors)+1)
+	for phase, list := range config.PostProcessors {
+		processors[phase] = list
+	}
+	processors[cleaner.PhaseMap] = append(processors[cleaner.PhaseMap], func(text string) (string, error) {
+		mu.Lock()
+		mapSummaries = append(mapSummaries, text)
+		mu.Unlock()
+		return text, nil
+	})
+	config.PostProcessors = processors
+
+	llm, err := cleaner.NewCleaner(client, config)
+	if err != nil {
+		return nil, err
+	}
+	reduce, err := llm.CleanAndStructureText(ctx, string(input))
+	if err != nil {
+		return nil, fmt.Errorf("ケース %s の Map・Reduce に失敗しました: %w", c.Name, err)
+	}
+	title := cleaner.ExtractTitleFromMarkdown(reduce)
+	if title == "" {
+		title = fallbackTitle
+	}
+	summary, err := llm.GenerateFinalSummary(ctx, title, reduce)
+	if err != nil {
+		return nil, fmt.Errorf("ケース %s の最終要約の生成に失敗しました: %w", c.Name, err)
+	}
+	script, err := llm.GenerateScriptForVoicevox(ctx, title, summary, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ケース %s のスクリプトの生成に失敗しました: %w", c.Name, err)
+	}
+
+	sort.Strings(mapSummaries)
+	outputs := map[string]string{
+		cleaner.PhaseMap:     strings.Join(mapSummaries, mapSeparator),
+		cleaner.PhaseReduce:  reduce,
+		cleaner.PhaseSummary: summary,
+		cleaner.PhaseScript:  script,
+	}
+	files := make(map[string]string, len(phaseFiles))
+	for _, pf := range phaseFiles {
+		files[pf.File] = strings.TrimRight(outputs[pf.Phase], "\n") + "\n"
+	}
+	return files, nil
+}
+
+// Config は出力を決定的にするため、フラグによらず使用する既定の Cleaner の設定です。
+// カセットの再生時はレート制限の待機も不要なため、待機時間を最小にします。
+func Config() cleaner.CleanerConfig {
+	return cleaner.CleanerConfig{LLMRateLimit: time.Millisecond}
+}
+
+// Replay はケースのカセットを再生して各フェーズを実行し、期待出力と比較します。
+// update が true の場合は比較せずに期待出力を今回の出力で更新します。
+// unused はカセットに記録されているが使用されなかったレスポンスの数です (プロンプトの変更の目安)。
+func (c Case) Replay(ctx context.Context, update bool) (mismatches []Mismatch, unused int, err error) {
+	cassette, err := LoadCassette(filepath.Join(c.Dir, CassetteFile))
+	if err != nil {
+		return nil, 0, fmt.Errorf("ケース %s: %w", c.Name, err)
+	}
+	outputs, err := c.Generate(ctx, NewReplayClient(cassette), Config())
+	if err != nil {
+		return nil, 0, err
+	}
+	if update {
+		return nil, cassette.Unused(), c.Update(outputs)
+	}
+	mismatches, err = c.Compare(outputs)
+	return mismatches, cassette.Unused(), err
+}
+
+// Compare は outputs をケースの期待出力と比較し、一致しなかったファイルを返します。
+// 期待出力のファイルがない場合は空のファイルとして比較します。
+func (c Case) Compare(outputs map[string]string) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	for _, pf := range phaseFiles {
+		path := filepath.Join(c.Dir, pf.File)
+		expected, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("期待出力の読み込みに失敗しました (%s): %w", path, err)
+		}
+		actual := outputs[pf.File]
+		if string(expected) == actual {
+			continue
+		}
+		mismatches = append(mismatches, Mismatch{
+			File: pf.File,
+			Diff: pipeline.RenderLineDiff(path+" (期待)", pf.File+" (今回)", string(expected), actual),
+		})
+	}
+	return mismatches, nil
+}
+
+// Update は outputs でケースの期待出力を上書きします。
+func (c Case) Update(outputs map[string]string) error {
+	for _, pf := range phaseFiles {
+		path := filepath.Join(c.Dir, pf.File)
+		if err := os.WriteFile(path, []byte(outputs[pf.File]), 0o644); err != nil {
+			return fmt.Errorf("期待出力の更新に失敗しました (%s): %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package golden
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+// update は期待出力を今回の出力で更新するフラグです (go test ./internal/golden -update)。
+var update = flag.Bool("update", false, "testdata/golden の期待出力を今回の出力で更新する")
+
+func TestGolden(t *testing.T) {
+	cases, err := LoadCases(filepath.Join("..", "..", DefaultDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			mismatches, unused, err := c.Replay(context.Background(), *update)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unused > 0 {
+				t.Logf("カセットに使用されなかったレスポンスが %d 件あります。プロンプトを変更した場合は 'golden --record' で記録し直してください", unused)
+			}
+			if *update {
+				t.Logf("期待出力を更新しました: %s", c.Dir)
+				return
+			}
+			for _, m := range mismatches {
+				t.Errorf("%s が期待出力と一致しません (意図した変更の場合は -update で更新してください):\n%s", m.File, m.Diff)
+			}
+		})
+	}
+}
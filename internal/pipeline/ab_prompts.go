@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"act-feed-clean-go/internal/cleaner"
+)
+
+// ----------------------------------------------------------------------
+// プロンプトセットの A/B 比較 (--ab-prompts)
+// ----------------------------------------------------------------------
+
+// DefaultABOutputDir は A/B 比較の結果を保存するデフォルトのディレクトリです。
+const DefaultABOutputDir = "asset/ab_prompts"
+
+// abComparisonFile は比較サマリを保存するファイル名です。
+const abComparisonFile = "comparison.md"
+
+// PromptVariant はプロンプトセット 1 つ分の最終要約とスクリプトの生成結果です。
+type PromptVariant struct {
+	Name      string // 変種名 (A_<ディレクトリ名> 形式)
+	PromptDir string // プロンプトセットのディレクトリ
+	OutputDir string // 最終要約・スクリプトの保存先
+
+	Summary string // 最終要約
+	Script  string // スクリプト
+
+	SummaryChars int     // 最終要約の文字数
+	ScriptChars  int     // スクリプトの文字数
+	ScriptLines  int     // スクリプトの行数 (空行を除く)
+	ScriptTokens int     // スクリプトの推定トークン数 (cleaner.EstimateTokens)
+	CostUSD      float64 // この変種の生成に要した LLM 呼び出しの推定コスト (USD)
+}
+
+// comparePromptVariants は ABPromptDirs の各プロンプトセットで、同じ中間要約 (Reduce結果) から
+// 最終要約とスクリプトを生成し、変種ごとのディレクトリに保存して比較サマリを標準エラーに出力します。
+// Map・Reduce は通常の実行結果を共有するため、プロンプトセットのうち Summary・Script のテンプレートが比較対象です。
+// 変種の数だけ最終要約・スクリプト生成の LLM 呼び出しが追加で発生します。
+func (p *Pipeline) comparePromptVariants(ctx context.Context, llm *cleaner.Cleaner, feedTitle string, artifacts *runArtifacts) ([]PromptVariant, error) {
+	title := cleaner.ExtractTitleFromMarkdown(artifacts.Reduce)
+	if title == "" {
+		title = feedTitle
+	}
+	var structure *cleaner.ReduceResult
+	if llm.StructuredReduce() {
+		if parsed, err := cleaner.ParseReduceResult(artifacts.Reduce); err == nil {
+			structure = parsed
+		}
+	}
+
+	outputDir := p.config.ABOutputDir
+	if outputDir == "" {
+		outputDir = DefaultABOutputDir
+	}
+
+	slog.InfoContext(ctx, "プロンプトセットの A/B 比較を開始します", slog.Int("variants", len(p.config.ABPromptDirs)), slog.String("output_dir", outputDir))
+	variants := make([]PromptVariant, 0, len(p.config.ABPromptDirs))
+	for i, dir := range p.config.ABPromptDirs {
+		variant := PromptVariant{
+			Name:      variantName(i, dir),
+			PromptDir: dir,
+		}
+		variant.OutputDir = filepath.Join(outputDir, variant.Name)
+
+		derived, err := llm.WithProfile(cleaner.CategoryProfile{PromptDir: dir})
+		if err != nil {
+			return variants, fmt.Errorf("プロンプトセット (%s) の読み込みに失敗しました: %w", dir, err)
+		}
+		before, _ := p.Cleaner.CostUSD()
+		variant.Summary, err = derived.GenerateFinalSummary(ctx, title, artifacts.Reduce)
+		if err != nil {
+			return variants, fmt.Errorf("プロンプトセット (%s) での最終要約の生成に失敗しました: %w", dir, err)
+		}
+		variant.Script, err = derived.GenerateScriptForVoicevox(ctx, title, variant.Summary, structure)
+		if err != nil {
+			return variants, fmt.Errorf("プロンプトセット (%s) でのスクリプトの生成に失敗しました: %w", dir, err)
+		}
+		after, _ := p.Cleaner.CostUSD()
+
+		variant.SummaryChars = utf8.RuneCountInString(variant.Summary)
+		variant.ScriptChars = utf8.RuneCountInString(variant.Script)
+		variant.ScriptLines = len(nonEmptyLines(variant.Script))
+		variant.ScriptTokens = cleaner.EstimateTokens(variant.Script)
+		variant.CostUSD = after - before
+
+		if err := saveVariant(variant); err != nil {
+			return variants, err
+		}
+		slog.InfoContext(ctx, "プロンプトセットの変種を生成しました",
+			slog.String("variant", variant.Name),
+			slog.Int("script_chars", variant.ScriptChars),
+			slog.Float64("cost_usd", variant.CostUSD),
+		)
+		variants = append(variants, variant)
+	}
+
+	comparison := renderVariantComparison(variants)
+	if err := os.WriteFile(filepath.Join(outputDir, abComparisonFile), []byte(comparison), 0o644); err != nil {
+		return variants, fmt.Errorf("A/B 比較サマリの保存に失敗しました: %w", err)
+	}
+	if _, err := io.WriteString(os.Stderr, comparison); err != nil {
+		return variants, fmt.Errorf("A/B 比較サマリの出力に失敗しました: %w", err)
+	}
+	return variants, nil
+}
+
+// saveVariant は変種の最終要約とスクリプトを variant.OutputDir に保存します。
+func saveVariant(variant PromptVariant) error {
+	if err := os.MkdirAll(variant.OutputDir, 0o755); err != nil {
+		return fmt.Errorf("A/B 比較の保存先ディレクトリの作成に失敗しました: %w", err)
+	}
+	files := []artifactFile{
+		{Name: "summary.md", Content: variant.Summary},
+		{Name: "script.txt", Content: variant.Script},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(variant.OutputDir, f.Name), []byte(f.Content), 0o644); err != nil {
+			return fmt.Errorf("変種 %s の %s の保存に失敗しました: %w", variant.Name, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// variantName は i 番目のプロンプトセットの変種名を返します (例: 0, "prompts/casual" → "A_casual")。
+func variantName(i int, dir string) string {
+	label := string(rune('A' + i%26))
+	if i >= 26 {
+		label += fmt.Sprint(i / 26)
+	}
+	return label + "_" + filepath.Base(filepath.Clean(dir))
+}
+
+// nonEmptyLines は空行を除いた行を返します。
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range splitLines(s) {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// renderVariantComparison は変種ごとの文字数・行数・推定トークン数・コストの表と、
+// 最初の変種を基準としたスクリプトの行単位の差分を返します。
+func renderVariantComparison(variants []PromptVariant) string {
+	var sb strings.Builder
+	sb.WriteString("# プロンプトの A/B 比較\n\n")
+	sb.WriteString("| 変種 | プロンプトセット | 要約の文字数 | スクリプトの文字数 | 行数 | 推定トークン数 | コスト (USD) |\n")
+	sb.WriteString("|---|---|---:|---:|---:|---:|---:|\n")
+	for _, v := range variants {
+		fmt.Fprintf(&sb, "| %s | %s | %d | %d | %d | %d | %.4f |\n",
+			v.Name, v.PromptDir, v.SummaryChars, v.ScriptChars, v.ScriptLines, v.ScriptTokens, v.CostUSD)
+	}
+	sb.WriteString("\n")
+	if len(variants) < 2 {
+		return sb.String()
+	}
+
+	base := variants[0]
+	for _, v := range variants[1:] {
+		fmt.Fprintf(&sb, "## スクリプトの差分 (%s → %s)\n\n", base.Name, v.Name)
+		sb.WriteString("```diff\n")
+		sb.WriteString(RenderLineDiff(base.Name, v.Name, base.Script, v.Script))
+		sb.WriteString("```\n\n")
+	}
+	return sb.String()
+}
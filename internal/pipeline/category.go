@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+
+	"act-feed-clean-go/internal/cleaner"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// カテゴリ別の処理設定の選択 (--category-profiles)
+// ----------------------------------------------------------------------
+
+// cleanerForArticles は記事のカテゴリ (フィードの item.Categories) に応じた Cleaner を返します。
+// preferred が空でなくマッピングに存在する場合 (ダイジェストのトピック名など) はそれを優先し、
+// それ以外は対象記事に最も多く付与されたカテゴリのうち、マッピングに存在するものを使用します。
+// 該当するカテゴリがない場合や設定の適用に失敗した場合は、デフォルトの p.Cleaner を返します。
+// target はログに出力する処理対象の名前です。
+func (p *Pipeline) cleanerForArticles(ctx context.Context, target, preferred string, results []types.URLResult, categories map[string][]string) *cleaner.Cleaner {
+	if len(p.config.CategoryProfiles) == 0 {
+		return p.Cleaner
+	}
+
+	category := cleaner.NormalizeCategory(preferred)
+	if _, ok := p.config.CategoryProfiles[category]; !ok {
+		category = majorityCategory(results, categories, p.config.CategoryProfiles)
+	}
+	if category == "" {
+		slog.InfoContext(ctx, "マッピングに該当するカテゴリがないため、デフォルト設定で処理します", slog.String("target", target))
+		return p.Cleaner
+	}
+
+	profile := p.config.CategoryProfiles[category]
+	derived, err := p.Cleaner.WithProfile(profile)
+	if err != nil {
+		slog.WarnContext(ctx, "カテゴリ別の処理設定を適用できなかったため、デフォルト設定で処理します",
+			slog.String("target", target),
+			slog.String("category", category),
+			slog.String("error", err.Error()),
+		)
+		return p.Cleaner
+	}
+	slog.InfoContext(ctx, "カテゴリ別の処理設定を適用します",
+		slog.String("target", target),
+		slog.String("category", category),
+		slog.Any("profile", profile),
+	)
+	return derived
+}
+
+// majorityCategory は記事に付与されたカテゴリのうち、profiles に存在し、付与された記事数が最も多いものを返します。
+// 同数の場合は先に現れたカテゴリを優先します。該当がない場合は空文字列です。
+func majorityCategory(results []types.URLResult, categories map[string][]string, profiles map[string]cleaner.CategoryProfile) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, res := range results {
+		seen := make(map[string]bool)
+		for _, c := range categories[res.URL] {
+			key := cleaner.NormalizeCategory(c)
+			if _, ok := profiles[key]; !ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if counts[key] == 0 {
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+	}
+
+	best := ""
+	for _, key := range order {
+		if counts[key] > counts[best] {
+			best = key
+		}
+	}
+	return best
+}
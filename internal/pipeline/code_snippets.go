@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------------
+// 記事本文からのコードスニペット抽出 (--extract-code)
+// ----------------------------------------------------------------------
+
+// CodeOmittedPlaceholder は本文から抽出したコードの位置に残す文字列です。
+const CodeOmittedPlaceholder = "（コード省略）"
+
+// CodeSnippet は記事本文から抽出したコードです。
+type CodeSnippet struct {
+	URL      string // 抽出元の記事URL
+	Language string // フェンスの info string に指定された言語名 (ない場合・インラインコードは空)
+	Code     string
+	Inline   bool // インラインコード (`...`) から抽出したか
+}
+
+// inlineCodePattern はインラインコード (`...`) に一致します。
+var inlineCodePattern = regexp.MustCompile("`([^`\\n]+)`")
+
+// extractCode は content からフェンスドコードブロック (``` / ~~~) を抽出し、
+// 本文側を CodeOmittedPlaceholder に置換した本文と、抽出したコードを返します。
+// inline が true の場合はインラインコードも同様に抽出します。
+// 閉じられていないフェンスは本文の末尾までをコードとみなします。
+func extractCode(url, content string, inline bool) (string, []CodeSnippet) {
+	var snippets []CodeSnippet
+	var out, code []string
+	var fence, language string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if f := codeFence(trimmed); f != "" {
+				fence = f
+				language, _, _ = strings.Cut(strings.TrimSpace(strings.TrimLeft(trimmed, f[:1])), " ")
+				code = nil
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			snippets = append(snippets, CodeSnippet{URL: url, Language: language, Code: strings.Join(code, "\n")})
+			out = append(out, CodeOmittedPlaceholder)
+			fence = ""
+			continue
+		}
+		code = append(code, line)
+	}
+	if fence != "" {
+		snippets = append(snippets, CodeSnippet{URL: url, Language: language, Code: strings.Join(code, "\n")})
+		out = append(out, CodeOmittedPlaceholder)
+	}
+
+	text := strings.Join(out, "\n")
+	if inline {
+		text = inlineCodePattern.ReplaceAllStringFunc(text, func(m string) string {
+			snippets = append(snippets, CodeSnippet{URL: url, Code: strings.Trim(m, "`"), Inline: true})
+			return CodeOmittedPlaceholder
+		})
+	}
+	return text, snippets
+}
+
+// codeFence は行がコードフェンスの開始であれば、そのフェンス文字列 (3 文字以上の ` または ~) を返します。
+func codeFence(trimmed string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, c))
+		if n < 3 {
+			continue
+		}
+		// ` のフェンスの info string には ` を含められない (インラインコードとの区別)
+		if c == "`" && strings.Contains(trimmed[n:], "`") {
+			return ""
+		}
+		return trimmed[:n]
+	}
+	return ""
+}
+
+// writeCodeSnippets は抽出したコードを記事ごとに Markdown のコードブロックとして path に書き出します。
+func writeCodeSnippets(ctx context.Context, path string, snippets []CodeSnippet) error {
+	var sb strings.Builder
+	sb.WriteString("# 抽出したコードスニペット\n")
+	lastURL := ""
+	for _, s := range snippets {
+		if s.URL != lastURL {
+			fmt.Fprintf(&sb, "\n## %s\n", s.URL)
+			lastURL = s.URL
+		}
+		if s.Inline {
+			fmt.Fprintf(&sb, "\n- `%s`\n", s.Code)
+			continue
+		}
+		fence := "```"
+		for strings.Contains(s.Code, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&sb, "\n%s%s\n%s\n%s\n", fence, s.Language, s.Code, fence)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("コードスニペットの出力先ディレクトリ作成に失敗しました (%s): %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("コードスニペットの書き込みに失敗しました (%s): %w", path, err)
+	}
+	slog.InfoContext(ctx, "コードスニペットを書き出しました", slog.String("path", path), slog.Int("snippets", len(snippets)))
+	return nil
+}
+
+// handleCodeSnippets は抽出したコードを result に記録し、CodeSnippetsPath が設定されていればファイルに書き出します。
+func (p *Pipeline) handleCodeSnippets(ctx context.Context, result *RunResult, snippets []CodeSnippet) error {
+	result.CodeSnippets = append(result.CodeSnippets, snippets...)
+	if p.config.CodeSnippetsPath == "" || len(result.CodeSnippets) == 0 {
+		return nil
+	}
+	return writeCodeSnippets(ctx, p.config.CodeSnippetsPath, result.CodeSnippets)
+}
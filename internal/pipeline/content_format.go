@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ----------------------------------------------------------------------
+// AI処理に渡す本文の形式 (Markdown / プレーンテキスト)
+// ----------------------------------------------------------------------
+
+// ContentFormat はスクレイピングした本文をどの形式で後続の処理へ渡すかを表します。
+type ContentFormat string
+
+const (
+	ContentMarkdown ContentFormat = "markdown" // スクレイパーが返した Markdown をそのまま使う
+	ContentPlain    ContentFormat = "plain"    // リンク・装飾・見出し記号などを除去したプレーンテキストにする
+)
+
+// ParseContentFormat は文字列を ContentFormat に変換します。空文字列の場合は ContentMarkdown を返します。
+func ParseContentFormat(s string) (ContentFormat, error) {
+	switch f := ContentFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "", ContentMarkdown:
+		return ContentMarkdown, nil
+	case ContentPlain:
+		return f, nil
+	default:
+		return "", fmt.Errorf("不明な本文の形式です: %q (markdown, plain のいずれかを指定してください)", s)
+	}
+}
+
+// markdownRule は Markdown の記法を 1 つ除去する置換規則です。適用順に意味があります。
+type markdownRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+var markdownRules = []markdownRule{
+	{regexp.MustCompile("(?m)^[ \\t]*(```|~~~).*$\\n?"), ""},                   // コードブロックの囲み (中身は残す)
+	{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},                       // 画像 → 代替テキスト
+	{regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), "$1"},                        // リンク → リンクテキスト
+	{regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`), "$1"},                       // 参照形式のリンク
+	{regexp.MustCompile(`(?m)^[ \t]*\[[^\]]+\]:[ \t]*\S+.*$\n?`), ""},          // 参照リンクの定義
+	{regexp.MustCompile(`<(https?://[^>\s]+)>`), "$1"},                         // 自動リンク
+	{regexp.MustCompile(`(?m)^[ \t]*([-*_])([ \t]*[-*_]){2,}[ \t]*$\n?`), ""},  // 水平線
+	{regexp.MustCompile(`(?m)^#{1,6}[ \t]+(.*?)[ \t]*#*[ \t]*$`), "$1"},        // 見出し
+	{regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`), ""},                             // 引用
+	{regexp.MustCompile(`(?m)^([ \t]*)[-*+][ \t]+`), "${1}・"},                  // 箇条書きの記号
+	{regexp.MustCompile(`\*\*([^*\n]+)\*\*`), "$1"},                            // 強調 (**)
+	{regexp.MustCompile(`__([^_\n]+)__`), "$1"},                                // 強調 (__)
+	{regexp.MustCompile(`~~([^~\n]+)~~`), "$1"},                                // 取り消し線
+	{regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*\n]*?)\*([^*\w]|$)`), "$1$2$3"}, // 斜体 (*)
+	{regexp.MustCompile("`([^`\\n]+)`"), "$1"},                                 // インラインコード
+	{regexp.MustCompile(`\n{3,}`), "\n\n"},                                     // 連続する空行
+}
+
+// markdownToPlain は Markdown からリンク・装飾・見出し記号などの記法を除去し、プレーンテキストにします。
+// 識別子中の "_" を誤って除去しないよう、単一の "_" による斜体は除去しません。
+func markdownToPlain(markdown string) string {
+	text := markdown
+	for _, rule := range markdownRules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return strings.TrimSpace(text)
+}
+
+// formatContent は ContentFormat に応じて本文を変換します。
+func (p *Pipeline) formatContent(content string) string {
+	if p.config.ContentFormat == ContentPlain {
+		return markdownToPlain(content)
+	}
+	return content
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"unicode/utf8"
+
+	"act-feed-clean-go/internal/cleaner"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 抽出した記事本文の統計
+// ----------------------------------------------------------------------
+
+const (
+	// outlierFactor は外れ値と判定する、中央値に対する文字数の倍率です (中央値の N 倍超、または 1/N 未満)。
+	outlierFactor = 5
+	// minArticlesForOutliers は外れ値を判定するのに必要な最小記事数です。
+	minArticlesForOutliers = 4
+	// unknownLanguage は本文に文字が含まれず言語を判定できない記事の言語ラベルです。
+	unknownLanguage = "unknown"
+)
+
+// ArticleLength は 1 記事分の本文の文字数と判定した言語です。
+type ArticleLength struct {
+	URL      string
+	Chars    int
+	Language string // cleaner.DetectLanguage の判定結果 (ja, en, mixed, unknown)
+}
+
+// LanguageStats は言語別の記事数と合計文字数です。
+type LanguageStats struct {
+	Articles int
+	Chars    int
+}
+
+// ContentStats は抽出した記事本文の文字数統計です。文字数は Unicode の文字 (rune) 単位です。
+type ContentStats struct {
+	Articles     int
+	TotalChars   int
+	AverageChars int
+	Longest      ArticleLength
+	Shortest     ArticleLength
+	ByLanguage   map[string]LanguageStats
+	// Outliers は中央値から極端に外れた (長すぎる/短すぎる) 記事です。
+	Outliers []ArticleLength
+}
+
+// AnalyzeContent は抽出結果の本文の文字数と言語を集計します。本文は保持しません。
+func AnalyzeContent(results []types.URLResult) ContentStats {
+	return summarizeLengths(measureContent(results))
+}
+
+// measureContent は各記事の本文の文字数と言語を計測します。
+func measureContent(results []types.URLResult) []ArticleLength {
+	lengths := make([]ArticleLength, 0, len(results))
+	for _, res := range results {
+		lang := cleaner.DetectLanguage(res.Content)
+		if lang == "" {
+			lang = unknownLanguage
+		}
+		lengths = append(lengths, ArticleLength{URL: res.URL, Chars: utf8.RuneCountInString(res.Content), Language: lang})
+	}
+	return lengths
+}
+
+// summarizeLengths は計測済みの記事の文字数から統計を作成します。
+func summarizeLengths(lengths []ArticleLength) ContentStats {
+	stats := ContentStats{Articles: len(lengths), ByLanguage: make(map[string]LanguageStats)}
+	if len(lengths) == 0 {
+		return stats
+	}
+
+	stats.Longest, stats.Shortest = lengths[0], lengths[0]
+	for _, l := range lengths {
+		stats.TotalChars += l.Chars
+		if l.Chars > stats.Longest.Chars {
+			stats.Longest = l
+		}
+		if l.Chars < stats.Shortest.Chars {
+			stats.Shortest = l
+		}
+		lang := stats.ByLanguage[l.Language]
+		lang.Articles++
+		lang.Chars += l.Chars
+		stats.ByLanguage[l.Language] = lang
+	}
+	stats.AverageChars = stats.TotalChars / len(lengths)
+
+	if len(lengths) < minArticlesForOutliers {
+		return stats
+	}
+	chars := make([]int, len(lengths))
+	for i, l := range lengths {
+		chars[i] = l.Chars
+	}
+	sort.Ints(chars)
+	median := chars[len(chars)/2]
+	for _, l := range lengths {
+		if l.Chars > median*outlierFactor || l.Chars*outlierFactor < median {
+			stats.Outliers = append(stats.Outliers, l)
+		}
+	}
+	return stats
+}
+
+// logContentStats は統計をログに出力し、外れ値の記事があれば警告します。
+func logContentStats(ctx context.Context, stats ContentStats) {
+	attrs := []any{
+		slog.Int("articles", stats.Articles),
+		slog.Int("total_chars", stats.TotalChars),
+		slog.Int("average_chars", stats.AverageChars),
+		slog.Int("longest_chars", stats.Longest.Chars),
+		slog.Int("shortest_chars", stats.Shortest.Chars),
+	}
+	for lang, ls := range stats.ByLanguage {
+		attrs = append(attrs, slog.Group("lang_"+lang, slog.Int("articles", ls.Articles), slog.Int("chars", ls.Chars)))
+	}
+	slog.InfoContext(ctx, "抽出した本文の統計", attrs...)
+
+	for _, o := range stats.Outliers {
+		slog.WarnContext(ctx, "本文の文字数が他の記事から極端に外れています",
+			slog.String("url", o.URL),
+			slog.Int("chars", o.Chars),
+			slog.Int("average_chars", stats.AverageChars),
+		)
+	}
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"act-feed-clean-go/internal/cleaner"
+)
+
+// ----------------------------------------------------------------------
+// 実行前のコスト見積もりと確認 (--estimate-only / --confirm-over-cost)
+// ----------------------------------------------------------------------
+
+// ErrCostNotConfirmed は見積もりコストが閾値を超え、実行が承認されなかったことを示します。
+var ErrCostNotConfirmed = errors.New("見積もりコストが閾値を超えたため、実行を中止しました")
+
+// CostConfirmFunc は見積もりコストが閾値を超えた場合に実行を続けるかどうかを確認する関数です。
+type CostConfirmFunc func(estimate cleaner.CostEstimate) (bool, error)
+
+// checkEstimatedCost は LLM 処理の前に result.CombinedText の Map フェーズのコストを見積もり、result に記録します。
+// EstimateOnly の場合は stop=true を返し (見積もりの出力は呼び出し側が行う)、
+// 見積もりが ConfirmOverCostUSD を超える場合は ConfirmCost で確認し、承認されなければ ErrCostNotConfirmed を返します。
+// ConfirmCost が nil の場合は確認せずに続行します (--yes)。
+func (p *Pipeline) checkEstimatedCost(ctx context.Context, result *RunResult, llm *cleaner.Cleaner) (stop bool, err error) {
+	if !p.config.EstimateOnly && p.config.ConfirmOverCostUSD <= 0 {
+		return false, nil
+	}
+	estimate, err := llm.EstimateCost(result.CombinedText)
+	if err != nil {
+		return false, fmt.Errorf("コストの見積もりに失敗しました: %w", err)
+	}
+	result.CostEstimate = &estimate
+	slog.InfoContext(ctx, "Mapフェーズのコストを見積もりました",
+		slog.Int("calls", estimate.Calls),
+		slog.Int("input_tokens", estimate.InputTokens),
+		slog.Int("output_tokens", estimate.OutputTokens),
+		slog.Float64("cost_usd", estimate.CostUSD),
+	)
+
+	if p.config.EstimateOnly {
+		return true, nil
+	}
+	if estimate.CostUSD <= p.config.ConfirmOverCostUSD || p.config.ConfirmCost == nil {
+		return false, nil
+	}
+	ok, err := p.config.ConfirmCost(estimate)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, fmt.Errorf("%w (見積もり $%.4f > 閾値 $%.4f)", ErrCostNotConfirmed, estimate.CostUSD, p.config.ConfirmOverCostUSD)
+	}
+	return false, nil
+}
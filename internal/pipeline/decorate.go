@@ -0,0 +1,183 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------
+// SNS・チャット向けの装飾出力 (--decorate)
+// ----------------------------------------------------------------------
+
+// DefaultDecorationTheme は装飾のデフォルトテーマです。
+const DefaultDecorationTheme = "news"
+
+// DecorationRules は装飾出力の整形ルールです。空のフィールドはその装飾を行いません。
+type DecorationRules struct {
+	// HeadingEmojis は見出しレベル (# → 1 番目, ## → 2 番目, ...) ごとに見出しの先頭へ付ける絵文字です。
+	// レベルが要素数を超える場合は最後の要素を使用します。
+	HeadingEmojis []string `json:"heading_emojis,omitempty"`
+	// Bullet は箇条書きの記号 ("- " / "* ") を置き換える記号です。
+	Bullet string `json:"bullet,omitempty"`
+	// Divider は区切り線 ("---") を置き換える装飾線です。
+	Divider string `json:"divider,omitempty"`
+	// SpeakerEmojis はスクリプトの話者タグ (例: "[ずんだもん]") で始まる行の先頭に付ける絵文字です。
+	SpeakerEmojis map[string]string `json:"speaker_emojis,omitempty"`
+	// Header / Footer は出力全体の先頭・末尾に付ける行です。
+	Header string `json:"header,omitempty"`
+	Footer string `json:"footer,omitempty"`
+}
+
+// builtinDecorationThemes は組み込みの装飾テーマです。
+var builtinDecorationThemes = map[string]DecorationRules{
+	"news": {
+		HeadingEmojis: []string{"📰", "📌", "▶️"},
+		Bullet:        "🔹",
+		Divider:       "━━━━━━━━━━━━━━━━",
+		SpeakerEmojis: map[string]string{"[ずんだもん]": "🎙️", "[めたん]": "🎙️"},
+	},
+	"tech": {
+		HeadingEmojis: []string{"💻", "🔧", "⚙️"},
+		Bullet:        "✅",
+		Divider:       "────────────────",
+		SpeakerEmojis: map[string]string{"[ずんだもん]": "🤖", "[めたん]": "👩‍💻"},
+	},
+	"casual": {
+		HeadingEmojis: []string{"🎉", "✨", "💡"},
+		Bullet:        "👉",
+		Divider:       "～～～～～～～～～～",
+		SpeakerEmojis: map[string]string{"[ずんだもん]": "🌱", "[めたん]": "🎀"},
+	},
+}
+
+// LoadDecorationRules はテーマの装飾ルールを返します。
+// rulesPath が空でない場合は、テーマ名をキーとする JSON ファイル ({"news": {...}}) を読み込み、
+// 指定されたフィールドで組み込みテーマのルールを上書きします。ファイルで新しいテーマを定義することもできます。
+func LoadDecorationRules(theme, rulesPath string) (*DecorationRules, error) {
+	theme = strings.ToLower(strings.TrimSpace(theme))
+	if theme == "" {
+		theme = DefaultDecorationTheme
+	}
+	rules, ok := builtinDecorationThemes[theme]
+
+	if rulesPath != "" {
+		raw, err := os.ReadFile(rulesPath)
+		if err != nil {
+			return nil, fmt.Errorf("装飾ルールファイルの読み込みに失敗しました: %w", err)
+		}
+		var custom map[string]DecorationRules
+		if err := json.Unmarshal(raw, &custom); err != nil {
+			return nil, fmt.Errorf("装飾ルールファイルの解析に失敗しました (%s): %w", rulesPath, err)
+		}
+		if override, found := custom[theme]; found {
+			rules = rules.merge(override)
+			ok = true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("不明な装飾テーマです: %q (%s のいずれか、または装飾ルールファイルで定義したテーマを指定してください)",
+			theme, strings.Join(builtinThemeNames(), ", "))
+	}
+	return &rules, nil
+}
+
+// builtinThemeNames は組み込みテーマ名を名前順に返します。
+func builtinThemeNames() []string {
+	names := make([]string, 0, len(builtinDecorationThemes))
+	for name := range builtinDecorationThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// merge は override で指定されたフィールドのみ r を上書きしたルールを返します。
+func (r DecorationRules) merge(override DecorationRules) DecorationRules {
+	if override.HeadingEmojis != nil {
+		r.HeadingEmojis = override.HeadingEmojis
+	}
+	if override.Bullet != "" {
+		r.Bullet = override.Bullet
+	}
+	if override.Divider != "" {
+		r.Divider = override.Divider
+	}
+	if override.SpeakerEmojis != nil {
+		r.SpeakerEmojis = override.SpeakerEmojis
+	}
+	if override.Header != "" {
+		r.Header = override.Header
+	}
+	if override.Footer != "" {
+		r.Footer = override.Footer
+	}
+	return r
+}
+
+// Decorate はテキストの見出し・箇条書き・区切り線・話者行に装飾を付与します。
+// コードブロック内の行は装飾しません。
+func (r *DecorationRules) Decorate(text string) string {
+	lines := strings.Split(text, "\n")
+	inCode := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		lines[i] = r.decorateLine(line, trimmed)
+	}
+
+	decorated := strings.Join(lines, "\n")
+	if r.Header != "" {
+		decorated = r.Header + "\n\n" + decorated
+	}
+	if r.Footer != "" {
+		decorated = strings.TrimRight(decorated, "\n") + "\n\n" + r.Footer + "\n"
+	}
+	return decorated
+}
+
+// decorate は装飾が有効な場合にテキスト出力を装飾します。無効な場合はそのまま返します。
+func (p *Pipeline) decorate(text string) string {
+	if p.config.Decoration == nil {
+		return text
+	}
+	return p.config.Decoration.Decorate(text)
+}
+
+// decorateLine は 1 行分の装飾を行います。
+func (r *DecorationRules) decorateLine(line, trimmed string) string {
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		title := strings.TrimSpace(trimmed[level:])
+		if len(r.HeadingEmojis) == 0 || title == "" || !strings.HasPrefix(trimmed[level:], " ") {
+			return line
+		}
+		emoji := r.HeadingEmojis[min(level, len(r.HeadingEmojis))-1]
+		return trimmed[:level] + " " + emoji + " " + title
+	case trimmed == "---" || trimmed == "***":
+		if r.Divider != "" {
+			return r.Divider
+		}
+	case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+		if r.Bullet != "" {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			return indent + r.Bullet + " " + strings.TrimSpace(trimmed[2:])
+		}
+	case strings.HasPrefix(trimmed, "["):
+		for tag, emoji := range r.SpeakerEmojis {
+			if strings.HasPrefix(trimmed, tag) {
+				return emoji + " " + line
+			}
+		}
+	}
+	return line
+}
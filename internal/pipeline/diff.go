@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"act-feed-clean-go/internal/state"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 差分モード (新着記事のみを処理)
+// ----------------------------------------------------------------------
+
+// stateStore は StatePath の状態ファイルを読み込みます。1 回の実行の中では同じストアを共有し、
+// 差分モード・成果物の再利用・読み上げ速度の学習のそれぞれの保存が、互いの記録を上書きしないようにします。
+func (p *Pipeline) stateStore() (*state.Store, error) {
+	p.storeMu.Lock()
+	defer p.storeMu.Unlock()
+	if p.store == nil {
+		store, err := state.Load(p.config.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		p.store = store
+	}
+	return p.store, nil
+}
+
+// filterNewArticles は、状態ファイルに記録されていない (新着の) 記事URLのみを返します。
+func filterNewArticles(ctx context.Context, source *feedSource, store *state.Store) []string {
+	var fresh []string
+	for _, u := range source.URLs {
+		if !store.IsProcessed(source.GUIDs[u]) {
+			fresh = append(fresh, u)
+		}
+	}
+	slog.InfoContext(ctx, "差分モード: 新着記事を抽出しました",
+		slog.Int("new", len(fresh)),
+		slog.Int("processed_before", len(source.URLs)-len(fresh)),
+	)
+	return fresh
+}
+
+// recordEpisode は今回処理した記事をエピソードとして状態ファイルに記録します。
+// WAVを出力した場合は、同じ場所にエピソード内容を示すメタファイル (*.meta.json) も書き出します。
+func (p *Pipeline) recordEpisode(ctx context.Context, store *state.Store, source *feedSource, results []types.URLResult) error {
+	episode := state.Episode{
+		CreatedAt: time.Now(),
+		FeedURL:   source.FeedURL,
+		Output:    p.audioOutputPath(),
+	}
+	titles := make([]string, 0, len(results))
+	for _, res := range results {
+		title := source.Titles[res.URL]
+		episode.Articles = append(episode.Articles, state.Article{
+			GUID:  source.GUIDs[res.URL],
+			URL:   res.URL,
+			Title: title,
+		})
+		titles = append(titles, title)
+	}
+	slog.InfoContext(ctx, "差分モード: エピソードに含まれる記事",
+		slog.Int("articles", len(titles)),
+		slog.Any("titles", titles),
+	)
+
+	store.RecordEpisode(episode)
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	if episode.Output != "" {
+		if err := writeEpisodeMeta(ctx, episode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// audioOutputPath は音声を出力する場合にそのWAVパスを返します。テキスト出力の場合は空文字列です。
+func (p *Pipeline) audioOutputPath() string {
+	if p.VoicevoxEngineExecutor != nil && p.config.OutputWAVPath != "" {
+		return p.config.OutputWAVPath
+	}
+	return ""
+}
+
+// writeEpisodeMeta はWAVファイルと同じ場所に <name>.meta.json を書き出します。
+func writeEpisodeMeta(ctx context.Context, episode state.Episode) error {
+	metaPath := strings.TrimSuffix(episode.Output, filepath.Ext(episode.Output)) + ".meta.json"
+	raw, err := json.MarshalIndent(episode, "", "  ")
+	if err != nil {
+		return fmt.Errorf("エピソードメタのシリアライズに失敗しました: %w", err)
+	}
+	if err := os.WriteFile(metaPath, raw, 0644); err != nil {
+		return fmt.Errorf("エピソードメタの書き込みに失敗しました: %w", err)
+	}
+	slog.InfoContext(ctx, "エピソードメタを書き出しました", slog.String("path", metaPath))
+	return nil
+}
@@ -0,0 +1,400 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"act-feed-clean-go/internal/cleaner"
+	itemfeed "act-feed-clean-go/internal/feed"
+
+	"github.com/shouni/go-utils/iohandler"
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 複数フィードのトピック別ダイジェスト
+// ----------------------------------------------------------------------
+
+// ArticleRef はダイジェストに含まれる記事の参照情報です。
+type ArticleRef struct {
+	URL          string
+	Title        string
+	FeedURL      string // 記事を取得したフィードのURL
+	FeedFallback bool   // 本文をフィードの要約文で代替したか
+	// Importance は記事の重要度 (0〜1) です (--sort-by importance 指定時のみ。importance.goで定義)。
+	Importance float64
+}
+
+// TopicDigest は 1 トピック分の要約と、そのトピックに分類された記事です。
+type TopicDigest struct {
+	Topic    string
+	Articles []ArticleRef
+	Summary  string // トピック単位の要約 (Markdown)。生成に失敗した場合は空
+}
+
+// RunResult は Run / RunMulti の実行結果を保持します。
+type RunResult struct {
+	FeedTitles []string      // 取得に成功したフィードのタイトル
+	Topics     []TopicDigest // トピック別の要約と記事の割り当て (RunMultiのみ)
+	// NotModified は、すべてのフィードが Conditional GET で 304 Not Modified を返し、処理をスキップしたことを示します
+	// (--feed-cache-file 指定時のみ)。このとき他のフィールドは空です。
+	NotModified bool
+	// Output は出力したスクリプトです。RunMulti ではメモリ使用量を抑えるためフィード単位で逐次出力し、保持しません (空)。
+	Output string
+	// TOC はダイジェストの目次構造です (フィードごとの目次を連結したもの。見出しが少なく目次を省略した場合は nil)。
+	// JSON で結果を返す場合はこのフィールドで目次を参照できます。
+	TOC []cleaner.TOCEntry
+
+	SpeakerBalance *cleaner.SpeakerBalance // スクリプトの話者別集計 (AI処理でスクリプトを生成した場合のみ)
+	ContentStats   *ContentStats           // 抽出した記事本文の文字数・言語別の統計 (content_stats.goで定義)
+	Articles       []ArticleMeta           // 処理した記事のメタ情報と画像の保存先 (Runのみ。images.goで定義)
+	CodeSnippets   []CodeSnippet           // 記事本文から抽出したコード (--extract-code 指定時のみ。code_snippets.goで定義)
+	ExtractedLinks []ExtractedLink         // 記事本文から抽出した外部リンク (--related-links / --extracted-links-file 指定時のみ。links.goで定義)
+	SummaryOverlap float64                 // 最終要約と原文の重複率 (0〜1。Run でAI処理を行った場合のみ)
+	SourceCoverage *SourceCoverage         // 最終要約に反映された記事の集計 (--cite-sources 指定時のみ。source_coverage.goで定義)
+
+	// 以下は Run でAI処理を行った場合の中間成果物です。失敗時は PartialResultError.Partial に生成できた分のみが入ります。
+	MapSummaries []string // Map要約 (Map・Reduceフェーズで失敗した場合のみ)
+	// DroppedSegments は Map要約に失敗し許容範囲内 (cleaner.CleanerConfig.MaxFailedSegments) として、または全体予算
+	// (cleaner.CleanerConfig.TotalTimeout) の不足で除外したセグメントです。
+	DroppedSegments     []cleaner.DroppedSegment
+	IntermediateSummary string // 中間統合要約 (Reduce結果)
+	FinalSummary        string // 最終要約
+	BulletSummary       string // 箇条書きの最終要約 (--summary-format both 指定時のみ)
+
+	// Variants はプロンプトセットごとの最終要約とスクリプトです (--ab-prompts 指定時のみ。ab_prompts.goで定義)。
+	Variants []PromptVariant
+
+	LayeredSummary *cleaner.LayeredSummary // 1行要約・段落要約・詳細要約 (--layered-summary 指定時のみ)
+	CostEstimate   *cleaner.CostEstimate   // 実行前のMapフェーズのコスト見積もり (--estimate-only / --confirm-over-cost 指定時のみ)
+	DryRun         *cleaner.DryRunReport   // フェーズ別のセグメント数・推定トークン数・推定コスト (--dry-run 指定時のみ)
+
+	// ScrapeErrors はスクレイピング失敗の種類 (ClassifyScrapeError) ごとの件数、ScrapeFailures は失敗した記事の一覧です
+	// (scrape_errors.goで定義。失敗がない場合は nil)。
+	ScrapeErrors   map[string]int
+	ScrapeFailures []ScrapeFailure
+
+	CostUSD        float64 // LLM呼び出しの累積推定コスト (USD)
+	CostLimitPhase string  // コスト上限で打ち切ったフェーズ (打ち切りがない場合は空)
+	// TokenUsage は LLM 呼び出しのフェーズ別の推定トークン数と推定コストです (LLM処理を行った場合のみ)。
+	TokenUsage *cleaner.TokenUsage
+
+	// Reused は、冪等キーが一致する成功済みの成果物を再利用し、LLM 処理をスキップしたことを示します (idempotency.goで定義)。
+	Reused bool
+
+	// CombinedText は AI処理の入力とした記事の結合テキストです (Run / Process のみ。AI処理をスキップした場合は結合したテキスト)。
+	CombinedText string
+	// Script は生成したスクリプトです (参照記事・免責文を付与する前。AI処理でスクリプトを生成した場合のみ)。
+	Script string
+	// URLStatuses は処理対象とした記事URLごとの抽出の成否です (Run / Process のみ。process.goで定義)。
+	URLStatuses []URLStatus
+	// ArticleQuality は抽出した記事ごとの品質指標です (--track-quality / --auto-block 指定時のみ。domain_quality.goで定義)。
+	ArticleQuality []ArticleQuality
+}
+
+// recordTokenUsage は Cleaner の LLM 呼び出しの推定トークン数と推定コストを result に記録し、INFO レベルで出力します。
+func (result *RunResult) recordTokenUsage(ctx context.Context, c *cleaner.Cleaner) {
+	usage := c.TokenUsage()
+	result.CostUSD = usage.CostUSD
+	result.TokenUsage = &usage
+	cleaner.LogTokenUsage(ctx, usage)
+}
+
+// RunMulti は複数のフィードを順に処理し、フィードごとに記事をLLMでトピック分類した上で、
+// トピックごとに要約したダイジェストを出力します。
+// メモリ使用量を抑えるため、フィード単位で「取得→抽出→要約→出力」を行い、記事本文は出力後に破棄します。
+// 複数フィードに同じ記事が含まれる場合は、最初に処理したフィードでのみ扱います。
+// どの記事がどのトピックに入ったかは RunResult.Topics に記録されます。
+func (p *Pipeline) RunMulti(ctx context.Context, feedURLs []string) (*RunResult, error) {
+	if p.Cleaner == nil {
+		return nil, fmt.Errorf("ダイジェストモードにはLLMクライアントが必要です")
+	}
+	if len(feedURLs) == 0 {
+		return nil, fmt.Errorf("フィードURLが指定されていません")
+	}
+
+	result := &RunResult{}
+	seen := make(map[string]bool) // 重複除去のため、処理済みの記事URLのみを保持する
+	toc := cleaner.NewTOCGenerator()
+	mem := &memSampler{}
+	var stats feedStats
+	var lastErr error
+	notModified := 0
+
+	for _, feedURL := range feedURLs {
+		section, err := p.digestFeed(ctx, feedURL, seen, toc, &stats, mem)
+		if errors.Is(err, itemfeed.ErrNotModified) {
+			slog.InfoContext(ctx, "フィードが前回の取得から更新されていないため、スキップします", slog.String("feed_url", feedURL))
+			notModified++
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, cleaner.ErrClosed) {
+				return result, err
+			}
+			slog.WarnContext(ctx, "フィードのダイジェスト生成に失敗したためスキップします", slog.String("feed_url", feedURL), slog.String("error", err.Error()))
+			lastErr = err
+			continue
+		}
+
+		// フィード単位で出力し、本文を含む中間データはここで手放す
+		if len(result.FeedTitles) == 0 {
+			if err := iohandler.WriteOutputString("", p.decorate("# トピック別ダイジェスト")+"\n\n"); err != nil {
+				return result, err
+			}
+		}
+		if err := iohandler.WriteOutputString("", p.decorate(section.markdown)); err != nil {
+			return result, err
+		}
+		result.FeedTitles = append(result.FeedTitles, section.title)
+		result.Topics = append(result.Topics, section.topics...)
+		result.TOC = append(result.TOC, section.toc...)
+		result.CodeSnippets = append(result.CodeSnippets, section.snippets...)
+		mem.sample("output:" + feedURL)
+	}
+	result.recordTokenUsage(ctx, p.Cleaner)
+	mem.log()
+	if err := p.handleCodeSnippets(ctx, result, nil); err != nil {
+		return result, err
+	}
+	result.recordScrapeFailures(stats.failures)
+	if len(stats.lengths) > 0 {
+		contentStats := summarizeLengths(stats.lengths)
+		logContentStats(ctx, contentStats)
+		result.ContentStats = &contentStats
+	}
+
+	if len(result.FeedTitles) == 0 {
+		if notModified == len(feedURLs) {
+			result.NotModified = true
+			return result, nil
+		}
+		if stats.beforeFilter > 0 && stats.afterFilter == 0 {
+			return nil, &AllFilteredOutError{Filter: p.sinceFilterLabel(), Before: stats.beforeFilter}
+		}
+		if lastErr != nil {
+			return nil, fmt.Errorf("すべてのフィード (%d 件) でダイジェストの生成に失敗しました: %w", len(feedURLs), lastErr)
+		}
+		return nil, fmt.Errorf("すべてのフィード (%d 件) から記事URLを取得できませんでした", len(feedURLs))
+	}
+	slog.InfoContext(ctx, "ダイジェストの出力が完了しました",
+		slog.Int("feeds", len(result.FeedTitles)),
+		slog.Int("articles", stats.articles),
+		slog.Int("topics", len(result.Topics)),
+	)
+
+	// フィード単位で出力済みのため、免責文は位置の設定にかかわらず末尾に付与する
+	if p.config.Disclaimer {
+		disclaimer, err := p.renderDisclaimer(strings.Join(result.FeedTitles, " / "), stats.articles)
+		if err != nil {
+			return result, err
+		}
+		if err := iohandler.WriteOutputString("", "> "+disclaimer+"\n"); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// feedStats は RunMulti 全体で集約する軽量な統計です。
+type feedStats struct {
+	beforeFilter int // フィルタ前の記事数
+	afterFilter  int // フィルタ後の記事数
+	articles     int // 要約に使用した記事数
+	// lengths は抽出した記事本文の文字数と言語です (本文自体は保持しない)。
+	lengths []ArticleLength
+	// failures はスクレイピングに失敗した記事です (scrape_errors.goで定義)。
+	failures []ScrapeFailure
+}
+
+// feedSection は 1 フィード分のダイジェスト出力です。記事本文は含みません。
+type feedSection struct {
+	title    string
+	markdown string
+	topics   []TopicDigest
+	toc      []cleaner.TOCEntry
+	snippets []CodeSnippet // 記事本文から抽出したコード (--extract-code 指定時のみ)
+}
+
+// digestFeed は 1 フィード分の取得・抽出・トピック別要約を行い、出力用の Markdown を返します。
+// 抽出した記事本文はこの関数のスコープ内でのみ保持されます。
+func (p *Pipeline) digestFeed(ctx context.Context, feedURL string, seen map[string]bool, toc *cleaner.TOCGenerator, stats *feedStats, mem *memSampler) (*feedSection, error) {
+	source, err := p.fetchFeed(ctx, feedURL)
+	if err != nil {
+		return nil, err
+	}
+	stats.beforeFilter += len(source.URLs)
+	if !p.config.Since.IsZero() {
+		source.URLs = p.filterBySince(ctx, source)
+	}
+	stats.afterFilter += len(source.URLs)
+
+	urls := make([]string, 0, len(source.URLs))
+	picked := make(map[string]bool, len(source.URLs))
+	for _, u := range source.URLs {
+		if seen[u] || picked[u] {
+			continue // 先に処理したフィードに含まれていた記事
+		}
+		picked[u] = true
+		urls = append(urls, u)
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("フィード (%s) に未処理の記事がありません", feedURL)
+	}
+	// 上限で除外した記事は後続のフィードで処理できるよう、処理済みとして扱わない
+	urls = p.prioritizeURLs(ctx, urls, source.Published)
+	for _, u := range urls {
+		seen[u] = true
+	}
+
+	successfulResults, snippets, failures, err := p.collectArticles(ctx, urls, source.Contents)
+	stats.failures = append(stats.failures, failures...)
+	if err != nil {
+		return nil, err
+	}
+	mem.sample("scrape:" + feedURL)
+	stats.lengths = append(stats.lengths, measureContent(successfulResults)...)
+
+	feedOf := make(map[string]string, len(urls))
+	for _, u := range urls {
+		feedOf[u] = feedURL
+	}
+	llmCtx, cancelLLM := p.phaseContext(ctx, PhaseLLM)
+	// 重要度の高い記事 (とそのトピック) を先頭に置く (--sort-by importance 指定時のみ)
+	successfulResults, importance, err := p.sortByImportance(llmCtx, successfulResults, source.Titles)
+	var topics []TopicDigest
+	if err == nil {
+		topics, err = p.summarizeByTopic(llmCtx, successfulResults, source.Titles, feedOf, source.Categories)
+	}
+	err = p.wrapPhaseError(ctx, llmCtx, PhaseLLM, err)
+	cancelLLM()
+	if err != nil {
+		return nil, err
+	}
+	for i := range topics {
+		for j := range topics[i].Articles {
+			topics[i].Articles[j].Importance = importance[topics[i].Articles[j].URL]
+		}
+	}
+	stats.articles += len(successfulResults)
+
+	markdown, entries := buildFeedSection(source.Title, feedURL, topics, toc)
+	return &feedSection{title: source.Title, markdown: markdown, topics: topics, toc: entries, snippets: snippets}, nil
+}
+
+// summarizeByTopic は記事をトピック分類し、トピックごとに Map-Reduce で要約します。
+// 各トピックは、トピック名または記事のカテゴリ (categories) に応じた設定で要約します (category.goで定義)。
+func (p *Pipeline) summarizeByTopic(ctx context.Context, results []types.URLResult, titlesMap, feedOf map[string]string, categories map[string][]string) ([]TopicDigest, error) {
+	groups, err := p.Cleaner.ClassifyTopics(ctx, results, titlesMap)
+	if err != nil {
+		return nil, fmt.Errorf("記事のトピック分類に失敗しました: %w", err)
+	}
+
+	byURL := make(map[string]types.URLResult, len(results))
+	for _, res := range results {
+		byURL[res.URL] = res
+	}
+
+	digests := make([]TopicDigest, 0, len(groups))
+	summarized := 0
+	for _, group := range groups {
+		digest := TopicDigest{Topic: group.Topic}
+		groupResults := make([]types.URLResult, 0, len(group.URLs))
+		for _, u := range group.URLs {
+			res := byURL[u]
+			groupResults = append(groupResults, res)
+			digest.Articles = append(digest.Articles, ArticleRef{
+				URL:          u,
+				Title:        titlesMap[u],
+				FeedURL:      feedOf[u],
+				FeedFallback: cleaner.IsFeedFallback(res.Content),
+			})
+		}
+
+		slog.InfoContext(ctx, "トピック別要約を開始します", slog.String("topic", group.Topic), slog.Int("articles", len(groupResults)))
+		llm := p.cleanerForArticles(ctx, group.Topic, group.Topic, groupResults, categories)
+		summary, err := llm.CleanAndStructureText(ctx, cleaner.CombineContents(groupResults, titlesMap))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "トピック別要約に失敗しました。記事一覧のみ出力します。", slog.String("topic", group.Topic), slog.String("error", err.Error()))
+		} else {
+			if body := cleaner.ExtractTextBetweenTags(summary, "FINAL_START", "FINAL_END"); body != "" {
+				summary = body
+			}
+			digest.Summary = summary
+			summarized++
+		}
+		digests = append(digests, digest)
+	}
+
+	if summarized == 0 {
+		return nil, fmt.Errorf("すべてのトピック (%d 件) で要約の生成に失敗しました", len(groups))
+	}
+	return digests, nil
+}
+
+// buildFeedSection は 1 フィード分のトピック別ダイジェスト (Markdown) を組み立てます。
+// 見出しが十分にある場合は、フィード見出しの直後にアンカー付きの目次を挿入し、その構造も返します。
+// toc は文書全体でアンカーの採番を引き継ぐための目次生成器です。
+func buildFeedSection(feedTitle, feedURL string, topics []TopicDigest, toc *cleaner.TOCGenerator) (string, []cleaner.TOCEntry) {
+	heading := fmt.Sprintf("## %s\n\n", feedTitle)
+
+	var body strings.Builder
+	for _, topic := range topics {
+		body.WriteString(fmt.Sprintf("### %s\n\n", topic.Topic))
+		if topic.Summary != "" {
+			// トピック見出しの下に収まるよう、要約内の見出しを3段階下げる
+			body.WriteString(demoteHeadings(strings.TrimSpace(topic.Summary), 3))
+			body.WriteString("\n\n")
+		}
+		body.WriteString("**関連記事**\n\n")
+		for _, article := range topic.Articles {
+			title := article.Title
+			if title == "" {
+				title = article.URL
+			}
+			if article.FeedFallback {
+				body.WriteString(fmt.Sprintf("- [%s](%s) ※本文取得失敗のためフィード要約で代替\n", title, article.URL))
+				continue
+			}
+			body.WriteString(fmt.Sprintf("- [%s](%s)\n", title, article.URL))
+		}
+		body.WriteString("\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(heading)
+	sb.WriteString(fmt.Sprintf("出典: %s\n\n", feedURL))
+
+	// 目次 (cleaner/toc.goで定義)。フィード見出しのアンカーも採番に含め、見出しが少ない場合は省略される
+	toc.Generate(heading)
+	entries := toc.Generate(body.String())
+	if len(entries) > 0 {
+		sb.WriteString(cleaner.RenderTableOfContents(entries))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(body.String())
+	sb.WriteString("---\n\n")
+	return sb.String(), entries
+}
+
+// demoteHeadings は Markdown の見出しレベルを levels 段階下げます (最大 ######)。
+func demoteHeadings(markdown string, levels int) string {
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, "#"))
+		if depth+levels > 6 || !strings.HasPrefix(line[depth:], " ") {
+			continue
+		}
+		lines[i] = strings.Repeat("#", levels) + line
+	}
+	return strings.Join(lines, "\n")
+}
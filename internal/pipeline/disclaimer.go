@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// 免責文 (AI生成である旨と生成日時・出典) の付与
+// ----------------------------------------------------------------------
+
+// DefaultDisclaimerTemplate は免責文のデフォルトテンプレートです。
+// 利用できるフィールドは DisclaimerData を参照してください。
+const DefaultDisclaimerTemplate = "この内容はAIによって自動生成されました。生成日時: {{.GeneratedAt}}、出典: {{.Source}} の記事 {{.SourceCount}} 件。正確な情報は元記事をご確認ください。"
+
+// DisclaimerSpeakerTag は音声出力時に免責文を読み上げる話者タグです。
+const DisclaimerSpeakerTag = "[めたん][ノーマル]"
+
+// DisclaimerPosition は免責文をテキスト出力のどこに付与するかを表します。
+type DisclaimerPosition string
+
+const (
+	DisclaimerHead DisclaimerPosition = "head" // 先頭に付与
+	DisclaimerTail DisclaimerPosition = "tail" // 末尾に付与
+)
+
+// DisclaimerData は免責テンプレートに埋め込むデータです。
+type DisclaimerData struct {
+	GeneratedAt string // 生成日時 (2006-01-02 15:04 形式)
+	Source      string // 出典 (フィードタイトル等)
+	SourceCount int    // 出典となった記事数
+}
+
+// ParseDisclaimerTemplate は免責テンプレートを解析・検証します。空文字列の場合はデフォルトテンプレートを使用します。
+func ParseDisclaimerTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultDisclaimerTemplate
+	}
+	tmpl, err := template.New("disclaimer").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("免責テンプレートの解析に失敗しました: %w", err)
+	}
+	// 存在しないフィールドの参照などは実行時にしか検出できないため、空データで試行する
+	if err := tmpl.Execute(io.Discard, DisclaimerData{}); err != nil {
+		return nil, fmt.Errorf("免責テンプレートが不正です: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ParseDisclaimerPosition は文字列を DisclaimerPosition に変換します。
+func ParseDisclaimerPosition(s string) (DisclaimerPosition, error) {
+	switch pos := DisclaimerPosition(strings.ToLower(strings.TrimSpace(s))); pos {
+	case "", DisclaimerHead:
+		return DisclaimerHead, nil
+	case DisclaimerTail:
+		return pos, nil
+	default:
+		return "", fmt.Errorf("不明な免責文の位置です: %q (head, tail のいずれかを指定してください)", s)
+	}
+}
+
+// renderDisclaimer は免責テンプレートにデータを埋め込み、1行の免責文を返します。
+// スクリプトの1行として読み上げられるよう、改行は空白に置き換えます。
+func (p *Pipeline) renderDisclaimer(source string, sourceCount int) (string, error) {
+	tmpl, err := ParseDisclaimerTemplate(p.config.DisclaimerTemplate)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	data := DisclaimerData{
+		GeneratedAt: time.Now().Format("2006-01-02 15:04"),
+		Source:      source,
+		SourceCount: sourceCount,
+	}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("免責文の生成に失敗しました: %w", err)
+	}
+	return strings.Join(strings.Fields(sb.String()), " "), nil
+}
+
+// attachDisclaimer は出力に免責文を付与します。
+// 音声合成するスクリプトの場合は冒頭のセリフとして挿入し、テキスト出力の場合は設定された位置に段落として付与します。
+func (p *Pipeline) attachDisclaimer(output, source string, sourceCount int, asScript bool) (string, error) {
+	disclaimer, err := p.renderDisclaimer(source, sourceCount)
+	if err != nil {
+		return "", err
+	}
+	if asScript {
+		return DisclaimerSpeakerTag + disclaimer + "\n" + output, nil
+	}
+	if p.config.DisclaimerPosition == DisclaimerTail {
+		return strings.TrimRight(output, "\n") + "\n\n> " + disclaimer + "\n", nil
+	}
+	return "> " + disclaimer + "\n\n" + output, nil
+}
@@ -0,0 +1,266 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 記事の品質指標とドメインの自動ブロック (--block-domains / --track-quality / --auto-block)
+// ----------------------------------------------------------------------
+
+// DefaultAutoBlockThreshold は、ドメインの平均品質スコアがこの値を下回った場合に自動ブロックするデフォルトの閾値です。
+const DefaultAutoBlockThreshold = 0.3
+
+// DefaultAutoBlockMinSamples は、自動ブロックの判定に必要なドメインの記事数のデフォルトです。
+// 少数の記事だけで判定しないよう、蓄積した記事数がこれに満たないドメインはブロックしません。
+const DefaultAutoBlockMinSamples = 3
+
+const (
+	qualityFullBodyChars = 1000 // 本文の長さのスコアが満点 (1) になる文字数
+	qualityMinLineRunes  = 10   // 重複率の判定に使用する行の最小文字数 (短い見出しや区切りは判定しない)
+	mojibakePenalty      = 20.0 // 文字化け率に掛ける係数 (文字化け率 5% で品質スコアが 0)
+)
+
+// DomainQualityConfig は記事の品質指標のドメイン別の蓄積と、品質の低いドメインの自動ブロックの設定です。
+type DomainQualityConfig struct {
+	Track      bool     // 記事ごとの品質指標を計測し、ドメイン別の平均品質スコアを状態ファイルに蓄積するか
+	AutoBlock  bool     // 平均品質スコアが Threshold を下回るドメインを自動ブロックし、次回以降の処理対象から除外するか (Track を含む)
+	Threshold  float64  // 自動ブロックする平均品質スコアの閾値 (0 以下の場合は DefaultAutoBlockThreshold)
+	MinSamples int      // 自動ブロックの判定に必要な記事数 (0 以下の場合は DefaultAutoBlockMinSamples)
+	Unblock    []string // 自動ブロックを解除するドメイン
+}
+
+// enabled は品質指標の計測または自動ブロックの解除が有効かを返します。
+func (c DomainQualityConfig) enabled() bool {
+	return c.Track || c.AutoBlock || len(c.Unblock) > 0
+}
+
+// ArticleQuality は 1 件の記事本文の品質指標です。
+type ArticleQuality struct {
+	URL            string  `json:"url"`
+	Domain         string  `json:"domain"`
+	BodyChars      int     `json:"body_chars"`      // 本文の文字数
+	DuplicateRatio float64 `json:"duplicate_ratio"` // 同じ実行の記事 (自身を含む) に 2 回以上現れる行の割合 (広告・定型文の目安)
+	MojibakeRatio  float64 `json:"mojibake_ratio"`  // 文字化けとみなした文字の割合
+	Score          float64 `json:"score"`           // 品質スコア (0〜1)
+}
+
+// ParseDomains は --block-domains / --unblock-domains のドメインを小文字に正規化して返します。
+// 先頭の "." と "www." は取り除きます。
+func ParseDomains(entries []string) ([]string, error) {
+	var domains []string
+	for _, entry := range entries {
+		domain := normalizeDomain(entry)
+		if domain == "" || strings.ContainsAny(domain, "/:?# ") {
+			return nil, fmt.Errorf("ドメインの形式が不正です: %q (例: example.com)", entry)
+		}
+		if !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+// normalizeDomain はドメインまたはホスト名を小文字にし、先頭の "." と "www." を取り除きます。
+func normalizeDomain(domain string) string {
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	return strings.TrimPrefix(domain, "www.")
+}
+
+// matchDomain は URL のホストが domains のいずれか (サブドメインを含む) に一致する場合に、そのドメインを返します。
+func matchDomain(rawURL string, domains []string) (string, bool) {
+	host := normalizeDomain(scrapeHost(rawURL))
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return domain, true
+		}
+	}
+	return "", false
+}
+
+// MeasureArticleQuality は記事本文ごとに、本文の長さ・行の重複率・文字化け率から品質スコアを計測します。
+// 品質スコアは「本文の長さのスコア (qualityFullBodyChars 文字で満点) × (1 − 重複率) × (1 − 文字化け率 × mojibakePenalty)」で、
+// 広告や定型文ばかりの短い本文や、文字コードの誤りで読めない本文ほど低くなります。
+func MeasureArticleQuality(results []types.URLResult) []ArticleQuality {
+	// 行の出現回数は実行内の全記事で数え、サイト共通の定型文 (広告・メニュー) も重複として扱う
+	lineCounts := make(map[string]int)
+	for _, res := range results {
+		for _, line := range qualityLines(res.Content) {
+			lineCounts[line]++
+		}
+	}
+
+	qualities := make([]ArticleQuality, 0, len(results))
+	for _, res := range results {
+		q := ArticleQuality{
+			URL:       res.URL,
+			Domain:    normalizeDomain(scrapeHost(res.URL)),
+			BodyChars: utf8.RuneCountInString(res.Content),
+		}
+		lines := qualityLines(res.Content)
+		duplicated := 0
+		for _, line := range lines {
+			if lineCounts[line] > 1 {
+				duplicated++
+			}
+		}
+		if len(lines) > 0 {
+			q.DuplicateRatio = float64(duplicated) / float64(len(lines))
+		}
+		if q.BodyChars > 0 {
+			q.MojibakeRatio = float64(countMojibake(res.Content)) / float64(q.BodyChars)
+		}
+
+		lengthScore := math.Min(1, float64(q.BodyChars)/qualityFullBodyChars)
+		q.Score = lengthScore * (1 - q.DuplicateRatio) * math.Max(0, 1-q.MojibakeRatio*mojibakePenalty)
+		q.DuplicateRatio = math.Round(q.DuplicateRatio*1000) / 1000
+		q.MojibakeRatio = math.Round(q.MojibakeRatio*1000) / 1000
+		q.Score = math.Round(q.Score*1000) / 1000
+		qualities = append(qualities, q)
+	}
+	return qualities
+}
+
+// qualityLines は重複率の判定に使用する行 (前後の空白を除いて qualityMinLineRunes 文字以上) を返します。
+func qualityLines(content string) []string {
+	var lines []string
+	for line := range strings.Lines(content) {
+		line = strings.TrimSpace(line)
+		if utf8.RuneCountInString(line) >= qualityMinLineRunes {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// countMojibake は文字化けとみなす文字の数を返します。
+// 置換文字 (U+FFFD)・C1 制御文字、UTF-8 を Latin-1 として読んだ場合に現れる "Ã" "â" などの後の続きバイト相当の文字、
+// UTF-8 を Shift_JIS として読んだ場合に頻出する "縺" "繧" "繝" を数えます。
+func countMojibake(content string) int {
+	count := 0
+	var prev rune
+	for _, r := range content {
+		switch {
+		case r == utf8.RuneError, r >= 0x80 && r <= 0x9f:
+			count++
+		case r >= 0xa0 && r <= 0xbf && (prev == 'Ã' || prev == 'â' || prev == 'ã' || prev == 'Â'):
+			count++
+		case r == '縺' || r == '繧' || r == '繝':
+			count++
+		}
+		prev = r
+	}
+	return count
+}
+
+// blockedDomains は処理対象から除外するドメインを返します。
+// BlockDomains に加え、AutoBlock 指定時は状態ファイルで自動ブロックしたドメイン (Unblock で解除するものを除く) を含みます。
+func (p *Pipeline) blockedDomains() ([]string, error) {
+	domains := slices.Clone(p.config.BlockDomains)
+	if !p.config.DomainQuality.AutoBlock {
+		return domains, nil
+	}
+	store, err := p.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	for domain, quality := range store.DomainQualities() {
+		if quality.Blocked && !slices.Contains(p.config.DomainQuality.Unblock, domain) && !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+// filterBlockedDomains は除外するドメイン (blockedDomains) の記事URLを取り除きます。
+// すべての記事が除外された場合は *AllFilteredOutError を返します。
+func (p *Pipeline) filterBlockedDomains(ctx context.Context, urls []string) ([]string, error) {
+	domains, err := p.blockedDomains()
+	if err != nil || len(domains) == 0 {
+		return urls, err
+	}
+	kept := make([]string, 0, len(urls))
+	blocked := make(map[string]int)
+	for _, u := range urls {
+		if domain, ok := matchDomain(u, domains); ok {
+			blocked[domain]++
+			continue
+		}
+		kept = append(kept, u)
+	}
+	if len(blocked) > 0 {
+		slog.InfoContext(ctx, "ブロックしたドメインの記事を処理対象から除外しました",
+			slog.Int("kept", len(kept)),
+			slog.Int("dropped", len(urls)-len(kept)),
+			slog.Any("domains", blocked),
+		)
+	}
+	if len(kept) == 0 {
+		return nil, &AllFilteredOutError{Filter: "block-domains", Before: len(urls)}
+	}
+	return kept, nil
+}
+
+// recordDomainQuality は記事の品質スコアをドメイン別に状態ファイルへ蓄積し、AutoBlock 指定時は
+// 平均品質スコアが閾値を下回ったドメインを自動ブロックします。Unblock のドメインの自動ブロックは先に解除します。
+// 蓄積は次回以降の判定に使用するもので、失敗しても実行結果には影響させず警告のみ出力します。
+func (p *Pipeline) recordDomainQuality(ctx context.Context, qualities []ArticleQuality) {
+	config := p.config.DomainQuality
+	store, err := p.stateStore()
+	if err != nil {
+		slog.WarnContext(ctx, "記事の品質スコアの蓄積に失敗しました", slog.String("error", err.Error()))
+		return
+	}
+	for _, domain := range config.Unblock {
+		if store.UnblockDomain(domain) {
+			slog.InfoContext(ctx, "ドメインの自動ブロックを解除しました", slog.String("domain", domain))
+		} else {
+			slog.WarnContext(ctx, "自動ブロックしていないドメインのため、解除をスキップします", slog.String("domain", domain))
+		}
+	}
+
+	threshold := config.Threshold
+	if threshold <= 0 {
+		threshold = DefaultAutoBlockThreshold
+	}
+	minSamples := config.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultAutoBlockMinSamples
+	}
+	now := time.Now()
+	if config.Track || config.AutoBlock {
+		for _, q := range qualities {
+			quality := store.RecordDomainQuality(q.Domain, q.Score, now)
+			slog.DebugContext(ctx, "記事の品質スコア",
+				slog.String("url", q.URL),
+				slog.Int("body_chars", q.BodyChars),
+				slog.Float64("duplicate_ratio", q.DuplicateRatio),
+				slog.Float64("mojibake_ratio", q.MojibakeRatio),
+				slog.Float64("score", q.Score),
+				slog.Float64("domain_score", math.Round(quality.Score*1000)/1000),
+			)
+			if !config.AutoBlock || quality.Blocked || quality.Samples < minSamples || quality.Score >= threshold {
+				continue
+			}
+			store.BlockDomain(q.Domain, now)
+			slog.WarnContext(ctx, "平均品質スコアが閾値を下回ったため、次回以降このドメインの記事を除外します (--unblock-domains で解除できます)",
+				slog.String("domain", q.Domain),
+				slog.Float64("score", math.Round(quality.Score*1000)/1000),
+				slog.Float64("threshold", threshold),
+				slog.Int("samples", quality.Samples),
+			)
+		}
+	}
+	if err := store.Save(); err != nil {
+		slog.WarnContext(ctx, "記事の品質スコアの保存に失敗しました", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,30 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"act-feed-clean-go/internal/cleaner"
+)
+
+// ----------------------------------------------------------------------
+// ドライラン (--dry-run)
+// ----------------------------------------------------------------------
+
+// planOnly は LLM 処理と出力を行わない見積もりのみの実行 (--estimate-only / --dry-run) かどうかを返します。
+// 見積もりのみの実行では、成果物の再利用・記録や差分モードの処理済み記録も行いません。
+func (p *Pipeline) planOnly() bool {
+	return p.config.EstimateOnly || (p.Cleaner != nil && p.Cleaner.DryRun())
+}
+
+// dryRun は LLM を呼び出さずに result.CombinedText のフェーズ別のセグメント数・推定トークン数・推定コストを見積もり、
+// result に記録して、通常の出力の代わりに出力するテキストを返します。
+func (p *Pipeline) dryRun(ctx context.Context, result *RunResult, llm *cleaner.Cleaner, feedTitle string) (string, error) {
+	report, err := llm.EstimateDryRun(feedTitle, result.CombinedText)
+	if err != nil {
+		return "", fmt.Errorf("ドライランの見積もりに失敗しました: %w", err)
+	}
+	result.DryRun = &report
+	cleaner.LogDryRun(ctx, report)
+	return report.String(), nil
+}
@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"act-feed-clean-go/internal/state"
+	"act-feed-clean-go/internal/voice"
+)
+
+// ----------------------------------------------------------------------
+// 目標の再生時間と読み上げ速度のキャリブレーション (--target-duration)
+// ----------------------------------------------------------------------
+
+// DefaultDurationTolerance は、合成後の再生時間が目標からこの割合を超えて外れた場合に
+// 読み上げ速度の係数を更新するデフォルトの許容誤差です (0.1 = ±10%)。
+const DefaultDurationTolerance = 0.1
+
+// CharsPerMinute は学習済みの読み上げ速度の係数を計測回数で重み付けした平均を返します。
+// 係数がない場合は voice.DefaultCharsPerMinute を返します。
+func CharsPerMinute(rates map[string]state.SpeechRate) float64 {
+	var sum float64
+	var weight int
+	for _, rate := range rates {
+		if rate.CharsPerMinute <= 0 || rate.Samples <= 0 {
+			continue
+		}
+		sum += rate.CharsPerMinute * float64(rate.Samples)
+		weight += rate.Samples
+	}
+	if weight == 0 {
+		return voice.DefaultCharsPerMinute
+	}
+	return sum / float64(weight)
+}
+
+// TargetScriptChars は目標の再生時間と 1分あたりの読み上げ文字数から、スクリプト全体の目標文字数を返します。
+func TargetScriptChars(target time.Duration, charsPerMinute float64) int {
+	if target <= 0 || charsPerMinute <= 0 {
+		return 0
+	}
+	return int(math.Round(target.Minutes() * charsPerMinute))
+}
+
+// calibrateSpeechRate は合成したWAVの再生時間を目標と比較し、許容誤差を超えて外れていた場合は
+// 字幕キューから話者・話速ごとの読み上げ速度を計測して、状態ファイルの係数を更新します。
+// キャリブレーションは次回以降の目標文字数の算出に使用するもので、失敗しても実行結果には影響させず警告のみ出力します。
+func (p *Pipeline) calibrateSpeechRate(ctx context.Context) {
+	target := p.config.TargetDuration
+	if target <= 0 {
+		return
+	}
+	path := p.audioOutputPath()
+	actual, err := voice.WAVFileDuration(path)
+	if err != nil {
+		slog.WarnContext(ctx, "合成した音声の再生時間を取得できないため、読み上げ速度のキャリブレーションをスキップします", slog.String("error", err.Error()))
+		return
+	}
+	tolerance := p.config.DurationTolerance
+	if tolerance <= 0 {
+		tolerance = DefaultDurationTolerance
+	}
+	deviation := (actual.Seconds() - target.Seconds()) / target.Seconds()
+	slog.InfoContext(ctx, "合成した音声の再生時間",
+		slog.Duration("target", target),
+		slog.Duration("actual", actual.Round(time.Second)),
+		slog.Float64("deviation", math.Round(deviation*1000)/1000),
+		slog.Float64("tolerance", tolerance),
+	)
+	if math.Abs(deviation) <= tolerance {
+		return
+	}
+
+	source, ok := p.VoicevoxEngineExecutor.(voice.CueSource)
+	if !ok {
+		slog.WarnContext(ctx, "音声合成エンジンがセグメントの再生時間を提供しないため、読み上げ速度のキャリブレーションをスキップします")
+		return
+	}
+	samples := voice.MeasureSpeechRates(source.Cues())
+	if len(samples) == 0 {
+		slog.WarnContext(ctx, "セグメントの再生時間が取得できなかったため、読み上げ速度のキャリブレーションをスキップします")
+		return
+	}
+
+	store, err := p.stateStore()
+	if err != nil {
+		slog.WarnContext(ctx, "読み上げ速度のキャリブレーションに失敗しました", slog.String("error", err.Error()))
+		return
+	}
+	now := time.Now()
+	for _, sample := range samples {
+		measured := sample.CharsPerMinute()
+		if measured <= 0 {
+			continue
+		}
+		rate := store.CalibrateSpeechRate(sample.Key, measured, now)
+		slog.InfoContext(ctx, "読み上げ速度の係数を更新しました",
+			slog.String("key", sample.Key),
+			slog.Float64("measured_chars_per_minute", math.Round(measured*10)/10),
+			slog.Float64("chars_per_minute", math.Round(rate.CharsPerMinute*10)/10),
+			slog.Int("samples", rate.Samples),
+		)
+	}
+	if err := store.Save(); err != nil {
+		slog.WarnContext(ctx, "読み上げ速度の係数の保存に失敗しました", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+	"github.com/shouni/web-text-pipe-go/pkg/scraper/runner"
+	"golang.org/x/time/rate"
+)
+
+// ----------------------------------------------------------------------
+// ホスト単位のスクレイピングの制限 (--per-host-parallel / --per-host-delay)
+// ----------------------------------------------------------------------
+
+// HostLimitedScraper は ScraperExecutor のスクレイピングを、全体の同時リクエスト数とは独立に
+// ホスト単位の同時リクエスト数とリクエスト間隔で制限するラッパーです。
+// 同一ホストへの集中を避けつつ、異なるホストへは全体の同時リクエスト数まで並列にリクエストします。
+// ラップした ScraperExecutor 自体の制限 (全体のレートリミットなど) も引き続き適用されます。
+type HostLimitedScraper struct {
+	inner           runner.ScraperExecutor
+	parallel        int           // 全体の同時リクエスト数
+	perHostParallel int           // ホストごとの同時リクエスト数 (0 の場合は制限しない)
+	perHostDelay    time.Duration // 同一ホストへのリクエストの開始間隔 (0 の場合は制限しない)
+}
+
+// NewHostLimitedScraper は inner を全体の同時リクエスト数 parallel と、ホストごとの同時リクエスト数 perHostParallel・
+// リクエスト間隔 perHostDelay で制限する HostLimitedScraper を返します。parallel が 0 以下の場合はデフォルトを使用します。
+func NewHostLimitedScraper(inner runner.ScraperExecutor, parallel, perHostParallel int, perHostDelay time.Duration) *HostLimitedScraper {
+	if parallel <= 0 {
+		parallel = runner.DefaultMaxConcurrency
+	}
+	return &HostLimitedScraper{
+		inner:           inner,
+		parallel:        parallel,
+		perHostParallel: perHostParallel,
+		perHostDelay:    perHostDelay,
+	}
+}
+
+// hostGate は 1 ホストへのリクエストのセマフォとレートリミッターです。
+type hostGate struct {
+	sem     chan struct{} // nil の場合は同時リクエスト数を制限しない
+	limiter *rate.Limiter // nil の場合はリクエスト間隔を制限しない
+}
+
+// ScrapeInParallel は runner.ScraperExecutor の実装です。結果は urls の順に返します。
+// 各 URL は、ホストのスロット → 全体のスロット → ホストのリクエスト間隔の順に待機してからスクレイピングします。
+// ホストのスロットを先に確保するため、同一ホストの順番待ちで全体のスロットを占有することはありません。
+func (s *HostLimitedScraper) ScrapeInParallel(ctx context.Context, urls []string) []types.URLResult {
+	gates := make(map[string]*hostGate)
+	for _, u := range urls {
+		host := scrapeHost(u)
+		if gates[host] != nil {
+			continue
+		}
+		gate := &hostGate{}
+		if s.perHostParallel > 0 {
+			gate.sem = make(chan struct{}, s.perHostParallel)
+		}
+		if s.perHostDelay > 0 {
+			gate.limiter = rate.NewLimiter(rate.Every(s.perHostDelay), 1)
+		}
+		gates[host] = gate
+	}
+	slog.DebugContext(ctx, "ホスト単位の制限付きでスクレイピングします",
+		slog.Int("urls", len(urls)),
+		slog.Int("hosts", len(gates)),
+		slog.Int("per_host_parallel", s.perHostParallel),
+		slog.Duration("per_host_delay", s.perHostDelay),
+	)
+
+	results := make([]types.URLResult, len(urls))
+	sem := make(chan struct{}, s.parallel)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gate := gates[scrapeHost(u)]
+			if gate.sem != nil {
+				select {
+				case gate.sem <- struct{}{}:
+					defer func() { <-gate.sem }()
+				case <-ctx.Done():
+					results[i] = types.URLResult{URL: u, Error: fmt.Errorf("ホスト単位の同時リクエスト数の待機中にキャンセル: %w", ctx.Err())}
+					return
+				}
+			}
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = types.URLResult{URL: u, Error: fmt.Errorf("同時リクエスト数の待機中にキャンセル: %w", ctx.Err())}
+				return
+			}
+			if gate.limiter != nil {
+				if err := gate.limiter.Wait(ctx); err != nil {
+					results[i] = types.URLResult{URL: u, Error: fmt.Errorf("ホスト単位のリクエスト間隔の待機中にキャンセル: %w", err)}
+					return
+				}
+			}
+			results[i] = s.scrapeOne(ctx, u)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// scrapeOne は 1 件の URL をラップした ScraperExecutor でスクレイピングします。
+func (s *HostLimitedScraper) scrapeOne(ctx context.Context, u string) types.URLResult {
+	res := s.inner.ScrapeInParallel(ctx, []string{u})
+	if len(res) == 0 {
+		return types.URLResult{URL: u, Error: fmt.Errorf("URL %s のスクレイピング結果が返されませんでした", u)}
+	}
+	return res[0]
+}
+
+// scrapeHost は URL のホスト名 (小文字) を返します。解析できない場合は URL 全体をホストとして扱います。
+func scrapeHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return rawURL
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// scraperExecutor はスクレイピングに使用する ScraperExecutor を返します。
+// PerHostParallel / PerHostDelay が指定された場合は、ホスト単位で制限する HostLimitedScraper でラップします。
+func (p *Pipeline) scraperExecutor() runner.ScraperExecutor {
+	executor := p.ScraperRunner.ScraperExecutor
+	if p.config.PerHostParallel <= 0 && p.config.PerHostDelay <= 0 {
+		return executor
+	}
+	return NewHostLimitedScraper(executor, p.config.Parallel, p.config.PerHostParallel, p.config.PerHostDelay)
+}
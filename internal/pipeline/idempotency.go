@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/state"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 処理の冪等キーによる成果物の再利用 (--force で無効化)
+// ----------------------------------------------------------------------
+
+// idempotencyKeyVersion は冪等キーの計算方法の版です。計算に含める入力を変えた場合は更新します。
+const idempotencyKeyVersion = "v1"
+
+// idempotencyEnabled は冪等キーによる成果物の再利用・記録を行うかどうかを返します。
+// 見積もりのみの実行では LLM 処理を行わないため対象外です。
+func (p *Pipeline) idempotencyEnabled() bool {
+	return p.Cleaner != nil && !p.planOnly()
+}
+
+// idempotencyKey は LLM 処理の全入力から冪等キーを計算します。
+// 正規化したソースURLの集合、結合した本文 (タイトル・記事順を含む) のハッシュ、プロンプトの版、
+// 出力に影響する設定 (モデル名を含む) と、スクリプトの入力を変える多層要約の有無が一致する場合に同じキーになります。
+func (p *Pipeline) idempotencyKey(llm *cleaner.Cleaner, results []types.URLResult, titlesMap map[string]string) string {
+	urls := make([]string, 0, len(results))
+	for _, res := range results {
+		u, err := NormalizeURL(res.URL)
+		if err != nil {
+			u = res.URL
+		}
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	content := sha256.Sum256([]byte(cleaner.CombineContents(results, titlesMap)))
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", idempotencyKeyVersion)
+	for _, u := range urls {
+		fmt.Fprintf(h, "url:%s\n", u)
+	}
+	fmt.Fprintf(h, "content:%x\nprompt:%s\nconfig:%s\nlayered:%t\n",
+		content, llm.PromptVersion(), llm.ConfigFingerprint(), p.config.LayeredSummary)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reusableArtifacts は冪等キー key で過去に成功した成果物を状態ファイルから返します。
+// Force 指定時と、成果物がない場合は nil を返します。
+func (p *Pipeline) reusableArtifacts(ctx context.Context, key string) (*runArtifacts, error) {
+	if p.config.Force {
+		return nil, nil
+	}
+	store, err := p.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	artifact, ok := store.Artifact(key)
+	if !ok {
+		return nil, nil
+	}
+	slog.InfoContext(ctx, "同じ入力で成功した成果物があるため、LLM処理をスキップして再利用します (再処理するには --force を指定してください)",
+		slog.String("idempotency_key", key[:12]),
+		slog.Time("created_at", artifact.CreatedAt),
+	)
+	artifacts := &runArtifacts{
+		Reduce:         artifact.Reduce,
+		Summary:        artifact.Summary,
+		Script:         artifact.Script,
+		SummaryOverlap: artifact.SummaryOverlap,
+		BulletSummary:  artifact.BulletSummary,
+	}
+	if l := artifact.SummaryLayers; l != nil {
+		artifacts.Layered = &cleaner.LayeredSummary{OneLine: l.OneLine, Paragraph: l.Paragraph, Detailed: l.Detailed}
+	}
+	return artifacts, nil
+}
+
+// saveArtifacts は成功した LLM 処理の成果物を冪等キー key で状態ファイルに記録します。
+func (p *Pipeline) saveArtifacts(key string, artifacts *runArtifacts) error {
+	store, err := p.stateStore()
+	if err != nil {
+		return err
+	}
+	artifact := state.Artifact{
+		Key:            key,
+		CreatedAt:      time.Now(),
+		Reduce:         artifacts.Reduce,
+		Summary:        artifacts.Summary,
+		Script:         artifacts.Script,
+		SummaryOverlap: artifacts.SummaryOverlap,
+		BulletSummary:  artifacts.BulletSummary,
+	}
+	if l := artifacts.Layered; l != nil {
+		artifact.SummaryLayers = &state.SummaryLayers{OneLine: l.OneLine, Paragraph: l.Paragraph, Detailed: l.Detailed}
+	}
+	store.RecordArtifact(artifact)
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("成果物の記録に失敗しました: %w", err)
+	}
+	return nil
+}
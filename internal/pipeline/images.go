@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"act-feed-clean-go/internal/cleaner"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 記事のアイキャッチ画像のダウンロード (--download-images-dir)
+// ----------------------------------------------------------------------
+
+const (
+	// DefaultImageDownloadParallel は画像の同時ダウンロード数のデフォルトです。
+	DefaultImageDownloadParallel = 4
+	// DefaultImageDownloadTimeout は画像 1 件あたりのダウンロードのタイムアウトのデフォルトです。
+	DefaultImageDownloadTimeout = 30 * time.Second
+	// maxImageBytes はダウンロードする画像の最大サイズです。超える場合はスキップします。
+	maxImageBytes = 20 << 20
+)
+
+// imageExtensions は Content-Type に対応する保存時の拡張子です。
+var imageExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/avif":    ".avif",
+	"image/svg+xml": ".svg",
+	"image/bmp":     ".bmp",
+}
+
+// ArticleMeta は処理した記事のメタ情報です。
+type ArticleMeta struct {
+	URL       string
+	Title     string
+	ImageURL  string // フィードから抽出したアイキャッチ画像の URL (ない場合は空)
+	ImagePath string // ダウンロードした画像の保存先パス (未ダウンロード・失敗時は空)
+	Author    string // フィードに記載された著者名 (ない場合は空)
+	// Language は本文の言語 (ja, en, mixed。判定できない場合は空)、LanguageConfidence はその信頼度 (0〜1) です
+	// (cleaner.DetectLanguageWithConfidence)。
+	Language           string
+	LanguageConfidence float64
+	// Importance は記事の重要度 (0〜1) です (--sort-by importance 指定時のみ。importance.goで定義)。
+	Importance float64
+}
+
+// articleMetas は抽出に成功した記事のメタ情報を作成し、download が true で DownloadImagesDir が設定されていれば画像をダウンロードします。
+func (p *Pipeline) articleMetas(ctx context.Context, source *feedSource, results []types.URLResult, download bool) []ArticleMeta {
+	metas := make([]ArticleMeta, 0, len(results))
+	for _, res := range results {
+		meta := ArticleMeta{URL: res.URL, Title: source.Titles[res.URL], ImageURL: source.Images[res.URL], Author: source.Authors[res.URL]}
+		meta.Language, meta.LanguageConfidence = cleaner.DetectLanguageWithConfidence(res.Content)
+		metas = append(metas, meta)
+	}
+	if download && p.config.DownloadImagesDir != "" {
+		p.downloadImages(ctx, metas)
+	}
+	return metas
+}
+
+// downloadImages は画像 URL を持つ記事の画像を同時実行数を制限してダウンロードし、保存先を ImagePath に記録します。
+// 失敗した画像は警告を出してスキップします。
+func (p *Pipeline) downloadImages(ctx context.Context, metas []ArticleMeta) {
+	dir := p.config.DownloadImagesDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.WarnContext(ctx, "画像の保存先ディレクトリを作成できないため、画像のダウンロードをスキップします", slog.String("dir", dir), slog.String("error", err.Error()))
+		return
+	}
+	parallel := p.config.ImageDownloadParallel
+	if parallel <= 0 {
+		parallel = DefaultImageDownloadParallel
+	}
+	timeout := p.config.ImageDownloadTimeout
+	if timeout <= 0 {
+		timeout = DefaultImageDownloadTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i := range metas {
+		if metas[i].ImageURL == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(meta *ArticleMeta) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			path, err := downloadImage(ctx, client, meta.ImageURL, filepath.Join(dir, imageFileBase(meta.URL)))
+			if err != nil {
+				slog.WarnContext(ctx, "画像のダウンロードに失敗したためスキップします",
+					slog.String("url", meta.URL),
+					slog.String("image_url", meta.ImageURL),
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+			meta.ImagePath = path
+		}(&metas[i])
+	}
+	wg.Wait()
+
+	downloaded := 0
+	for _, meta := range metas {
+		if meta.ImagePath != "" {
+			downloaded++
+		}
+	}
+	slog.InfoContext(ctx, "記事の画像をダウンロードしました", slog.Int("downloaded", downloaded), slog.Int("articles", len(metas)), slog.String("dir", dir))
+}
+
+// imageFileBase は記事 URL から保存ファイル名 (拡張子なし) を決めます。同じ記事は実行をまたいで同じファイル名になります。
+func imageFileBase(articleURL string) string {
+	sum := sha1.Sum([]byte(articleURL))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// downloadImage は imageURL を取得し、Content-Type が画像の場合のみ base に拡張子を付けたパスへ保存します。
+func downloadImage(ctx context.Context, client *http.Client, imageURL, base string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("HTTPステータス %d", resp.StatusCode)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("画像ではないContent-Typeです (%q)", resp.Header.Get("Content-Type"))
+	}
+	ext, ok := imageExtensions[mediaType]
+	if !ok {
+		ext = ".img"
+	}
+	if resp.ContentLength > maxImageBytes {
+		return "", fmt.Errorf("画像が大きすぎます (%d バイト)", resp.ContentLength)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("画像の読み込みに失敗しました: %w", err)
+	}
+	if len(body) > maxImageBytes {
+		return "", fmt.Errorf("画像が大きすぎます (%d バイト超)", maxImageBytes)
+	}
+	path := base + ext
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return "", fmt.Errorf("画像の保存に失敗しました: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"act-feed-clean-go/internal/cleaner"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 記事の重要度による並べ替え (--sort-by importance)
+// ----------------------------------------------------------------------
+
+// SortOrder はスクレイピング後の記事の並び順です。
+type SortOrder string
+
+const (
+	SortByFeed       SortOrder = "feed"       // フィードの取得順 (従来どおり)
+	SortByImportance SortOrder = "importance" // 重要度の降順
+)
+
+// ParseSortOrder は文字列を SortOrder に変換します。空文字列の場合は SortByFeed を返します。
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch o := SortOrder(strings.ToLower(strings.TrimSpace(s))); o {
+	case "", SortByFeed:
+		return SortByFeed, nil
+	case SortByImportance:
+		return o, nil
+	default:
+		return "", fmt.Errorf("不明な並び順です: %q (feed, importance のいずれかを指定してください)", s)
+	}
+}
+
+// ImportanceMethod は重要度のスコアの算出方法です。
+type ImportanceMethod string
+
+const (
+	ImportanceByLength   ImportanceMethod = "length"   // 本文の文字数
+	ImportanceByKeywords ImportanceMethod = "keywords" // タイトルと本文のキーワードの出現回数
+	ImportanceByLLM      ImportanceMethod = "llm"      // LLM による採点 (呼び出しが 1 回増えます)
+)
+
+// DefaultImportanceMethods は LLM を使わない既定の算出方法です。
+var DefaultImportanceMethods = []ImportanceMethod{ImportanceByLength, ImportanceByKeywords}
+
+// ParseImportanceMethods は算出方法の名前のリストを ImportanceMethod に変換します。空の場合は DefaultImportanceMethods を返します。
+func ParseImportanceMethods(names []string) ([]ImportanceMethod, error) {
+	if len(names) == 0 {
+		return DefaultImportanceMethods, nil
+	}
+	methods := make([]ImportanceMethod, 0, len(names))
+	for _, name := range names {
+		switch m := ImportanceMethod(strings.ToLower(strings.TrimSpace(name))); m {
+		case ImportanceByLength, ImportanceByKeywords, ImportanceByLLM:
+			if !slices.Contains(methods, m) {
+				methods = append(methods, m)
+			}
+		default:
+			return nil, fmt.Errorf("不明な重要度の算出方法です: %q (length, keywords, llm のいずれかを指定してください)", name)
+		}
+	}
+	return methods, nil
+}
+
+// ImportanceConfig は重要度のスコアの算出設定です。
+type ImportanceConfig struct {
+	// Methods は使用する算出方法です。各方法のスコアを 0〜1 に正規化し、その平均を重要度とします。
+	Methods []ImportanceMethod
+	// Keywords は ImportanceByKeywords で出現回数を数えるキーワードです (大文字・小文字を区別しません)。
+	Keywords []string
+}
+
+// sortByImportance は SortBy が SortByImportance の場合に、記事を重要度の降順に並べ替えた新しいスライスと、
+// URL ごとの重要度 (0〜1) を返します。重要度が等しい記事は元の順序を維持します。
+// それ以外の場合は results をそのまま返し、スコアは nil です。
+func (p *Pipeline) sortByImportance(ctx context.Context, results []types.URLResult, titlesMap map[string]string) ([]types.URLResult, map[string]float64, error) {
+	if p.config.SortBy != SortByImportance || len(results) == 0 {
+		return results, nil, nil
+	}
+
+	var components []map[string]float64
+	for _, method := range p.config.Importance.Methods {
+		switch method {
+		case ImportanceByLength:
+			components = append(components, normalizeScores(results, func(res types.URLResult) float64 {
+				return float64(utf8.RuneCountInString(res.Content))
+			}))
+		case ImportanceByKeywords:
+			if len(p.config.Importance.Keywords) == 0 {
+				slog.DebugContext(ctx, "重要度のキーワードが指定されていないため、キーワードによる算出をスキップします")
+				continue
+			}
+			components = append(components, normalizeScores(results, func(res types.URLResult) float64 {
+				return float64(keywordHits(titlesMap[res.URL]+"\n"+res.Content, p.config.Importance.Keywords))
+			}))
+		case ImportanceByLLM:
+			if p.Cleaner == nil {
+				slog.WarnContext(ctx, "AI処理コンポーネントが未設定のため、LLMによる重要度の採点をスキップします")
+				continue
+			}
+			scores, err := p.Cleaner.ScoreImportance(ctx, results, titlesMap)
+			if err != nil && (ctx.Err() != nil || errors.Is(err, cleaner.ErrClosed)) {
+				return nil, nil, err
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "LLMによる重要度の採点に失敗したため、他の算出方法のみで並べ替えます", slog.String("error", err.Error()))
+				continue
+			}
+			components = append(components, scores)
+		}
+	}
+
+	importance := make(map[string]float64, len(results))
+	for _, res := range results {
+		sum := 0.0
+		for _, scores := range components {
+			sum += scores[res.URL]
+		}
+		if len(components) > 0 {
+			importance[res.URL] = sum / float64(len(components))
+		}
+	}
+
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, func(a, b types.URLResult) int {
+		return cmp.Compare(importance[b.URL], importance[a.URL])
+	})
+	slog.InfoContext(ctx, "記事を重要度順に並べ替えました", slog.Int("articles", len(sorted)), slog.Any("methods", p.config.Importance.Methods))
+	return sorted, importance, nil
+}
+
+// normalizeScores は記事ごとの値を最大値が 1 になるよう正規化したスコアを返します。全記事が 0 の場合はすべて 0 です。
+func normalizeScores(results []types.URLResult, value func(types.URLResult) float64) map[string]float64 {
+	raw := make(map[string]float64, len(results))
+	maxValue := 0.0
+	for _, res := range results {
+		v := value(res)
+		raw[res.URL] = v
+		maxValue = max(maxValue, v)
+	}
+	if maxValue > 0 {
+		for u, v := range raw {
+			raw[u] = v / maxValue
+		}
+	}
+	return raw
+}
+
+// keywordHits はテキストに含まれるキーワードの出現回数の合計を返します (大文字・小文字を区別しません)。
+func keywordHits(text string, keywords []string) int {
+	text = strings.ToLower(text)
+	hits := 0
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			hits += strings.Count(text, keyword)
+		}
+	}
+	return hits
+}
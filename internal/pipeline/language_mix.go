@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"act-feed-clean-go/internal/cleaner"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 記事本文の言語の混在チェック (--require-single-language)
+// ----------------------------------------------------------------------
+
+// languageCount は 1 言語の記事数と合計文字数です (最多言語の判定用)。
+type languageCount struct {
+	Lang     string
+	Articles int
+	Chars    int
+}
+
+// checkLanguages は各記事の本文の言語を cleaner.DetectLanguage で判定し、2言語以上が混在する場合は警告します。
+// RequireSingleLanguage が有効な場合は、最多言語 (記事数が同じ場合は合計文字数が多い言語) 以外の記事を除外した結果を返します。
+// 日本語・英語のどちらとも判定できない (mixed) 記事や文字を含まない記事は、混在の判定には数えず、除外の対象になります。
+func (p *Pipeline) checkLanguages(ctx context.Context, results []types.URLResult) []types.URLResult {
+	languages := make([]string, len(results))
+	counts := make(map[string]*languageCount)
+	for i, res := range results {
+		lang := cleaner.DetectLanguage(res.Content)
+		languages[i] = lang
+		if lang != cleaner.OutputLangJapanese && lang != cleaner.OutputLangEnglish {
+			continue
+		}
+		if counts[lang] == nil {
+			counts[lang] = &languageCount{Lang: lang}
+		}
+		counts[lang].Articles++
+		counts[lang].Chars += len([]rune(res.Content))
+	}
+	if len(counts) == 0 {
+		return results
+	}
+
+	ranked := make([]languageCount, 0, len(counts))
+	for _, c := range counts {
+		ranked = append(ranked, *c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Articles != ranked[j].Articles {
+			return ranked[i].Articles > ranked[j].Articles
+		}
+		return ranked[i].Chars > ranked[j].Chars
+	})
+	majority := ranked[0].Lang
+
+	if len(ranked) > 1 {
+		attrs := []any{slog.String("majority", majority)}
+		for _, c := range ranked {
+			attrs = append(attrs, slog.Int("lang_"+c.Lang, c.Articles))
+		}
+		slog.WarnContext(ctx, "処理対象の記事に複数の言語が混在しています。要約の一貫性が損なわれる可能性があります", attrs...)
+	}
+	if !p.config.RequireSingleLanguage {
+		return results
+	}
+
+	kept := make([]types.URLResult, 0, len(results))
+	for i, res := range results {
+		if languages[i] == majority {
+			kept = append(kept, res)
+			continue
+		}
+		slog.InfoContext(ctx, "最多言語と異なるため記事を除外します (--require-single-language)",
+			slog.String("url", res.URL),
+			slog.String("language", languages[i]),
+			slog.String("majority", majority),
+		)
+	}
+	if excluded := len(results) - len(kept); excluded > 0 {
+		slog.InfoContext(ctx, "最多言語以外の記事を除外しました", slog.String("language", majority), slog.Int("excluded", excluded), slog.Int("kept", len(kept)))
+	}
+	return kept
+}
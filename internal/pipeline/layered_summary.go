@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"act-feed-clean-go/internal/cleaner"
+)
+
+// ----------------------------------------------------------------------
+// 多層要約とスクリプトの入力の選択 (--layered-summary / --script-source)
+// ----------------------------------------------------------------------
+
+// ScriptSource はスクリプト生成の入力にどの要約を使うかを表します。
+type ScriptSource string
+
+const (
+	ScriptSourceFinal     ScriptSource = "final"     // 最終要約 (従来どおり)
+	ScriptSourceDetailed  ScriptSource = "detailed"  // 多層要約の詳細要約
+	ScriptSourceParagraph ScriptSource = "paragraph" // 多層要約の段落要約 (短い番組向け)
+)
+
+// ParseScriptSource は文字列を ScriptSource に変換します。空文字列の場合は ScriptSourceFinal を返します。
+func ParseScriptSource(s string) (ScriptSource, error) {
+	switch src := ScriptSource(strings.ToLower(strings.TrimSpace(s))); src {
+	case "", ScriptSourceFinal:
+		return ScriptSourceFinal, nil
+	case ScriptSourceDetailed, ScriptSourceParagraph:
+		return src, nil
+	default:
+		return "", fmt.Errorf("不明なスクリプトの入力です: %q (final, detailed, paragraph のいずれかを指定してください)", s)
+	}
+}
+
+// scriptInput は ScriptSource に応じてスクリプト生成に渡す要約を返します。
+// 多層要約がない場合や選択したレベルが空の場合は最終要約を返します。
+func (p *Pipeline) scriptInput(finalSummary string, layered *cleaner.LayeredSummary) string {
+	if layered == nil {
+		return finalSummary
+	}
+	var text string
+	switch p.config.ScriptSource {
+	case ScriptSourceDetailed:
+		text = layered.Detailed
+	case ScriptSourceParagraph:
+		text = layered.Paragraph
+	}
+	if strings.TrimSpace(text) == "" {
+		return finalSummary
+	}
+	return text
+}
@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 記事本文の外部リンクの抽出 (--related-links / --extracted-links-file)
+// ----------------------------------------------------------------------
+
+// RelatedLinksHeading は出力の末尾に付与する関連リンクセクションの見出しです。
+const RelatedLinksHeading = "関連リンク"
+
+// DefaultRelatedLinksMax は関連リンクセクションに掲載するリンクの最大件数です。
+const DefaultRelatedLinksMax = 10
+
+// ExtractedLink は記事本文から抽出した外部リンクです。
+type ExtractedLink struct {
+	URL     string   // 正規化したリンク先 (NormalizeURL)
+	Text    string   // リンクテキスト (Markdown のリンクの場合。最初に出現したもの)
+	Sources []string // リンクを含む記事のURL (出現順)
+}
+
+var (
+	// markdownLinkPattern は Markdown のリンク [text](url "title") です。先頭の ! は画像を表します。
+	markdownLinkPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\((https?://[^)\s]+)(?:\s+"[^"]*")?\)`)
+	// bareURLPattern は本文中の URL 表記です (日本語の句読点・括弧の手前で区切る)。
+	bareURLPattern = regexp.MustCompile(`https?://[^\s<>()\[\]"'、。「」（）]+`)
+)
+
+// ExtractExternalLinks は記事本文から Markdown のリンクと URL 表記を抽出し、正規化して重複を除去します。
+// 記事と同じドメイン (サブドメインを含む) へのリンクはサイト内のナビゲーションとみなして除外し、画像のリンクも含めません。
+// ContentFormat が ContentPlain の場合はリンク記法が除去済みのため、URL 表記のみが対象になります。
+func ExtractExternalLinks(results []types.URLResult) []ExtractedLink {
+	var links []ExtractedLink
+	index := make(map[string]int)
+	add := func(source, raw, text string) {
+		normalized, err := NormalizeURL(strings.TrimRight(raw, ".,;:!?"))
+		if err != nil || sameSite(source, normalized) {
+			return
+		}
+		i, ok := index[normalized]
+		if !ok {
+			index[normalized] = len(links)
+			links = append(links, ExtractedLink{URL: normalized, Text: text, Sources: []string{source}})
+			return
+		}
+		link := &links[i]
+		if link.Text == "" {
+			link.Text = text
+		}
+		if link.Sources[len(link.Sources)-1] != source {
+			link.Sources = append(link.Sources, source)
+		}
+	}
+
+	for _, res := range results {
+		if res.Error != nil || res.Content == "" {
+			continue
+		}
+		content := res.Content
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(content, -1) {
+			if m[1] == "" {
+				add(res.URL, m[3], strings.TrimSpace(m[2]))
+			}
+		}
+		// Markdown のリンクを除いた残りから URL 表記を抽出する (リンクの URL を二重に数えないため)
+		content = markdownLinkPattern.ReplaceAllString(content, "")
+		for _, raw := range bareURLPattern.FindAllString(content, -1) {
+			add(res.URL, raw, "")
+		}
+	}
+	return links
+}
+
+// sameSite は articleURL と linkURL のホストが同じドメイン (www. の有無とサブドメインを区別しない) かどうかを返します。
+func sameSite(articleURL, linkURL string) bool {
+	a, errA := url.Parse(articleURL)
+	b, errB := url.Parse(linkURL)
+	if errA != nil || errB != nil {
+		return false
+	}
+	hostA := strings.TrimPrefix(strings.ToLower(a.Hostname()), "www.")
+	hostB := strings.TrimPrefix(strings.ToLower(b.Hostname()), "www.")
+	if hostA == "" || hostB == "" {
+		return false
+	}
+	return hostA == hostB || strings.HasSuffix(hostA, "."+hostB) || strings.HasSuffix(hostB, "."+hostA)
+}
+
+// extractLinksEnabled は外部リンクの抽出が必要な設定かどうかを返します。
+func (p *Pipeline) extractLinksEnabled() bool {
+	return p.config.RelatedLinks || p.config.ExtractedLinksFile != ""
+}
+
+// renderRelatedLinks は抽出した外部リンクを関連リンクセクションとして返します。
+// 多くの記事から参照されているリンクを優先し、DefaultRelatedLinksMax 件までを掲載します。リンクがない場合は空文字列を返します。
+func (p *Pipeline) renderRelatedLinks(links []ExtractedLink) string {
+	if len(links) == 0 {
+		return ""
+	}
+	sorted := make([]ExtractedLink, len(links))
+	copy(sorted, links)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Sources) > len(sorted[j].Sources) })
+	if len(sorted) > DefaultRelatedLinksMax {
+		sorted = sorted[:DefaultRelatedLinksMax]
+	}
+
+	plain := p.config.ContentFormat == ContentPlain
+	var sb strings.Builder
+	if plain {
+		sb.WriteString(RelatedLinksHeading + "\n\n")
+	} else {
+		sb.WriteString("## " + RelatedLinksHeading + "\n\n")
+	}
+	for _, link := range sorted {
+		switch {
+		case link.Text == "":
+			fmt.Fprintf(&sb, "- %s\n", link.URL)
+		case plain:
+			fmt.Fprintf(&sb, "- %s\n  %s\n", link.Text, link.URL)
+		default:
+			fmt.Fprintf(&sb, "- [%s](%s)\n", escapeLinkText(link.Text), link.URL)
+		}
+	}
+	return sb.String()
+}
+
+// writeExtractedLinks は抽出した外部リンクを 1行1URL で ExtractedLinksFile に書き出します。
+// 出力は --urls-stdin でそのまま次回の処理対象として読み込めます。
+func (p *Pipeline) writeExtractedLinks(ctx context.Context, links []ExtractedLink) error {
+	path := p.config.ExtractedLinksFile
+	if path == "" {
+		return nil
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("抽出したリンクの出力先ディレクトリの作成に失敗しました: %w", err)
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("# 記事本文から抽出した外部リンク (--urls-stdin で読み込めます)\n")
+	for _, link := range links {
+		sb.WriteString(link.URL + "\n")
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return fmt.Errorf("抽出したリンクの書き出しに失敗しました: %w", err)
+	}
+	slog.InfoContext(ctx, "記事本文から抽出した外部リンクを書き出しました", slog.String("path", path), slog.Int("links", len(links)))
+	return nil
+}
+
+// joinSections は出力の末尾に付与するセクションを空行区切りで連結します。空のセクションは無視します。
+func joinSections(sections ...string) string {
+	var nonEmpty []string
+	for _, s := range sections {
+		if s = strings.TrimRight(s, "\n"); s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+	return strings.Join(nonEmpty, "\n\n") + "\n"
+}
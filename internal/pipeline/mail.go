@@ -0,0 +1,287 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// メールによるダイジェスト配信 (--smtp-host / --mail-to)
+// ----------------------------------------------------------------------
+
+// DefaultSMTPPort は SMTP サーバーのポートのデフォルト (サブミッションポート) です。
+const DefaultSMTPPort = 587
+
+// SMTP の認証情報を指定する環境変数名です。
+const (
+	SMTPUsernameEnv = "ACT_FEED_SMTP_USERNAME"
+	SMTPPasswordEnv = "ACT_FEED_SMTP_PASSWORD"
+)
+
+// mailDateLayout はメールの件名に付与する日付の書式です。
+const mailDateLayout = "2006-01-02"
+
+// MailConfig は処理結果のダイジェストをメールで配信する設定です。
+type MailConfig struct {
+	Host string   // SMTP サーバーのホスト名
+	Port int      // SMTP サーバーのポート (サーバーが対応していれば STARTTLS を使用します)
+	From string   // 送信元アドレス
+	To   []string // 宛先アドレス
+	// Username が空でない場合、PLAIN 認証を行います (環境変数 SMTPUsernameEnv / SMTPPasswordEnv から設定します)。
+	Username string
+	Password string
+	// AttachAudio が true の場合、合成した音声ファイル (OutputWAVPath) をメールに添付します。
+	AttachAudio bool
+	// AudioURL が空でない場合、音声ファイルへのリンクとして本文に記載します (公開先のURL)。
+	AudioURL string
+}
+
+// mailReference はメール本文の参照リンクの1件です。
+type mailReference struct {
+	Title string
+	URL   string
+}
+
+// mailTemplateData はメール本文のテンプレートに渡す値です。
+type mailTemplateData struct {
+	Title      string
+	Date       string
+	Highlights []string
+	Summary    template.HTML
+	References []mailReference
+	AudioURL   string
+}
+
+var mailTemplate = template.Must(template.New("mail").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head><meta charset="UTF-8"><title>{{.Title}}</title></head>
+<body style="font-family: sans-serif; line-height: 1.6; max-width: 720px;">
+<h1 style="font-size: 1.4em;">{{.Title}} <small style="color: #666;">{{.Date}}</small></h1>
+{{- if .Highlights}}
+<h2 style="font-size: 1.1em;">ハイライト</h2>
+<ul>
+{{- range .Highlights}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<h2 style="font-size: 1.1em;">要約</h2>
+{{.Summary}}
+{{- if .AudioURL}}
+<p>音声: <a href="{{.AudioURL}}">{{.AudioURL}}</a></p>
+{{- end}}
+{{- if .References}}
+<h2 style="font-size: 1.1em;">参照記事</h2>
+<ul>
+{{- range .References}}
+<li><a href="{{.URL}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// sendDigestMail は最終要約 (AI処理をスキップした場合は結合した本文) をダイジェストメールとして配信します。
+// 件名はフィードタイトルと日付、本文は最終要約の見出しによるハイライト・要約・参照リンクを HTML に整形したものです。
+// 送信に失敗しても他の出力は完了しているため、警告のみ出力して続行します。
+func (p *Pipeline) sendDigestMail(ctx context.Context, feedTitle, summary string, results []types.URLResult, titlesMap map[string]string) {
+	cfg := p.config.Mail
+	now := time.Now()
+	subject := fmt.Sprintf("%s (%s)", feedTitle, now.Format(mailDateLayout))
+	message, err := p.buildDigestMail(ctx, cfg, subject, feedTitle, now, summary, results, titlesMap)
+	if err == nil {
+		err = sendMail(cfg, message)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "ダイジェストメールの送信に失敗しました。他の出力は完了しています",
+			slog.String("host", cfg.Host),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	slog.InfoContext(ctx, "ダイジェストメールを送信しました", slog.String("subject", subject), slog.Int("recipients", len(cfg.To)))
+}
+
+// buildDigestMail は MIME 形式のメッセージ (ヘッダーと本文) を組み立てます。
+// 音声ファイルを添付する場合は multipart/mixed、それ以外は text/html の単一パートです。
+func (p *Pipeline) buildDigestMail(ctx context.Context, cfg *MailConfig, subject, feedTitle string, now time.Time, summary string, results []types.URLResult, titlesMap map[string]string) ([]byte, error) {
+	data := mailTemplateData{
+		Title:      feedTitle,
+		Date:       now.Format(mailDateLayout),
+		Highlights: summaryHeadings(summary),
+		Summary:    renderSummaryHTML(summary),
+		AudioURL:   cfg.AudioURL,
+	}
+	for _, res := range results {
+		if res.Error != nil || res.Content == "" {
+			continue
+		}
+		title := titlesMap[res.URL]
+		if title == "" {
+			title = res.URL
+		}
+		data.References = append(data.References, mailReference{Title: title, URL: res.URL})
+	}
+	var body bytes.Buffer
+	if err := mailTemplate.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("メール本文の生成に失敗しました: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	audioPath := p.mailAttachmentPath(ctx, cfg)
+	if audioPath == "" {
+		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64Lines(&msg, body.Bytes())
+		return msg.Bytes(), nil
+	}
+
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("添付する音声ファイルの読み込みに失敗しました: %w", err)
+	}
+	mw := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+	parts := []struct {
+		header  textproto.MIMEHeader
+		content []byte
+	}{
+		{textproto.MIMEHeader{
+			"Content-Type":              {"text/html; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"base64"},
+		}, body.Bytes()},
+		{textproto.MIMEHeader{
+			"Content-Type":              {"audio/wav"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(audioPath)})},
+		}, audio},
+	}
+	for _, part := range parts {
+		w, err := mw.CreatePart(part.header)
+		if err != nil {
+			return nil, fmt.Errorf("メールの組み立てに失敗しました: %w", err)
+		}
+		var encoded bytes.Buffer
+		writeBase64Lines(&encoded, part.content)
+		if _, err := w.Write(encoded.Bytes()); err != nil {
+			return nil, fmt.Errorf("メールの組み立てに失敗しました: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("メールの組み立てに失敗しました: %w", err)
+	}
+	return msg.Bytes(), nil
+}
+
+// mailAttachmentPath は添付する音声ファイルのパスを返します。
+// 添付が無効な場合や、音声を合成しなかった場合 (エンジンが利用できずテキスト出力にフォールバックした場合を含む) は空文字列です。
+func (p *Pipeline) mailAttachmentPath(ctx context.Context, cfg *MailConfig) string {
+	path := p.audioOutputPath()
+	if !cfg.AttachAudio || path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		slog.WarnContext(ctx, "音声ファイルが見つからないため、メールに添付しません", slog.String("path", path))
+		return ""
+	}
+	return path
+}
+
+// ValidateMailAddress はメールの送信元・宛先アドレスを検証します。
+// アドレスはそのままヘッダーと SMTP のエンベロープに書き出すため、表示名を含まない単一のアドレスのみを受け付けます (改行によるヘッダーの注入を防ぎます)。
+func ValidateMailAddress(address string) error {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || parsed.Address != address {
+		return fmt.Errorf("メールアドレスの形式が正しくありません: %q", address)
+	}
+	return nil
+}
+
+// sendMail は cfg の SMTP サーバーにメッセージを送信します。
+func sendMail(cfg *MailConfig, message []byte) error {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	return smtp.SendMail(addr, auth, cfg.From, cfg.To, message)
+}
+
+// writeBase64Lines は data を base64 で 76 文字ごとに改行して書き出します (RFC 2045)。
+func writeBase64Lines(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+// summaryHeadings は最終要約の見出し (## ) をハイライトとして返します。
+func summaryHeadings(summary string) []string {
+	var headings []string
+	for _, line := range strings.Split(summary, "\n") {
+		if heading, ok := strings.CutPrefix(strings.TrimSpace(line), "## "); ok {
+			headings = append(headings, strings.TrimSpace(heading))
+		}
+	}
+	return headings
+}
+
+// renderSummaryHTML は Markdown の最終要約を簡易的に HTML に変換します。
+// 見出し (#) ・箇条書き (- / *) ・段落のみを扱い、それ以外の記法はエスケープしたテキストとして残します。
+func renderSummaryHTML(summary string) template.HTML {
+	var sb strings.Builder
+	inList := false
+	closeList := func() {
+		if inList {
+			sb.WriteString("</ul>\n")
+			inList = false
+		}
+	}
+	for _, line := range strings.Split(summary, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			closeList()
+		case strings.HasPrefix(trimmed, "#"):
+			closeList()
+			level := min(len(trimmed)-len(strings.TrimLeft(trimmed, "#"))+1, 6)
+			fmt.Fprintf(&sb, "<h%d>%s</h%d>\n", level, template.HTMLEscapeString(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))), level)
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			if !inList {
+				sb.WriteString("<ul>\n")
+				inList = true
+			}
+			fmt.Fprintf(&sb, "<li>%s</li>\n", template.HTMLEscapeString(strings.TrimSpace(trimmed[2:])))
+		default:
+			closeList()
+			fmt.Fprintf(&sb, "<p>%s</p>\n", template.HTMLEscapeString(trimmed))
+		}
+	}
+	closeList()
+	return template.HTML(sb.String())
+}
@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox"
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// attachTestExecutor は音声合成済みとして扱うためだけの voicevox.EngineExecutor です (メソッドは呼び出されません)。
+type attachTestExecutor struct {
+	voicevox.EngineExecutor
+}
+
+// newMailTestPipeline は wavPath に音声を出力した Pipeline を生成します。
+func newMailTestPipeline(wavPath string) *Pipeline {
+	return &Pipeline{
+		VoicevoxEngineExecutor: attachTestExecutor{},
+		config:                 PipelineConfig{OutputWAVPath: wavPath},
+	}
+}
+
+// buildTestDigestMail は buildDigestMail の出力を net/mail でパースしたメッセージを返します。
+func buildTestDigestMail(t *testing.T, p *Pipeline, cfg *MailConfig) *mail.Message {
+	t.Helper()
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	results := []types.URLResult{
+		{URL: "https://example.com/a", Content: "記事Aの本文です。"},
+		{URL: "https://example.com/failed", Error: io.ErrUnexpectedEOF},
+	}
+	titles := map[string]string{"https://example.com/a": "記事A"}
+	summary := "## 今日の話題\n- 長い要約の箇条書きです。" + strings.Repeat("本文が続きます。", 40)
+
+	raw, err := p.buildDigestMail(context.Background(), cfg, "テストフィード (2026-10-16)", "テストフィード", now, summary, results, titles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("メッセージのパースに失敗しました: %v", err)
+	}
+	if got := msg.Header.Get("From"); got != cfg.From {
+		t.Errorf("From = %q, want %q", got, cfg.From)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "テストフィード (2026-10-16)" {
+		t.Errorf("Subject = %q (err = %v), want テストフィード (2026-10-16)", subject, err)
+	}
+	return msg
+}
+
+// decodeBase64Body は 76 文字ごとに改行された base64 の本文をデコードします。
+func decodeBase64Body(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+	encoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(encoded), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Errorf("base64 の本文が改行されていません (%d 行)", len(lines))
+	}
+	for i, line := range lines {
+		if len(line) > 76 {
+			t.Errorf("base64 の %d 行目が 76 文字を超えています: %d 文字", i+1, len(line))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+	if err != nil {
+		t.Fatalf("base64 のデコードに失敗しました: %v", err)
+	}
+	return decoded
+}
+
+func TestBuildDigestMailWithAttachment(t *testing.T) {
+	wavPath := filepath.Join(t.TempDir(), "episode.wav")
+	audio := bytes.Repeat([]byte("RIFF-WAVE-DATA"), 20)
+	if err := os.WriteFile(wavPath, audio, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &MailConfig{From: "from@example.com", To: []string{"a@example.com", "b@example.com"}, AttachAudio: true}
+	msg := buildTestDigestMail(t, newMailTestPipeline(wavPath), cfg)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" || params["boundary"] == "" {
+		t.Fatalf("Content-Type = %q (err = %v), want boundary 付きの multipart/mixed", msg.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	html, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("本文のパートを読み込めません: %v", err)
+	}
+	if ct := html.Header.Get("Content-Type"); ct != "text/html; charset=UTF-8" {
+		t.Errorf("本文の Content-Type = %q", ct)
+	}
+	if body := decodeBase64Body(t, html); !bytes.Contains(body, []byte(`<a href="https://example.com/a">記事A</a>`)) || bytes.Contains(body, []byte("failed")) {
+		t.Errorf("本文に成功した記事のみの参照リンクが含まれていません:\n%s", body)
+	}
+
+	attachment, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("添付のパートを読み込めません: %v", err)
+	}
+	if ct := attachment.Header.Get("Content-Type"); ct != "audio/wav" {
+		t.Errorf("添付の Content-Type = %q, want audio/wav", ct)
+	}
+	if name := attachment.FileName(); name != "episode.wav" {
+		t.Errorf("添付のファイル名 = %q, want episode.wav", name)
+	}
+	if got := decodeBase64Body(t, attachment); !bytes.Equal(got, audio) {
+		t.Errorf("添付の内容が音声ファイルと一致しません")
+	}
+
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("パートは本文と添付の 2 つのみであることを期待: %v", err)
+	}
+}
+
+func TestBuildDigestMailSkipsMissingAttachment(t *testing.T) {
+	wavPath := filepath.Join(t.TempDir(), "missing.wav")
+	cfg := &MailConfig{From: "from@example.com", To: []string{"a@example.com"}, AttachAudio: true}
+	msg := buildTestDigestMail(t, newMailTestPipeline(wavPath), cfg)
+
+	if mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type")); err != nil || mediaType != "text/html" {
+		t.Fatalf("Content-Type = %q (err = %v), 音声ファイルがない場合は添付なしの text/html を期待", msg.Header.Get("Content-Type"), err)
+	}
+	if cte := msg.Header.Get("Content-Transfer-Encoding"); cte != "base64" {
+		t.Errorf("Content-Transfer-Encoding = %q, want base64", cte)
+	}
+	if body := decodeBase64Body(t, msg.Body); !bytes.Contains(body, []byte("<li>今日の話題</li>")) {
+		t.Errorf("本文にハイライトが含まれていません:\n%s", body)
+	}
+}
+
+func TestValidateMailAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{"plain address", "digest@example.com", false},
+		{"empty", "", true},
+		{"not an address", "digest", true},
+		{"display name", "Digest <digest@example.com>", true},
+		{"multiple addresses", "a@example.com, b@example.com", true},
+		{"header injection with crlf", "digest@example.com\r\nBcc: victim@example.com", true},
+		{"header injection with lf", "digest@example.com\nBcc: victim@example.com", true},
+		{"surrounding spaces", " digest@example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateMailAddress(tt.address); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMailAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"log/slog"
+	"runtime"
+)
+
+// ----------------------------------------------------------------------
+// メモリ使用量の計測
+// ----------------------------------------------------------------------
+
+// memSampler は処理の節目でヒープ使用量を計測し、観測したピークを保持します。
+// 詳細な分析には --memprofile で取得したヒーププロファイルを使用してください。
+type memSampler struct {
+	peakBytes uint64
+	peakLabel string
+}
+
+// sample は現在のヒープ使用量 (HeapInuse) を計測し、ピークを更新します。
+func (m *memSampler) sample(label string) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapInuse > m.peakBytes {
+		m.peakBytes = stats.HeapInuse
+		m.peakLabel = label
+	}
+	slog.Debug("ヒープ使用量", slog.String("at", label), slog.Float64("heap_inuse_mb", toMB(stats.HeapInuse)))
+}
+
+// log は観測したピークをログに出力します。
+func (m *memSampler) log() {
+	slog.Info("ヒープ使用量のピーク (計測点での観測値)",
+		slog.Float64("peak_heap_inuse_mb", toMB(m.peakBytes)),
+		slog.String("at", m.peakLabel),
+	)
+}
+
+func toMB(bytes uint64) float64 {
+	return float64(bytes*10/(1<<20)) / 10
+}
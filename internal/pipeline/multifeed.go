@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"act-feed-clean-go/internal/cleaner"
+	itemfeed "act-feed-clean-go/internal/feed"
+)
+
+// ----------------------------------------------------------------------
+// 複数フィードのマージ (--feed-urls)
+// ----------------------------------------------------------------------
+
+// feedTitleSeparator はマージしたフィードのタイトルを連結する区切り文字です。
+const feedTitleSeparator = " / "
+
+// RunFeeds は複数のフィードを取得して記事URLをマージ・重複除去し、1 本のスクリプトとして処理します。
+// フィードの取得は FeedConcurrency 件ずつ並列に行い、マージした全記事を 1 回のスクレイピングで処理します。
+// 取得に失敗したフィードは警告を出してスキップし、すべてのフィードが失敗した場合のみエラーを返します。
+// マージ後のフィードタイトルは各フィードのタイトルを " / " で連結したものです。
+func (p *Pipeline) RunFeeds(ctx context.Context, feedURLs []string) (*RunResult, error) {
+	if len(feedURLs) == 0 {
+		return nil, fmt.Errorf("フィードURLが指定されていません")
+	}
+
+	// フィードの取得・パースのみを先に並列で行い、抽出とマージはフィードの指定順に行う
+	fetched := itemfeed.FetchAll(ctx, itemfeed.FetcherFunc(p.fetchAndParse), feedURLs, p.config.FeedConcurrency)
+
+	var sources []*feedSource
+	var lastErr error
+	notModified := 0
+	for _, res := range fetched {
+		feedURL, err := res.FeedURL, res.Err
+		var source *feedSource
+		if err != nil {
+			err = fmt.Errorf("フィードの処理エラー: %w", err)
+		} else {
+			source, err = p.newFeedSource(ctx, feedURL, res.Feed)
+		}
+		if errors.Is(err, itemfeed.ErrNotModified) {
+			slog.InfoContext(ctx, "フィードが前回の取得から更新されていないため、スキップします", slog.String("feed_url", feedURL))
+			notModified++
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, cleaner.ErrClosed) {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "フィードの取得に失敗したためスキップします", slog.String("feed_url", feedURL), slog.String("error", err.Error()))
+			lastErr = err
+			continue
+		}
+		sources = append(sources, source)
+	}
+
+	if len(sources) == 0 {
+		if notModified == len(feedURLs) {
+			return &RunResult{NotModified: true}, nil
+		}
+		return nil, fmt.Errorf("すべてのフィードの取得に失敗しました: %w", lastErr)
+	}
+
+	merged := mergeFeedSources(ctx, sources)
+	slog.InfoContext(ctx, "複数フィードの記事URLをマージしました",
+		slog.Int("feeds", len(sources)),
+		slog.Int("skipped", len(feedURLs)-len(sources)),
+		slog.Int("urls", len(merged.URLs)),
+	)
+
+	result, err := p.runSource(ctx, merged)
+	if result != nil {
+		result.FeedTitles = make([]string, 0, len(sources))
+		for _, s := range sources {
+			result.FeedTitles = append(result.FeedTitles, s.Title)
+		}
+	}
+	return result, err
+}
+
+// mergeFeedSources は複数のフィードを 1 つの feedSource にマージします。
+// 記事URLは最初に出現したフィードの順序を保って重複を除去し、メタデータも最初に出現したものを使用します。
+func mergeFeedSources(ctx context.Context, sources []*feedSource) *feedSource {
+	merged := &feedSource{
+		Titles:     make(map[string]string),
+		Contents:   make(map[string]string),
+		GUIDs:      make(map[string]string),
+		Images:     make(map[string]string),
+		Authors:    make(map[string]string),
+		Categories: make(map[string][]string),
+		Published:  make(map[string]time.Time),
+	}
+	feedURLs := make([]string, 0, len(sources))
+	titles := make([]string, 0, len(sources))
+	seen := make(map[string]bool)
+	duplicates := 0
+	for _, s := range sources {
+		feedURLs = append(feedURLs, s.FeedURL)
+		if s.Title != "" {
+			titles = append(titles, s.Title)
+		}
+		for _, u := range s.URLs {
+			if seen[u] {
+				duplicates++
+				continue
+			}
+			seen[u] = true
+			merged.URLs = append(merged.URLs, u)
+			if title, ok := s.Titles[u]; ok {
+				merged.Titles[u] = title
+			}
+			if content, ok := s.Contents[u]; ok {
+				merged.Contents[u] = content
+			}
+			if guid, ok := s.GUIDs[u]; ok {
+				merged.GUIDs[u] = guid
+			}
+			if image, ok := s.Images[u]; ok {
+				merged.Images[u] = image
+			}
+			if author, ok := s.Authors[u]; ok {
+				merged.Authors[u] = author
+			}
+			if categories, ok := s.Categories[u]; ok {
+				merged.Categories[u] = categories
+			}
+			if published, ok := s.Published[u]; ok {
+				merged.Published[u] = published
+			}
+		}
+	}
+	if duplicates > 0 {
+		slog.InfoContext(ctx, "複数フィード間で重複した記事URLを除外しました", slog.Int("duplicates", duplicates))
+	}
+	merged.FeedURL = strings.Join(feedURLs, ",")
+	merged.Title = strings.Join(titles, feedTitleSeparator)
+	return merged
+}
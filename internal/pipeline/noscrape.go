@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/correlation"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// フィード本文のみで処理する軽量モード (--no-scrape)
+// ----------------------------------------------------------------------
+
+// DefaultNoScrapeMinChars は --no-scrape モードで記事として扱うフィード本文の最小文字数の既定値です。
+const DefaultNoScrapeMinChars = 200
+
+// feedArticles はスクレイピングを行わず、フィードに含まれる本文 (item.Content / item.Description。FeedBodyPrefer に従う)
+// を記事本文として返します (NoScrape 指定時のスクレイピングフェーズの代替)。
+// 本文がない記事と、NoScrapeMinChars 未満の短い記事はスキップします。処理できる記事が 0 件の場合はエラーを返します。
+func (p *Pipeline) feedArticles(ctx context.Context, urls []string, feedContents map[string]string) ([]types.URLResult, error) {
+	minChars := p.config.NoScrapeMinChars
+	results := make([]types.URLResult, 0, len(urls))
+	empty, short := 0, 0
+	for _, u := range urls {
+		content := feedContents[u]
+		articleCtx := correlation.WithID(ctx, correlation.ArticleID(u))
+		chars := utf8.RuneCountInString(content)
+		switch {
+		case content == "":
+			empty++
+			slog.DebugContext(articleCtx, "フィードに本文がないためスキップします", slog.String("url", u))
+			continue
+		case chars < minChars:
+			short++
+			slog.DebugContext(articleCtx, "フィードの本文が短いためスキップします", slog.String("url", u), slog.Int("chars", chars), slog.Int("min_chars", minChars))
+			continue
+		}
+		results = append(results, types.URLResult{URL: u, Content: content})
+	}
+
+	slog.InfoContext(ctx, "フィードの本文を記事本文として使用しました (スクレイピングなし)",
+		slog.Int("articles", len(results)),
+		slog.Int("skipped_empty", empty),
+		slog.Int("skipped_short", short),
+		slog.Int("total", len(urls)),
+	)
+	p.logContentPreviews(ctx, results)
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("フィードに %d 文字以上の本文を持つ記事が一つもありませんでした (--no-scrape)", minChars)
+	}
+	return results, nil
+}
+
+// collectArticles は記事本文を取得します。NoScrape が有効な場合はフィードの本文を使用し (feedArticles)、
+// それ以外の場合は並列スクレイピングを行います (scrapeArticles)。SanitizeInput が有効な場合は本文を正規化します。
+// 取得後に本文の言語の混在をチェックし、RequireSingleLanguage が有効な場合は最多言語以外の記事を除外します (language_mix.goで定義)。
+func (p *Pipeline) collectArticles(ctx context.Context, urls []string, feedContents map[string]string) ([]types.URLResult, []CodeSnippet, []ScrapeFailure, error) {
+	var (
+		results  []types.URLResult
+		snippets []CodeSnippet
+		failures []ScrapeFailure
+		err      error
+	)
+	if p.config.NoScrape {
+		results, err = p.feedArticles(ctx, urls, feedContents)
+	} else {
+		results, snippets, failures, err = p.scrapeArticles(ctx, urls, feedContents)
+	}
+	if err == nil && p.config.SanitizeInput {
+		p.sanitizeArticles(ctx, results)
+	}
+	if err == nil {
+		results = p.checkLanguages(ctx, results)
+	}
+	return results, snippets, failures, err
+}
+
+// sanitizeArticles は記事本文に cleaner.SanitizeText を適用し、除去・置換した文字の種類を記事ごとにデバッグログへ出力します。
+func (p *Pipeline) sanitizeArticles(ctx context.Context, results []types.URLResult) {
+	changed := 0
+	for i := range results {
+		sanitized, stats := cleaner.SanitizeTextWithStats(results[i].Content)
+		if !stats.Changed() {
+			continue
+		}
+		changed++
+		results[i].Content = sanitized
+		articleCtx := correlation.WithID(ctx, correlation.ArticleID(results[i].URL))
+		slog.DebugContext(articleCtx, "記事本文の不可視文字・空白を正規化しました",
+			append([]any{slog.String("url", results[i].URL)}, stats.LogAttrs()...)...)
+	}
+	slog.InfoContext(ctx, "記事本文を正規化しました (--sanitize-input)", slog.Int("changed", changed), slog.Int("articles", len(results)))
+}
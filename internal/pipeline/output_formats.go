@@ -0,0 +1,218 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// 複数の出力フォーマットの一括生成 (--output-formats)
+// ----------------------------------------------------------------------
+
+// OutputFormat は 1 回の実行で生成する出力のフォーマットです。
+type OutputFormat string
+
+const (
+	OutputText     OutputFormat = "text"     // Markdown の記法を除去したスクリプト (.txt)
+	OutputMarkdown OutputFormat = "markdown" // スクリプト (--decorate 指定時は装飾済み) (.md)
+	OutputJSON     OutputFormat = "json"     // 実行結果 (RunResult) (.json)
+	OutputWAV      OutputFormat = "wav"      // 音声合成したWAV (.wav)
+	OutputSRT      OutputFormat = "srt"      // 音声合成のタイムコード付き字幕 (.srt)
+	OutputVTT      OutputFormat = "vtt"      // 音声合成のタイムコード付き字幕 (.vtt)
+	OutputRSS      OutputFormat = "rss"      // 最終要約を 1 件のアイテムとする RSS 2.0 (.xml)
+)
+
+// DefaultOutputDir は --output-formats の出力先ディレクトリのデフォルトです。
+const DefaultOutputDir = "output"
+
+// outputBaseName は --output-formats の各出力ファイルの拡張子を除いた名前です。
+const outputBaseName = "script"
+
+// outputFormatOrder は生成する順序です。字幕は音声合成のタイムコードを使うため WAV の後に生成します。
+var outputFormatOrder = []OutputFormat{OutputWAV, OutputSRT, OutputVTT, OutputText, OutputMarkdown, OutputRSS, OutputJSON}
+
+// outputFormatExts は各フォーマットの出力ファイルの拡張子です。
+var outputFormatExts = map[OutputFormat]string{
+	OutputText:     ".txt",
+	OutputMarkdown: ".md",
+	OutputJSON:     ".json",
+	OutputWAV:      ".wav",
+	OutputSRT:      ".srt",
+	OutputVTT:      ".vtt",
+	OutputRSS:      ".xml",
+}
+
+// ParseOutputFormats は --output-formats の値を重複を除いて生成順に並べた OutputFormat に変換します。
+// 字幕 (srt / vtt) は音声合成のタイムコードから生成するため、wav と併せて指定する必要があります。
+func ParseOutputFormats(values []string) ([]OutputFormat, error) {
+	requested := make(map[OutputFormat]bool)
+	for _, v := range values {
+		f := OutputFormat(strings.ToLower(strings.TrimSpace(v)))
+		if f == "" {
+			continue
+		}
+		if _, ok := outputFormatExts[f]; !ok {
+			return nil, fmt.Errorf("不明な出力フォーマットです: %q (text, markdown, json, wav, srt, vtt, rss のいずれかを指定してください)", v)
+		}
+		requested[f] = true
+	}
+	if (requested[OutputSRT] || requested[OutputVTT]) && !requested[OutputWAV] {
+		return nil, fmt.Errorf("出力フォーマットの srt / vtt は wav と併せて指定してください")
+	}
+
+	var formats []OutputFormat
+	for _, f := range outputFormatOrder {
+		if requested[f] {
+			formats = append(formats, f)
+		}
+	}
+	return formats, nil
+}
+
+// OutputFormatPath は出力先ディレクトリ dir における format の出力ファイルのパスを返します。
+func OutputFormatPath(dir string, format OutputFormat) string {
+	return filepath.Join(dir, outputBaseName+outputFormatExts[format])
+}
+
+// HasOutputFormat は formats に format が含まれるかを返します。
+func HasOutputFormat(formats []OutputFormat, format OutputFormat) bool {
+	return slices.Contains(formats, format)
+}
+
+// writeOutputFormats は OutputFormats の各フォーマットを、1 回の AI処理の結果から OutputDir 配下に生成します。
+// 1 つのフォーマットの生成に失敗しても残りのフォーマットの生成を続け、失敗はフォーマットごとに集約して最後にまとめて返します。
+// WAV・字幕の出力先 (OutputWAVPath / SRTPath / VTTPath) は、呼び出し側で OutputFormatPath に設定されている前提です。
+func (p *Pipeline) writeOutputFormats(ctx context.Context, result *RunResult, feedTitle, summary, scriptText string) error {
+	var errs []error
+	fail := func(format OutputFormat, err error) {
+		slog.WarnContext(ctx, "出力フォーマットの生成に失敗しました。他のフォーマットの生成は続行します",
+			slog.String("format", string(format)),
+			slog.String("error", err.Error()),
+		)
+		errs = append(errs, fmt.Errorf("%s: %w", format, err))
+	}
+
+	synthesized := false
+	for _, format := range p.config.OutputFormats {
+		path := OutputFormatPath(p.config.OutputDir, format)
+		var err error
+		switch format {
+		case OutputWAV:
+			if err = p.synthesizeAudio(ctx, scriptText); err == nil {
+				synthesized = true
+			}
+		case OutputSRT, OutputVTT:
+			// 字幕は SRTPath / VTTPath の両方を 1 回で書き出すため、WAV の直後に 1 度だけ生成する
+			if format == OutputVTT && HasOutputFormat(p.config.OutputFormats, OutputSRT) {
+				continue
+			}
+			if !synthesized {
+				err = fmt.Errorf("音声合成に失敗したため字幕を生成できません")
+				break
+			}
+			err = p.writeSubtitles(ctx)
+		case OutputText:
+			err = writeOutputFile(ctx, path, markdownToPlain(scriptText))
+		case OutputMarkdown:
+			err = writeOutputFile(ctx, path, p.decorate(scriptText))
+		case OutputRSS:
+			err = p.writeRSS(ctx, path, feedTitle, summary, scriptText, synthesized)
+		case OutputJSON:
+			var raw []byte
+			if raw, err = json.MarshalIndent(result, "", "  "); err == nil {
+				err = writeOutputFile(ctx, path, string(raw)+"\n")
+			}
+		}
+		if err != nil {
+			fail(format, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d 件の出力フォーマットの生成に失敗しました: %w", len(errs), errors.Join(errs...))
+	}
+	slog.InfoContext(ctx, "出力フォーマットをすべて生成しました", slog.String("dir", p.config.OutputDir), slog.Any("formats", p.config.OutputFormats))
+	return nil
+}
+
+// rssDocument は RSS 2.0 の出力です。
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Description string    `xml:"description"`
+	PubDate     string    `xml:"pubDate"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Description string        `xml:"description"`
+	PubDate     string        `xml:"pubDate"`
+	GUID        string        `xml:"guid"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// writeRSS は最終要約 (AI処理をスキップした場合はスクリプト) を 1 件のアイテムとする RSS を path に書き出します。
+// 音声を合成した場合は、WAV ファイルを出力先ディレクトリからの相対パスの enclosure として付与します。
+func (p *Pipeline) writeRSS(ctx context.Context, path, feedTitle, summary, scriptText string, synthesized bool) error {
+	now := time.Now()
+	description := summary
+	if description == "" {
+		description = scriptText
+	}
+	item := rssItem{
+		Title:       feedTitle,
+		Description: description,
+		PubDate:     now.Format(time.RFC1123Z),
+		GUID:        fmt.Sprintf("%s-%s", outputBaseName, now.Format("20060102-150405")),
+	}
+	if synthesized {
+		wavPath := OutputFormatPath(p.config.OutputDir, OutputWAV)
+		if info, err := os.Stat(wavPath); err == nil {
+			item.Enclosure = &rssEnclosure{URL: filepath.Base(wavPath), Length: info.Size(), Type: "audio/wav"}
+		}
+	}
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{Title: feedTitle, Description: feedTitle, PubDate: item.PubDate, Items: []rssItem{item}},
+	}
+	raw, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("RSSの生成に失敗しました: %w", err)
+	}
+	return writeOutputFile(ctx, path, xml.Header+string(raw)+"\n")
+}
+
+// writeOutputFile は出力を path に書き込みます。
+func writeOutputFile(ctx context.Context, path, content string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("出力ディレクトリの作成に失敗しました (%s): %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("出力ファイルの書き込みに失敗しました (%s): %w", path, err)
+	}
+	slog.InfoContext(ctx, "出力ファイルを書き出しました", slog.String("path", path))
+	return nil
+}
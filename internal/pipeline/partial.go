@@ -0,0 +1,41 @@
+package pipeline
+
+import "fmt"
+
+// ----------------------------------------------------------------------
+// 失敗時の部分成果 (PartialResultError)
+// ----------------------------------------------------------------------
+
+// StageOutput は音声合成・テキスト出力の段階を表す PartialResultError.Stage の値です。
+// LLM処理の段階は cleaner.PhaseMap / PhaseReduce / PhaseSummary / PhaseScript の値を使用します。
+const StageOutput = "output"
+
+// PartialResultError は Run が途中で失敗した場合に、それまでに得られた部分成果を保持するエラーです。
+// errors.As で取り出し、Partial の Map要約・中間要約・最終要約・スクリプトを保存したり、再開に利用したりできます。
+type PartialResultError struct {
+	Stage   string     // 失敗した段階 (map, reduce, summary, script, output)
+	Partial *RunResult // 失敗時点までの部分成果
+	Err     error
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("%s の段階で失敗しました: %v", e.Stage, e.Err)
+}
+
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
+}
+
+// recordArtifacts は生成済みの成果物を result に記録します。失敗時は生成できた分のみが記録されます。
+func (result *RunResult) recordArtifacts(artifacts *runArtifacts) {
+	if artifacts == nil {
+		return
+	}
+	result.MapSummaries = artifacts.MapSummaries
+	result.DroppedSegments = artifacts.Dropped
+	result.IntermediateSummary = artifacts.Reduce
+	result.FinalSummary = artifacts.Summary
+	result.SummaryOverlap = artifacts.SummaryOverlap
+	result.LayeredSummary = artifacts.Layered
+	result.BulletSummary = artifacts.BulletSummary
+}
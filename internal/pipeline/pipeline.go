@@ -2,13 +2,22 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"act-feed-clean-go/internal/cleaner"
+	"act-feed-clean-go/internal/correlation"
+	itemfeed "act-feed-clean-go/internal/feed"
+	"act-feed-clean-go/internal/state"
+	"act-feed-clean-go/internal/voice"
 
+	"github.com/mmcdole/gofeed"
 	"github.com/shouni/go-utils/iohandler"
 	"github.com/shouni/go-voicevox/pkg/voicevox"
 	"github.com/shouni/go-web-exact/v2/pkg/types"
@@ -20,7 +29,136 @@ type PipelineConfig struct {
 	Parallel      int
 	Verbose       bool
 	OutputWAVPath string
-	ClientTimeout time.Duration
+	Timeouts      TimeoutBudget // フェーズ別のタイムアウト予算
+	// PerHostParallel / PerHostDelay が指定された場合、スクレイピングをホスト単位の同時リクエスト数とリクエスト間隔で
+	// 制限します (Parallel とは独立に適用されます。host_limit.goで定義)。0 の場合は制限しません。
+	PerHostParallel int
+	PerHostDelay    time.Duration
+	// TargetLUFS は出力WAVのラウドネス正規化の目標値です (0 の場合は正規化しません)。
+	TargetLUFS float64
+	// SRTPath / VTTPath が空でない場合、音声合成のタイムコードから字幕ファイルを出力します。
+	SRTPath string
+	VTTPath string
+	// VTTSpeakerTags は WebVTT の各キューに話者名を <v 話者> タグで埋め込むかどうかです。
+	VTTSpeakerTags bool
+	// DiffOnly は、状態ファイルに記録された処理済み記事を除外し、新着記事のみを処理するかどうかです。
+	DiffOnly bool
+	// StatePath は処理済み記事とエピソード履歴を保存する状態ファイルのパスです。
+	StatePath string
+	// Since がゼロ値でない場合、公開時刻がこれより前の記事を処理対象から除外します。
+	// SinceUndated は公開時刻のない記事の扱いです (itemfeed.UndatedInclude / UndatedExclude。空の場合は UndatedInclude)。
+	Since        time.Time
+	SinceUndated string
+	// Disclaimer は、AI生成である旨と生成日時・出典を示す免責文を出力に付与するかどうかです。
+	Disclaimer bool
+	// DisclaimerTemplate は免責文の text/template テンプレートです (空の場合は DefaultDisclaimerTemplate)。
+	DisclaimerTemplate string
+	// DisclaimerPosition はテキスト出力時に免責文を付与する位置です (音声の場合は常に冒頭)。
+	DisclaimerPosition DisclaimerPosition
+	// Stream は、スクリプト生成をストリーミングで行い、生成中の行を標準出力へ逐次表示するかどうかです。
+	Stream bool
+	// FallbackToFeedContent は、スクレイピングに失敗した記事の本文をフィードの要約文で代替するかどうかです。
+	FallbackToFeedContent bool
+	// NoScrape は、スクレイピングを行わずフィードの本文 (FeedBodyPrefer に従う) だけで記事を処理するかどうかです (noscrape.goで定義)。
+	// NoScrapeMinChars 未満の本文しかない記事はスキップします (0 の場合は本文が空の記事のみスキップ)。
+	NoScrape         bool
+	NoScrapeMinChars int
+	// SanitizeInput は、記事本文を結合する前に不可視文字・制御文字を除去し、改行と空白を正規化するかどうかです (cleaner.SanitizeText)。
+	SanitizeInput bool
+	// RequireSingleLanguage は、本文の言語 (cleaner.DetectLanguage) が最多の言語と異なる記事を除外するかどうかです。
+	// 無効の場合も、2言語以上が混在していれば警告します (language_mix.goで定義)。
+	RequireSingleLanguage bool
+	// FeedConcurrency は RunFeeds で複数のフィードを並列に取得する際の同時取得数です (0 以下の場合は itemfeed.DefaultFetchConcurrency)。
+	FeedConcurrency int
+	// FeedBodyPrefer は、フィードの item.Content と item.Description のどちらを本文候補として優先するかです
+	// (itemfeed.PreferContent / PreferDescription / PreferLonger。空の場合は PreferContent)。
+	FeedBodyPrefer string
+	// SaveRunDir が空でない場合、中間要約・最終要約・スクリプトをこのディレクトリに保存します。
+	SaveRunDir string
+	// ABPromptDirs が 2 つ以上指定された場合、各プロンプトセットで最終要約とスクリプトを追加で生成し、
+	// ABOutputDir (空の場合は DefaultABOutputDir) に変種ごとに保存して比較します (ab_prompts.goで定義)。
+	ABPromptDirs []string
+	ABOutputDir  string
+	// RelatedLinks は、記事本文から抽出した外部リンクを関連リンクセクションとしてテキスト出力の末尾に付与するかどうかです。
+	// ExtractedLinksFile が空でない場合、抽出した外部リンクを 1行1URL でこのファイルに書き出します (links.goで定義)。
+	RelatedLinks       bool
+	ExtractedLinksFile string
+	// DiffAgainst が空でない場合、このディレクトリに保存された前回の実行結果と今回の結果の差分を標準エラーに出力します。
+	DiffAgainst string
+	// ContentFormat は、AI処理とAIスキップ時の出力に渡す本文を Markdown のまま使うかプレーンテキストにするかです
+	// (空の場合は ContentMarkdown)。
+	ContentFormat ContentFormat
+	// Decoration が nil でない場合、テキスト出力の直前に見出し・箇条書き・区切り線などへ絵文字・装飾を付与します
+	// (decorate.goで定義)。音声合成するスクリプトには適用しません。
+	Decoration *DecorationRules
+	// DownloadImagesDir が空でない場合、処理した記事のアイキャッチ画像をこのディレクトリにダウンロードします (images.goで定義)。
+	DownloadImagesDir string
+	// ImageDownloadParallel / ImageDownloadTimeout は画像の同時ダウンロード数と 1 件あたりのタイムアウトです (0 の場合はデフォルト)。
+	ImageDownloadParallel int
+	ImageDownloadTimeout  time.Duration
+	// ExtractCode は、記事本文のフェンスドコードブロックを抽出して本文から除外するかどうかです (code_snippets.goで定義)。
+	// ExtractInlineCode が true の場合はインラインコードも抽出します。
+	ExtractCode       bool
+	ExtractInlineCode bool
+	// CodeSnippetsPath が空でない場合、抽出したコードを Markdown としてこのファイルに書き出します。
+	CodeSnippetsPath string
+	// CategoryProfiles は正規化したカテゴリ名 (cleaner.NormalizeCategory) をキーとする、カテゴリ別の処理設定です
+	// (category.goで定義)。該当するカテゴリがない記事・グループはデフォルトの設定で処理します。
+	CategoryProfiles map[string]cleaner.CategoryProfile
+	// LayeredSummary が true の場合、最終要約に加えて 1行要約・段落要約・詳細要約の多層要約を生成します (Run のみ)。
+	// ScriptSource はスクリプト生成の入力にどの要約を使うかです (layered_summary.goで定義)。
+	LayeredSummary bool
+	ScriptSource   ScriptSource
+	// Priority はスクレイピング前にURLを並べ替える優先度ルールです (priority.goで定義)。
+	// MaxArticles が 0 より大きい場合、優先度順に並べ替えた上位の件数のみを処理します (フィードごと)。
+	Priority    URLPriority
+	MaxArticles int
+	// BlockDomains は処理対象から除外するドメインです (サブドメインにも一致)。
+	// DomainQuality は記事の品質指標のドメイン別の蓄積と自動ブロックの設定です (domain_quality.goで定義)。
+	BlockDomains  []string
+	DomainQuality DomainQualityConfig
+	// SpeakerMapping は生成したスクリプトの話者を置き換えるマッピング (元の話者タグ → 新しい話者タグ) です
+	// (cleaner.RemapSpeakers)。音声合成・テキスト出力の前に適用します。
+	SpeakerMapping map[string]string
+	// Speakers はキャラクターごとの VOICEVOX の話者IDと、話者の指定がない行のキャラクターです (voice.SpeakerConfig)。
+	// 空の場合はスクリプトのタグのとおりに合成します。
+	Speakers voice.SpeakerConfig
+	// Prosody は音声合成の話速・音高・抑揚です (voice.Prosody)。ゼロ値の場合はエンジンの既定値で合成します。
+	Prosody voice.Prosody
+	// TTSNormalizer が nil でない場合、音声合成の前にスクリプトの数値・単位・英略語を読みに変換します (cleaner.TTSNormalizer)。
+	// テキスト出力には適用しません。字幕は変換後のテキストになります。
+	TTSNormalizer *cleaner.TTSNormalizer
+	// Mail が nil でない場合、出力の完了後に最終要約をダイジェストメールとして配信します (mail.goで定義)。
+	Mail *MailConfig
+	// OutputFormats が空でない場合、標準出力・OutputWAVPath への出力の代わりに、1 回の AI処理の結果から
+	// 各フォーマットを OutputDir 配下に生成します (output_formats.goで定義)。
+	OutputFormats []OutputFormat
+	OutputDir     string
+	// Force は、冪等キー (idempotency.goで定義) が一致する成功済みの成果物が状態ファイルにあっても、LLM 処理をやり直すかどうかです。
+	Force bool
+	// EstimateOnly が true の場合、記事の取得後に Map フェーズのコストの見積もりのみを出力し、LLM 処理を行いません (cost_gate.goで定義)。
+	EstimateOnly bool
+	// ConfirmOverCostUSD が 0 より大きい場合、見積もりコストがこれを超えると ConfirmCost で実行を確認します。
+	// ConfirmCost が nil の場合は確認せずに続行します。
+	ConfirmOverCostUSD float64
+	ConfirmCost        CostConfirmFunc
+	// SortBy が SortByImportance の場合、スクレイピング後の記事を Importance の設定で算出した重要度の降順に並べ替えます
+	// (importance.goで定義)。
+	SortBy     SortOrder
+	Importance ImportanceConfig
+	// PreviewContentChars が 0 より大きい場合、抽出した各記事の本文の先頭のこの文字数と文字数を debug ログに出力します
+	// (preview.goで定義)。
+	PreviewContentChars int
+	// References が true の場合、テキスト出力の末尾に記事タイトルとURLの一覧を「参照記事」セクションとして付与します
+	// (references.goで定義)。音声合成するスクリプトには付与しません。
+	References bool
+	// MinSourceCoverage は、最終要約のソースカバレッジ (source_coverage.goで定義) がこれを下回る場合に警告する割合 (0〜1) です。
+	// カバレッジはクリーナーの CiteSources が有効な場合のみ集計します。
+	MinSourceCoverage float64
+	// TargetDuration が 0 より大きい場合、合成後のWAVの再生時間を目標と比較し、DurationTolerance (割合) を超えて外れていれば
+	// 話者・話速ごとの読み上げ速度の係数を StatePath の状態ファイルに保存します (duration.goで定義)。
+	TargetDuration    time.Duration
+	DurationTolerance float64
 }
 
 // Pipeline は記事の取得から結合までの一連の流れを管理します。
@@ -29,6 +167,9 @@ type Pipeline struct {
 	Cleaner                *cleaner.Cleaner
 	VoicevoxEngineExecutor voicevox.EngineExecutor
 	config                 PipelineConfig
+
+	storeMu sync.Mutex
+	store   *state.Store // 1 回の実行で共有する状態ファイル (stateStore で読み込む)
 }
 
 // New は新しい Pipeline インスタンスを初期化し、必要な依存関係と設定を注入します。
@@ -47,115 +188,722 @@ func New(
 }
 
 // Run はフィードの取得、記事の並列抽出、AI処理、およびI/O処理を実行します。
-func (p *Pipeline) Run(ctx context.Context, feedURL string) error {
+// 各フェーズには TimeoutBudget に従った個別のタイムアウトが適用されます。
+// 生成したスクリプトや話者別集計は RunResult として返します。
+// 出力を行わずに結果のみが必要な場合は Process を使用します (process.goで定義)。
+func (p *Pipeline) Run(ctx context.Context, feedURL string) (*RunResult, error) {
+
+	// --- 1. フィードの取得とURL抽出 ---
+	source, err := p.fetchFeed(ctx, feedURL)
+	if errors.Is(err, itemfeed.ErrNotModified) {
+		slog.InfoContext(ctx, "フィードが前回の取得から更新されていないため、処理をスキップします", slog.String("feed_url", feedURL))
+		return &RunResult{NotModified: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.runSource(ctx, source)
+}
+
+// runSource は取得済みのフィード (複数フィードをマージしたものを含む) に対して、
+// フィルタ・記事の抽出・AI処理 (processSource) を行い、結果を出力します。
+func (p *Pipeline) runSource(ctx context.Context, source *feedSource) (*RunResult, error) {
+	result, gen, err := p.processSource(ctx, source, true)
+	if err != nil || gen == nil {
+		return result, err
+	}
+
+	// --- 5. 出力 ---
+	if err := p.output(ctx, result, gen); err != nil {
+		return result, err
+	}
+
+	// --- 6. 差分モード: エピソードの記録 (見積もりのみの場合は記録しない) ---
+	if gen.store != nil && !p.planOnly() {
+		if err := p.recordEpisode(ctx, gen.store, source, gen.results); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// processSource は取得済みのフィードに対して、フィルタ・記事の抽出・AI処理を実行し、出力前の成果を返します。
+// emit が false の場合は、コード・外部リンク・画像のファイルへの書き出し、スクリプトのストリーミング表示、
+// プロンプトセットの A/B 比較を行いません (Process)。処理対象の記事がない場合、成果は nil です。
+func (p *Pipeline) processSource(ctx context.Context, source *feedSource, emit bool) (*RunResult, *generated, error) {
+	feedTitle := source.Title
+	articleTitlesMap := source.Titles
+	result := &RunResult{FeedTitles: []string{feedTitle}}
+	var err error
 
-	runnerConfig := runner.RunnerConfig{
-		FeedURL:                  feedURL,
-		ClientTimeout:            p.config.ClientTimeout,
-		OverallTimeoutMultiplier: 10,
+	// --- 2. 公開時刻によるフィルタ (since.goで定義) ---
+	if !p.config.Since.IsZero() {
+		before := len(source.URLs)
+		source.URLs = p.filterBySince(ctx, source)
+		if len(source.URLs) == 0 {
+			return nil, nil, &AllFilteredOutError{Filter: p.sinceFilterLabel(), Before: before}
+		}
 	}
 
-	// --- 1. ScrapeAndRun の呼び出し ---
-	// 修正: 戻り値の型を *runner.RunnerResult に変更
-	runnerResult, err := p.ScraperRunner.ScrapeAndRun(ctx, runnerConfig)
+	// ブロックしたドメインの記事の除外 (--block-domains / --auto-block。domain_quality.goで定義)
+	if source.URLs, err = p.filterBlockedDomains(ctx, source.URLs); err != nil {
+		return nil, nil, err
+	}
+
+	// --- 2'. 差分モード: 処理済み記事の除外 (diff.goで定義) ---
+	var store *state.Store
+	if p.config.DiffOnly {
+		store, err = p.stateStore()
+		if err != nil {
+			return nil, nil, err
+		}
+		source.URLs = filterNewArticles(ctx, source, store)
+		if len(source.URLs) == 0 {
+			slog.InfoContext(ctx, "前回から新着記事がないため、処理をスキップします", slog.String("feed_url", source.FeedURL))
+			return result, nil, nil
+		}
+	}
+
+	// --- 2''. 優先度による並べ替えと件数の上限 (priority.goで定義) ---
+	source.URLs = p.prioritizeURLs(ctx, source.URLs, source.Published)
+
+	// --- 3. 記事本文の並列スクレイピングと成功リストの作成 (NoScrape 指定時はフィードの本文を使用) ---
+	successfulResults, snippets, failures, err := p.collectArticles(ctx, source.URLs, source.Contents)
+	result.recordScrapeFailures(failures)
+	result.URLStatuses = urlStatuses(source.URLs, successfulResults, failures)
+	if err != nil {
+		return result, nil, err
+	}
+	// 記事の品質指標の計測とドメイン別の蓄積 (--track-quality / --auto-block 指定時のみ。見積もりのみの場合は蓄積しない)
+	if p.config.DomainQuality.enabled() {
+		result.ArticleQuality = MeasureArticleQuality(successfulResults)
+		if emit && !p.planOnly() {
+			p.recordDomainQuality(ctx, result.ArticleQuality)
+		}
+	}
+	if !emit {
+		result.CodeSnippets = append(result.CodeSnippets, snippets...)
+	} else if err := p.handleCodeSnippets(ctx, result, snippets); err != nil {
+		return result, nil, err
+	}
+
+	// 重要度による並べ替え (--sort-by importance 指定時のみ。importance.goで定義)
+	successfulResults, importance, err := p.sortByImportance(ctx, successfulResults, articleTitlesMap)
+	if err != nil {
+		return result, nil, err
+	}
+
+	// 記事のメタ情報 (--download-images-dir 指定時は画像をダウンロード)
+	result.Articles = p.articleMetas(ctx, source, successfulResults, emit)
+	for i := range result.Articles {
+		result.Articles[i].Importance = importance[result.Articles[i].URL]
+	}
+
+	// --- 4. AI処理 ---
+	gen, err := p.generate(ctx, result, feedTitle, successfulResults, articleTitlesMap, source.Categories, emit)
+	if err != nil {
+		return result, nil, err
+	}
+	gen.store = store
+	return result, gen, nil
+}
+
+// generated は generate で生成した出力前の成果です。output で出力します。
+type generated struct {
+	feedTitle string
+	results   []types.URLResult
+	titlesMap map[string]string
+	store     *state.Store // 差分モードの状態ファイル (差分モード以外は nil)
+
+	artifacts      *runArtifacts // AI処理の成果物 (LLM未設定時は nil)
+	idempotencyKey string        // 成果物を保存する冪等キー (再利用した場合・冪等性の保証が無効な場合は空)
+	summary        string        // メール配信・出力フォーマットに使用するテキスト向けの最終要約
+	references     string        // 参照記事・関連リンクのセクション (音声合成する場合は空)
+	streamed       bool          // スクリプトをストリーミングで表示済みか
+	// notice は通常の出力の代わりに出力するテキストです (見積もりのみ・ドライラン・コスト上限による打ち切りの場合)。
+	notice string
+}
+
+// generateAndOutput は抽出済みの記事からスクリプトを生成 (LLM未設定時は結合) し、
+// 音声合成またはテキスト出力を実行します。結果は result に記録されます。
+// categories は記事URLごとのカテゴリで、AI処理の設定の切り替えに使用します (nil 可)。
+func (p *Pipeline) generateAndOutput(ctx context.Context, result *RunResult, feedTitle string, successfulResults []types.URLResult, titlesMap map[string]string, categories map[string][]string) error {
+	gen, err := p.generate(ctx, result, feedTitle, successfulResults, titlesMap, categories, true)
 	if err != nil {
 		return err
 	}
+	return p.output(ctx, result, gen)
+}
 
-	// --- 2. 抽出結果の確認と成功リストの作成 ---
-	successCount := 0
-	var successfulResults []types.URLResult
+// generate は抽出済みの記事からスクリプトを生成 (LLM未設定時は結合) し、結果を result に記録します。
+// 成果物の保存・音声合成・テキスト出力は行わず、出力に必要な情報を返します (output で出力します)。
+// emit が false の場合は、外部リンクのファイルへの書き出し、スクリプトのストリーミング表示、A/B 比較を行いません。
+func (p *Pipeline) generate(ctx context.Context, result *RunResult, feedTitle string, successfulResults []types.URLResult, titlesMap map[string]string, categories map[string][]string, emit bool) (*generated, error) {
+	gen := &generated{feedTitle: feedTitle, results: successfulResults, titlesMap: titlesMap}
+
+	// 本文の統計 (content_stats.goで定義)。処理本体には影響しない
+	contentStats := AnalyzeContent(successfulResults)
+	logContentStats(ctx, contentStats)
+	result.ContentStats = &contentStats
+
+	// 記事本文の外部リンクの抽出 (--related-links / --extracted-links-file 指定時のみ。links.goで定義)
+	if p.extractLinksEnabled() {
+		result.ExtractedLinks = ExtractExternalLinks(successfulResults)
+		slog.InfoContext(ctx, "記事本文から外部リンクを抽出しました", slog.Int("links", len(result.ExtractedLinks)))
+		if emit {
+			if err := p.writeExtractedLinks(ctx, result.ExtractedLinks); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var scriptText, references string
+	var err error
+	if p.Cleaner != nil {
+		// LLMが利用可能な場合 (記事のカテゴリに応じた設定で処理する)
+		llm := p.cleanerForArticles(ctx, feedTitle, "", successfulResults, categories)
+		result.CombinedText = cleaner.CombineContents(successfulResults, titlesMap)
+		// ドライランの場合は見積もりのみを記録して終了する (dry_run.goで定義)
+		if llm.DryRun() {
+			gen.notice, err = p.dryRun(ctx, result, llm, feedTitle)
+			return gen, err
+		}
+		// 同じ入力で成功した成果物があれば LLM 処理をスキップして再利用する (idempotency.goで定義)
+		var artifacts *runArtifacts
+		if p.idempotencyEnabled() {
+			key := p.idempotencyKey(llm, successfulResults, titlesMap)
+			if artifacts, err = p.reusableArtifacts(ctx, key); err != nil {
+				return nil, err
+			}
+			if artifacts == nil {
+				gen.idempotencyKey = key
+			}
+		}
+		if artifacts != nil {
+			result.Reused = true
+			result.recordArtifacts(artifacts)
+			if p.config.References {
+				artifacts.References = p.renderReferences(successfulResults, titlesMap, true)
+			}
+		} else {
+			if stop, err := p.checkEstimatedCost(ctx, result, llm); stop || err != nil {
+				if stop {
+					gen.notice = result.CostEstimate.String() + "\n"
+				}
+				return gen, err
+			}
+			stream := emit && p.config.Stream
+			llmCtx, cancelLLM := p.phaseContext(ctx, PhaseLLM)
+			artifacts, err = p.processWithAI(llmCtx, llm, feedTitle, successfulResults, titlesMap, stream)
+			err = p.wrapPhaseError(ctx, llmCtx, PhaseLLM, err)
+			cancelLLM()
+			result.recordTokenUsage(ctx, p.Cleaner)
+			// 失敗時も生成できた分の成果物を記録する (partial.goで定義)
+			result.recordArtifacts(artifacts)
+			var costErr *cleaner.CostLimitError
+			if errors.As(err, &costErr) {
+				gen.notice, err = recordPartial(ctx, result, costErr)
+				return gen, err
+			}
+			if err != nil {
+				var partialErr *PartialResultError
+				if errors.As(err, &partialErr) {
+					partialErr.Partial = result
+				}
+				return nil, err
+			}
+			gen.streamed = stream && p.audioOutputPath() == ""
+		}
+		gen.artifacts = artifacts
+		// 最終要約に反映された記事の集計 (source_coverage.goで定義)
+		if llm.CiteSources() {
+			result.SourceCoverage = p.sourceCoverage(ctx, artifacts.Summary, successfulResults, titlesMap)
+		}
+		// プロンプトセットの A/B 比較 (--ab-prompts 指定時のみ。ab_prompts.goで定義)
+		if len(p.config.ABPromptDirs) > 0 && emit {
+			abCtx, cancelAB := p.phaseContext(ctx, PhaseLLM)
+			result.Variants, err = p.comparePromptVariants(abCtx, llm, feedTitle, artifacts)
+			err = p.wrapPhaseError(ctx, abCtx, PhaseLLM, err)
+			cancelAB()
+			result.recordTokenUsage(ctx, p.Cleaner)
+			if err != nil {
+				return nil, err
+			}
+		}
+		scriptText, gen.summary = artifacts.Script, artifacts.textSummary()
+		if len(p.config.SpeakerMapping) > 0 {
+			if scriptText, _, err = cleaner.RemapSpeakers(scriptText, p.config.SpeakerMapping); err != nil {
+				return nil, fmt.Errorf("スクリプトの話者の置き換えに失敗しました: %w", err)
+			}
+		}
+		balance := p.Cleaner.SpeakerBalance(scriptText)
+		result.SpeakerBalance = &balance
+		result.Script = scriptText
+		references = artifacts.References
+	} else {
+		// LLMが利用不可の場合 (AI処理スキップ)
+		slog.InfoContext(ctx, "AI処理コンポーネントが未設定のため、抽出結果を結合して出力します。", slog.String("mode", "AIスキップ"))
+		scriptText, err = p.processWithoutAI(ctx, feedTitle, successfulResults, titlesMap)
+		if err != nil {
+			return nil, err
+		}
+		result.CombinedText = scriptText
+		slog.InfoContext(ctx, "AI処理スキップモードでスクリプトが正常に生成されました。", slog.String("mode", "AIスキップ"))
+	}
+
+	// 関連リンクセクション (links.goで定義) は参照記事セクションの後に続ける
+	if p.config.RelatedLinks {
+		references = joinSections(references, p.renderRelatedLinks(result.ExtractedLinks))
+	}
+
+	// 参照記事・関連リンクのセクション (references.goで定義)。音声合成するスクリプトには付与しない
+	if references != "" {
+		if p.audioOutputPath() != "" {
+			slog.InfoContext(ctx, "音声を出力するため、参照記事・関連リンクのセクションは付与しません")
+			references = ""
+		} else {
+			scriptText = appendReferences(scriptText, references)
+		}
+	}
+	gen.references = references
+
+	if p.config.Disclaimer {
+		// 免責文の付与 (disclaimer.goで定義)。音声合成時はスクリプトの冒頭行として挿入する
+		scriptText, err = p.attachDisclaimer(scriptText, feedTitle, len(successfulResults), p.audioOutputPath() != "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Output = scriptText
+	return gen, nil
+}
+
+// output は generate の成果を出力します。成果物の保存と前回の実行結果との比較、
+// 音声合成またはテキスト出力、ダイジェストメールの配信を行います。
+func (p *Pipeline) output(ctx context.Context, result *RunResult, gen *generated) error {
+	// 見積もりのみ・ドライラン・コスト上限による打ち切りの場合は、見積もりや部分成果のみを出力する
+	if gen.notice != "" {
+		return iohandler.WriteOutputString("", gen.notice)
+	}
+	if gen.artifacts != nil {
+		if gen.idempotencyKey != "" {
+			if err := p.saveArtifacts(gen.idempotencyKey, gen.artifacts); err != nil {
+				return err
+			}
+		}
+		// 前回の実行結果との比較と今回の結果の保存 (rundiff.goで定義)
+		if err := p.handleRunArtifacts(ctx, gen.artifacts); err != nil {
+			return err
+		}
+	}
+
+	// 出力分岐
+	scriptText := result.Output
+	if gen.streamed {
+		// スクリプトはストリーミングで表示済みのため、テキストの再出力は行わず参照記事と免責文のみ末尾に出力する
+		if err := p.writeStreamTail(gen.feedTitle, gen.references, len(gen.results)); err != nil {
+			return err
+		}
+	} else if len(p.config.OutputFormats) > 0 {
+		// 複数の出力フォーマットの一括生成 (output_formats.goで定義)
+		if err := p.writeOutputFormats(ctx, result, gen.feedTitle, gen.summary, scriptText); err != nil {
+			return &PartialResultError{Stage: StageOutput, Partial: result, Err: err}
+		}
+	} else if err := p.handleOutput(ctx, scriptText); err != nil {
+		return &PartialResultError{Stage: StageOutput, Partial: result, Err: err}
+	}
+
+	// ダイジェストメールの配信 (mail.goで定義)。送信に失敗しても警告のみで続行する
+	if p.config.Mail != nil {
+		summary := gen.summary
+		if summary == "" {
+			summary = scriptText
+		}
+		p.sendDigestMail(ctx, gen.feedTitle, summary, gen.results, gen.titlesMap)
+	}
+	return nil
+}
+
+// writeStreamTail はストリーミング出力の末尾に参照記事と免責文を出力します (どちらもない場合は何もしません)。
+func (p *Pipeline) writeStreamTail(feedTitle, references string, articles int) error {
+	var tail string
+	if references != "" {
+		tail = "\n\n" + references
+	}
+	if p.config.Disclaimer {
+		disclaimer, err := p.renderDisclaimer(feedTitle, articles)
+		if err != nil {
+			return err
+		}
+		tail += "\n> " + disclaimer + "\n"
+	}
+	if tail == "" {
+		return nil
+	}
+	return iohandler.WriteOutputString("", tail)
+}
+
+// streamScript はスクリプトをストリーミング生成し、完成した行から標準出力へ書き出します。
+// 戻り値は全体を蓄積した確定スクリプトで、音声合成にはこちらを使用します。
+func (p *Pipeline) streamScript(ctx context.Context, llm *cleaner.Cleaner, title string, finalSummary string, structure *cleaner.ReduceResult) (string, error) {
+	stream, err := llm.GenerateScriptStream(ctx, title, finalSummary, structure)
+	if err != nil {
+		return "", err
+	}
+	for chunk := range stream.Chunks() {
+		if _, err := io.WriteString(os.Stdout, chunk); err != nil {
+			slog.WarnContext(ctx, "ストリーミング出力の書き込みに失敗しました", slog.String("error", err.Error()))
+		}
+	}
+	return stream.Wait()
+}
 
-	// 修正: runnerResult からメタデータと結果を取得
-	feedTitle := runnerResult.FeedTitle
-	articleTitlesMap := runnerResult.TitlesMap
-	// 処理対象のURL結果リスト
-	results := runnerResult.Results
+// recordPartial はコスト上限で打ち切られた時点の部分成果を result に記録し、出力するテキストとして返します。
+// 部分成果はスクリプト形式ではないため、音声合成は行いません。部分成果がない場合は costErr を返します。
+func recordPartial(ctx context.Context, result *RunResult, costErr *cleaner.CostLimitError) (string, error) {
+	result.CostLimitPhase = costErr.Phase
+	slog.WarnContext(ctx, "LLMの累積推定コストが上限に達したため、残りの処理を中止しました",
+		slog.String("phase", costErr.Phase),
+		slog.Float64("cost_usd", costErr.CostUSD),
+		slog.Float64("limit_usd", costErr.LimitUSD),
+		slog.Bool("has_partial", costErr.Partial != ""),
+	)
+	if costErr.Partial == "" {
+		return "", costErr
+	}
+
+	result.Output = costErr.Partial
+	slog.InfoContext(ctx, "打ち切り時点までの部分成果をテキストで出力します (音声合成は行いません)")
+	return costErr.Partial, nil
+}
+
+// ----------------------------------------------------------------------
+// ヘルパー関数 (取得処理)
+// ----------------------------------------------------------------------
+
+// feedSource は 1 つのフィードから抽出した記事URLとメタデータです。
+type feedSource struct {
+	FeedURL  string
+	Title    string
+	URLs     []string
+	Titles   map[string]string // URLをキー、記事タイトルを値とするマップ
+	Contents map[string]string // URLをキー、フィードに含まれる本文/要約 (プレーンテキスト) を値とするマップ
+	GUIDs    map[string]string // URLをキー、アイテムのGUID (未設定の場合はURL) を値とするマップ
+	Images   map[string]string // URLをキー、アイキャッチ画像のURLを値とするマップ (画像のない記事は含まない)
+	Authors  map[string]string // URLをキー、著者名を値とするマップ (著者のない記事は含まない)
+	// Categories はURLをキー、アイテムのカテゴリ (item.Categories) を値とするマップです。カテゴリのない記事は含みません。
+	Categories map[string][]string
+	// Published はURLをキー、公開時刻 (未設定の場合は更新時刻) を値とするマップです。時刻のない記事は含みません。
+	Published map[string]time.Time
+}
+
+// fetchFeed はフィードを取得・パースし、記事URLとタイトルを抽出します (フィードフェーズ)。
+func (p *Pipeline) fetchFeed(ctx context.Context, feedURL string) (*feedSource, error) {
+	rssFeed, err := p.fetchAndParse(ctx, feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("フィードの処理エラー: %w", err)
+	}
+	return p.newFeedSource(ctx, feedURL, rssFeed)
+}
 
-	// ScrapeAndRun で処理されたURLの総数 (results の長さを使用)
-	totalProcessedURLs := len(results)
+// fetchAndParse はフィードフェーズのタイムアウトを適用してフィードを取得・パースします。
+// itemfeed.FetcherFunc として複数フィードの並列取得にも使用します。
+func (p *Pipeline) fetchAndParse(ctx context.Context, feedURL string) (*gofeed.Feed, error) {
+	feedCtx, cancelFeed := p.phaseContext(ctx, PhaseFeed)
+	defer cancelFeed()
 
+	slog.InfoContext(ctx, "フィードURLを解析中",
+		slog.String("feed_url", feedURL),
+		slog.Duration("timeout", p.config.Timeouts.Feed),
+	)
+	rssFeed, err := p.ScraperRunner.FeedParser.FetchAndParse(feedCtx, feedURL)
+	if err = p.wrapPhaseError(ctx, feedCtx, PhaseFeed, err); err != nil {
+		return nil, err
+	}
+	return rssFeed, nil
+}
+
+// newFeedSource はパース済みのフィードから記事URLとメタデータを抽出します。
+func (p *Pipeline) newFeedSource(ctx context.Context, feedURL string, rssFeed *gofeed.Feed) (*feedSource, error) {
+	// タイトル・公開時刻・著者は gofeed が解析した値をそのまま使う
+	items := itemfeed.ExtractItems(rssFeed)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("フィード (%s) から処理対象のURLが一つも抽出されませんでした", feedURL)
+	}
+	slog.InfoContext(ctx, "フィードからURLを抽出", slog.String("feed_url", feedURL), slog.Int("extracted_count", len(items)))
+
+	urls := make([]string, 0, len(items))
+	titles := make(map[string]string)
+	published := make(map[string]time.Time)
+	authors := make(map[string]string)
+	for _, item := range items {
+		urls = append(urls, item.URL)
+		if item.Title != "" {
+			titles[item.URL] = item.Title
+		}
+		if item.HasPublished() {
+			published[item.URL] = item.Published
+		}
+		if item.Author != "" {
+			authors[item.URL] = item.Author
+		}
+	}
+
+	contents := make(map[string]string)
+	guids := make(map[string]string)
+	images := make(map[string]string)
+	categories := make(map[string][]string)
+	for _, item := range rssFeed.Items {
+		if item == nil || item.Link == "" {
+			continue
+		}
+		guids[item.Link] = item.GUID
+		if item.GUID == "" {
+			guids[item.Link] = item.Link
+		}
+		// item.Content (全文) と item.Description (要約) のどちらを優先するかは FeedBodyPrefer に従う
+		if text := itemfeed.ExtractItemBody(item, p.config.FeedBodyPrefer); text != "" {
+			contents[item.Link] = text
+		}
+		if image := itemfeed.ExtractMedia(item).ImageURL(); image != "" {
+			images[item.Link] = image
+		}
+		if len(item.Categories) > 0 {
+			categories[item.Link] = item.Categories
+		}
+	}
+
+	return &feedSource{
+		FeedURL:  feedURL,
+		Title:    rssFeed.Title,
+		URLs:     urls,
+		Titles:   titles,
+		Contents: contents,
+		GUIDs:    guids,
+		Images:   images,
+		Authors:  authors,
+
+		Categories: categories,
+		Published:  published,
+	}, nil
+}
+
+// scrapeArticles は記事本文を並列で取得し、成功した結果のみを返します (スクレイピングフェーズ)。
+// FallbackToFeedContent が有効な場合、失敗した記事は feedContents の要約文で代替され、
+// 本文の先頭に cleaner.FeedFallbackMarker が付与されます。
+// ExtractCode が有効な場合、抽出に成功した本文のコードは CodeOmittedPlaceholder に置換され、抽出したコードを返します。
+// ContentFormat が ContentPlain の場合、抽出に成功した本文はプレーンテキストに変換されます (content_format.goで定義)。
+// 失敗した記事は種類を分類して返します (フィードの要約文で代替した記事を含む。scrape_errors.goで定義)。
+// 成功件数が 0 の場合は、失敗した記事とともにエラーを返します。
+func (p *Pipeline) scrapeArticles(ctx context.Context, urls []string, feedContents map[string]string) ([]types.URLResult, []CodeSnippet, []ScrapeFailure, error) {
+	scrapeCtx, cancelScrape := p.phaseContext(ctx, PhaseScrape)
+	slog.InfoContext(ctx, "並列スクレイピング実行中",
+		slog.Int("total_urls", len(urls)),
+		slog.Duration("timeout", p.config.Timeouts.Scrape),
+	)
+	results := p.scraperExecutor().ScrapeInParallel(scrapeCtx, urls)
+	scrapeErr := p.wrapPhaseError(ctx, scrapeCtx, PhaseScrape, scrapeCtx.Err())
+	cancelScrape()
+	if scrapeErr != nil {
+		slog.WarnContext(ctx, "スクレイピングフェーズが期限内に完了しませんでした。取得済みの記事のみで続行します。",
+			slog.String("error", scrapeErr.Error()),
+		)
+	}
+
+	var successfulResults []types.URLResult
+	var snippets []CodeSnippet
+	var failures []ScrapeFailure
+	fallbackCount := 0
 	for _, res := range results {
+		articleCtx := correlation.WithID(ctx, correlation.ArticleID(res.URL))
 		if res.Error == nil {
-			successCount++
+			rawLength := len(res.Content)
+			// コードの抽出はプレーンテキスト化でフェンスが除去される前に行う
+			var found []CodeSnippet
+			if p.config.ExtractCode {
+				res.Content, found = extractCode(res.URL, res.Content, p.config.ExtractInlineCode)
+				snippets = append(snippets, found...)
+			}
+			res.Content = p.formatContent(res.Content)
+			slog.DebugContext(articleCtx, "抽出成功",
+				slog.String("url", res.URL),
+				slog.Int("content_length", len(res.Content)),
+				slog.Int("raw_length", rawLength),
+				slog.Int("code_snippets", len(found)),
+			)
 			successfulResults = append(successfulResults, res) // 成功した結果を格納
+			continue
+		}
+
+		failure := newScrapeFailure(res.URL, res.Error)
+		failures = append(failures, failure)
+		if fallback := feedContents[res.URL]; p.config.FallbackToFeedContent && fallback != "" {
+			slog.WarnContext(articleCtx, "抽出エラーのため、フィードの要約文で本文を代替します",
+				slog.String("url", res.URL),
+				slog.String("error", res.Error.Error()),
+				slog.String("error_type", failure.Type),
+				slog.Int("fallback_length", len(fallback)),
+			)
+			successfulResults = append(successfulResults, types.URLResult{URL: res.URL, Content: cleaner.MarkFeedFallback(fallback)})
+			fallbackCount++
 		} else {
-			slog.Warn("抽出エラー",
+			slog.WarnContext(articleCtx, "抽出エラー",
 				slog.String("url", res.URL),
 				slog.String("error", res.Error.Error()),
+				slog.String("error_type", failure.Type),
 			)
 		}
 	}
 
-	slog.Info("抽出完了",
-		slog.Int("success", successCount),
-		slog.Int("total", totalProcessedURLs),
+	// スクレイピングで処理されたURLの総数 (results の長さを使用)
+	slog.InfoContext(ctx, "抽出完了",
+		slog.Int("success", len(successfulResults)-fallbackCount),
+		slog.Int("feed_fallback", fallbackCount),
+		slog.Int("total", len(results)),
+		slog.String("content_format", string(p.config.ContentFormat)),
 	)
-
-	if successCount == 0 {
-		return fmt.Errorf("処理すべき記事本文が一つも見つかりませんでした")
+	if p.config.ExtractCode {
+		slog.InfoContext(ctx, "記事本文からコードを抽出しました", slog.Int("snippets", len(snippets)), slog.Bool("inline", p.config.ExtractInlineCode))
 	}
+	logScrapeFailures(ctx, failures)
+	p.logContentPreviews(ctx, successfulResults)
 
-	// --- 4. AI処理の実行分岐 ---
-	if p.Cleaner != nil {
-		// LLMが利用可能な場合
-		scriptText, err := p.processWithAI(ctx, feedTitle, successfulResults, articleTitlesMap)
-		if err != nil {
-			return err
+	if len(successfulResults) == 0 {
+		if scrapeErr != nil {
+			return nil, nil, failures, fmt.Errorf("処理すべき記事本文が一つも見つかりませんでした: %w", scrapeErr)
 		}
-		// 5. 出力分岐 (AI処理結果の出力)
-		return p.handleOutput(ctx, scriptText)
+		return nil, nil, failures, fmt.Errorf("処理すべき記事本文が一つも見つかりませんでした")
 	}
-
-	// LLMが利用不可の場合 (AI処理スキップ)
-	slog.Info("AI処理コンポーネントが未設定のため、抽出結果を結合して出力します。", slog.String("mode", "AIスキップ"))
-	combinedScriptText, err := p.processWithoutAI(feedTitle, successfulResults, articleTitlesMap)
-	if err != nil {
-		return err
-	}
-	slog.Info("AI処理スキップモードでスクリプトが正常に生成されました。", slog.String("mode", "AIスキップ"))
-	// 5. 出力分岐 (AI処理スキップ結果の出力)
-	return p.handleOutput(ctx, combinedScriptText)
+	return successfulResults, snippets, failures, nil
 }
 
 // ----------------------------------------------------------------------
 // ヘルパー関数 (AI処理)
 // ----------------------------------------------------------------------
 
-// processWithAI は AI による Map-Reduce、Summary、Script Generation を実行します。
-func (p *Pipeline) processWithAI(ctx context.Context, feedTitle string, results []types.URLResult, titlesMap map[string]string) (string, error) {
-	slog.Info("LLM処理開始", slog.String("phase", "Map-Reduce"))
+// processWithAI は AI による Map-Reduce、Summary、Script Generation を実行し、各フェーズの成果物を返します。
+// llm は記事のカテゴリに応じて選択した Cleaner です (category.goで定義)。
+// stream が true の場合は、スクリプトを生成しながら標準出力へ逐次表示します。
+// 失敗した場合も、それまでに生成できた成果物と、失敗した段階を示す *PartialResultError を返します。
+func (p *Pipeline) processWithAI(ctx context.Context, llm *cleaner.Cleaner, feedTitle string, results []types.URLResult, titlesMap map[string]string, stream bool) (*runArtifacts, error) {
+	slog.InfoContext(ctx, "LLM処理開始", slog.String("phase", "Map-Reduce"))
+	artifacts := &runArtifacts{}
+	fail := func(stage string, err error) (*runArtifacts, error) {
+		return artifacts, &PartialResultError{Stage: stage, Err: err}
+	}
 
 	// Map-Reduce のための結合テキスト構築
 	combinedTextForAI := cleaner.CombineContents(results, titlesMap)
 
-	reduceResult, err := p.Cleaner.CleanAndStructureText(ctx, combinedTextForAI)
+	// コスト上限で打ち切られた場合は、CostLimitError.Partial に部分成果を付けて返す
+	var costErr *cleaner.CostLimitError
+	reduceResult, mapReport, err := llm.CleanAndStructureTextWithReport(ctx, combinedTextForAI)
+	artifacts.Dropped = mapReport.Dropped
 	if err != nil {
-		slog.Error("AIによるコンテンツの構造化に失敗しました", slog.String("error", err.Error()))
-		return "", fmt.Errorf("AIによるコンテンツの構造化に失敗しました: %w", err)
+		stage := cleaner.PhaseReduce
+		var cleanerPartial *cleaner.PartialError
+		if errors.As(err, &cleanerPartial) {
+			stage = cleanerPartial.Phase
+			artifacts.MapSummaries = cleanerPartial.MapSummaries
+		}
+		if errors.As(err, &costErr) {
+			return fail(stage, err)
+		}
+		slog.ErrorContext(ctx, "AIによるコンテンツの構造化に失敗しました", slog.String("error", err.Error()))
+		return fail(stage, fmt.Errorf("AIによるコンテンツの構造化に失敗しました: %w", err))
 	}
+	artifacts.Reduce = reduceResult
 
 	// Final Summary
 	title := cleaner.ExtractTitleFromMarkdown(reduceResult)
 	if title == "" {
-		slog.Warn("AIによるタイトル抽出に失敗しました。フィードのタイトルを代替として使用します。", slog.String("fallback_title", feedTitle))
+		slog.WarnContext(ctx, "AIによるタイトル抽出に失敗しました。フィードのタイトルを代替として使用します。", slog.String("fallback_title", feedTitle))
 		title = feedTitle
 	}
 
-	finalSummary, err := p.Cleaner.GenerateFinalSummary(ctx, title, reduceResult)
+	finalSummary, err := llm.GenerateFinalSummary(ctx, title, reduceResult)
+	if errors.As(err, &costErr) {
+		costErr.Partial = reduceResult
+		return fail(cleaner.PhaseSummary, err)
+	}
 	if err != nil {
-		slog.Error("Final Summaryの生成に失敗しました", slog.String("error", err.Error()))
-		return "", fmt.Errorf("Final Summaryの生成に失敗しました: %w", err)
+		slog.ErrorContext(ctx, "Final Summaryの生成に失敗しました", slog.String("error", err.Error()))
+		return fail(cleaner.PhaseSummary, fmt.Errorf("Final Summaryの生成に失敗しました: %w", err))
 	}
+	artifacts.Summary = finalSummary
 
-	// Script Generation
-	scriptText, err := p.Cleaner.GenerateScriptForVoicevox(ctx, title, finalSummary)
+	// 原文との重複率の検証 (--paraphrase-strict 指定時は言い換えを強めて再生成する)
+	finalSummary, overlap, err := llm.CheckSummaryOverlap(ctx, title, reduceResult, finalSummary, combinedTextForAI)
+	if err != nil {
+		slog.ErrorContext(ctx, "要約と原文の重複率の検証に失敗しました", slog.String("error", err.Error()))
+		return fail(cleaner.PhaseSummary, fmt.Errorf("要約と原文の重複率の検証に失敗しました: %w", err))
+	}
+	artifacts.Summary, artifacts.SummaryOverlap = finalSummary, overlap
+
+	// 箇条書きの最終要約 (--summary-format both 指定時のみ)。散文の最終要約はスクリプト生成に、箇条書きはテキスト向けの出力に使う
+	if llm.SummaryFormat() == cleaner.SummaryFormatBoth {
+		bullet, err := llm.GenerateBulletSummary(ctx, title, reduceResult)
+		if errors.As(err, &costErr) {
+			costErr.Partial = finalSummary
+			return fail(cleaner.PhaseSummary, err)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "箇条書きの最終要約の生成に失敗しました", slog.String("error", err.Error()))
+			return fail(cleaner.PhaseSummary, fmt.Errorf("箇条書きの最終要約の生成に失敗しました: %w", err))
+		}
+		artifacts.BulletSummary = bullet
+	}
+
+	// 多層要約 (有効時のみ)
+	if p.config.LayeredSummary {
+		layered, err := llm.GenerateLayeredSummary(ctx, reduceResult)
+		if errors.As(err, &costErr) {
+			costErr.Partial = finalSummary
+			return fail(cleaner.PhaseSummary, err)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "多層要約の生成に失敗しました", slog.String("error", err.Error()))
+			return fail(cleaner.PhaseSummary, fmt.Errorf("多層要約の生成に失敗しました: %w", err))
+		}
+		artifacts.Layered = &layered
+	}
+
+	// Reduce結果のセクション構造 (有効時のみ)
+	var structure *cleaner.ReduceResult
+	if llm.StructuredReduce() {
+		structure, err = cleaner.ParseReduceResult(reduceResult)
+		if err != nil {
+			slog.WarnContext(ctx, "Reduce結果のセクション構造を解析できませんでした。構造なしでスクリプトを生成します。", slog.String("error", err.Error()))
+			structure = nil
+		} else {
+			slog.InfoContext(ctx, "Reduce結果をセクション構造に変換しました", slog.Int("points", len(structure.Points)))
+		}
+	}
+
+	// Script Generation (stream が true の場合は生成中の行を逐次表示する)
+	generateScript := llm.GenerateScriptForVoicevox
+	if stream {
+		generateScript = func(ctx context.Context, title, finalSummary string, structure *cleaner.ReduceResult) (string, error) {
+			return p.streamScript(ctx, llm, title, finalSummary, structure)
+		}
+	}
+	scriptText, err := generateScript(ctx, title, p.scriptInput(finalSummary, artifacts.Layered), structure)
+	if errors.As(err, &costErr) {
+		costErr.Partial = finalSummary
+		return fail(cleaner.PhaseScript, err)
+	}
 	if err != nil {
-		slog.Error("VOICEVOXスクリプトの生成に失敗しました", slog.String("error", err.Error()))
-		return "", fmt.Errorf("VOICEVOXスクリプトの生成に失敗しました: %w", err)
+		slog.ErrorContext(ctx, "VOICEVOXスクリプトの生成に失敗しました", slog.String("error", err.Error()))
+		return fail(cleaner.PhaseScript, fmt.Errorf("VOICEVOXスクリプトの生成に失敗しました: %w", err))
+	}
+	artifacts.Script = scriptText
+
+	// 参照記事セクション (有効時のみ)。ソース番号は CombineContents の SOURCE DOCUMENT n と対応する
+	if p.config.References {
+		artifacts.References = p.renderReferences(results, titlesMap, true)
 	}
 
-	return scriptText, nil
+	return artifacts, nil
 }
 
 // ----------------------------------------------------------------------
@@ -166,33 +914,93 @@ func (p *Pipeline) processWithAI(ctx context.Context, feedTitle string, results
 func (p *Pipeline) handleOutput(ctx context.Context, scriptText string) error {
 	// 5-A. VOICEVOXによる音声合成とWAV出力
 	if p.VoicevoxEngineExecutor != nil && p.config.OutputWAVPath != "" {
-		slog.Info("AI生成スクリプトをVOICEVOXで音声合成します", slog.String("output", p.config.OutputWAVPath))
-		err := p.VoicevoxEngineExecutor.Execute(ctx, scriptText, p.config.OutputWAVPath)
+		err := p.synthesizeAudio(ctx, scriptText)
+		if errors.Is(err, voice.ErrEngineUnavailable) {
+			// エンジンの初期化に失敗していた場合は、生成したスクリプトを失わないようテキスト出力にフォールバックする
+			slog.WarnContext(ctx, "VOICEVOXエンジンを利用できないため、スクリプトをテキストで出力します", slog.String("error", err.Error()))
+			return iohandler.WriteOutputString("", p.decorate(scriptText))
+		}
 		if err != nil {
-			return fmt.Errorf("音声合成パイプラインの実行に失敗しました: %w", err)
+			return err
 		}
-		slog.Info("VOICEVOXによる音声合成が完了し、ファイルに保存されました。", "output_file", p.config.OutputWAVPath)
-		return nil
+
+		// 5-A''. 字幕ファイルの出力 (subtitles.goで定義)
+		return p.writeSubtitles(ctx)
 	}
 
-	// 5-B. テキスト出力
-	return iohandler.WriteOutputString("", scriptText)
+	// 5-B. テキスト出力 (--decorate 指定時は装飾してから出力)
+	return iohandler.WriteOutputString("", p.decorate(scriptText))
+}
+
+// synthesizeAudio はスクリプトを VOICEVOX で音声合成して OutputWAVPath に保存し、
+// 読み上げ速度のキャリブレーションとラウドネス正規化 (有効時のみ) を行います。
+func (p *Pipeline) synthesizeAudio(ctx context.Context, scriptText string) error {
+	slog.InfoContext(ctx, "AI生成スクリプトをVOICEVOXで音声合成します",
+		slog.String("output", p.config.OutputWAVPath),
+		slog.Duration("timeout", p.config.Timeouts.Synthesis),
+	)
+	synthText := scriptText
+	if p.config.TTSNormalizer != nil {
+		synthText = p.config.TTSNormalizer.Normalize(scriptText)
+	}
+	synthCtx, cancelSynth := p.phaseContext(ctx, PhaseSynthesis)
+	options := voice.SynthesisOptions{Speakers: p.config.Speakers, Prosody: p.config.Prosody}
+	err := voice.ExecuteWithOptions(synthCtx, p.VoicevoxEngineExecutor, synthText, p.config.OutputWAVPath, options)
+	err = p.wrapPhaseError(ctx, synthCtx, PhaseSynthesis, err)
+	cancelSynth()
+	if errors.Is(err, voice.ErrEngineUnavailable) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("音声合成パイプラインの実行に失敗しました: %w", err)
+	}
+	slog.InfoContext(ctx, "VOICEVOXによる音声合成が完了し、ファイルに保存されました。", "output_file", p.config.OutputWAVPath)
+
+	// 目標の再生時間とのずれによる読み上げ速度のキャリブレーション (duration.goで定義)
+	p.calibrateSpeechRate(ctx)
+
+	// 5-A'. ラウドネス正規化 (有効時のみ)
+	if p.config.TargetLUFS != 0 {
+		if _, err := voice.NormalizeLoudnessFile(p.config.OutputWAVPath, p.config.TargetLUFS, voice.DefaultPeakCeilingDB); err != nil {
+			if errors.Is(err, voice.ErrSilentAudio) {
+				slog.WarnContext(ctx, "無音のためラウドネス正規化をスキップしました", slog.String("output", p.config.OutputWAVPath))
+				return nil
+			}
+			return fmt.Errorf("ラウドネス正規化に失敗しました: %w", err)
+		}
+	}
+	return nil
 }
 
 // processWithoutAI は LLMAPIKeyがない場合に実行される処理
-func (p *Pipeline) processWithoutAI(feedTitle string, successfulResults []types.URLResult, titlesMap map[string]string) (string, error) {
+func (p *Pipeline) processWithoutAI(ctx context.Context, feedTitle string, successfulResults []types.URLResult, titlesMap map[string]string) (string, error) {
+	// プレーンテキスト指定時は見出しと区切り線にも Markdown 記法を使わない
+	headingFormat, articleFormat, separator := "# %s\n\n", "## %s\n\n", "\n\n---\n\n"
+	if p.config.ContentFormat == ContentPlain {
+		headingFormat, articleFormat, separator = "%s\n\n", "■ %s\n\n", "\n\n"+strings.Repeat("─", 20)+"\n\n"
+	}
+
 	var combinedTextBuilder strings.Builder
-	combinedTextBuilder.WriteString(fmt.Sprintf("# %s\n\n", feedTitle))
+	combinedTextBuilder.WriteString(fmt.Sprintf(headingFormat, feedTitle))
 
 	for _, res := range successfulResults {
 		articleTitle := titlesMap[res.URL]
 		if articleTitle == "" {
-			slog.Warn("記事タイトルが見つかりませんでした。URLを使用します。", slog.String("url", res.URL))
+			articleCtx := correlation.WithID(ctx, correlation.ArticleID(res.URL))
+			slog.WarnContext(articleCtx, "記事タイトルが見つかりませんでした。URLを使用します。", slog.String("url", res.URL))
 			articleTitle = res.URL // または "不明なタイトル" など、適切なフォールバック
 		}
-		combinedTextBuilder.WriteString(fmt.Sprintf("## %s\n\n", articleTitle))
+		if cleaner.IsFeedFallback(res.Content) {
+			articleTitle += " (フィード要約で代替)"
+		}
+		combinedTextBuilder.WriteString(fmt.Sprintf(articleFormat, articleTitle))
 		combinedTextBuilder.WriteString(res.Content)
-		combinedTextBuilder.WriteString("\n\n---\n\n")
+		combinedTextBuilder.WriteString(separator)
+	}
+
+	// 参照記事セクション (有効時のみ。音声合成する場合は付与しない)
+	if p.config.References && p.audioOutputPath() == "" {
+		return appendReferences(combinedTextBuilder.String(), p.renderReferences(successfulResults, titlesMap, false)), nil
 	}
 	return combinedTextBuilder.String(), nil
 }
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"act-feed-clean-go/internal/correlation"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 抽出した記事本文のプレビュー (--preview-content)
+// ----------------------------------------------------------------------
+
+// DefaultPreviewContentChars はプレビューとしてログに出力する本文の先頭の既定の文字数です。
+const DefaultPreviewContentChars = 300
+
+// logContentPreviews は PreviewContentChars が設定されている場合に、各記事の本文の先頭と文字数を debug ログに出力します。
+// 本文は機密情報を含み得るため、既定では出力しません。改行は 1 行で確認できるよう空白に置き換えます。
+func (p *Pipeline) logContentPreviews(ctx context.Context, results []types.URLResult) {
+	limit := p.config.PreviewContentChars
+	if limit <= 0 {
+		return
+	}
+	for _, res := range results {
+		runes := []rune(strings.Join(strings.Fields(res.Content), " "))
+		preview := string(runes[:min(len(runes), limit)])
+		if len(runes) > limit {
+			preview += "…"
+		}
+		slog.DebugContext(correlation.WithID(ctx, correlation.ArticleID(res.URL)), "本文プレビュー",
+			slog.String("url", res.URL),
+			slog.Int("chars", utf8.RuneCountInString(res.Content)),
+			slog.Bool("empty", len(runes) == 0),
+			slog.String("preview", preview),
+		)
+	}
+}
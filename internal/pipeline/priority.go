@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	itemfeed "act-feed-clean-go/internal/feed"
+)
+
+// ----------------------------------------------------------------------
+// スクレイピング対象URLの優先度付け (--domain-priority / --prefer-recent / --max-articles)
+// ----------------------------------------------------------------------
+
+// URLPriority はスクレイピング前にURLを並べ替える優先度ルールです。
+type URLPriority struct {
+	// Domains はドメインをキー、優先度 (大きいほど先に処理) を値とするマップです。
+	// サブドメインにも一致し、複数のドメインに一致する場合は最も長いドメインの優先度を使用します。
+	// 一致しないURLの優先度は 0 です。
+	Domains map[string]int
+	// PreferRecent が true の場合、同じ優先度のURLを公開時刻の新しい順に並べます。公開時刻のないURLはその後ろに並びます。
+	PreferRecent bool
+}
+
+// isZero はルールが 1 つも設定されていないかを返します。
+func (r URLPriority) isZero() bool {
+	return len(r.Domains) == 0 && !r.PreferRecent
+}
+
+// ParseDomainPriorities は "example.com=10" 形式の指定をドメインと優先度のマップに変換します。
+func ParseDomainPriorities(entries []string) (map[string]int, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	priorities := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		domain, value, ok := strings.Cut(entry, "=")
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if !ok || domain == "" {
+			return nil, fmt.Errorf("ドメイン優先度の形式が不正です: %q (例: example.com=10)", entry)
+		}
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("ドメイン優先度の値が整数ではありません: %q", entry)
+		}
+		priorities[domain] = priority
+	}
+	return priorities, nil
+}
+
+// PrioritizeURLs は rules に従ってURLを優先度の高い順に並べ替えた新しいスライスを返します。
+// 並べ替えは安定で、ルール上の優先度が等しいURL (ルール未設定のURLを含む) は元の順序を維持します。
+// published はURLをキー、公開時刻を値とするマップです (nil 可)。
+func PrioritizeURLs(urls []string, published map[string]time.Time, rules URLPriority) []string {
+	sorted := slices.Clone(urls)
+	if rules.isZero() {
+		return sorted
+	}
+
+	priorities := make(map[string]int, len(urls))
+	for _, u := range urls {
+		priorities[u] = domainPriority(u, rules.Domains)
+	}
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		if c := cmp.Compare(priorities[b], priorities[a]); c != 0 {
+			return c
+		}
+		if !rules.PreferRecent {
+			return 0
+		}
+		ta, okA := published[a]
+		tb, okB := published[b]
+		switch {
+		case okA && okB:
+			return tb.Compare(ta)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+	return sorted
+}
+
+// domainPriority はURLのホストに一致するドメインのうち、最も長いものの優先度を返します。一致しない場合は 0 です。
+func domainPriority(rawURL string, domains map[string]int) int {
+	if len(domains) == 0 {
+		return 0
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	host := strings.ToLower(parsed.Hostname())
+	priority, matched := 0, ""
+	for domain, p := range domains {
+		if (host == domain || strings.HasSuffix(host, "."+domain)) && len(domain) > len(matched) {
+			priority, matched = p, domain
+		}
+	}
+	return priority
+}
+
+// prioritizeURLs はURLを優先度順に並べ替え、MaxArticles が設定されていれば上位の件数に絞り込みます。
+// 優先度ルールも件数の上限もない場合は urls をそのまま返します。
+func (p *Pipeline) prioritizeURLs(ctx context.Context, urls []string, published map[string]time.Time) []string {
+	if p.config.Priority.isZero() && p.config.MaxArticles <= 0 {
+		return urls
+	}
+	sorted := PrioritizeURLs(urls, published, p.config.Priority)
+	kept, dropped := itemfeed.LimitLinks(sorted, p.config.MaxArticles)
+	if dropped > 0 {
+		slog.InfoContext(ctx, "--max-articles の上限を超えた優先度の低い記事を処理対象から除外しました",
+			slog.Int("max_articles", p.config.MaxArticles),
+			slog.Int("kept", len(kept)),
+			slog.Int("dropped", dropped),
+		)
+		slog.DebugContext(ctx, "上限により除外した記事", slog.Any("urls", sorted[len(kept):]))
+	}
+	sorted = kept
+	slog.DebugContext(ctx, "スクレイピング対象のURLを優先度順に並べ替えました", slog.Any("urls", sorted))
+	return sorted
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	itemfeed "act-feed-clean-go/internal/feed"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 出力を伴わない処理 (Process)
+// ----------------------------------------------------------------------
+
+// errNotExtracted は、スクレイピングに失敗していないが本文を抽出できなかった
+// (または処理対象から除外された) 記事の URLStatus.Error です。
+const errNotExtracted = "本文を抽出できなかったか、処理対象から除外されました"
+
+// URLStatus は処理対象とした 1 件の記事URLの抽出の成否です。
+type URLStatus struct {
+	URL   string `json:"url"`
+	OK    bool   `json:"ok"`
+	Type  string `json:"type,omitempty"`  // 失敗の分類 (ClassifyScrapeError。スクレイピングに失敗した場合のみ)
+	Error string `json:"error,omitempty"` // 失敗の内容
+}
+
+// Process は Run と同じくフィードの取得・記事の抽出・AI処理を行い、結果を RunResult として返します。
+// Run と異なり、音声合成・テキスト出力・成果物や状態ファイルへの保存・メール配信などの出力は行いません。
+// RunResult には結合テキスト (CombinedText)、中間統合要約・最終要約、スクリプト (Script と、参照記事などを付与した Output)、
+// 記事URLごとの抽出の成否 (URLStatuses)、推定トークン使用量 (TokenUsage) が入ります。
+// --stream によるストリーミング表示、画像のダウンロード、プロンプトセットの A/B 比較も行いません。
+func (p *Pipeline) Process(ctx context.Context, feedURL string) (*RunResult, error) {
+	source, err := p.fetchFeed(ctx, feedURL)
+	if errors.Is(err, itemfeed.ErrNotModified) {
+		slog.InfoContext(ctx, "フィードが前回の取得から更新されていないため、処理をスキップします", slog.String("feed_url", feedURL))
+		return &RunResult{NotModified: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := p.processSource(ctx, source, false)
+	return result, err
+}
+
+// urlStatuses は処理対象の記事URL urls の抽出の成否を urls の順に返します。
+func urlStatuses(urls []string, results []types.URLResult, failures []ScrapeFailure) []URLStatus {
+	succeeded := make(map[string]bool, len(results))
+	for _, res := range results {
+		succeeded[res.URL] = true
+	}
+	failed := make(map[string]ScrapeFailure, len(failures))
+	for _, f := range failures {
+		failed[f.URL] = f
+	}
+
+	statuses := make([]URLStatus, 0, len(urls))
+	for _, u := range urls {
+		status := URLStatus{URL: u, OK: succeeded[u]}
+		if !status.OK {
+			status.Error = errNotExtracted
+			if f, ok := failed[u]; ok {
+				status.Type, status.Error = f.Type, f.Error
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
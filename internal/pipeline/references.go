@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 参照記事セクション (--references)
+// ----------------------------------------------------------------------
+
+// ReferencesHeading は出力の末尾に付与する参照記事セクションの見出しです。
+const ReferencesHeading = "参照記事"
+
+// renderReferences は記事タイトルとURLの一覧を参照記事セクションとして返します。
+// numbered が true の場合は、AI処理に渡したソース文書の番号 (cleaner.CombineContents の SOURCE DOCUMENT n)
+// と対応する [n] を付与します。番号を揃えるため、本文が空または取得に失敗した記事は CombineContents と同様に除外します。
+// ContentFormat が ContentPlain の場合は Markdown 記法を使いません。
+func (p *Pipeline) renderReferences(results []types.URLResult, titlesMap map[string]string, numbered bool) string {
+	plain := p.config.ContentFormat == ContentPlain
+
+	var sb strings.Builder
+	if plain {
+		sb.WriteString(ReferencesHeading + "\n\n")
+	} else {
+		sb.WriteString("## " + ReferencesHeading + "\n\n")
+	}
+
+	n := 0
+	for _, res := range results {
+		if res.Error != nil || res.Content == "" {
+			continue
+		}
+		n++
+		title := titlesMap[res.URL]
+		if title == "" {
+			title = res.URL
+		}
+
+		prefix := "- "
+		if numbered {
+			prefix = fmt.Sprintf("- [%d] ", n)
+		}
+		switch {
+		case plain && title == res.URL:
+			fmt.Fprintf(&sb, "%s%s\n", prefix, res.URL)
+		case plain:
+			fmt.Fprintf(&sb, "%s%s\n  %s\n", prefix, title, res.URL)
+		default:
+			fmt.Fprintf(&sb, "%s[%s](%s)\n", prefix, escapeLinkText(title), res.URL)
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return sb.String()
+}
+
+// appendReferences は出力の末尾に参照記事セクションを付与します。references が空の場合は output をそのまま返します。
+func appendReferences(output, references string) string {
+	if references == "" {
+		return output
+	}
+	return strings.TrimRight(output, "\n") + "\n\n" + references
+}
+
+// escapeLinkText は Markdown のリンクテキストとして解釈が崩れる角括弧をエスケープします。
+func escapeLinkText(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
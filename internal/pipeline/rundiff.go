@@ -0,0 +1,228 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"act-feed-clean-go/internal/cleaner"
+)
+
+// ----------------------------------------------------------------------
+// 実行結果の保存と前回結果との差分 (--save-run / --diff-against)
+// ----------------------------------------------------------------------
+
+const (
+	// maxDiffLines は差分を行単位で表示する上限です。変更行がこれを超える場合は件数サマリのみを表示します。
+	maxDiffLines = 200
+	// maxDiffCells は行単位の差分計算 (LCS) を行う表の最大サイズです。超える場合は件数サマリのみを表示します。
+	maxDiffCells = 4_000_000
+)
+
+// runArtifacts は 1 回の実行で生成した中間成果物です。
+type runArtifacts struct {
+	Reduce  string // 中間要約 (Reduce結果)
+	Summary string // 最終要約
+	Script  string // スクリプト (免責文を付与する前)
+
+	// 以下はファイルには保存しない
+	MapSummaries   []string                 // Map要約 (CleanAndStructureText が失敗した場合のみ)
+	Dropped        []cleaner.DroppedSegment // Map要約から除外して続行したセグメント (失敗または全体予算の不足)
+	SummaryOverlap float64                  // 最終要約と原文の重複率
+	Layered        *cleaner.LayeredSummary  // 多層要約 (--layered-summary 指定時のみ)
+	BulletSummary  string                   // 箇条書きの最終要約 (--summary-format both 指定時のみ)
+	References     string                   // 参照記事セクション (--references 指定時のみ)
+}
+
+// textSummary はメール・RSS などのテキスト向けの出力に使う最終要約を返します。
+// 箇条書きの最終要約 (--summary-format both) がある場合はそちらを、ない場合は最終要約を返します。
+func (a *runArtifacts) textSummary() string {
+	if a.BulletSummary != "" {
+		return a.BulletSummary
+	}
+	return a.Summary
+}
+
+// artifactFile は成果物とその保存ファイル名の対応です。
+type artifactFile struct {
+	Name    string
+	Content string
+}
+
+// files は成果物を保存・比較する順序で返します。
+func (a *runArtifacts) files() []artifactFile {
+	return []artifactFile{
+		{Name: "reduce.md", Content: a.Reduce},
+		{Name: "summary.md", Content: a.Summary},
+		{Name: "script.txt", Content: a.Script},
+	}
+}
+
+// handleRunArtifacts は設定に応じて、前回の実行結果との差分を標準エラーに出力し、今回の成果物を保存します。
+func (p *Pipeline) handleRunArtifacts(ctx context.Context, artifacts *runArtifacts) error {
+	if p.config.DiffAgainst != "" {
+		if err := diffRunArtifacts(ctx, os.Stderr, p.config.DiffAgainst, artifacts); err != nil {
+			return err
+		}
+	}
+	if p.config.SaveRunDir != "" {
+		if err := saveRunArtifacts(ctx, p.config.SaveRunDir, artifacts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveRunArtifacts は成果物を dir に保存します。dir が存在しない場合は作成します。
+func saveRunArtifacts(ctx context.Context, dir string, artifacts *runArtifacts) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("実行結果の保存先ディレクトリの作成に失敗しました: %w", err)
+	}
+	for _, f := range artifacts.files() {
+		if err := os.WriteFile(filepath.Join(dir, f.Name), []byte(f.Content), 0o644); err != nil {
+			return fmt.Errorf("実行結果 (%s) の保存に失敗しました: %w", f.Name, err)
+		}
+	}
+	slog.InfoContext(ctx, "実行結果を保存しました", slog.String("dir", dir))
+	return nil
+}
+
+// diffRunArtifacts は prevDir に保存された前回の成果物と今回の成果物を行単位で比較し、w に出力します。
+// 前回の成果物が存在しない場合は警告を出してスキップします。
+func diffRunArtifacts(ctx context.Context, w io.Writer, prevDir string, artifacts *runArtifacts) error {
+	for _, f := range artifacts.files() {
+		path := filepath.Join(prevDir, f.Name)
+		prev, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.WarnContext(ctx, "前回の実行結果が見つからないため、差分をスキップします", slog.String("path", path))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("前回の実行結果 (%s) の読み込みに失敗しました: %w", path, err)
+		}
+		if _, err := io.WriteString(w, RenderLineDiff(path+" (前回)", f.Name+" (今回)", string(prev), f.Content)); err != nil {
+			return fmt.Errorf("差分の出力に失敗しました: %w", err)
+		}
+	}
+	return nil
+}
+
+// diffOp は行単位の差分の 1 行です。Kind は ' ' (共通), '-' (削除), '+' (追加) のいずれかです。
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// RenderLineDiff は prev と current の行単位の差分を、変更行のみを出力する形式で返します。
+// 変更行が maxDiffLines を超える場合や、差分計算が大きすぎる場合は件数サマリのみを返します。
+func RenderLineDiff(prevName, currentName, prev, current string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", prevName, currentName)
+
+	if prev == current {
+		sb.WriteString("差分なし\n\n")
+		return sb.String()
+	}
+
+	a, b := splitLines(prev), splitLines(current)
+	if len(a)*len(b) > maxDiffCells {
+		fmt.Fprintf(&sb, "差分が大きすぎるため行単位の比較を省略しました (前回 %d 行 / 今回 %d 行)\n\n", len(a), len(b))
+		return sb.String()
+	}
+
+	ops := diffLines(a, b)
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.Kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	if added+removed > maxDiffLines {
+		fmt.Fprintf(&sb, "差分: +%d 行 / -%d 行 (表示上限 %d 行を超えたため件数のみ表示)\n\n", added, removed, maxDiffLines)
+		return sb.String()
+	}
+
+	// 変更箇所ごとに、今回の出力での行番号を付けて出力する
+	line := 1
+	inHunk := false
+	for _, op := range ops {
+		if op.Kind == ' ' {
+			line++
+			inHunk = false
+			continue
+		}
+		if !inHunk {
+			fmt.Fprintf(&sb, "@@ %d 行目 @@\n", line)
+			inHunk = true
+		}
+		sb.WriteByte(op.Kind)
+		sb.WriteString(op.Line)
+		sb.WriteByte('\n')
+		if op.Kind == '+' {
+			line++
+		}
+	}
+	fmt.Fprintf(&sb, "差分: +%d 行 / -%d 行\n\n", added, removed)
+	return sb.String()
+}
+
+// splitLines は末尾の改行を除いてテキストを行に分割します。
+func splitLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines は最長共通部分列 (LCS) に基づいて a から b への行単位の差分を計算します。
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	// lcs[i][j] は a[i:] と b[j:] の最長共通部分列の長さ
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, max(n, m))
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: ' ', Line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{Kind: '-', Line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: '+', Line: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{Kind: '-', Line: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{Kind: '+', Line: b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shouni/go-http-kit/pkg/httpkit"
+)
+
+// ----------------------------------------------------------------------
+// スクレイピング失敗の分類と集計
+// ----------------------------------------------------------------------
+
+// スクレイピング失敗の種類 (ClassifyScrapeError の戻り値)
+const (
+	ScrapeErrorTimeout      = "timeout"           // タイムアウト (フェーズ・リクエストの期限切れ)
+	ScrapeErrorCanceled     = "canceled"          // キャンセル (レートリミット待機中の中断など)
+	ScrapeErrorNotFound     = "not_found"         // 404 / 410
+	ScrapeErrorForbidden    = "forbidden"         // 401 / 403
+	ScrapeErrorRobots       = "robots_denied"     // robots.txt による拒否
+	ScrapeErrorRateLimited  = "rate_limited"      // 429
+	ScrapeErrorClientError  = "client_error"      // その他の 4xx
+	ScrapeErrorServerError  = "server_error"      // 5xx
+	ScrapeErrorNetwork      = "network"           // DNS解決・接続・TLS などのネットワークエラー
+	ScrapeErrorTooLarge     = "too_large"         // レスポンスボディのサイズ超過
+	ScrapeErrorNoContent    = "extraction_failed" // HTML は取得できたが本文を抽出できなかった
+	ScrapeErrorUnclassified = "other"             // 上記のいずれにも該当しない
+)
+
+// statusCodePattern はエラー文字列に含まれる HTTP ステータスコードを抽出します
+// (例: "ステータスコード 404"、"(5xx リトライ対象): 503"、"status 404")。
+var statusCodePattern = regexp.MustCompile(`(?i)(?:ステータスコード|status(?:\s*code)?|リトライ対象\)):?\s*([1-5][0-9]{2})\b`)
+
+// ScrapeFailure はスクレイピングに失敗した 1 記事の URL と失敗の種類です。
+type ScrapeFailure struct {
+	URL   string `json:"url"`
+	Host  string `json:"host"`
+	Type  string `json:"type"`  // ClassifyScrapeError の分類
+	Error string `json:"error"` // 元のエラーメッセージ
+}
+
+// ClassifyScrapeError はスクレイピングのエラーを、エラーの型・ステータスコード・エラー文字列から分類します。
+// err が nil の場合は空文字列を返します。
+func ClassifyScrapeError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var httpErr *httpkit.NonRetryableHTTPError
+	if errors.As(err, &httpErr) {
+		return classifyStatusCode(httpErr.StatusCode)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ScrapeErrorTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ScrapeErrorCanceled
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ScrapeErrorTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "robots") {
+		return ScrapeErrorRobots
+	}
+	if m := statusCodePattern.FindStringSubmatch(msg); m != nil {
+		if code, convErr := strconv.Atoi(m[1]); convErr == nil {
+			return classifyStatusCode(code)
+		}
+	}
+	switch {
+	case containsAny(msg, "timeout", "deadline exceeded", "タイムアウト"):
+		return ScrapeErrorTimeout
+	case containsAny(msg, "キャンセル", "canceled"):
+		return ScrapeErrorCanceled
+	case containsAny(msg, "最大サイズ", "制限値"):
+		return ScrapeErrorTooLarge
+	case containsAny(msg, "抽出できません", "抽出に失敗", "html解析"):
+		return ScrapeErrorNoContent
+	case errors.As(err, &netErr) || containsAny(msg, "no such host", "connection refused", "connection reset", "tls:", "x509:", "eof"):
+		return ScrapeErrorNetwork
+	default:
+		return ScrapeErrorUnclassified
+	}
+}
+
+// classifyStatusCode は HTTP ステータスコードを失敗の種類に変換します。
+func classifyStatusCode(code int) string {
+	switch {
+	case code == 404 || code == 410:
+		return ScrapeErrorNotFound
+	case code == 401 || code == 403:
+		return ScrapeErrorForbidden
+	case code == 429:
+		return ScrapeErrorRateLimited
+	case code >= 500:
+		return ScrapeErrorServerError
+	case code >= 400:
+		return ScrapeErrorClientError
+	default:
+		return ScrapeErrorUnclassified
+	}
+}
+
+// containsAny は s が subs のいずれかを含むかどうかを返します。
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// newScrapeFailure は失敗した記事の URL とエラーから ScrapeFailure を作成します。
+func newScrapeFailure(rawURL string, err error) ScrapeFailure {
+	host := rawURL
+	if u, parseErr := url.Parse(rawURL); parseErr == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	return ScrapeFailure{URL: rawURL, Host: host, Type: ClassifyScrapeError(err), Error: err.Error()}
+}
+
+// CountScrapeErrors は失敗の種類ごとの件数を返します。失敗がない場合は nil を返します。
+func CountScrapeErrors(failures []ScrapeFailure) map[string]int {
+	if len(failures) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, f := range failures {
+		counts[f.Type]++
+	}
+	return counts
+}
+
+// recordScrapeFailures は失敗した記事と種類ごとの件数を result に追加します。
+func (r *RunResult) recordScrapeFailures(failures []ScrapeFailure) {
+	if len(failures) == 0 {
+		return
+	}
+	r.ScrapeFailures = append(r.ScrapeFailures, failures...)
+	r.ScrapeErrors = CountScrapeErrors(r.ScrapeFailures)
+}
+
+// logScrapeFailures は失敗の種類ごとの件数と、失敗の多いホストをログに出力します。
+func logScrapeFailures(ctx context.Context, failures []ScrapeFailure) {
+	if len(failures) == 0 {
+		return
+	}
+	counts := CountScrapeErrors(failures)
+	attrs := make([]any, 0, len(counts)+2)
+	attrs = append(attrs, slog.Int("failed", len(failures)))
+	for _, kind := range keysByCount(counts) {
+		attrs = append(attrs, slog.Int(kind, counts[kind]))
+	}
+
+	hosts := make(map[string]int)
+	for _, f := range failures {
+		hosts[f.Host]++
+	}
+	var hostSummary []string
+	for _, host := range keysByCount(hosts) {
+		hostSummary = append(hostSummary, host+"="+strconv.Itoa(hosts[host]))
+	}
+	attrs = append(attrs, slog.String("hosts", strings.Join(hostSummary, ",")))
+	slog.WarnContext(ctx, "スクレイピング失敗の内訳", attrs...)
+}
+
+// keysByCount は件数のマップのキーを件数の降順 (同数の場合はキーの昇順) で返します。
+func keysByCount(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
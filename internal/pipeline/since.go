@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	itemfeed "act-feed-clean-go/internal/feed"
+)
+
+// ----------------------------------------------------------------------
+// 公開時刻によるフィルタ (--since)
+// ----------------------------------------------------------------------
+
+// ErrAllFilteredOut はフィルタ条件によって全記事が除外されたことを示します。
+var ErrAllFilteredOut = errors.New("フィルタ条件によって全記事が除外されました")
+
+// AllFilteredOutError は、フィルタ前には記事があったが条件で全件除外された場合のエラーです。
+// 記事がそもそも存在しない場合と区別できるよう、フィルタ条件と除外前の件数を保持します。
+type AllFilteredOutError struct {
+	Filter string // 適用したフィルタ条件 (例: "since=2025-01-01T00:00:00+09:00")
+	Before int    // フィルタ前の記事数
+}
+
+func (e *AllFilteredOutError) Error() string {
+	return fmt.Sprintf("フィルタ前は %d 件の記事がありましたが、%s の条件で全件除外されました。条件を緩めて再実行してください", e.Before, e.Filter)
+}
+
+func (e *AllFilteredOutError) Unwrap() error {
+	return ErrAllFilteredOut
+}
+
+// ParseSince は --since の値を時刻に変換します。
+// "24h" や "90m" などの期間 (now から遡る)、"3d" 形式の日数、RFC3339、"2006-01-02" 形式の日付 (ローカル時刻) を受け付けます。
+// 空文字列の場合はゼロ値 (フィルタなし) を返します。
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("--since に負の期間は指定できません: %q", value)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("--since の形式が不正です: %q (例: 24h, 3d, 2025-01-01, 2025-01-01T09:00:00+09:00)", value)
+}
+
+// filterBySince は公開時刻が Since より前の記事を除外したURLリストを返します。
+// 公開時刻が取得できない記事は SinceUndated に従って残すか除外します (デフォルトは残す)。
+func (p *Pipeline) filterBySince(ctx context.Context, source *feedSource) []string {
+	since := p.config.Since
+	kept := make([]string, 0, len(source.URLs))
+	undated := 0
+	for _, u := range source.URLs {
+		published, dated := source.Published[u]
+		if !dated {
+			undated++
+		}
+		if itemfeed.KeepSince(published, dated, since, p.config.SinceUndated) {
+			kept = append(kept, u)
+		}
+	}
+
+	slog.InfoContext(ctx, "公開時刻で記事をフィルタしました",
+		slog.String("feed_url", source.FeedURL),
+		slog.String("since", since.Format(time.RFC3339)),
+		slog.Int("before", len(source.URLs)),
+		slog.Int("after", len(kept)),
+		slog.Int("undated", undated),
+		slog.Bool("undated_excluded", p.config.SinceUndated == itemfeed.UndatedExclude),
+	)
+	return kept
+}
+
+// sinceFilterLabel はエラーメッセージ用のフィルタ条件の表記を返します。
+func (p *Pipeline) sinceFilterLabel() string {
+	return "since=" + p.config.Since.Format(time.RFC3339)
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+
+	"act-feed-clean-go/internal/cleaner"
+
+	"github.com/shouni/go-web-exact/v2/pkg/types"
+)
+
+// ----------------------------------------------------------------------
+// 最終要約のソースカバレッジ (--cite-sources)
+// ----------------------------------------------------------------------
+
+// DefaultMinSourceCoverage は、ソースカバレッジがこれを下回ると警告する割合のデフォルトです。
+const DefaultMinSourceCoverage = 0.5
+
+// SourceCoverage は最終要約に反映された記事の集計です。
+// 反映の判定は最終要約に出現するソース番号 [n] による近似です (cleaner.MeasureSourceCoverage)。
+type SourceCoverage struct {
+	Total   int          // AI処理に渡した記事の数
+	Cited   int          // 最終要約にソース番号が出現した記事の数
+	Ratio   float64      // カバレッジ率 (Cited / Total)
+	Uncited []ArticleRef // 最終要約にソース番号が出現しなかった記事
+}
+
+// sourceCoverage は最終要約 summary のソースカバレッジを集計し、MinSourceCoverage を下回る場合は警告します。
+// ソース番号は cleaner.CombineContents の SOURCE DOCUMENT n と対応させるため、本文が空または取得に失敗した記事は除外して数えます。
+func (p *Pipeline) sourceCoverage(ctx context.Context, summary string, results []types.URLResult, titlesMap map[string]string) *SourceCoverage {
+	var sources []types.URLResult
+	for _, res := range results {
+		if res.Error == nil && res.Content != "" {
+			sources = append(sources, res)
+		}
+	}
+
+	measured := cleaner.MeasureSourceCoverage(summary, len(sources))
+	coverage := &SourceCoverage{Total: measured.Total, Cited: len(measured.Cited), Ratio: measured.Ratio}
+	for _, n := range measured.Uncited {
+		res := sources[n-1]
+		coverage.Uncited = append(coverage.Uncited, ArticleRef{
+			URL:          res.URL,
+			Title:        titlesMap[res.URL],
+			FeedFallback: cleaner.IsFeedFallback(res.Content),
+		})
+	}
+
+	slog.InfoContext(ctx, "最終要約のソースカバレッジ",
+		slog.Int("cited", coverage.Cited),
+		slog.Int("total", coverage.Total),
+		slog.Float64("ratio", coverage.Ratio),
+	)
+	if coverage.Total > 0 && coverage.Ratio < p.config.MinSourceCoverage {
+		uncited := make([]string, len(coverage.Uncited))
+		for i, article := range coverage.Uncited {
+			uncited[i] = article.URL
+		}
+		slog.WarnContext(ctx, "最終要約に反映されていない記事が多くあります。記事のフィルタやセグメントの分割を確認してください",
+			slog.Float64("ratio", coverage.Ratio),
+			slog.Float64("min_ratio", p.config.MinSourceCoverage),
+			slog.Any("uncited", uncited),
+		)
+	}
+	return coverage
+}
@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"act-feed-clean-go/internal/voice"
+)
+
+// ----------------------------------------------------------------------
+// 字幕ファイル (SRT / WebVTT) の出力
+// ----------------------------------------------------------------------
+
+// writeSubtitles は直前の音声合成のタイムコードから、設定された字幕ファイルを書き出します。
+// 合成エンジンがタイムコードを提供しない場合は警告して何もしません。
+func (p *Pipeline) writeSubtitles(ctx context.Context) error {
+	if p.config.SRTPath == "" && p.config.VTTPath == "" {
+		return nil
+	}
+	source, ok := p.VoicevoxEngineExecutor.(voice.CueSource)
+	if !ok || len(source.Cues()) == 0 {
+		slog.WarnContext(ctx, "音声合成のタイムコードを取得できなかったため、字幕ファイルを出力しません")
+		return nil
+	}
+	cues := source.Cues()
+
+	if p.config.SRTPath != "" {
+		if err := writeSubtitleFile(ctx, p.config.SRTPath, voice.GenerateSRT(cues)); err != nil {
+			return err
+		}
+	}
+	if p.config.VTTPath != "" {
+		if err := writeSubtitleFile(ctx, p.config.VTTPath, voice.GenerateVTT(cues, p.config.VTTSpeakerTags)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSubtitleFile は字幕を path に書き込みます。
+func writeSubtitleFile(ctx context.Context, path, content string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("字幕ファイルのディレクトリ作成に失敗しました (%s): %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("字幕ファイルの書き込みに失敗しました (%s): %w", path, err)
+	}
+	slog.InfoContext(ctx, "字幕ファイルを出力しました", slog.String("path", path))
+	return nil
+}
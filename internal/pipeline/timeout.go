@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// フェーズ定義とタイムアウト予算
+// ----------------------------------------------------------------------
+
+// Phase はパイプラインの処理段階を表します。
+type Phase string
+
+const (
+	PhaseFeed      Phase = "feed"      // フィードの取得とパース
+	PhaseScrape    Phase = "scrape"    // 記事本文の並列スクレイピング
+	PhaseLLM       Phase = "llm"       // Map-Reduce、最終要約、スクリプト生成
+	PhaseSynthesis Phase = "synthesis" // VOICEVOXによる音声合成
+)
+
+const (
+	// DefaultOverallTimeout は、パイプライン全体の実行に許容される最大時間です。
+	DefaultOverallTimeout = 20 * time.Minute
+	// DefaultFeedTimeout は、フィード取得フェーズのデフォルト予算です。
+	DefaultFeedTimeout = 1 * time.Minute
+	// DefaultScrapeTimeout は、スクレイピングフェーズのデフォルト予算です。
+	DefaultScrapeTimeout = 5 * time.Minute
+	// DefaultLLMTimeout は、LLM処理フェーズのデフォルト予算です。
+	DefaultLLMTimeout = 10 * time.Minute
+	// DefaultSynthesisTimeout は、音声合成フェーズのデフォルト予算です。
+	DefaultSynthesisTimeout = 10 * time.Minute
+)
+
+// TimeoutBudget は全体上限と各フェーズに配分するタイムアウト予算を保持します。
+// フェーズの値が 0 以下の場合、そのフェーズは全体上限のみで制御されます。
+type TimeoutBudget struct {
+	Overall   time.Duration
+	Feed      time.Duration
+	Scrape    time.Duration
+	LLM       time.Duration
+	Synthesis time.Duration
+}
+
+// For は指定されたフェーズに配分された予算を返します。
+func (b TimeoutBudget) For(phase Phase) time.Duration {
+	switch phase {
+	case PhaseFeed:
+		return b.Feed
+	case PhaseScrape:
+		return b.Scrape
+	case PhaseLLM:
+		return b.LLM
+	case PhaseSynthesis:
+		return b.Synthesis
+	default:
+		return 0
+	}
+}
+
+// ----------------------------------------------------------------------
+// タイムアウトエラー
+// ----------------------------------------------------------------------
+
+// PhaseTimeoutError は、どのフェーズでタイムアウトが発生したかを示すエラーです。
+type PhaseTimeoutError struct {
+	Phase   Phase
+	Timeout time.Duration // 超過した予算 (全体上限による場合は 0)
+	Overall bool          // 全体上限によるタイムアウトか
+	Err     error
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	if e.Overall {
+		return fmt.Sprintf("%s フェーズ実行中にパイプライン全体のタイムアウトを超過しました: %v", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("%s フェーズがタイムアウト予算 (%s) を超過しました: %v", e.Phase, e.Timeout, e.Err)
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ----------------------------------------------------------------------
+// ヘルパー関数
+// ----------------------------------------------------------------------
+
+// phaseContext は指定されたフェーズの予算を適用した子コンテキストを作成します。
+func (p *Pipeline) phaseContext(ctx context.Context, phase Phase) (context.Context, context.CancelFunc) {
+	timeout := p.config.Timeouts.For(phase)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// wrapPhaseError は、フェーズのコンテキストが期限切れの場合に err を PhaseTimeoutError に変換します。
+// parent は全体上限を持つ親コンテキストで、どちらの期限で打ち切られたかの判定に使用します。
+func (p *Pipeline) wrapPhaseError(parent, phaseCtx context.Context, phase Phase, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(phaseCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(parent.Err(), context.DeadlineExceeded) {
+		return &PhaseTimeoutError{Phase: phase, Overall: true, Err: err}
+	}
+	return &PhaseTimeoutError{Phase: phase, Timeout: p.config.Timeouts.For(phase), Err: err}
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// ----------------------------------------------------------------------
+// URLリスト入力 (フィードを介さない処理)
+// ----------------------------------------------------------------------
+
+// URLListTitle は URLリストモードでフィードタイトルの代わりに使用するタイトルです。
+const URLListTitle = "URLリスト"
+
+// NormalizeURL は URL を正規化します。スキームとホストを小文字化し、
+// 既定ポート (http:80 / https:443) とフラグメントを除去します。
+// http / https 以外のスキームやホストのない URL はエラーになります。
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("URLの解析に失敗しました: %w", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("http または https のURLではありません: %q", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("ホストが含まれていません: %q", raw)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), nil
+}
+
+// ReadURLList は r から 1行1URL のリストを読み込みます。
+// 空行と `#` で始まるコメント行は無視し、正規化後に重複を除去します。
+// 不正なURLは警告を出してスキップします。
+func ReadURLList(r io.Reader) ([]string, error) {
+	var urls []string
+	seen := make(map[string]bool)
+	read := 0
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		read++
+
+		normalized, err := NormalizeURL(line)
+		if err != nil {
+			slog.Warn("不正なURLをスキップします", slog.Int("line", lineNo), slog.String("error", err.Error()))
+			continue
+		}
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		urls = append(urls, normalized)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("URLリストの読み込みに失敗しました: %w", err)
+	}
+
+	slog.Info("URLリストを読み込みました", slog.Int("read", read), slog.Int("valid", len(urls)))
+	return urls, nil
+}
+
+// RunURLs はフィードを介さず、指定された URL の記事を並列抽出して AI処理と出力を実行します。
+// 記事タイトルはフィードから取得できないため、AI処理ではReduce結果から抽出したタイトルを使用します。
+func (p *Pipeline) RunURLs(ctx context.Context, urls []string) (*RunResult, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("処理対象のURLが1件もありません")
+	}
+	result := &RunResult{FeedTitles: []string{URLListTitle}}
+
+	urls, err := p.filterBlockedDomains(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	urls = p.prioritizeURLs(ctx, urls, nil)
+	successfulResults, snippets, failures, err := p.collectArticles(ctx, urls, nil)
+	result.recordScrapeFailures(failures)
+	if err != nil {
+		return result, err
+	}
+	if p.config.DomainQuality.enabled() {
+		result.ArticleQuality = MeasureArticleQuality(successfulResults)
+		if !p.planOnly() {
+			p.recordDomainQuality(ctx, result.ArticleQuality)
+		}
+	}
+	if err := p.handleCodeSnippets(ctx, result, snippets); err != nil {
+		return result, err
+	}
+	if successfulResults, _, err = p.sortByImportance(ctx, successfulResults, map[string]string{}); err != nil {
+		return result, err
+	}
+
+	if err := p.generateAndOutput(ctx, result, URLListTitle, successfulResults, map[string]string{}, nil); err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,345 @@
+// Package state は、処理済み記事 (GUID) と生成したエピソードの履歴を
+// JSONファイルに永続化し、実行間で共有するためのストアを提供します。
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultPath は状態ファイルのデフォルトの保存先です。
+const DefaultPath = "asset/processed_state.json"
+
+// MaxRuns は状態ファイルに保持する実行履歴の最大件数です。超えた分は古いものから削除します。
+const MaxRuns = 100
+
+// MaxArtifacts は状態ファイルに保持する成果物 (冪等キーごと) の最大件数です。超えた分は古いものから削除します。
+const MaxArtifacts = 50
+
+// MaxEpisodes は状態ファイルに保持するエピソード履歴の最大件数です。超えた分は古いものから削除します。
+const MaxEpisodes = 100
+
+// ProcessedRetention は処理済みGUIDを保持する期間です。処理からこの期間を過ぎたGUIDは削除します。
+// フィードに残っている期間より十分長くとり、同じ記事を再処理しないようにしています。
+const ProcessedRetention = 90 * 24 * time.Hour
+
+// SpeechRateLearningRate は読み上げ速度の係数を更新する際に、新しい計測値に与える重み (0〜1) です。
+const SpeechRateLearningRate = 0.5
+
+// 実行履歴のステータス
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// Article はエピソードに含まれる記事の情報です。
+type Article struct {
+	GUID  string `json:"guid"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// Episode は 1 回の実行で生成された成果物と、そこに含まれる記事の記録です。
+type Episode struct {
+	CreatedAt time.Time `json:"created_at"`
+	FeedURL   string    `json:"feed_url"`
+	Output    string    `json:"output,omitempty"` // 出力先 (WAVパス。標準出力の場合は空)
+	Articles  []Article `json:"articles"`
+}
+
+// Run は 1 回の実行の記録です。
+type Run struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Mode       string    `json:"mode"`   // 実行モード (feed, feeds, digest, urls)
+	Status     string    `json:"status"` // RunSucceeded または RunFailed
+	Error      string    `json:"error,omitempty"`
+	FeedTitles []string  `json:"feed_titles,omitempty"`
+	Summary    string    `json:"summary,omitempty"` // 出力の冒頭部分
+	CostUSD    float64   `json:"cost_usd,omitempty"`
+
+	Skipped      bool           `json:"skipped,omitempty"`       // フィードに更新がなく処理をスキップした (304 Not Modified)
+	ScrapeErrors map[string]int `json:"scrape_errors,omitempty"` // スクレイピング失敗の種類ごとの件数
+
+	SummaryLayers *SummaryLayers `json:"summary_layers,omitempty"` // 多層要約 (--layered-summary 指定時のみ)
+}
+
+// SummaryLayers は実行で生成した 1行要約・段落要約・詳細要約です。
+type SummaryLayers struct {
+	OneLine   string `json:"one_line"`
+	Paragraph string `json:"paragraph"`
+	Detailed  string `json:"detailed"`
+}
+
+// Artifact は冪等キー (処理の全入力から計算した識別子) に対応する、成功した LLM 処理の成果物です。
+type Artifact struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	Reduce    string    `json:"reduce"`
+	Summary   string    `json:"summary"`
+	Script    string    `json:"script"`
+
+	SummaryOverlap float64        `json:"summary_overlap,omitempty"`
+	SummaryLayers  *SummaryLayers `json:"summary_layers,omitempty"` // 多層要約 (--layered-summary 指定時のみ)
+	BulletSummary  string         `json:"bullet_summary,omitempty"` // 箇条書きの最終要約 (--summary-format both 指定時のみ)
+}
+
+// SpeechRate は話者・話速ごとに学習した 1分あたりの読み上げ文字数の係数です。
+type SpeechRate struct {
+	CharsPerMinute float64   `json:"chars_per_minute"`
+	Samples        int       `json:"samples"` // 係数の更新に使用した計測の回数
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DomainQuality はドメインごとに蓄積した記事の品質スコアと自動ブロックの状態です。
+type DomainQuality struct {
+	Score     float64   `json:"score"`             // 記事の品質スコア (0〜1) の平均
+	Samples   int       `json:"samples"`           // スコアの平均に使用した記事数
+	Blocked   bool      `json:"blocked,omitempty"` // 平均スコアが閾値を下回り、自動ブロックしたか
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// data は状態ファイルのJSON構造です。
+type data struct {
+	Processed   map[string]time.Time  `json:"processed"` // GUID -> 処理日時
+	Episodes    []Episode             `json:"episodes"`
+	Runs        []Run                 `json:"runs,omitempty"`
+	SpeechRates map[string]SpeechRate `json:"speech_rates,omitempty"` // 話者・話速のキー -> 読み上げ速度の係数
+	Artifacts   []Artifact            `json:"artifacts,omitempty"`    // 冪等キーごとの成果物 (古い順)
+	// DomainQuality はドメインをキーとする記事の品質スコアの蓄積と自動ブロックの状態です。
+	DomainQuality map[string]DomainQuality `json:"domain_quality,omitempty"`
+}
+
+// Store は処理済みGUIDとエピソード履歴を保持する、並行安全なストアです。
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data data
+}
+
+// Load は path から状態を読み込みます。ファイルが存在しない場合は空のストアを返します。
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, data: data{Processed: make(map[string]time.Time)}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("状態ファイルの読み込みに失敗しました: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("状態ファイルの解析に失敗しました (%s): %w", path, err)
+	}
+	if s.data.Processed == nil {
+		s.data.Processed = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// IsProcessed は GUID が処理済みかどうかを返します。
+func (s *Store) IsProcessed(guid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.data.Processed[guid]
+	return ok
+}
+
+// RecordEpisode はエピソードを履歴に追加し、含まれる記事を処理済みとして記録します。
+// 履歴が MaxEpisodes を超えた場合は古いものから削除し、処理から ProcessedRetention を過ぎたGUIDも削除します。
+func (s *Store) RecordEpisode(episode Episode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range episode.Articles {
+		s.data.Processed[a.GUID] = episode.CreatedAt
+	}
+	cutoff := episode.CreatedAt.Add(-ProcessedRetention)
+	for guid, processedAt := range s.data.Processed {
+		if processedAt.Before(cutoff) {
+			delete(s.data.Processed, guid)
+		}
+	}
+	s.data.Episodes = append(s.data.Episodes, episode)
+	if over := len(s.data.Episodes) - MaxEpisodes; over > 0 {
+		s.data.Episodes = append([]Episode(nil), s.data.Episodes[over:]...)
+	}
+}
+
+// RecordRun は実行の記録を履歴に追加します。履歴が MaxRuns を超えた場合は古いものから削除します。
+func (s *Store) RecordRun(run Run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Runs = append(s.data.Runs, run)
+	if over := len(s.data.Runs) - MaxRuns; over > 0 {
+		s.data.Runs = append([]Run(nil), s.data.Runs[over:]...)
+	}
+}
+
+// Runs は実行履歴を新しい順に最大 limit 件返します。limit が 0 以下の場合はすべて返します。
+func (s *Store) Runs(limit int) []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.data.Runs)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	runs := make([]Run, 0, limit)
+	for i := n - 1; i >= n-limit; i-- {
+		runs = append(runs, s.data.Runs[i])
+	}
+	return runs
+}
+
+// RunByID は ID に一致する実行の記録を返します。
+func (s *Store) RunByID(id string) (Run, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.data.Runs {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return Run{}, false
+}
+
+// Artifact は冪等キー key に対応する成果物を返します。
+func (s *Store) Artifact(key string) (Artifact, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.data.Artifacts {
+		if a.Key == key {
+			return a, true
+		}
+	}
+	return Artifact{}, false
+}
+
+// RecordArtifact は成果物を記録します。同じ冪等キーの成果物がある場合は置き換え、
+// 件数が MaxArtifacts を超えた場合は古いものから削除します。
+func (s *Store) RecordArtifact(artifact Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.data.Artifacts[:0]
+	for _, a := range s.data.Artifacts {
+		if a.Key != artifact.Key {
+			kept = append(kept, a)
+		}
+	}
+	s.data.Artifacts = append(kept, artifact)
+	if over := len(s.data.Artifacts) - MaxArtifacts; over > 0 {
+		s.data.Artifacts = append([]Artifact(nil), s.data.Artifacts[over:]...)
+	}
+}
+
+// SpeechRates は学習済みの読み上げ速度の係数のコピーを返します。
+func (s *Store) SpeechRates() map[string]SpeechRate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rates := make(map[string]SpeechRate, len(s.data.SpeechRates))
+	for key, rate := range s.data.SpeechRates {
+		rates[key] = rate
+	}
+	return rates
+}
+
+// CalibrateSpeechRate は key の読み上げ速度の係数を計測値 charsPerMinute で更新し、更新後の係数を返します。
+// 初回は計測値をそのまま使用し、以降は SpeechRateLearningRate の重みで計測値に近づけます。
+func (s *Store) CalibrateSpeechRate(key string, charsPerMinute float64, now time.Time) SpeechRate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.SpeechRates == nil {
+		s.data.SpeechRates = make(map[string]SpeechRate)
+	}
+	rate := s.data.SpeechRates[key]
+	if rate.Samples == 0 {
+		rate.CharsPerMinute = charsPerMinute
+	} else {
+		rate.CharsPerMinute += (charsPerMinute - rate.CharsPerMinute) * SpeechRateLearningRate
+	}
+	rate.Samples++
+	rate.UpdatedAt = now
+	s.data.SpeechRates[key] = rate
+	return rate
+}
+
+// DomainQualities はドメインごとの品質スコアのコピーを返します。
+func (s *Store) DomainQualities() map[string]DomainQuality {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	qualities := make(map[string]DomainQuality, len(s.data.DomainQuality))
+	for domain, quality := range s.data.DomainQuality {
+		qualities[domain] = quality
+	}
+	return qualities
+}
+
+// RecordDomainQuality は domain の記事 1 件の品質スコア score を平均に加え、更新後の状態を返します。
+func (s *Store) RecordDomainQuality(domain string, score float64, now time.Time) DomainQuality {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.DomainQuality == nil {
+		s.data.DomainQuality = make(map[string]DomainQuality)
+	}
+	quality := s.data.DomainQuality[domain]
+	quality.Samples++
+	quality.Score += (score - quality.Score) / float64(quality.Samples)
+	quality.UpdatedAt = now
+	s.data.DomainQuality[domain] = quality
+	return quality
+}
+
+// BlockDomain は domain を自動ブロックしたドメインとして記録します。
+func (s *Store) BlockDomain(domain string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.DomainQuality == nil {
+		s.data.DomainQuality = make(map[string]DomainQuality)
+	}
+	quality := s.data.DomainQuality[domain]
+	quality.Blocked = true
+	quality.UpdatedAt = now
+	s.data.DomainQuality[domain] = quality
+}
+
+// UnblockDomain は domain の自動ブロックを解除し、すぐに再ブロックされないよう品質スコアの蓄積もリセットします。
+// domain を自動ブロックしていなかった場合は false を返します。
+func (s *Store) UnblockDomain(domain string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quality, ok := s.data.DomainQuality[domain]
+	if !ok || !quality.Blocked {
+		return false
+	}
+	delete(s.data.DomainQuality, domain)
+	return true
+}
+
+// Save は状態をファイルに書き込みます。書き込み途中の破損を防ぐため、一時ファイル経由で置き換えます。
+func (s *Store) Save() error {
+	s.mu.Lock()
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("状態のシリアライズに失敗しました: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("状態ファイルのディレクトリ作成に失敗しました (%s): %w", dir, err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("状態ファイルの書き込みに失敗しました: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("状態ファイルの置き換えに失敗しました: %w", err)
+	}
+	return nil
+}
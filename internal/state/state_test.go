@@ -0,0 +1,43 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordEpisodeRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.RecordEpisode(Episode{CreatedAt: start, Articles: []Article{{GUID: "old"}}})
+	for i := range MaxEpisodes + 10 {
+		createdAt := start.Add(ProcessedRetention + time.Duration(i)*time.Hour)
+		s.RecordEpisode(Episode{CreatedAt: createdAt, Articles: []Article{{GUID: fmt.Sprintf("guid-%d", i)}}})
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.IsProcessed("old") {
+		t.Error("ProcessedRetention を過ぎたGUIDが残っています")
+	}
+	if !loaded.IsProcessed("guid-0") || !loaded.IsProcessed(fmt.Sprintf("guid-%d", MaxEpisodes+9)) {
+		t.Error("保持期間内のGUIDが削除されています")
+	}
+	if got := len(loaded.data.Episodes); got != MaxEpisodes {
+		t.Fatalf("エピソード数 = %d, want %d", got, MaxEpisodes)
+	}
+	if got := loaded.data.Episodes[0].Articles[0].GUID; got != "guid-10" {
+		t.Errorf("最も古いエピソード = %s, want guid-10", got)
+	}
+}
@@ -0,0 +1,94 @@
+package voice
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox"
+)
+
+// ----------------------------------------------------------------------
+// 並列合成の設定
+// ----------------------------------------------------------------------
+
+// DefaultConcurrency は audio_query / synthesis を同時に実行する行数のデフォルト値です。
+const DefaultConcurrency = voicevox.DefaultMaxParallelSegments
+
+// engineConfig は並列数に応じたエンジン設定を返します。
+// エンジンはレートリミット間隔ごとに 1 行ずつ合成を開始するため、並列数をデフォルトより増やした場合は
+// 開始間隔を並列数に比例して短くし、増やした同時数が実際に使われるようにします。
+func engineConfig(concurrency int) voicevox.EngineConfig {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	rateLimit := voicevox.DefaultSegmentRateLimit
+	if concurrency > DefaultConcurrency {
+		rateLimit = voicevox.DefaultSegmentRateLimit * time.Duration(DefaultConcurrency) / time.Duration(concurrency)
+	}
+	return voicevox.EngineConfig{
+		MaxParallelSegments: concurrency,
+		SegmentTimeout:      voicevox.DefaultSegmentTimeout,
+		SegmentRateLimit:    rateLimit,
+	}
+}
+
+// ----------------------------------------------------------------------
+// サンプルフォーマットの統一
+// ----------------------------------------------------------------------
+
+// queryFormat は 1 回の合成で最初に得たオーディオクエリの出力フォーマット
+// (outputSamplingRate / outputStereo) を記録し、以降のクエリをそれに揃えます。
+// 行ごとのWAVは最初の行のヘッダーで結合されるため、並列合成でフォーマットが混在しないようにします。
+type queryFormat struct {
+	mu           sync.Mutex
+	set          bool
+	samplingRate json.RawMessage
+	stereo       json.RawMessage
+}
+
+// reset は新しい合成バッチの開始時に、記録したフォーマットを破棄します。
+func (f *queryFormat) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set = false
+	f.samplingRate, f.stereo = nil, nil
+}
+
+// align はクエリの出力フォーマットを記録済みのフォーマットに揃えたクエリを返します。
+// 最初のクエリの場合は、そのフォーマットを記録してそのまま返します。
+func (f *queryFormat) align(query []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(query, &fields); err != nil {
+		return nil, fmt.Errorf("オーディオクエリの解析に失敗しました: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.set {
+		f.set = true
+		f.samplingRate, f.stereo = fields["outputSamplingRate"], fields["outputStereo"]
+		return query, nil
+	}
+	if string(fields["outputSamplingRate"]) == string(f.samplingRate) && string(fields["outputStereo"]) == string(f.stereo) {
+		return query, nil
+	}
+
+	slog.Debug("オーディオクエリの出力フォーマットを揃えました",
+		slog.String("sampling_rate", string(fields["outputSamplingRate"])+" -> "+string(f.samplingRate)),
+		slog.String("stereo", string(fields["outputStereo"])+" -> "+string(f.stereo)),
+	)
+	if f.samplingRate != nil {
+		fields["outputSamplingRate"] = f.samplingRate
+	}
+	if f.stereo != nil {
+		fields["outputStereo"] = f.stereo
+	}
+	aligned, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("オーディオクエリの再構築に失敗しました: %w", err)
+	}
+	return aligned, nil
+}
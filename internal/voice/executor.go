@@ -0,0 +1,230 @@
+// Package voice は go-voicevox のエンジンを組み立て、合成の進捗通知などの
+// アプリケーション固有の機能を付加した EngineExecutor を提供します。
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox"
+	"github.com/shouni/go-voicevox/pkg/voicevox/api"
+	"github.com/shouni/go-voicevox/pkg/voicevox/parser"
+	"github.com/shouni/go-voicevox/pkg/voicevox/speaker"
+)
+
+// defaultAPIURL は VOICEVOX_API_URL が未設定の場合に使用するエンジンのURLです。
+const defaultAPIURL = "http://localhost:50021"
+
+// progressClient は AudioQueryClient をラップし、合成完了ごとに進捗と再生時間を記録します。
+type progressClient struct {
+	voicevox.AudioQueryClient
+	tracker *progressTracker
+	timings *segmentTimings
+	format  *queryFormat
+	prosody *queryProsody
+}
+
+// RunAudioQuery はオーディオクエリを委譲し、話速・音高・抑揚の指定を適用して出力フォーマットをバッチ内で揃えたうえで、
+// 合成時に再生時間をテキストと対応付けられるよう記録します。
+func (c *progressClient) RunAudioQuery(text string, styleID int, ctx context.Context) ([]byte, error) {
+	query, err := c.AudioQueryClient.RunAudioQuery(text, styleID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if query, err = c.prosody.apply(query); err != nil {
+		return nil, err
+	}
+	if query, err = c.format.align(query); err != nil {
+		return nil, err
+	}
+	c.timings.query(query, text)
+	return query, nil
+}
+
+// RunSynthesis は合成を委譲し、成功した場合に 1 行分の完了と再生時間を記録します。
+func (c *progressClient) RunSynthesis(queryBody []byte, styleID int, ctx context.Context) ([]byte, error) {
+	wav, err := c.AudioQueryClient.RunSynthesis(queryBody, styleID, ctx)
+	if err == nil {
+		c.tracker.complete()
+		c.timings.synthesized(queryBody, wav)
+	}
+	return wav, err
+}
+
+// segmentTimings は並列合成されるセグメントの再生時間と話速を、テキスト単位で記録します。
+type segmentTimings struct {
+	mu        sync.Mutex
+	texts     map[string]string        // オーディオクエリ -> テキスト
+	durations map[string]time.Duration // テキスト -> 再生時間
+	speeds    map[string]float64       // テキスト -> 話速 (speedScale)
+}
+
+func newSegmentTimings() *segmentTimings {
+	return &segmentTimings{texts: make(map[string]string), durations: make(map[string]time.Duration), speeds: make(map[string]float64)}
+}
+
+func (t *segmentTimings) query(query []byte, text string) {
+	var q struct {
+		SpeedScale float64 `json:"speedScale"`
+	}
+	if err := json.Unmarshal(query, &q); err != nil {
+		slog.Debug("オーディオクエリから話速を取得できませんでした", slog.String("error", err.Error()))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.texts[string(query)] = text
+	t.speeds[text] = q.SpeedScale
+}
+
+func (t *segmentTimings) synthesized(query, wav []byte) {
+	d, err := wavDuration(wav)
+	if err != nil {
+		slog.Debug("合成した音声の再生時間を取得できませんでした", slog.String("error", err.Error()))
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations[t.texts[string(query)]] = d
+}
+
+func (t *segmentTimings) duration(text string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.durations[text]
+}
+
+func (t *segmentTimings) speed(text string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.speeds[text]
+}
+
+// progressExecutor は合成対象の総行数を事前に数え、進捗と所要時間のサマリを出力します。
+// 合成に成功した場合は、セグメントごとの再生時間から字幕キューを組み立てます (CueSource を満たします)。
+type progressExecutor struct {
+	engine  voicevox.EngineExecutor
+	tracker *progressTracker
+	timings *segmentTimings
+	format  *queryFormat
+	prosody *queryProsody
+	cues    []Cue
+
+	// 以下は話者IDを指定した合成 (ExecuteWithOptions) で、話者データを差し替えたエンジンを組み立てるために保持します。
+	client voicevox.AudioQueryClient
+	data   voicevox.DataFinder
+	config voicevox.EngineConfig
+}
+
+// Cues は直前の Execute で合成したセグメントの字幕キューを返します。
+func (e *progressExecutor) Cues() []Cue {
+	return e.cues
+}
+
+// Execute はスクリプトの行数を数えて進捗をリセットした後、エンジンに合成を委譲します。
+func (e *progressExecutor) Execute(ctx context.Context, scriptContent string, outputWavFile string, opts ...voicevox.ExecuteOption) error {
+	return e.execute(ctx, e.engine, scriptContent, outputWavFile, Prosody{}, opts...)
+}
+
+// ExecuteWithOptions は SynthesisExecutor の実装です。話者の指定がある場合は、スクリプトを話者タグの形式に揃え
+// (SpeakerConfig.NormalizeScript)、IDs で指定したキャラクターの行をそのスタイルIDで合成します。
+// 話速・音高・抑揚の指定は、各行のオーディオクエリに適用します。
+func (e *progressExecutor) ExecuteWithOptions(ctx context.Context, scriptContent string, outputWavFile string, options SynthesisOptions, opts ...voicevox.ExecuteOption) error {
+	if options.Speakers.IsZero() {
+		return e.execute(ctx, e.engine, scriptContent, outputWavFile, options.Prosody, opts...)
+	}
+	speakers := options.Speakers
+	engine := voicevox.NewEngine(e.client, speakerFinder{inner: e.data, ids: speakers.IDs}, parser.NewParser(), e.config)
+	return e.execute(ctx, engine, speakers.NormalizeScript(scriptContent), outputWavFile, options.Prosody, opts...)
+}
+
+// execute はスクリプトの行数を数えて進捗をリセットし、話速・音高・抑揚の指定を設定した後、engine に合成を委譲します。
+func (e *progressExecutor) execute(ctx context.Context, engine voicevox.EngineExecutor, scriptContent string, outputWavFile string, prosody Prosody, opts ...voicevox.ExecuteOption) error {
+	segments, err := parser.NewParser().Parse(scriptContent, speaker.VvTagNormal)
+	if err != nil {
+		return fmt.Errorf("スクリプトの解析に失敗しました: %w", err)
+	}
+	var speakers, texts []string
+	for _, seg := range segments {
+		if seg.Text != "" {
+			speakers = append(speakers, strings.Trim(seg.BaseSpeakerTag, "[]"))
+			texts = append(texts, seg.Text)
+		}
+	}
+	e.tracker.reset(len(texts))
+	e.format.reset()
+	e.prosody.set(prosody)
+	e.cues = nil
+
+	err = engine.Execute(ctx, scriptContent, outputWavFile, opts...)
+	if err == nil {
+		durations := make([]time.Duration, len(texts))
+		speeds := make([]float64, len(texts))
+		for i, text := range texts {
+			durations[i] = e.timings.duration(text)
+			speeds[i] = e.timings.speed(text)
+		}
+		e.cues = buildCues(speakers, texts, durations, speeds)
+	}
+
+	p := e.tracker.snapshot()
+	slog.Info("音声合成サマリ",
+		slog.Int("synthesized_lines", p.Done),
+		slog.Int("total_lines", p.Total),
+		slog.Duration("elapsed", p.Elapsed.Round(time.Millisecond)),
+		slog.Bool("success", err == nil),
+	)
+	return err
+}
+
+// NewEngineExecutor は voicevox.NewEngineExecutor と同様にエンジンを初期化し、
+// 合成の進捗を onProgress に通知する EngineExecutor を返します。
+// 各行の audio_query / synthesis は最大 concurrency 行ずつ並列に実行し、順序を保って結合します
+// (0 以下の場合は DefaultConcurrency)。enabled が false の場合はライブラリの実装をそのまま返します。
+func NewEngineExecutor(ctx context.Context, httpTimeout time.Duration, enabled bool, concurrency int, onProgress ProgressFunc) (voicevox.EngineExecutor, error) {
+	if !enabled {
+		return voicevox.NewEngineExecutor(ctx, httpTimeout, enabled)
+	}
+
+	apiURL := os.Getenv("VOICEVOX_API_URL")
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+		slog.Warn("VOICEVOX_API_URL 環境変数が設定されていません。", slog.String("default_url", apiURL))
+	}
+	client := api.NewClient(apiURL, httpTimeout)
+
+	slog.Info("VOICEVOX話者スタイルデータをロード中...")
+	speakerData, err := speaker.LoadSpeakers(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("VOICEVOXエンジンへの接続または話者データのロードに失敗しました: %w", err)
+	}
+	slog.Info("VOICEVOX話者スタイルデータのロード完了。", slog.Int("styles_count", len(speakerData.StyleIDMap)))
+	initializeSpeakers(ctx, apiURL, httpTimeout, speakerData) // warmup.goで定義
+
+	tracker := &progressTracker{onUpdate: onProgress}
+	timings := newSegmentTimings()
+	format := &queryFormat{}
+	prosody := &queryProsody{}
+	config := engineConfig(concurrency)
+	queryClient := &progressClient{AudioQueryClient: client, tracker: tracker, timings: timings, format: format, prosody: prosody}
+	engine := voicevox.NewEngine(queryClient, speakerData, parser.NewParser(), config)
+	slog.Info("VOICEVOX Executorの初期化が完了しました。",
+		slog.Int("max_parallel", config.MaxParallelSegments),
+		slog.Duration("segment_rate_limit", config.SegmentRateLimit),
+	)
+	return &progressExecutor{
+		engine:  engine,
+		tracker: tracker,
+		timings: timings,
+		format:  format,
+		prosody: prosody,
+		client:  queryClient,
+		data:    speakerData,
+		config:  config,
+	}, nil
+}
@@ -0,0 +1,249 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+)
+
+// ----------------------------------------------------------------------
+// ラウドネス測定と正規化 (EBU R128 / ITU-R BS.1770)
+// ----------------------------------------------------------------------
+
+const (
+	// DefaultPeakCeilingDB は正規化後のリミッターが許容する最大ピーク (dBFS) です。
+	DefaultPeakCeilingDB = -1.0
+
+	absoluteGateLUFS = -70.0 // 絶対ゲート
+	relativeGateLU   = -10.0 // 相対ゲート
+	blockSeconds     = 0.4   // ゲーティングブロック長 (400ms)
+	blockStepSeconds = 0.1   // ブロックの移動幅 (75% オーバーラップ)
+	limiterRelease   = 0.05  // リミッターのリリース時間 (秒)
+)
+
+// ErrSilentAudio は無音のためラウドネスを測定できない場合のエラーです。
+var ErrSilentAudio = errors.New("音声が無音のためラウドネスを測定できません")
+
+// LoudnessResult は正規化前後のラウドネス測定結果です。
+type LoudnessResult struct {
+	BeforeLUFS float64
+	AfterLUFS  float64
+	GainDB     float64 // 適用したゲイン
+	Limited    bool    // リミッターによるゲインリダクションが発生したか
+}
+
+// NormalizeLoudnessFile は WAV ファイルの統合ラウドネスを測定し、targetLUFS になるようゲインを適用して上書きします。
+// ゲイン適用後のピークが ceilingDB を超える場合は、リミッターで抑制します。
+func NormalizeLoudnessFile(path string, targetLUFS, ceilingDB float64) (*LoudnessResult, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("WAVファイルの読み込みに失敗しました: %w", err)
+	}
+	wav, err := parsePCMWAV(raw)
+	if err != nil {
+		return nil, fmt.Errorf("WAVファイルの解析に失敗しました (%s): %w", path, err)
+	}
+
+	samples := wav.samples()
+	before, err := integratedLoudness(samples, wav.channels, wav.sampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	gainDB := targetLUFS - before
+	gain := math.Pow(10, gainDB/20)
+	for i := range samples {
+		samples[i] *= gain
+	}
+	limited := applyLimiter(samples, wav.channels, wav.sampleRate, math.Pow(10, ceilingDB/20))
+
+	after, err := integratedLoudness(samples, wav.channels, wav.sampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	wav.setSamples(samples)
+	if err := os.WriteFile(path, wav.raw, 0644); err != nil {
+		return nil, fmt.Errorf("正規化したWAVファイルの書き込みに失敗しました: %w", err)
+	}
+
+	result := &LoudnessResult{BeforeLUFS: before, AfterLUFS: after, GainDB: gainDB, Limited: limited}
+	slog.Info("ラウドネス正規化が完了しました",
+		slog.String("output", path),
+		slog.Float64("before_lufs", round1(before)),
+		slog.Float64("after_lufs", round1(after)),
+		slog.Float64("target_lufs", targetLUFS),
+		slog.Float64("gain_db", round1(gainDB)),
+		slog.Bool("limited", limited),
+	)
+	return result, nil
+}
+
+// integratedLoudness は BS.1770 に基づく統合ラウドネス (LUFS) を算出します。
+func integratedLoudness(samples []float64, channels, sampleRate int) (float64, error) {
+	frames := len(samples) / channels
+	if frames == 0 {
+		return 0, ErrSilentAudio
+	}
+
+	// チャンネルごとにKウェイティングフィルタを適用し、二乗値を保持
+	squared := make([][]float64, channels)
+	for ch := 0; ch < channels; ch++ {
+		shelf, highpass := kWeightingFilters(float64(sampleRate))
+		sq := make([]float64, frames)
+		for i := 0; i < frames; i++ {
+			y := highpass.process(shelf.process(samples[i*channels+ch]))
+			sq[i] = y * y
+		}
+		squared[ch] = sq
+	}
+
+	blockLen := int(blockSeconds * float64(sampleRate))
+	step := int(blockStepSeconds * float64(sampleRate))
+	if blockLen > frames {
+		blockLen = frames // ブロック長に満たない短い音声は全体を1ブロックとする
+	}
+
+	var blockPowers []float64
+	for start := 0; start+blockLen <= frames; start += step {
+		power := 0.0
+		for ch := 0; ch < channels; ch++ {
+			sum := 0.0
+			for _, v := range squared[ch][start : start+blockLen] {
+				sum += v
+			}
+			power += sum / float64(blockLen) // L/R/C のチャンネル重みは 1.0
+		}
+		blockPowers = append(blockPowers, power)
+	}
+
+	// 絶対ゲート
+	var gated []float64
+	for _, p := range blockPowers {
+		if powerToLUFS(p) > absoluteGateLUFS {
+			gated = append(gated, p)
+		}
+	}
+	if len(gated) == 0 {
+		return 0, ErrSilentAudio
+	}
+
+	// 相対ゲート
+	relativeGate := powerToLUFS(mean(gated)) + relativeGateLU
+	var final []float64
+	for _, p := range gated {
+		if powerToLUFS(p) > relativeGate {
+			final = append(final, p)
+		}
+	}
+	if len(final) == 0 {
+		return 0, ErrSilentAudio
+	}
+	return powerToLUFS(mean(final)), nil
+}
+
+// applyLimiter は瞬時アタック・指数リリースのピークリミッターを適用し、
+// ゲインリダクションが発生した場合に true を返します。
+func applyLimiter(samples []float64, channels, sampleRate int, ceiling float64) bool {
+	release := math.Exp(-1.0 / (limiterRelease * float64(sampleRate)))
+	envelope := 1.0
+	limited := false
+
+	for i := 0; i+channels <= len(samples); i += channels {
+		peak := 0.0
+		for ch := 0; ch < channels; ch++ {
+			peak = math.Max(peak, math.Abs(samples[i+ch]))
+		}
+		target := 1.0
+		if peak > ceiling {
+			target = ceiling / peak
+			limited = true
+		}
+		if target < envelope {
+			envelope = target // アタックは即時
+		} else {
+			envelope = target + (envelope-target)*release
+		}
+		for ch := 0; ch < channels; ch++ {
+			samples[i+ch] *= envelope
+		}
+	}
+	return limited
+}
+
+// ----------------------------------------------------------------------
+// Kウェイティングフィルタ
+// ----------------------------------------------------------------------
+
+// biquad は直接形 I の2次IIRフィルタです。
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeightingFilters は任意のサンプルレートに対する BS.1770 の
+// ハイシェルフ (前段) とハイパス (RLB) フィルタを返します。
+func kWeightingFilters(fs float64) (*biquad, *biquad) {
+	// 前段: +4dB ハイシェルフ (fc=1500Hz, Q=1/√2)
+	const shelfGainDB, shelfFc = 4.0, 1500.0
+	A := math.Pow(10, shelfGainDB/40)
+	w0 := 2 * math.Pi * shelfFc / fs
+	alpha := math.Sin(w0) / (2 * (1 / math.Sqrt2))
+	cosW := math.Cos(w0)
+	sqrtA := math.Sqrt(A)
+	a0 := (A + 1) - (A-1)*cosW + 2*sqrtA*alpha
+	shelf := &biquad{
+		b0: A * ((A + 1) + (A-1)*cosW + 2*sqrtA*alpha) / a0,
+		b1: -2 * A * ((A - 1) + (A+1)*cosW) / a0,
+		b2: A * ((A + 1) + (A-1)*cosW - 2*sqrtA*alpha) / a0,
+		a1: 2 * ((A - 1) - (A+1)*cosW) / a0,
+		a2: ((A + 1) - (A-1)*cosW - 2*sqrtA*alpha) / a0,
+	}
+
+	// RLB: ハイパス (fc=38Hz, Q=0.5)
+	const hpFc, hpQ = 38.0, 0.5
+	w0 = 2 * math.Pi * hpFc / fs
+	alpha = math.Sin(w0) / (2 * hpQ)
+	cosW = math.Cos(w0)
+	a0 = 1 + alpha
+	highpass := &biquad{
+		b0: (1 + cosW) / 2 / a0,
+		b1: -(1 + cosW) / a0,
+		b2: (1 + cosW) / 2 / a0,
+		a1: -2 * cosW / a0,
+		a2: (1 - alpha) / a0,
+	}
+	return shelf, highpass
+}
+
+// ----------------------------------------------------------------------
+// ヘルパー関数
+// ----------------------------------------------------------------------
+
+func powerToLUFS(power float64) float64 {
+	if power <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(power)
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,134 @@
+package voice
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// 合成進捗
+// ----------------------------------------------------------------------
+
+// Progress は音声合成の進捗状況です。
+type Progress struct {
+	Done      int           // 合成が完了した行 (セグメント) 数
+	Total     int           // 合成対象の総行数
+	Elapsed   time.Duration // 合成開始からの経過時間
+	Remaining time.Duration // 完了行の平均所要時間から推定した残り時間
+}
+
+// String は `[合成 24/80 行, 残り約45秒]` 形式の進捗表示を返します。
+func (p Progress) String() string {
+	if p.Done == 0 {
+		return fmt.Sprintf("[合成 %d/%d 行, 残り時間を計測中]", p.Done, p.Total)
+	}
+	return fmt.Sprintf("[合成 %d/%d 行, 残り約%d秒]", p.Done, p.Total, int(p.Remaining.Round(time.Second).Seconds()))
+}
+
+// ProgressFunc は合成が 1 行完了するたびに呼び出される通知関数です。
+type ProgressFunc func(Progress)
+
+// progressTracker は完了行数と経過時間から残り時間を推定します。
+// 並列合成でも正しく推定できるよう、1 行あたりの平均は「経過時間 / 完了行数」で算出します。
+type progressTracker struct {
+	mu       sync.Mutex
+	total    int
+	done     int
+	started  time.Time
+	onUpdate ProgressFunc
+}
+
+// reset は新しい合成バッチの開始を記録します。
+func (t *progressTracker) reset(total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = total
+	t.done = 0
+	t.started = time.Now()
+}
+
+// complete は 1 行分の合成完了を記録し、進捗を通知します。
+func (t *progressTracker) complete() {
+	t.mu.Lock()
+	t.done++
+	p := t.snapshotLocked()
+	t.mu.Unlock()
+
+	if t.onUpdate != nil {
+		t.onUpdate(p)
+	}
+}
+
+// snapshot は現在の進捗を返します。
+func (t *progressTracker) snapshot() Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshotLocked()
+}
+
+func (t *progressTracker) snapshotLocked() Progress {
+	p := Progress{Done: t.done, Total: t.total, Elapsed: time.Since(t.started)}
+	if t.done > 0 && t.total > t.done {
+		perLine := p.Elapsed / time.Duration(t.done)
+		p.Remaining = perLine * time.Duration(t.total-t.done)
+	}
+	return p
+}
+
+// ----------------------------------------------------------------------
+// 進捗表示
+// ----------------------------------------------------------------------
+
+// DefaultProgressLogInterval は、非TTY環境で進捗ログを出力する最小間隔です。
+const DefaultProgressLogInterval = 10 * time.Second
+
+// NewConsoleProgressFunc は出力先に応じた ProgressFunc を返します。
+// out が端末 (TTY) の場合は同じ行を上書きして進捗を表示し、
+// それ以外の場合は logInterval ごと (および完了時) に slog で進捗を記録します。
+func NewConsoleProgressFunc(out *os.File, logInterval time.Duration) ProgressFunc {
+	if isTerminal(out) {
+		return func(p Progress) {
+			fmt.Fprintf(out, "\r\033[K%s", p)
+			if p.Done >= p.Total {
+				fmt.Fprintln(out)
+			}
+		}
+	}
+	return newIntervalLogger(logInterval)
+}
+
+// newIntervalLogger は一定間隔で進捗を slog に出力する ProgressFunc を返します。
+func newIntervalLogger(interval time.Duration) ProgressFunc {
+	var mu sync.Mutex
+	var last time.Time
+	return func(p Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Done < p.Total && time.Since(last) < interval {
+			return
+		}
+		last = time.Now()
+		slog.Info("音声合成の進捗",
+			slog.Int("done", p.Done),
+			slog.Int("total", p.Total),
+			slog.Duration("remaining", p.Remaining.Round(time.Second)),
+		)
+	}
+}
+
+// isTerminal は w が文字デバイス (端末) かどうかを判定します。
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,140 @@
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox"
+)
+
+// ----------------------------------------------------------------------
+// 話速・音高・抑揚の指定 (--speech-speed / --speech-pitch / --speech-intonation)
+// ----------------------------------------------------------------------
+
+// 話速・音高・抑揚に指定できる範囲 (VOICEVOX エディタの設定範囲) です。
+const (
+	MinSpeedScale      = 0.5
+	MaxSpeedScale      = 2.0
+	MinPitchScale      = -0.15
+	MaxPitchScale      = 0.15
+	MaxIntonationScale = 2.0
+)
+
+// Prosody はオーディオクエリの話速 (speedScale)・音高 (pitchScale)・抑揚 (intonationScale) の指定です。
+// 各フィールドが 0 の場合はエンジンが返す既定値のまま合成します (VOICEVOX の音高の既定値も 0 です)。
+type Prosody struct {
+	SpeedScale      float64
+	PitchScale      float64
+	IntonationScale float64
+}
+
+// IsZero は話速・音高・抑揚のいずれも指定がないかを返します。
+func (p Prosody) IsZero() bool {
+	return p == Prosody{}
+}
+
+// Validate は指定された値が VOICEVOX の設定範囲内かを検証します (0 は指定なしとして扱います)。
+func (p Prosody) Validate() error {
+	if p.SpeedScale != 0 && (p.SpeedScale < MinSpeedScale || p.SpeedScale > MaxSpeedScale) {
+		return fmt.Errorf("話速は %.1f〜%.1f の範囲で指定してください: %v", MinSpeedScale, MaxSpeedScale, p.SpeedScale)
+	}
+	if p.PitchScale < MinPitchScale || p.PitchScale > MaxPitchScale {
+		return fmt.Errorf("音高は %.2f〜%.2f の範囲で指定してください: %v", MinPitchScale, MaxPitchScale, p.PitchScale)
+	}
+	if p.IntonationScale < 0 || p.IntonationScale > MaxIntonationScale {
+		return fmt.Errorf("抑揚は 0〜%.1f の範囲で指定してください: %v", MaxIntonationScale, p.IntonationScale)
+	}
+	return nil
+}
+
+// apply はオーディオクエリの話速・音高・抑揚を指定の値に置き換えたクエリを返します。
+// 指定がない場合はクエリをそのまま返します。
+func (p Prosody) apply(query []byte) ([]byte, error) {
+	if p.IsZero() {
+		return query, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(query, &fields); err != nil {
+		return nil, fmt.Errorf("オーディオクエリの解析に失敗しました: %w", err)
+	}
+	for name, value := range map[string]float64{
+		"speedScale":      p.SpeedScale,
+		"pitchScale":      p.PitchScale,
+		"intonationScale": p.IntonationScale,
+	} {
+		if value == 0 {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("オーディオクエリの %s の設定に失敗しました: %w", name, err)
+		}
+		fields[name] = raw
+	}
+	applied, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("オーディオクエリの再構築に失敗しました: %w", err)
+	}
+	return applied, nil
+}
+
+// queryProsody は合成バッチの間、オーディオクエリに適用する話速・音高・抑揚を保持します。
+type queryProsody struct {
+	mu      sync.Mutex
+	prosody Prosody
+}
+
+// set は新しい合成バッチの開始時に、適用する話速・音高・抑揚を設定します。
+func (q *queryProsody) set(p Prosody) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prosody = p
+}
+
+// apply は設定された話速・音高・抑揚をクエリに適用します。
+func (q *queryProsody) apply(query []byte) ([]byte, error) {
+	q.mu.Lock()
+	p := q.prosody
+	q.mu.Unlock()
+	return p.apply(query)
+}
+
+// ----------------------------------------------------------------------
+// 合成オプションの指定 (話者ID・話速など)
+// ----------------------------------------------------------------------
+
+// SynthesisOptions は go-voicevox の ExecuteOption では指定できない、アプリケーション固有の合成の指定です。
+type SynthesisOptions struct {
+	Speakers SpeakerConfig // キャラクターごとの話者IDとデフォルトのキャラクター
+	Prosody  Prosody       // 話速・音高・抑揚
+}
+
+// IsZero は合成の指定がないかを返します。
+func (o SynthesisOptions) IsZero() bool {
+	return o.Speakers.IsZero() && o.Prosody.IsZero()
+}
+
+// SynthesisExecutor は、話者IDや話速などを指定して合成できる EngineExecutor です。
+// go-voicevox の Execute はこれらの指定を受け取らないため、このパッケージで拡張として定義します。
+type SynthesisExecutor interface {
+	voicevox.EngineExecutor
+	ExecuteWithOptions(ctx context.Context, scriptContent string, outputWavFile string, options SynthesisOptions, opts ...voicevox.ExecuteOption) error
+}
+
+// ExecuteWithOptions は executor が SynthesisExecutor であれば options を指定して合成し、そうでなければ
+// options を無視して (警告を出して) Execute で合成します。options が空の場合は常に Execute を使用します。
+func ExecuteWithOptions(ctx context.Context, executor voicevox.EngineExecutor, scriptContent string, outputWavFile string, options SynthesisOptions, opts ...voicevox.ExecuteOption) error {
+	if options.IsZero() {
+		return executor.Execute(ctx, scriptContent, outputWavFile, opts...)
+	}
+	if se, ok := executor.(SynthesisExecutor); ok {
+		return se.ExecuteWithOptions(ctx, scriptContent, outputWavFile, options, opts...)
+	}
+	slog.Warn("音声合成エンジンが話者ID・話速などの指定に対応していないため、--speaker / --default-speaker / --speech-* は無視されます",
+		slog.String("executor", fmt.Sprintf("%T", executor)),
+	)
+	return executor.Execute(ctx, scriptContent, outputWavFile, opts...)
+}
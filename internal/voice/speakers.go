@@ -0,0 +1,150 @@
+package voice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox"
+	"github.com/shouni/go-voicevox/pkg/voicevox/speaker"
+)
+
+// ----------------------------------------------------------------------
+// キャラクターごとの話者IDの指定 (--speaker / --default-speaker)
+// ----------------------------------------------------------------------
+
+// DefaultSpeaker は、話者の指定がない行を読み上げるデフォルトのキャラクターです。
+const DefaultSpeaker = "ずんだもん"
+
+// maxSpeakerNameRunes は「キャラ名：セリフ」形式のキャラクター名として扱う最大の文字数です。
+const maxSpeakerNameRunes = 20
+
+var (
+	// reTaggedLine は go-voicevox のスクリプト形式 ([話者タグ][スタイルタグ] テキスト) の行です。
+	reTaggedLine = regexp.MustCompile(`^\[.+?\]\s*\[.+?\]`)
+	// reDialogueLine は「キャラ名：セリフ」形式 (全角・半角のコロン) の行です。
+	reDialogueLine = regexp.MustCompile(`^([^\s\[\]：:]+)\s*[：:]\s*(.*)$`)
+)
+
+// SpeakerConfig はキャラクターごとの VOICEVOX の話者 (スタイル) ID と、話者の指定がない行を読み上げるキャラクターです。
+type SpeakerConfig struct {
+	// IDs はキャラクター名 (タグの [] を除いた名前。例: ずんだもん) をキー、スタイルIDを値とするマップです。
+	// 指定したキャラクターは、スクリプトのスタイルタグにかかわらずこのスタイルIDで合成します。
+	IDs map[string]int
+	// Default は「キャラ名：」の指定も話者タグもない行を読み上げるキャラクターです (空の場合は DefaultSpeaker)。
+	Default string
+}
+
+// IsZero は話者の指定がないかを返します。
+func (c SpeakerConfig) IsZero() bool {
+	return len(c.IDs) == 0 && c.Default == ""
+}
+
+// ParseSpeakerIDs は "ずんだもん=3" 形式の指定をキャラクター名とスタイルIDのマップに変換します。
+// VOICEVOX の話者名 (例: 四国めたん) はスクリプトで使用するタグの名前 (例: めたん) に読み替えます。
+func ParseSpeakerIDs(entries []string) (map[string]int, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	ids := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		name = SpeakerName(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("話者IDの形式が不正です: %q (例: ずんだもん=3)", entry)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("話者IDには0以上の整数を指定してください: %q", entry)
+		}
+		ids[name] = id
+	}
+	return ids, nil
+}
+
+// SpeakerName はキャラクター名を、前後の空白と [] を取り除き、VOICEVOX の話者名をタグの名前に読み替えて返します。
+func SpeakerName(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), "[]")
+	for _, mapping := range speaker.SupportedSpeakers {
+		if name == mapping.APIName {
+			return strings.Trim(mapping.ToolTag, "[]")
+		}
+	}
+	return name
+}
+
+// defaultSpeaker は話者の指定がない行を読み上げるキャラクターを返します。
+func (c SpeakerConfig) defaultSpeaker() string {
+	if c.Default != "" {
+		return SpeakerName(c.Default)
+	}
+	return DefaultSpeaker
+}
+
+// knownSpeaker は name が「キャラ名：セリフ」のキャラクター名として扱える (IDs で指定した、または既定の話者) かを返します。
+// 「注意：」のような見出しを誤ってキャラクター名として扱わないよう、既知の名前のみを対象とします。
+func (c SpeakerConfig) knownSpeaker(name string) bool {
+	if _, ok := c.IDs[name]; ok {
+		return true
+	}
+	if name == c.defaultSpeaker() {
+		return true
+	}
+	for _, mapping := range speaker.SupportedSpeakers {
+		if name == strings.Trim(mapping.ToolTag, "[]") {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeScript はスクリプトの各行を go-voicevox のスクリプト形式 ([話者タグ][スタイルタグ] テキスト) に揃えます。
+// 「キャラ名：セリフ」形式の行はそのキャラクターの [ノーマル] スタイルの行に、話者タグも「キャラ名：」もない行は
+// デフォルトのキャラクターの行に変換します。話者タグ付きの行はそのまま残します。
+func (c SpeakerConfig) NormalizeScript(script string) string {
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || reTaggedLine.MatchString(trimmed) {
+			continue
+		}
+		name, text := c.defaultSpeaker(), trimmed
+		if m := reDialogueLine.FindStringSubmatch(trimmed); m != nil && utf8.RuneCountInString(m[1]) <= maxSpeakerNameRunes {
+			if candidate := SpeakerName(m[1]); c.knownSpeaker(candidate) {
+				name, text = candidate, m[2]
+			}
+		}
+		if text == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = "[" + name + "]" + speaker.VvTagNormal + " " + text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// speakerFinder は IDs で指定したキャラクターのスタイルIDを優先して返す DataFinder です。
+// 指定のないキャラクターは VOICEVOX から取得した話者データ (inner) で検索します。
+type speakerFinder struct {
+	inner voicevox.DataFinder
+	ids   map[string]int
+}
+
+// GetStyleID は話者タグ ([キャラ名][スタイル]) のキャラクターに IDs の指定があればそのスタイルIDを返します。
+func (f speakerFinder) GetStyleID(combinedTag string) (int, bool) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(combinedTag, "["), "]")
+	if id, ok := f.ids[name]; ok {
+		return id, true
+	}
+	return f.inner.GetStyleID(combinedTag)
+}
+
+// GetDefaultTag は IDs で指定したキャラクターには [ノーマル] スタイルのタグを返します (GetStyleID で指定のIDに解決されます)。
+func (f speakerFinder) GetDefaultTag(speakerToolTag string) (string, bool) {
+	if _, ok := f.ids[strings.Trim(speakerToolTag, "[]")]; ok {
+		return speakerToolTag + speaker.VvTagNormal, true
+	}
+	return f.inner.GetDefaultTag(speakerToolTag)
+}
@@ -0,0 +1,77 @@
+package voice
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unicode"
+)
+
+// ----------------------------------------------------------------------
+// 話者・話速ごとの読み上げ速度 (1分あたりの文字数) の計測
+// ----------------------------------------------------------------------
+
+// DefaultCharsPerMinute は計測結果がない場合に使用する、1分あたりの読み上げ文字数の目安です。
+const DefaultCharsPerMinute = 300.0
+
+// SpeechSample は同じ話者・話速で合成したセグメントの文字数と再生時間の合計です。
+type SpeechSample struct {
+	Key      string // SpeechRateKey で作成したキー
+	Chars    int
+	Duration time.Duration
+}
+
+// CharsPerMinute は 1分あたりの読み上げ文字数を返します。再生時間が 0 の場合は 0 を返します。
+func (s SpeechSample) CharsPerMinute() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Chars) / s.Duration.Minutes()
+}
+
+// SpeechRateKey は話者と話速から読み上げ速度の集計キーを作成します (例: "ずんだもん@1.00")。
+// 話速が取得できない (0) 場合は標準の 1.0 として扱います。
+func SpeechRateKey(speaker string, speed float64) string {
+	if speed <= 0 {
+		speed = 1
+	}
+	return fmt.Sprintf("%s@%.2f", speaker, speed)
+}
+
+// CountSpeechChars は読み上げの対象となる文字数 (空白を除く) を返します。
+func CountSpeechChars(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// MeasureSpeechRates は字幕キューを話者・話速ごとに集計し、キーの昇順で返します。
+// 再生時間が取得できなかったキューは含めません。
+func MeasureSpeechRates(cues []Cue) []SpeechSample {
+	byKey := make(map[string]*SpeechSample)
+	for _, cue := range cues {
+		d := cue.End - cue.Start
+		if d <= 0 {
+			continue
+		}
+		key := SpeechRateKey(cue.Speaker, cue.Speed)
+		s, ok := byKey[key]
+		if !ok {
+			s = &SpeechSample{Key: key}
+			byKey[key] = s
+		}
+		s.Chars += CountSpeechChars(cue.Text)
+		s.Duration += d
+	}
+
+	samples := make([]SpeechSample, 0, len(byKey))
+	for _, s := range byKey {
+		samples = append(samples, *s)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Key < samples[j].Key })
+	return samples
+}
@@ -0,0 +1,76 @@
+package voice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// 字幕 (SRT / WebVTT) の生成
+// ----------------------------------------------------------------------
+
+// Cue は字幕の 1 キュー (スクリプトの 1 セグメント) です。
+type Cue struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string // 話者名 (例: "ずんだもん")
+	Text    string
+	Speed   float64 // 話速 (オーディオクエリの speedScale。取得できない場合は 0)
+}
+
+// CueSource は、直前の Execute で合成したセグメントの字幕キューを提供できる EngineExecutor です。
+type CueSource interface {
+	Cues() []Cue
+}
+
+// buildCues は合成順のセグメントとその再生時間から、連続したタイムコードのキューを組み立てます。
+// 各セグメントの音声は間を空けずに結合されるため、開始時刻は直前までの再生時間の累計になります。
+func buildCues(speakers, texts []string, durations []time.Duration, speeds []float64) []Cue {
+	cues := make([]Cue, 0, len(texts))
+	var offset time.Duration
+	for i, text := range texts {
+		cues = append(cues, Cue{
+			Start:   offset,
+			End:     offset + durations[i],
+			Speaker: speakers[i],
+			Text:    text,
+			Speed:   speeds[i],
+		})
+		offset += durations[i]
+	}
+	return cues
+}
+
+// formatTimecode は "HH:MM:SS<sep>mmm" 形式のタイムコードを返します (SRT は ',', WebVTT は '.')。
+func formatTimecode(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, sep, ms%1000)
+}
+
+// GenerateSRT はキューを SRT 形式の字幕に変換します。
+func GenerateSRT(cues []Cue) string {
+	var sb strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatTimecode(cue.Start, ','), formatTimecode(cue.End, ','), cue.Text)
+	}
+	return sb.String()
+}
+
+// vttEscaper は WebVTT のキューテキストで特別な意味を持つ文字をエスケープします。
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// GenerateVTT はキューを WebVTT 形式の字幕に変換します。
+// withVoice が true の場合、話者名を <v 話者> タグで埋め込みます。
+func GenerateVTT(cues []Cue, withVoice bool) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for i, cue := range cues {
+		text := vttEscaper.Replace(cue.Text)
+		if withVoice && cue.Speaker != "" {
+			text = fmt.Sprintf("<v %s>%s", vttEscaper.Replace(cue.Speaker), text)
+		}
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, formatTimecode(cue.Start, '.'), formatTimecode(cue.End, '.'), text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,148 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shouni/go-voicevox/pkg/voicevox"
+	"github.com/shouni/go-voicevox/pkg/voicevox/speaker"
+)
+
+// ----------------------------------------------------------------------
+// エンジンのプリウォーム (バックグラウンドでの初期化)
+// ----------------------------------------------------------------------
+
+// ErrEngineUnavailable は、バックグラウンドで初期化していた VOICEVOX エンジンが利用できないことを示します。
+// 呼び出し側はテキスト出力へのフォールバックの判定に使用します。
+var ErrEngineUnavailable = errors.New("VOICEVOXエンジンを利用できません")
+
+// WarmupExecutor は VOICEVOX エンジンの初期化 (話者データのロードと話者の初期化) をバックグラウンドで行う EngineExecutor です。
+// 合成の開始 (Execute) 時に初期化が終わっていなければ完了まで待機し、初期化に失敗していた場合は ErrEngineUnavailable を返します。
+type WarmupExecutor struct {
+	done     chan struct{}
+	executor voicevox.EngineExecutor
+	err      error
+	started  time.Time
+	waitLog  sync.Once // 待機のログを 1 回だけ出力する
+}
+
+// StartEngineExecutor は NewEngineExecutor (音声合成を有効化) をバックグラウンドで開始し、すぐに WarmupExecutor を返します。
+// ctx がキャンセルされると初期化も中断されます。
+func StartEngineExecutor(ctx context.Context, httpTimeout time.Duration, concurrency int, onProgress ProgressFunc) *WarmupExecutor {
+	w := &WarmupExecutor{done: make(chan struct{}), started: time.Now()}
+	slog.Info("VOICEVOXエンジンの初期化をバックグラウンドで開始します")
+	go func() {
+		defer close(w.done)
+		w.executor, w.err = NewEngineExecutor(ctx, httpTimeout, true, concurrency, onProgress)
+		if w.err != nil {
+			slog.Warn("VOICEVOXエンジンのバックグラウンド初期化に失敗しました", slog.String("error", w.err.Error()))
+			return
+		}
+		slog.Info("VOICEVOXエンジンのバックグラウンド初期化が完了しました", slog.Duration("elapsed", time.Since(w.started)))
+	}()
+	return w
+}
+
+// Ready はエンジンの初期化の完了を待ち、初期化したエンジンを返します。
+// 初期化に失敗していた場合は ErrEngineUnavailable をラップしたエラーを、ctx が先に終了した場合は ctx.Err() を返します。
+func (w *WarmupExecutor) Ready(ctx context.Context) (voicevox.EngineExecutor, error) {
+	select {
+	case <-w.done:
+	default:
+		w.waitLog.Do(func() {
+			slog.Info("VOICEVOXエンジンの初期化の完了を待機しています", slog.Duration("elapsed", time.Since(w.started)))
+		})
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("VOICEVOXエンジンの初期化の待機中に中断されました: %w", ctx.Err())
+		}
+	}
+	if w.err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEngineUnavailable, w.err)
+	}
+	return w.executor, nil
+}
+
+// Execute はエンジンの初期化の完了を待ってから合成を委譲します。
+func (w *WarmupExecutor) Execute(ctx context.Context, scriptContent string, outputWavFile string, opts ...voicevox.ExecuteOption) error {
+	executor, err := w.Ready(ctx)
+	if err != nil {
+		return err
+	}
+	return executor.Execute(ctx, scriptContent, outputWavFile, opts...)
+}
+
+// ExecuteWithOptions はエンジンの初期化の完了を待ってから、話者IDや話速などを指定した合成を委譲します。
+// 初期化したエンジンが SynthesisExecutor でない場合は、指定なしで合成します。
+func (w *WarmupExecutor) ExecuteWithOptions(ctx context.Context, scriptContent string, outputWavFile string, options SynthesisOptions, opts ...voicevox.ExecuteOption) error {
+	executor, err := w.Ready(ctx)
+	if err != nil {
+		return err
+	}
+	return ExecuteWithOptions(ctx, executor, scriptContent, outputWavFile, options, opts...)
+}
+
+// Cues は初期化したエンジンの直前の Execute の字幕キューを返します。初期化前・失敗時は nil です。
+func (w *WarmupExecutor) Cues() []Cue {
+	select {
+	case <-w.done:
+	default:
+		return nil
+	}
+	if source, ok := w.executor.(CueSource); ok {
+		return source.Cues()
+	}
+	return nil
+}
+
+// initializeSpeakers は必須話者のデフォルトスタイルを /initialize_speaker で事前に初期化し、初回の合成の遅延を減らします。
+// 初期化は合成に必須ではないため、失敗した場合は警告のみで続行します。
+func initializeSpeakers(ctx context.Context, apiURL string, httpTimeout time.Duration, data *speaker.SpeakerData) {
+	var styleIDs []int
+	for _, tag := range data.DefaultStyleMap {
+		if id, ok := data.StyleIDMap[tag]; ok {
+			styleIDs = append(styleIDs, id)
+		}
+	}
+	sort.Ints(styleIDs)
+
+	client := &http.Client{Timeout: httpTimeout}
+	for _, id := range styleIDs {
+		if err := initializeSpeaker(ctx, client, apiURL, id); err != nil {
+			slog.Warn("VOICEVOX話者の事前初期化に失敗しました。合成時に初期化されます", slog.Int("style_id", id), slog.String("error", err.Error()))
+			return
+		}
+	}
+	slog.Info("VOICEVOX話者の事前初期化が完了しました", slog.Any("style_ids", styleIDs))
+}
+
+// initializeSpeaker は 1 つのスタイルを /initialize_speaker で初期化します (初期化済みの場合は何もしません)。
+func initializeSpeaker(ctx context.Context, client *http.Client, apiURL string, styleID int) error {
+	endpoint, err := url.JoinPath(apiURL, "initialize_speaker")
+	if err != nil {
+		return err
+	}
+	query := url.Values{"speaker": {strconv.Itoa(styleID)}, "skip_reinit": {"true"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("予期しないステータス: %s", resp.Status)
+	}
+	return nil
+}
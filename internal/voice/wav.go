@@ -0,0 +1,108 @@
+package voice
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// ----------------------------------------------------------------------
+// WAV (16bit PCM) の読み書き
+// ----------------------------------------------------------------------
+
+// pcmWAV は 16bit リニアPCMのWAVデータと、そのサンプル領域の位置を保持します。
+type pcmWAV struct {
+	raw        []byte // WAVファイル全体 (ヘッダーを含む)
+	channels   int
+	sampleRate int
+	dataOffset int // data チャンク本体の開始位置
+	dataSize   int // data チャンク本体のバイト数
+}
+
+// parsePCMWAV はRIFFチャンクを走査し、16bit PCM の fmt / data チャンクを特定します。
+func parsePCMWAV(raw []byte) (*pcmWAV, error) {
+	if len(raw) < 12 || string(raw[0:4]) != "RIFF" || string(raw[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("WAVヘッダーが不正です")
+	}
+
+	w := &pcmWAV{raw: raw}
+	formatFound := false
+	for offset := 12; offset+8 <= len(raw); {
+		chunkID := string(raw[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(raw[offset+4 : offset+8]))
+		body := offset + 8
+		if body+chunkSize > len(raw) {
+			chunkSize = len(raw) - body // 途中で切れたチャンクは残り全体とみなす
+		}
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 {
+				return nil, fmt.Errorf("fmt チャンクが短すぎます (%d バイト)", chunkSize)
+			}
+			audioFormat := binary.LittleEndian.Uint16(raw[body : body+2])
+			bitsPerSample := binary.LittleEndian.Uint16(raw[body+14 : body+16])
+			if audioFormat != 1 || bitsPerSample != 16 {
+				return nil, fmt.Errorf("16bit リニアPCM以外のWAVには対応していません (format=%d, bits=%d)", audioFormat, bitsPerSample)
+			}
+			w.channels = int(binary.LittleEndian.Uint16(raw[body+2 : body+4]))
+			w.sampleRate = int(binary.LittleEndian.Uint32(raw[body+4 : body+8]))
+			formatFound = true
+		case "data":
+			w.dataOffset = body
+			w.dataSize = chunkSize - chunkSize%2
+		}
+
+		// チャンクは2バイト境界に揃えられる
+		offset = body + chunkSize + chunkSize%2
+	}
+
+	if !formatFound || w.channels == 0 || w.sampleRate == 0 {
+		return nil, fmt.Errorf("fmt チャンクが見つかりません")
+	}
+	if w.dataSize == 0 {
+		return nil, fmt.Errorf("data チャンクが見つからないか空です")
+	}
+	return w, nil
+}
+
+// samples はサンプル値を [-1, 1) の浮動小数点に変換して返します (インターリーブ順)。
+func (w *pcmWAV) samples() []float64 {
+	n := w.dataSize / 2
+	out := make([]float64, n)
+	for i := 0; i < n; i++ {
+		v := int16(binary.LittleEndian.Uint16(w.raw[w.dataOffset+2*i:]))
+		out[i] = float64(v) / 32768.0
+	}
+	return out
+}
+
+// setSamples は浮動小数点のサンプル値を 16bit に量子化して書き戻します。
+func (w *pcmWAV) setSamples(samples []float64) {
+	for i, s := range samples {
+		v := math.Round(s * 32768.0)
+		v = math.Max(math.MinInt16, math.Min(math.MaxInt16, v))
+		binary.LittleEndian.PutUint16(w.raw[w.dataOffset+2*i:], uint16(int16(v)))
+	}
+}
+
+// WAVFileDuration は 16bit PCM の WAV ファイルの再生時間を返します。
+func WAVFileDuration(path string) (time.Duration, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("WAVファイルの読み込みに失敗しました: %w", err)
+	}
+	return wavDuration(raw)
+}
+
+// wavDuration は 16bit PCM の WAV データの再生時間を返します。
+func wavDuration(raw []byte) (time.Duration, error) {
+	w, err := parsePCMWAV(raw)
+	if err != nil {
+		return 0, err
+	}
+	frames := w.dataSize / 2 / w.channels
+	return time.Duration(frames) * time.Second / time.Duration(w.sampleRate), nil
+}
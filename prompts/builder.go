@@ -18,35 +18,120 @@ var ReduceFinalPromptTemplate string
 //go:embed summary_prompt.md
 var FinalSummaryPromptTemplate string
 
+//go:embed layered_summary_prompt.md
+var LayeredSummaryPromptTemplate string
+
+//go:embed topic_prompt.md
+var TopicClassificationPromptTemplate string
+
+//go:embed importance_prompt.md
+var ImportanceScoringPromptTemplate string
+
 //go:embed zundametan_duet.md
 var zundametanDuetPromptTemplate string // VOICEVOXスクリプト生成用テンプレート
 
+//go:embed output_style.md
+var outputStylePartialTemplate string // 全フェーズ共通の出力スタイル指示 ("output_style" テンプレート)
+
 // ---
 
+// プロンプトセットのディレクトリで組み込みテンプレートを上書きする際のファイル名 (埋め込みファイルと同じ名前)。
+const (
+	MapPromptFile     = "map_prompt.md"
+	ReducePromptFile  = "reduce_prompt.md"
+	SummaryPromptFile = "summary_prompt.md"
+	ScriptPromptFile  = "zundametan_duet.md"
+)
+
 // ----------------------------------------------------------------
 // テンプレート構造体
 // ----------------------------------------------------------------
 
+// OutputStyle は全フェーズのテンプレートに共通で注入する出力スタイルの指示文。
+// 各フィールドはそのままプロンプトに埋め込まれる (例: Language = "日本語")。空のフィールドは指示しない。
+type OutputStyle struct {
+	Language   string // 出力言語
+	Politeness string // 敬体／常体
+	Formality  string // トーンの硬さ
+}
+
+// MapTemplateData は 1 セグメント分のクリーンアップ・要約に使用する。
 type MapTemplateData struct {
-	Title       string
-	SegmentText string
+	Title         string
+	SegmentText   string
+	EnforceFormat bool // true の場合「トピック見出し＋箇条書き」の固定フォーマットを指示する
+	CiteSources   bool // true の場合、各記述に根拠のソース文書の番号 [n] を付けさせる
+	OutputStyle   OutputStyle
 }
 
 // ReduceTemplateData は Mapの結果を統合する（中間要約）。
 type ReduceTemplateData struct {
-	CombinedText string // Mapフェーズの結果を統合した中間要約テキスト
+	CombinedText       string // Mapフェーズの結果を統合した中間要約テキスト
+	StructuredSections bool   // true の場合「概要／主要ポイント／結論」のセクション構造で出力させる
+	CiteSources        bool   // true の場合、Map要約に付いたソース番号 [n] を統合後も保持させる
+	OutputStyle        OutputStyle
 }
 
 // FinalSummaryTemplateData は中間要約を元に最終要約を作成する。
 type FinalSummaryTemplateData struct {
 	Title               string
 	IntermediateSummary string // Reduceフェーズの結果（中間要約）
+	CiteSources         bool   // true の場合、中間要約に付いたソース番号 [n] を各文の末尾に付けさせる
+	Format              string // 出力形式 ("bullet": 箇条書き, "prose" または空: 散文)
+	ExpertiseLevel      string // 想定読者の専門性 ("beginner": 用語に注釈, "expert": 前提知識を省略, "intermediate" または空: 平易に意訳)
+	OutputStyle         OutputStyle
+}
+
+// LayeredSummaryTemplateData は中間要約を元に、詳細度の異なる要約を作成する。
+// OneLine / Paragraph / Detailed のうち true のレベルのみを出力させる。
+type LayeredSummaryTemplateData struct {
+	IntermediateSummary string // Reduceフェーズの結果（中間要約）
+	OneLine             bool   // 1行要約を出力させるか
+	Paragraph           bool   // 段落要約を出力させるか
+	Detailed            bool   // 詳細要約を出力させるか
+	OutputStyle         OutputStyle
 }
 
 // ScriptTemplateData は最終要約を元にVOICEVOX用スクリプトを作成する。
 type ScriptTemplateData struct {
 	Title            string
 	FinalSummaryText string // Final Summaryフェーズの結果
+
+	// 以下はセクション構造化されたReduce結果。設定されている場合、この順で会話を展開させる。
+	Overview   string
+	Points     []string
+	Conclusion string
+
+	TargetTurns     int // 目標のターン数 (話者が交代するまでの発言のまとまりの数)。0 の場合は指示しない
+	MaxCharsPerTurn int // 1ターンあたりの最大文字数。0 の場合は指示しない
+	TargetChars     int // 会話全体の目標文字数 (目標の再生時間から算出)。0 の場合は指示しない
+
+	// 以下は会話の冒頭・末尾に入れる挨拶 (日付などを埋め込み済みのテンプレート)。空の場合は指示しない
+	OpeningTemplate string
+	ClosingTemplate string
+	OpeningSpeaker  string // 冒頭の挨拶を担当する話者タグ (例: [ずんだもん])。空の場合は最初の話者
+	ClosingSpeaker  string // 末尾の挨拶を担当する話者タグ。空の場合は最後の話者
+
+	OutputStyle OutputStyle
+}
+
+// TopicArticle はトピック分類の対象となる 1 記事分の情報。
+type TopicArticle struct {
+	Index   int // 1始まりの記事番号
+	Title   string
+	Excerpt string
+}
+
+// TopicTemplateData は複数記事をトピック別に分類する。
+type TopicTemplateData struct {
+	Granularity string // 分類粒度の指示文
+	MaxTopics   int
+	Articles    []TopicArticle
+}
+
+// ImportanceTemplateData は複数記事の重要度を採点する (記事の情報はトピック分類と共通)。
+type ImportanceTemplateData struct {
+	Articles []TopicArticle
 }
 
 // ----------------------------------------------------------------
@@ -56,32 +141,74 @@ type ScriptTemplateData struct {
 // PromptBuilder はプロンプトの構成とテンプレート実行を管理します。
 type PromptBuilder struct {
 	tmpl *template.Template
+	text string // テンプレートの元の文字列 (Source で参照)
 	err  error
 }
 
 // NewMapPromptBuilder は Mapフェーズ用の PromptBuilder を初期化します。
 func NewMapPromptBuilder() *PromptBuilder {
-	tmpl, err := template.New("map_segment").Parse(MapSegmentPromptTemplate)
-	return &PromptBuilder{tmpl: tmpl, err: err}
+	return newStyledPromptBuilder("map_segment", MapSegmentPromptTemplate)
 }
 
 // NewReducePromptBuilder は Reduceフェーズ用の PromptBuilder を初期化します。
 func NewReducePromptBuilder() *PromptBuilder {
-	tmpl, err := template.New("reduce_final").Parse(ReduceFinalPromptTemplate)
-	return &PromptBuilder{tmpl: tmpl, err: err}
+	return newStyledPromptBuilder("reduce_final", ReduceFinalPromptTemplate)
 }
 
 // NewFinalSummaryPromptBuilder は 最終要約フェーズ用の PromptBuilder を初期化します。
 func NewFinalSummaryPromptBuilder() *PromptBuilder {
-	tmpl, err := template.New("final_summary").Parse(FinalSummaryPromptTemplate)
-	return &PromptBuilder{tmpl: tmpl, err: err}
+	return newStyledPromptBuilder("final_summary", FinalSummaryPromptTemplate)
+}
+
+// NewLayeredSummaryPromptBuilder は 多層要約フェーズ用の PromptBuilder を初期化します。
+func NewLayeredSummaryPromptBuilder() *PromptBuilder {
+	return newStyledPromptBuilder("layered_summary", LayeredSummaryPromptTemplate)
 }
 
 // NewScriptPromptBuilder は VOICEVOXスクリプト作成フェーズ用の PromptBuilder を初期化します。
 // zundametan_duet.md テンプレートを使用します。
 func NewScriptPromptBuilder() *PromptBuilder {
-	tmpl, err := template.New("script_voicevox").Parse(zundametanDuetPromptTemplate)
-	return &PromptBuilder{tmpl: tmpl, err: err}
+	return newStyledPromptBuilder("script_voicevox", zundametanDuetPromptTemplate)
+}
+
+// NewTopicPromptBuilder は トピック分類用の PromptBuilder を初期化します。
+func NewTopicPromptBuilder() *PromptBuilder {
+	tmpl, err := newTemplate("topic_classification").Parse(TopicClassificationPromptTemplate)
+	return &PromptBuilder{tmpl: tmpl, text: TopicClassificationPromptTemplate, err: err}
+}
+
+// NewImportancePromptBuilder は 記事の重要度採点用の PromptBuilder を初期化します。
+func NewImportancePromptBuilder() *PromptBuilder {
+	tmpl, err := newTemplate("importance_scoring").Parse(ImportanceScoringPromptTemplate)
+	return &PromptBuilder{tmpl: tmpl, text: ImportanceScoringPromptTemplate, err: err}
+}
+
+// NewPromptBuilderFromText は任意のテンプレート文字列から PromptBuilder を初期化します。
+// 組み込みテンプレートと同様に、共通の出力スタイル指示 ("output_style") を参照できます。
+func NewPromptBuilderFromText(name, text string) *PromptBuilder {
+	return newStyledPromptBuilder(name, text)
+}
+
+// newStyledPromptBuilder は、共通の出力スタイル指示 ("output_style") を参照できる PromptBuilder を初期化します。
+func newStyledPromptBuilder(name, text string) *PromptBuilder {
+	tmpl, err := newTemplate(name).Parse(text)
+	if err == nil {
+		_, err = tmpl.Parse(outputStylePartialTemplate)
+	}
+	return &PromptBuilder{tmpl: tmpl, text: text + outputStylePartialTemplate, err: err}
+}
+
+// newTemplate はプロンプト用のテンプレートを作成します。
+// マップのキーが存在しない場合に空文字列として扱わずエラーにするため、missingkey=error を設定します
+// (構造体の未定義フィールドは実行時に常にエラーになります。事前の検証は validate.go の ValidateTemplate を参照)。
+func newTemplate(name string) *template.Template {
+	return template.New(name).Option("missingkey=error")
+}
+
+// Source はテンプレートの元の文字列 (共通の出力スタイル指示を含む) を返します。
+// プロンプトの版の識別 (テンプレートの変更の検出) に使用します。
+func (b *PromptBuilder) Source() string {
+	return b.text
 }
 
 // Err は PromptBuilder の初期化（テンプレートパース）時に発生したエラーを返します。
@@ -149,6 +276,20 @@ func (b *PromptBuilder) BuildFinalSummary(data FinalSummaryTemplateData) (string
 	})
 }
 
+// BuildLayeredSummary は LayeredSummaryTemplateData を埋め込み、プロンプト文字列を完成させます。
+func (b *PromptBuilder) BuildLayeredSummary(data LayeredSummaryTemplateData) (string, error) {
+	return b.buildPrompt(data, func(d interface{}) error {
+		data := d.(LayeredSummaryTemplateData)
+		if data.IntermediateSummary == "" {
+			return fmt.Errorf("LayeredSummaryTemplateData.IntermediateSummaryが空です")
+		}
+		if !data.OneLine && !data.Paragraph && !data.Detailed {
+			return fmt.Errorf("LayeredSummaryTemplateData に出力するレベルが指定されていません")
+		}
+		return nil
+	})
+}
+
 // BuildScript は ScriptTemplateData を埋め込み、プロンプト文字列を完成させます。
 func (b *PromptBuilder) BuildScript(data ScriptTemplateData) (string, error) {
 	return b.buildPrompt(data, func(d interface{}) error {
@@ -158,3 +299,23 @@ func (b *PromptBuilder) BuildScript(data ScriptTemplateData) (string, error) {
 		return nil
 	})
 }
+
+// BuildTopic は TopicTemplateData を埋め込み、プロンプト文字列を完成させます。
+func (b *PromptBuilder) BuildTopic(data TopicTemplateData) (string, error) {
+	return b.buildPrompt(data, func(d interface{}) error {
+		if len(d.(TopicTemplateData).Articles) == 0 {
+			return fmt.Errorf("TopicTemplateData.Articlesが空です")
+		}
+		return nil
+	})
+}
+
+// BuildImportance は ImportanceTemplateData を埋め込み、プロンプト文字列を完成させます。
+func (b *PromptBuilder) BuildImportance(data ImportanceTemplateData) (string, error) {
+	return b.buildPrompt(data, func(d interface{}) error {
+		if len(d.(ImportanceTemplateData).Articles) == 0 {
+			return fmt.Errorf("ImportanceTemplateData.Articlesが空です")
+		}
+		return nil
+	})
+}
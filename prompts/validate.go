@@ -0,0 +1,92 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------
+// テンプレートの事前検証 (上書きテンプレートのプレースホルダ確認)
+// ----------------------------------------------------------------
+
+// placeholderMarker は検証用のサンプルデータで必須のプレースホルダに埋め込む値です。
+// 展開後のプロンプトにこの値が含まれない場合、テンプレートが本文を参照していないと判定します。
+const placeholderMarker = "\x00PROMPT_PLACEHOLDER\x00"
+
+// templateSample は検証に使用するサンプルデータと、プロンプトに必ず展開されるべきフィールドです。
+type templateSample struct {
+	required string        // 必須フィールド名 (エラーメッセージ用)
+	samples  []interface{} // 条件分岐の両側を実行するため、フラグ類を有効・無効にしたデータ
+}
+
+// templateSamples はテンプレートファイル名ごとの検証用サンプルデータです。
+var templateSamples = map[string]templateSample{
+	MapPromptFile: {
+		required: "SegmentText",
+		samples: []interface{}{
+			MapTemplateData{SegmentText: placeholderMarker},
+			MapTemplateData{Title: "title", SegmentText: placeholderMarker, EnforceFormat: true, CiteSources: true, OutputStyle: sampleOutputStyle},
+		},
+	},
+	ReducePromptFile: {
+		required: "CombinedText",
+		samples: []interface{}{
+			ReduceTemplateData{CombinedText: placeholderMarker},
+			ReduceTemplateData{CombinedText: placeholderMarker, StructuredSections: true, CiteSources: true, OutputStyle: sampleOutputStyle},
+		},
+	},
+	SummaryPromptFile: {
+		required: "IntermediateSummary",
+		samples: []interface{}{
+			FinalSummaryTemplateData{IntermediateSummary: placeholderMarker},
+			FinalSummaryTemplateData{Title: "title", IntermediateSummary: placeholderMarker, CiteSources: true, ExpertiseLevel: "beginner", OutputStyle: sampleOutputStyle},
+		},
+	},
+	ScriptPromptFile: {
+		required: "FinalSummaryText",
+		samples: []interface{}{
+			ScriptTemplateData{FinalSummaryText: placeholderMarker},
+			ScriptTemplateData{
+				Title:            "title",
+				FinalSummaryText: placeholderMarker,
+				Overview:         "overview",
+				Points:           []string{"point"},
+				Conclusion:       "conclusion",
+				TargetTurns:      1,
+				MaxCharsPerTurn:  1,
+				TargetChars:      1,
+				OpeningTemplate:  "opening",
+				ClosingTemplate:  "closing",
+				OpeningSpeaker:   "[speaker]",
+				ClosingSpeaker:   "[speaker]",
+				OutputStyle:      sampleOutputStyle,
+			},
+		},
+	},
+}
+
+// sampleOutputStyle は出力スタイル指示の分岐を実行するためのサンプルです。
+var sampleOutputStyle = OutputStyle{Language: "日本語", Politeness: "敬体", Formality: "標準"}
+
+// ValidateTemplate は file (MapPromptFile など) のテンプレートをサンプルデータで試行し、
+// 未定義のフィールド・マップキーの参照と、必須のプレースホルダ (本文など) の欠落を検出します。
+// 検証用のサンプルデータがないファイル名の場合は何もしません。
+func ValidateTemplate(file string, b *PromptBuilder) error {
+	if err := b.Err(); err != nil {
+		return err
+	}
+	sample, ok := templateSamples[file]
+	if !ok {
+		return nil
+	}
+	for _, data := range sample.samples {
+		var sb strings.Builder
+		if err := b.tmpl.Execute(&sb, data); err != nil {
+			return fmt.Errorf("テンプレート (%s) が未定義のフィールドまたはキーを参照しています: %w", file, err)
+		}
+		if !strings.Contains(sb.String(), placeholderMarker) {
+			return fmt.Errorf("テンプレート (%s) に必須のプレースホルダ {{.%s}} が含まれていません", file, sample.required)
+		}
+	}
+	return nil
+}